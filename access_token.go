@@ -0,0 +1,66 @@
+package jwt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The typ header of a JWT access token, RFC 9068 section 2.1
+const AccessTokenType = "at+jwt"
+
+// The claims every JWT access token carries, RFC 9068 section 2.2
+var accessTokenClaims = []string{"iss", "exp", "aud", "sub", "client_id", "iat", "jti"}
+
+// Checks that a parsed token is an OAuth 2.0 JWT access token as profiled by
+// RFC 9068: its typ header is "at+jwt", it carries the iss, exp, aud, sub,
+// client_id, iat and jti claims, iss equals expectedIssuer and aud contains
+// expectedAudience.  exp and iat are checked against TimeFunc.
+//
+// token must be the result of a successful Parse; the signature is not
+// checked again.  Failures are reported as a *ValidationError.
+func ValidateAccessToken(token *Token, expectedIssuer, expectedAudience string) error {
+	claims, err := toMapClaims(token.Claims)
+	if err != nil {
+		return &ValidationError{Inner: err, Errors: ValidationErrorClaimsInvalid}
+	}
+	if bits, err := checkAccessTokenProfile(token.Header, claims); err != nil {
+		return NewValidationError(err.Error(), bits)
+	}
+
+	if iss, _ := claims["iss"].(string); iss != expectedIssuer {
+		return NewValidationError(fmt.Sprintf("token issuer %q is not %q", iss, expectedIssuer), ValidationErrorIssuer)
+	}
+	if _, ok := claims.MatchedAudience(expectedAudience); !ok {
+		return NewValidationError(fmt.Sprintf("token audience does not contain %q", expectedAudience), ValidationErrorAudience)
+	}
+	return claims.ValidateClaims(TimeFunc().Unix(), "exp", "iat")
+}
+
+// Checks the typ header and the presence and types of the claims of an
+// access token, returning why it fails and the error bits to report
+func checkAccessTokenProfile(header map[string]interface{}, claims MapClaims) (uint32, error) {
+	typ, _ := header["typ"].(string)
+	typ = strings.TrimPrefix(strings.ToLower(typ), "application/")
+	if typ != AccessTokenType {
+		return ValidationErrorClaimsInvalid, fmt.Errorf("token typ %q is not %q", header["typ"], AccessTokenType)
+	}
+
+	for _, name := range accessTokenClaims {
+		if _, ok := claims[name]; !ok {
+			return ValidationErrorClaimsInvalid, fmt.Errorf("access token has no %v claim", name)
+		}
+	}
+	for _, name := range []string{"sub", "client_id", "jti"} {
+		if _, ok := claims[name].(string); !ok {
+			return ValidationErrorClaimsInvalid, fmt.Errorf("access token %v claim is not a string", name)
+		}
+	}
+	if _, ok := claims.numericDate("exp"); !ok {
+		return ValidationErrorExpired, errors.New("access token exp claim is not a numeric date")
+	}
+	if _, ok := claims.numericDate("iat"); !ok {
+		return ValidationErrorIssuedAt, errors.New("access token iat claim is not a numeric date")
+	}
+	return 0, nil
+}
@@ -0,0 +1,120 @@
+package jwt_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/form3tech-oss/jwt-go"
+)
+
+func TestValidateAccessToken(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	now := time.Now().Unix()
+	compliant := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":       "https://as.example.com",
+			"exp":       now + 300,
+			"aud":       []string{"https://rs.example.com", "https://other.example.com"},
+			"sub":       "5ba552d67",
+			"client_id": "s6BhdRkqt3",
+			"iat":       now,
+			"jti":       "dbe39bf3a3ba4238a513f51d6e1691c4",
+		}
+	}
+
+	var accessTokenTestData = []struct {
+		name   string
+		typ    string
+		claims jwt.MapClaims
+		errors uint32
+	}{
+		{"compliant", jwt.AccessTokenType, compliant(), 0},
+		{"media type", "application/at+JWT", compliant(), 0},
+		{"plain JWT", "", compliant(), jwt.ValidationErrorClaimsInvalid},
+		{"no client_id", jwt.AccessTokenType, withoutClaim(compliant(), "client_id"), jwt.ValidationErrorClaimsInvalid},
+		{"no jti", jwt.AccessTokenType, withoutClaim(compliant(), "jti"), jwt.ValidationErrorClaimsInvalid},
+		{"other issuer", jwt.AccessTokenType, withClaim(compliant(), "iss", "https://evil.example.com"), jwt.ValidationErrorIssuer},
+		{"other audience", jwt.AccessTokenType, withClaim(compliant(), "aud", "https://evil.example.com"), jwt.ValidationErrorAudience},
+		{"iat in the future", jwt.AccessTokenType, withClaim(compliant(), "iat", now+100), jwt.ValidationErrorIssuedAt},
+	}
+
+	for _, data := range accessTokenTestData {
+		tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, data.claims, jwt.WithType(data.typ)).SignedString(key)
+		token, err := (&jwt.Parser{SkipClaimsValidation: true}).Parse(tokenString, keyFunc)
+		if err != nil {
+			t.Fatalf("[%v] Error while parsing token: %v", data.name, err)
+		}
+		err = jwt.ValidateAccessToken(token, "https://as.example.com", "https://rs.example.com")
+		if data.errors == 0 && err != nil {
+			t.Errorf("[%v] Error while validating access token: %v", data.name, err)
+		}
+		if data.errors != 0 {
+			if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != data.errors {
+				t.Errorf("[%v] Expecting error bits %v, got %v", data.name, data.errors, err)
+			}
+		}
+	}
+}
+
+func withClaim(claims jwt.MapClaims, name string, value interface{}) jwt.MapClaims {
+	claims[name] = value
+	return claims
+}
+
+func withoutClaim(claims jwt.MapClaims, name string) jwt.MapClaims {
+	delete(claims, name)
+	return claims
+}
+
+func TestParser_WithAccessTokenProfile(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	now := time.Now().Unix()
+	compliant := func() jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":       "https://as.example.com",
+			"exp":       now + 300,
+			"aud":       "https://rs.example.com",
+			"sub":       "5ba552d67",
+			"client_id": "s6BhdRkqt3",
+			"iat":       now,
+			"jti":       "dbe39bf3a3ba4238a513f51d6e1691c4",
+			"scope":     "openid profile reademail",
+		}
+	}
+	parser := jwt.NewParser(jwt.WithAccessTokenProfile("https://as.example.com", "https://rs.example.com"))
+
+	var profileTestData = []struct {
+		name   string
+		typ    string
+		claims jwt.MapClaims
+		errors uint32
+	}{
+		{"compliant", jwt.AccessTokenType, compliant(), 0},
+		{"plain JWT", "JWT", compliant(), jwt.ValidationErrorClaimsInvalid},
+		{"no sub", jwt.AccessTokenType, withoutClaim(compliant(), "sub"), jwt.ValidationErrorClaimsInvalid},
+		{"other issuer", jwt.AccessTokenType, withClaim(compliant(), "iss", "https://evil.example.com"), jwt.ValidationErrorIssuer},
+		{"other audience", jwt.AccessTokenType, withClaim(compliant(), "aud", "https://evil.example.com"), jwt.ValidationErrorAudience},
+		{"expired", jwt.AccessTokenType, withClaim(compliant(), "exp", now-100), jwt.ValidationErrorExpired},
+	}
+
+	for _, data := range profileTestData {
+		tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, data.claims, jwt.WithType(data.typ)).SignedString(key)
+		token, err := parser.Parse(tokenString, keyFunc)
+		if data.errors == 0 {
+			if err != nil {
+				t.Errorf("[%v] Error while parsing token: %v", data.name, err)
+				continue
+			}
+			claims := token.Claims.(jwt.MapClaims)
+			if claims.ClientID() != "s6BhdRkqt3" || !claims.HasScope("reademail") {
+				t.Errorf("[%v] Expecting the client_id and scopes, got %v and %v", data.name, claims.ClientID(), claims.Scopes())
+			}
+			continue
+		}
+		if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != data.errors {
+			t.Errorf("[%v] Expecting error bits %v, got %v", data.name, data.errors, err)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package jwt
+
+import "strings"
+
+// A Keyfunc variant for key sources with high latency, such as a remote key
+// service.  It is called with the parsed, but unverified Token, must start
+// resolving the key without blocking, e.g. in a goroutine, and returns a
+// function that waits for and returns the result.
+type AsyncKeyfunc func(*Token) func() (interface{}, error)
+
+// Like ParseWithClaims, but overlaps key resolution with claims validation.
+// See Parser.ParseWithClaimsAsync.
+func ParseWithClaimsAsync(tokenString string, claims Claims, keyFunc AsyncKeyfunc) (*Token, error) {
+	return new(Parser).ParseWithClaimsAsync(tokenString, claims, keyFunc)
+}
+
+// Like ParseWithClaims, but starts resolving the key with keyFunc as soon as
+// the token is decoded and validates the claims while the key is fetched.
+// Both are joined before the signature is verified, and the result is the
+// same as that of ParseWithClaims: claims errors of a token whose signature
+// doesn't verify are not reported unless WithClaimsValidationOnInvalidSignature
+// is set.
+//
+// The claims are validated before the signature verified, so Claims.Valid and
+// checks such as WithRevocationChecker also see forged tokens.  Use
+// ParseWithClaims if they must not.
+func (p *Parser) ParseWithClaimsAsync(tokenString string, claims Claims, keyFunc AsyncKeyfunc) (*Token, error) {
+	if p.hooks == nil {
+		return p.parseAsync(tokenString, claims, keyFunc)
+	}
+	p.hooks.parseStart()
+	token, err := p.parseAsync(tokenString, claims, keyFunc)
+	p.hooks.parseDone(token, err)
+	return token, err
+}
+
+func (p *Parser) parseAsync(tokenString string, claims Claims, keyFunc AsyncKeyfunc) (*Token, error) {
+	token, parts, err := p.ParseUnverified(tokenString, claims)
+	if err != nil {
+		return token, err
+	}
+
+	token.Signature = parts[2]
+	if p.paddingAllowed {
+		token.Signature = strings.TrimRight(token.Signature, "=")
+	}
+
+	if err = p.checkHeader(token); err != nil {
+		return token, err
+	}
+	if p.verifiedRecently(tokenString) {
+		// The signature verified recently, there is no key to wait for
+		return token, p.validate(token, parts, nil)
+	}
+
+	var resolve Keyfunc
+	if keyFunc != nil {
+		wait := keyFunc(token)
+		resolve = func(*Token) (interface{}, error) { return wait() }
+	}
+
+	var cErr *ValidationError
+	if !p.SkipClaimsValidation {
+		cErr = p.validateClaims(token, parts)
+	}
+
+	key, err := p.lookupKey(token, resolve)
+	if err != nil {
+		return token, err
+	}
+	err = p.verifySignature(token, tokenString, parts, key)
+
+	if err = p.validateWithClaims(token, parts, err, cErr); err != nil {
+		return token, err
+	}
+	return token, nil
+}
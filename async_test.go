@@ -0,0 +1,74 @@
+package jwt_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/form3tech-oss/jwt-go"
+)
+
+// Claims signalling when they were validated
+type signallingClaims struct {
+	jwt.StandardClaims
+	validated chan struct{}
+}
+
+func (c *signallingClaims) Valid() error {
+	close(c.validated)
+	return c.StandardClaims.Valid()
+}
+
+func TestParser_ParseWithClaimsAsync(t *testing.T) {
+	key := []byte("secret")
+	now := time.Now().Unix()
+	errFetch := errors.New("key service unavailable")
+
+	var asyncTestData = []struct {
+		name    string
+		claims  jwt.StandardClaims
+		key     interface{}
+		keyErr  error
+		errors  uint32
+		wrapped error
+	}{
+		{"valid", jwt.StandardClaims{ExpiresAt: now + 100}, key, nil, 0, nil},
+		{"expired", jwt.StandardClaims{ExpiresAt: now - 100}, key, nil, jwt.ValidationErrorExpired, nil},
+		{"wrong key", jwt.StandardClaims{ExpiresAt: now - 100}, []byte("other"), nil, jwt.ValidationErrorSignatureInvalid, jwt.ErrSignatureInvalid},
+		{"fetch failed", jwt.StandardClaims{ExpiresAt: now + 100}, nil, errFetch, jwt.ValidationErrorUnverifiable, errFetch},
+	}
+
+	for _, data := range asyncTestData {
+		tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, data.claims).SignedString(key)
+		claims := &signallingClaims{validated: make(chan struct{})}
+
+		// The key only becomes available once the claims were validated, so
+		// the parse would deadlock if it waited for the key first
+		keyFunc := func(*jwt.Token) func() (interface{}, error) {
+			result := make(chan interface{}, 1)
+			go func() {
+				<-claims.validated
+				result <- data.key
+			}()
+			return func() (interface{}, error) {
+				return <-result, data.keyErr
+			}
+		}
+
+		token, err := jwt.ParseWithClaimsAsync(tokenString, claims, keyFunc)
+		if data.errors == 0 {
+			if err != nil || !token.Valid {
+				t.Errorf("[%v] Error while parsing token: %v", data.name, err)
+			}
+			continue
+		}
+		ve, ok := err.(*jwt.ValidationError)
+		if !ok || ve.Errors != data.errors {
+			t.Errorf("[%v] Expecting errors %v, got %v", data.name, data.errors, err)
+			continue
+		}
+		if data.wrapped != nil && ve.Inner != data.wrapped {
+			t.Errorf("[%v] Expecting %v as the cause, got %v", data.name, data.wrapped, ve.Inner)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package jwt
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// A store of the token strings whose signature verified, consulted by
+// WithVerificationStore to skip the key lookup and the cryptographic check
+// when a token is presented again.  Implement it over a shared cache, e.g.
+// Redis, so that the replicas of a service skip the tokens any of them
+// verified; VerificationCache is the in-memory implementation.  Tokens are
+// identified by key, the hex encoded SHA-256 hash of the token string,
+// never by the token itself, following the namespace of the parser and a
+// colon when it has one.
+type VerificationStore interface {
+	// Reports whether the token identified by key verified and its entry has
+	// not expired.  An error counts as a miss, so the signature is verified.
+	Contains(ctx context.Context, key string) (bool, error)
+
+	// Records that the signature of the token identified by key verified.  exp
+	// is the time of its exp claim, zero if it has none; the entry must not
+	// outlive it, and may expire sooner.  Errors are ignored.
+	Add(ctx context.Context, key string, exp time.Time) error
+}
+
+// A size-bounded LRU of token strings whose signature verified, used with
+// WithVerificationCache to skip the Keyfunc and the cryptographic check when
+// the same token is presented again within the TTL, or until its exp claim
+// if that comes first.  Claims are still validated against the current time
+// on every parse.
+//
+// A hit skips the key lookup, so only share a cache between parsers that
+// would look up the same keys.  A VerificationCache is safe for concurrent
+// use.
+type VerificationCache struct {
+	size int
+	ttl  time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // Most recently used first
+}
+
+type verificationCacheEntry struct {
+	key     string
+	expires time.Time
+}
+
+// Creates a VerificationCache holding up to size token strings, each for ttl
+// after its signature verified
+func NewVerificationCache(size int, ttl time.Duration) *VerificationCache {
+	return &VerificationCache{
+		size:    size,
+		ttl:     ttl,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Reports whether the token identified by key verified within the TTL.
+// Implements VerificationStore.
+func (c *VerificationCache) Contains(ctx context.Context, key string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return false, nil
+	}
+	if !TimeFunc().Before(elem.Value.(*verificationCacheEntry).expires) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return false, nil
+	}
+	c.order.MoveToFront(elem)
+	return true, nil
+}
+
+// Records that the token identified by key verified, until the TTL or exp
+// runs out.  Implements VerificationStore.
+func (c *VerificationCache) Add(ctx context.Context, key string, exp time.Time) error {
+	if c.size <= 0 {
+		return nil
+	}
+	expires := TimeFunc().Add(c.ttl)
+	if !exp.IsZero() && exp.Before(expires) {
+		expires = exp
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*verificationCacheEntry).expires = expires
+		c.order.MoveToFront(elem)
+		return nil
+	}
+	c.entries[key] = c.order.PushFront(&verificationCacheEntry{key, expires})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*verificationCacheEntry).key)
+	}
+	return nil
+}
+
+// Number of token strings in the cache, including expired ones not yet evicted
+func (c *VerificationCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// The key of tokenString in the VerificationStore of the parser
+func (p *Parser) verificationKey(tokenString string) string {
+	hash := sha256.Sum256([]byte(tokenString))
+	if p.verificationSpace == "" {
+		return hex.EncodeToString(hash[:])
+	}
+	return p.verificationSpace + ":" + hex.EncodeToString(hash[:])
+}
+
+// Reports whether the signature of tokenString verified recently
+func (p *Parser) verifiedRecently(tokenString string) bool {
+	if p.verificationStore == nil {
+		return false
+	}
+	ok, err := p.verificationStore.Contains(p.context(), p.verificationKey(tokenString))
+	return ok && err == nil
+}
+
+// Records that the signature of token, parsed from tokenString, verified
+func (p *Parser) recordVerified(token *Token, tokenString string, parts []string) {
+	var exp time.Time
+	if claims, err := p.mapClaims(token, parts); err == nil {
+		if date, ok := claims.numericDate("exp"); ok {
+			exp = time.Unix(date, 0)
+		}
+	}
+	p.verificationStore.Add(p.context(), p.verificationKey(tokenString), exp)
+}
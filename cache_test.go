@@ -0,0 +1,139 @@
+package jwt_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/form3tech-oss/jwt-go"
+)
+
+func TestParser_WithVerificationCache(t *testing.T) {
+	defer func() { jwt.TimeFunc = time.Now }()
+	now := time.Unix(1500000000, 0)
+	jwt.TimeFunc = func() time.Time { return now }
+
+	key := []byte("secret")
+	calls := 0
+	keyFunc := func(*jwt.Token) (interface{}, error) {
+		calls++
+		return key, nil
+	}
+	sign := func(sub string) string {
+		tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": sub, "exp": float64(now.Unix() + 60)}).SignedString(key)
+		return tokenString
+	}
+	alice, bob := sign("alice"), sign("bob")
+
+	parser := jwt.NewParser(jwt.WithVerificationCache(jwt.NewVerificationCache(1, 5*time.Minute)))
+	expectCalls := func(name string, tokenString string, want int, valid bool) {
+		_, err := parser.Parse(tokenString, keyFunc)
+		if valid && err != nil {
+			t.Errorf("[%v] Error while parsing token: %v", name, err)
+		}
+		if !valid && err == nil {
+			t.Errorf("[%v] Expecting an error", name)
+		}
+		if calls != want {
+			t.Errorf("[%v] Expecting %d Keyfunc calls, got %d", name, want, calls)
+		}
+	}
+
+	expectCalls("first", alice, 1, true)
+	expectCalls("cached", alice, 1, true)
+
+	// Tampered signatures are never cached
+	expectCalls("tampered", alice+"x", 2, false)
+	expectCalls("tampered again", alice+"x", 3, false)
+
+	// The cache holds a single token, so bob evicts alice
+	expectCalls("other", bob, 4, true)
+	expectCalls("evicted", alice, 5, true)
+
+	// The entry lasts until exp, however long the TTL
+	now = now.Add(2 * time.Minute)
+	_, err := parser.Parse(alice, keyFunc)
+	if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorExpired {
+		t.Errorf("[expired] Expecting ValidationErrorExpired, got %v", err)
+	}
+	if calls != 6 {
+		t.Errorf("[expired] Expecting the signature to be verified again, got %d Keyfunc calls", calls)
+	}
+
+	// Past the TTL the signature is verified again
+	long, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "carol"}).SignedString(key)
+	expectCalls("no exp", long, 7, true)
+	expectCalls("no exp, cached", long, 7, true)
+	now = now.Add(5 * time.Minute)
+	expectCalls("ttl", long, 8, true)
+}
+
+type storeContextKey struct{}
+
+type recordingStore struct {
+	entries map[string]time.Time
+	ctx     context.Context
+}
+
+func (s *recordingStore) Contains(ctx context.Context, key string) (bool, error) {
+	s.ctx = ctx
+	_, ok := s.entries[key]
+	return ok, nil
+}
+
+func (s *recordingStore) Add(ctx context.Context, key string, exp time.Time) error {
+	s.entries[key] = exp
+	return nil
+}
+
+func TestParser_WithVerificationStore(t *testing.T) {
+	key := []byte("secret")
+	exp := time.Now().Add(time.Hour).Truncate(time.Second)
+	tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"exp": float64(exp.Unix())}).SignedString(key)
+	store := &recordingStore{entries: map[string]time.Time{}}
+	parser := jwt.NewParser(jwt.WithVerificationStore(store, ""))
+
+	calls := 0
+	keyFunc := func(ctx context.Context, token *jwt.Token) (interface{}, error) {
+		calls++
+		return key, nil
+	}
+	ctx := context.WithValue(context.Background(), storeContextKey{}, "request")
+	for i := 0; i < 2; i++ {
+		if _, err := parser.ParseWithClaimsContext(ctx, tokenString, jwt.MapClaims{}, keyFunc); err != nil {
+			t.Fatalf("Error while parsing token: %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expecting the store to skip the second verification, got %d Keyfunc calls", calls)
+	}
+	if store.ctx != ctx {
+		t.Errorf("Expecting the store to get the context of the parse")
+	}
+
+	hash := sha256.Sum256([]byte(tokenString))
+	if got, ok := store.entries[hex.EncodeToString(hash[:])]; !ok || !got.Equal(exp) {
+		t.Errorf("Expecting an entry keyed by the token hash until %v, got %v", exp, store.entries)
+	}
+
+	// A hit doesn't skip the signing method and header checks
+	strict := jwt.NewParser(jwt.WithVerificationStore(store, ""), jwt.WithValidMethods([]string{"RS256"}))
+	if _, err := strict.ParseWithClaimsContext(ctx, tokenString, jwt.MapClaims{}, keyFunc); err == nil || err.(*jwt.ValidationError).Errors != jwt.ValidationErrorSignatureInvalid {
+		t.Errorf("[methods] Expecting ValidationErrorSignatureInvalid, got %v", err)
+	}
+	strict = jwt.NewParser(jwt.WithVerificationStore(store, ""), jwt.WithExpectedType("at+jwt"))
+	if _, err := strict.ParseWithClaimsContext(ctx, tokenString, jwt.MapClaims{}, keyFunc); err == nil {
+		t.Errorf("[typ] Expecting the header check to fail")
+	}
+
+	// Parsers with another namespace don't share the entries
+	other := jwt.NewParser(jwt.WithVerificationStore(store, "tenant-b"))
+	if _, err := other.ParseWithClaimsContext(ctx, tokenString, jwt.MapClaims{}, keyFunc); err != nil || calls != 2 {
+		t.Errorf("[namespace] Expecting the token to be verified again, got %d Keyfunc calls (%v)", calls, err)
+	}
+	if _, ok := store.entries["tenant-b:"+hex.EncodeToString(hash[:])]; !ok {
+		t.Errorf("[namespace] Expecting an entry prefixed by the namespace, got %v", store.entries)
+	}
+}
@@ -0,0 +1,37 @@
+package jwt
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// WithCanonicalClaims encodes the claims of the token in canonical form,
+// see canonicalJSON, so that a partner verifying over the canonical claims
+// with WithCanonicalPayload computes the same signing input.
+func WithCanonicalClaims() TokenOption {
+	return func(t *Token) {
+		t.canonicalClaims = true
+	}
+}
+
+// Re-encodes a JSON document with object members sorted by name, no
+// insignificant whitespace and no escaping of <, > and &.  Numbers keep
+// their textual form.  This is not the full RFC 8785 canonicalization, only
+// what is needed to make the encoding independent of member order and
+// formatting.
+func canonicalJSON(data []byte) ([]byte, error) {
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
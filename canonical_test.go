@@ -0,0 +1,45 @@
+package jwt_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/form3tech-oss/jwt-go"
+)
+
+func TestCanonicalPayload(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	parser := jwt.NewParser(jwt.WithCanonicalPayload())
+
+	// Struct claims are encoded in field order, not sorted
+	claims := &jwt.StandardClaims{Subject: "alice", Issuer: "https://a&b.example.com", Audience: jwt.ClaimStrings{"billing"}}
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims, jwt.WithCanonicalClaims()).SignedString(key)
+	if err != nil {
+		t.Fatalf("Error signing token: %v", err)
+	}
+	payload, _ := jwt.DecodeSegment(strings.Split(tokenString, ".")[1])
+	if expected := `{"aud":"billing","iss":"https://a&b.example.com","sub":"alice"}`; string(payload) != expected {
+		t.Errorf("[round trip] Expecting canonical claims %v, got %v", expected, string(payload))
+	}
+	if _, err := parser.ParseWithClaims(tokenString, &jwt.StandardClaims{}, keyFunc); err != nil {
+		t.Errorf("[round trip] Error while parsing token: %v", err)
+	}
+
+	// The issuer signs the canonical claims but transmits them reformatted
+	header := jwt.EncodeSegment([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	canonical := jwt.EncodeSegment([]byte(`{"foo":"bar","sub":"alice"}`))
+	sig, _ := jwt.SigningMethodHS256.Sign(header+"."+canonical, key)
+	transmitted := header + "." + jwt.EncodeSegment([]byte("{\n  \"sub\": \"alice\",\n  \"foo\": \"bar\"\n}")) + "." + sig
+
+	if _, err := jwt.Parse(transmitted, keyFunc); err == nil {
+		t.Errorf("[default] Expecting the reformatted claims to fail verification")
+	}
+	token, err := parser.Parse(transmitted, keyFunc)
+	if err != nil || !token.Valid {
+		t.Fatalf("[canonical] Error while parsing token: %v", err)
+	}
+	if token.Claims.(jwt.MapClaims)["foo"] != "bar" {
+		t.Errorf("[canonical] Unexpected claims %v", token.Claims)
+	}
+}
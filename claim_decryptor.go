@@ -0,0 +1,49 @@
+package jwt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+)
+
+// A decryptor for the value of a claim.  See WithClaimDecryptor.
+type claimDecryptor struct {
+	fn     func([]byte) ([]byte, error)
+	asJSON bool // Decode the cleartext as JSON instead of keeping it as a string
+}
+
+func (p *Parser) addClaimDecryptor(name string, d claimDecryptor) {
+	if p.claimDecryptors == nil {
+		p.claimDecryptors = make(map[string]claimDecryptor)
+	}
+	p.claimDecryptors[name] = d
+}
+
+// Returns the cleartext of the value v of the claim called name
+func (d claimDecryptor) decrypt(codec JSONCodec, name string, v interface{}) (interface{}, error) {
+	encoded, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("claim %v is not a base64 string", name)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		if ciphertext, err = base64.RawURLEncoding.DecodeString(encoded); err != nil {
+			return nil, fmt.Errorf("claim %v is not a base64 string", name)
+		}
+	}
+	cleartext, err := d.fn(ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("claim %v could not be decrypted: %v", name, err)
+	}
+	if !d.asJSON {
+		return string(cleartext), nil
+	}
+
+	var value interface{}
+	dec := codec.NewDecoder(bytes.NewReader(cleartext))
+	dec.UseNumber()
+	if err := dec.Decode(&value); err != nil {
+		return nil, fmt.Errorf("claim %v cleartext is not JSON: %v", name, err)
+	}
+	return value, nil
+}
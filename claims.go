@@ -7,7 +7,9 @@ import (
 )
 
 // For a type to be a Claims object, it must just have a Valid method that determines
-// if the token is invalid for any supported reason
+// if the token is invalid for any supported reason.  Custom claims are
+// responsible for their own validation, including exp, nbf and iat; see
+// WithForcedTimeValidation to have the parser check those regardless.
 type Claims interface {
 	Valid() error
 }
@@ -16,20 +18,24 @@ type Claims interface {
 // https://tools.ietf.org/html/rfc7519#section-4.1
 // See examples for how to use this with your own claim types
 type StandardClaims struct {
-	Audience  []string `json:"aud,omitempty"`
-	ExpiresAt int64  `json:"exp,omitempty"`
-	Id        string `json:"jti,omitempty"`
-	IssuedAt  int64  `json:"iat,omitempty"`
-	Issuer    string `json:"iss,omitempty"`
-	NotBefore int64  `json:"nbf,omitempty"`
-	Subject   string `json:"sub,omitempty"`
+	Audience  ClaimStrings `json:"aud,omitempty"`
+	ExpiresAt int64        `json:"exp,omitempty"`
+	Id        string       `json:"jti,omitempty"`
+	IssuedAt  int64        `json:"iat,omitempty"`
+	Issuer    string       `json:"iss,omitempty"`
+	NotBefore int64        `json:"nbf,omitempty"`
+	Subject   string       `json:"sub,omitempty"`
 }
 
 // Validates time based claims "exp, iat, nbf".
 // There is no accounting for clock skew.
 // As well, if any of the above claims are not in the token, it will still
-// be considered a valid claim.
+// be considered a valid claim.  Without any of them TimeFunc is not called.
 func (c StandardClaims) Valid() error {
+	if c.ExpiresAt == 0 && c.IssuedAt == 0 && c.NotBefore == 0 {
+		return nil
+	}
+
 	vErr := new(ValidationError)
 	now := TimeFunc().Unix()
 
@@ -103,13 +109,18 @@ func verifyAud(aud []string, cmp string, required bool) bool {
 	return false
 }
 
+// Per RFC 7519 the current time must be before exp, so the token is already
+// expired at now == exp
 func verifyExp(exp int64, now int64, required bool) bool {
 	if exp == 0 {
 		return !required
 	}
-	return now <= exp
+	return now < exp
 }
 
+// A token must not be used before it was issued, so the token is valid at
+// now == iat and rejected when iat is in the future.  Callers tolerating clock
+// skew pass now+skew, accepting an iat up to skew seconds ahead of the clock.
 func verifyIat(iat int64, now int64, required bool) bool {
 	if iat == 0 {
 		return !required
@@ -128,6 +139,8 @@ func verifyIss(iss string, cmp string, required bool) bool {
 	}
 }
 
+// Per RFC 7519 the current time must be after or equal to nbf, so the token
+// is valid at now == nbf
 func verifyNbf(nbf int64, now int64, required bool) bool {
 	if nbf == 0 {
 		return !required
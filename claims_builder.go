@@ -0,0 +1,175 @@
+package jwt
+
+import "time"
+
+// Builds MapClaims with the standard claim names spelled correctly and the
+// time based claims encoded as numeric dates.  Each method sets one claim and
+// returns the builder, so calls can be chained:
+//
+//	claims := NewClaimsBuilder().Issuer("auth").Subject("alice").ExpiresIn(time.Hour).Build()
+type ClaimsBuilder struct {
+	claims    MapClaims
+	expires   bool          // Set exp when the claims are built. See ExpiresIn
+	expiresIn time.Duration // From the time the claims are built
+}
+
+// Creates an empty ClaimsBuilder
+func NewClaimsBuilder() *ClaimsBuilder {
+	return &ClaimsBuilder{claims: MapClaims{}}
+}
+
+// Sets the iss claim
+func (b *ClaimsBuilder) Issuer(s string) *ClaimsBuilder {
+	return b.Set("iss", s)
+}
+
+// Sets the sub claim
+func (b *ClaimsBuilder) Subject(s string) *ClaimsBuilder {
+	return b.Set("sub", s)
+}
+
+// Sets the aud claim, as a single string for one audience and as an array
+// otherwise
+func (b *ClaimsBuilder) Audience(aud ...string) *ClaimsBuilder {
+	if len(aud) == 1 {
+		return b.Set("aud", aud[0])
+	}
+	return b.Set("aud", append([]string(nil), aud...))
+}
+
+// Sets the exp claim to d after the time the claims are built, as returned
+// by TimeFunc, and the iat claim to that time unless IssuedAt sets it.  A
+// reused builder computes exp again on every Build.
+func (b *ClaimsBuilder) ExpiresIn(d time.Duration) *ClaimsBuilder {
+	b.expires, b.expiresIn = true, d
+	return b
+}
+
+// Sets the nbf claim to t
+func (b *ClaimsBuilder) NotBefore(t time.Time) *ClaimsBuilder {
+	return b.Set("nbf", float64(t.Unix()))
+}
+
+// Sets the iat claim to t
+func (b *ClaimsBuilder) IssuedAt(t time.Time) *ClaimsBuilder {
+	return b.Set("iat", float64(t.Unix()))
+}
+
+// Sets the jti claim
+func (b *ClaimsBuilder) ID(s string) *ClaimsBuilder {
+	return b.Set("jti", s)
+}
+
+// Sets the claim key to value, for private claims
+func (b *ClaimsBuilder) Set(key string, value interface{}) *ClaimsBuilder {
+	b.claims[key] = value
+	return b
+}
+
+// Returns the claims built so far.  The builder can be reused; later calls
+// don't affect claims already returned.
+func (b *ClaimsBuilder) Build() MapClaims {
+	claims := make(MapClaims, len(b.claims)+2)
+	for k, v := range b.claims {
+		claims[k] = v
+	}
+	if b.expires {
+		now := TimeFunc()
+		claims["exp"] = float64(now.Add(b.expiresIn).Unix())
+		if _, ok := claims["iat"]; !ok {
+			claims["iat"] = float64(now.Unix())
+		}
+	}
+	return claims
+}
+
+// Builds and signs a token in one chain of calls, setting the claims as
+// ClaimsBuilder does:
+//
+//	tokenString, err := NewBuilder(SigningMethodRS256, key).
+//		Issuer("auth").Audience("api").ExpiresIn(15 * time.Minute).
+//		Claim("scope", "read").Sign()
+type Builder struct {
+	method SigningMethod
+	key    interface{}
+	header map[string]interface{}
+	claims *ClaimsBuilder
+}
+
+// Creates a Builder of tokens signed with method and key
+func NewBuilder(method SigningMethod, key interface{}) *Builder {
+	return &Builder{method: method, key: key, header: map[string]interface{}{}, claims: NewClaimsBuilder()}
+}
+
+// Sets the iss claim
+func (b *Builder) Issuer(s string) *Builder {
+	b.claims.Issuer(s)
+	return b
+}
+
+// Sets the sub claim
+func (b *Builder) Subject(s string) *Builder {
+	b.claims.Subject(s)
+	return b
+}
+
+// Sets the aud claim, see ClaimsBuilder.Audience
+func (b *Builder) Audience(aud ...string) *Builder {
+	b.claims.Audience(aud...)
+	return b
+}
+
+// Sets the exp claim to d after the time the token is signed, as returned by
+// TimeFunc, and the iat claim to that time unless IssuedAt sets it
+func (b *Builder) ExpiresIn(d time.Duration) *Builder {
+	b.claims.ExpiresIn(d)
+	return b
+}
+
+// Sets the nbf claim to t
+func (b *Builder) NotBefore(t time.Time) *Builder {
+	b.claims.NotBefore(t)
+	return b
+}
+
+// Sets the iat claim to t
+func (b *Builder) IssuedAt(t time.Time) *Builder {
+	b.claims.IssuedAt(t)
+	return b
+}
+
+// Sets the jti claim
+func (b *Builder) ID(s string) *Builder {
+	b.claims.ID(s)
+	return b
+}
+
+// Sets the claim key to value, for private claims
+func (b *Builder) Claim(key string, value interface{}) *Builder {
+	b.claims.Set(key, value)
+	return b
+}
+
+// Sets the kid header, naming the key the token is signed with
+func (b *Builder) KeyID(kid string) *Builder {
+	return b.Header("kid", kid)
+}
+
+// Sets the header key to value.  The alg header is always the alg of the
+// signing method.
+func (b *Builder) Header(key string, value interface{}) *Builder {
+	b.header[key] = value
+	return b
+}
+
+// Signs the token, returning its compact serialization.  The builder can be
+// reused to sign further tokens, each expiring ExpiresIn after it is signed.
+func (b *Builder) Sign() (string, error) {
+	token := NewWithClaims(b.method, b.claims.Build())
+	for k, v := range b.header {
+		if k != "alg" {
+			token.Header[k] = v
+		}
+	}
+	return token.SignedString(b.key)
+}
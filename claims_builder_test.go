@@ -0,0 +1,122 @@
+package jwt_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/form3tech-oss/jwt-go"
+	"github.com/form3tech-oss/jwt-go/test"
+)
+
+func TestClaimsBuilder(t *testing.T) {
+	defer func() { jwt.TimeFunc = time.Now }()
+	now := time.Now()
+	jwt.TimeFunc = func() time.Time { return now }
+
+	builder := jwt.NewClaimsBuilder().
+		Issuer("https://auth.example.com").
+		Subject("alice").
+		Audience("billing", "reports").
+		ExpiresIn(time.Hour).
+		NotBefore(now).
+		IssuedAt(now).
+		ID("4f1g23a12aa").
+		Set("role", "admin")
+	claims := builder.Build()
+
+	expected := jwt.MapClaims{
+		"iss":  "https://auth.example.com",
+		"sub":  "alice",
+		"aud":  []string{"billing", "reports"},
+		"exp":  float64(now.Add(time.Hour).Unix()),
+		"nbf":  float64(now.Unix()),
+		"iat":  float64(now.Unix()),
+		"jti":  "4f1g23a12aa",
+		"role": "admin",
+	}
+	if !reflect.DeepEqual(claims, expected) {
+		t.Errorf("Expecting claims %v, got %v", expected, claims)
+	}
+	if err := claims.Valid(); err != nil {
+		t.Errorf("Error while validating built claims: %v", err)
+	}
+	if !claims.VerifyAudience("reports", true) {
+		t.Errorf("Expecting the built audience to verify")
+	}
+
+	// Round trip through a signed token
+	key := []byte("secret")
+	tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+	if _, err := jwt.Parse(tokenString, func(*jwt.Token) (interface{}, error) { return key, nil }); err != nil {
+		t.Errorf("Error while parsing token: %v", err)
+	}
+
+	// Built claims are independent of later builder calls
+	builder.Audience("admin").ExpiresIn(-time.Hour)
+	if claims["aud"] == "admin" {
+		t.Errorf("Expecting built claims to be unaffected by the builder")
+	}
+	if err := builder.Build().Valid(); err == nil {
+		t.Errorf("Expecting the rebuilt, expired claims to be invalid")
+	}
+}
+
+func TestBuilder(t *testing.T) {
+	privateKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	now := time.Now()
+
+	tokenString, err := jwt.NewBuilder(jwt.SigningMethodRS256, privateKey).
+		Issuer("me").
+		Subject("alice").
+		Audience("api").
+		ExpiresIn(15*time.Minute).
+		IssuedAt(now).
+		ID("1").
+		Claim("scope", "read").
+		KeyID("rsa-1").
+		Header("alg", "none").
+		Sign()
+	if err != nil {
+		t.Fatalf("Error signing token: %v", err)
+	}
+
+	token, err := jwt.NewParser(jwt.WithIssuer("me"), jwt.WithAudience("api")).Parse(tokenString, defaultKeyFunc)
+	if err != nil {
+		t.Fatalf("Error while parsing token: %v", err)
+	}
+	if token.Header["kid"] != "rsa-1" || token.Header["alg"] != "RS256" {
+		t.Errorf("Expecting the kid and the alg of the signing method, got %v", token.Header)
+	}
+	claims := token.Claims.(jwt.MapClaims)
+	if claims["sub"] != "alice" || claims["scope"] != "read" || claims["jti"] != "1" || claims["iat"] != float64(now.Unix()) {
+		t.Errorf("Unexpected claims %v", claims)
+	}
+	if exp := int64(claims["exp"].(float64)); exp < now.Add(15*time.Minute).Unix()-1 || exp > now.Add(15*time.Minute).Unix()+1 {
+		t.Errorf("Expecting exp 15 minutes from now, got %v", exp)
+	}
+
+	// A reused builder computes exp and iat as each token is signed
+	defer func() { jwt.TimeFunc = time.Now }()
+	jwt.TimeFunc = func() time.Time { return now }
+	builder := jwt.NewBuilder(jwt.SigningMethodHS256, []byte("secret")).ExpiresIn(time.Minute)
+	first, _ := builder.Sign()
+	later := now.Add(time.Hour)
+	jwt.TimeFunc = func() time.Time { return later }
+	second, _ := builder.Sign()
+	for _, data := range []struct {
+		name        string
+		tokenString string
+		signed      time.Time
+	}{{"first", first, now}, {"second", second, later}} {
+		claims := jwt.MapClaims{}
+		jwt.NewParser().ParseUnverified(data.tokenString, claims)
+		if claims["exp"] != float64(data.signed.Add(time.Minute).Unix()) || claims["iat"] != float64(data.signed.Unix()) {
+			t.Errorf("[%v] Expecting exp and iat from the time of signing, got %v", data.name, claims)
+		}
+	}
+
+	if _, err := jwt.NewBuilder(jwt.SigningMethodRS256, []byte("secret")).Sign(); err != jwt.ErrInvalidKeyType {
+		t.Errorf("Expecting ErrInvalidKeyType signing with the wrong key type, got %v", err)
+	}
+}
@@ -0,0 +1,20 @@
+package jwt
+
+import "context"
+
+// The context key under which WithTokenContext stores the token
+type tokenContextKey struct{}
+
+// Returns a copy of ctx carrying token, typically a token verified by an HTTP
+// middleware, for handlers further down the chain.  Retrieve it with
+// TokenFromContext.
+func WithTokenContext(ctx context.Context, token *Token) context.Context {
+	return context.WithValue(ctx, tokenContextKey{}, token)
+}
+
+// Returns the token stored in ctx by WithTokenContext, and whether there was
+// one
+func TokenFromContext(ctx context.Context) (*Token, bool) {
+	token, ok := ctx.Value(tokenContextKey{}).(*Token)
+	return token, ok && token != nil
+}
@@ -0,0 +1,35 @@
+package jwt_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/form3tech-oss/jwt-go"
+)
+
+func TestTokenContext(t *testing.T) {
+	if _, ok := jwt.TokenFromContext(context.Background()); ok {
+		t.Errorf("[missing] Expecting no token in an empty context")
+	}
+	if _, ok := jwt.TokenFromContext(jwt.WithTokenContext(context.Background(), nil)); ok {
+		t.Errorf("[nil] Expecting no token for a nil token")
+	}
+
+	key := []byte("secret")
+	tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "alice"}).SignedString(key)
+	token, err := jwt.Parse(tokenString, func(*jwt.Token) (interface{}, error) { return key, nil })
+	if err != nil {
+		t.Fatalf("Error while parsing token: %v", err)
+	}
+
+	ctx := jwt.WithTokenContext(context.Background(), token)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	got, ok := jwt.TokenFromContext(ctx)
+	if !ok || got != token {
+		t.Errorf("[stored] Expecting the stored token, got %v", got)
+	}
+	if got.Claims.(jwt.MapClaims)["sub"] != "alice" {
+		t.Errorf("[stored] Unexpected claims %v", got.Claims)
+	}
+}
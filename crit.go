@@ -0,0 +1,46 @@
+package jwt
+
+import (
+	"fmt"
+)
+
+// Header parameters defined by RFC 7515 and RFC 7516, which crit must not
+// list
+var registeredHeaderParams = map[string]bool{
+	"alg": true, "jku": true, "jwk": true, "kid": true, "x5u": true, "x5c": true,
+	"x5t": true, "x5t#S256": true, "typ": true, "cty": true, "crit": true,
+	"enc": true, "zip": true,
+}
+
+// Checks the crit header of token, RFC 7515 section 4.1.11.  Every parameter
+// it lists must be present in the header and understood, that is have a
+// handler registered with WithCriticalHeader, which must accept its value.
+func (p *Parser) checkCrit(token *Token) error {
+	raw, ok := token.Header["crit"]
+	if !ok {
+		return nil
+	}
+	names, ok := raw.([]interface{})
+	if !ok || len(names) == 0 {
+		return NewValidationError("crit header is not a non-empty array", ValidationErrorMalformed)
+	}
+
+	for _, entry := range names {
+		name, ok := entry.(string)
+		if !ok || registeredHeaderParams[name] {
+			return NewValidationError(fmt.Sprintf("crit header lists invalid parameter %v", entry), ValidationErrorMalformed)
+		}
+		value, ok := token.Header[name]
+		if !ok {
+			return NewValidationError(fmt.Sprintf("crit header parameter %q is missing", name), ValidationErrorMalformed)
+		}
+		handler, ok := p.critHandlers[name]
+		if !ok {
+			return NewValidationError(fmt.Sprintf("crit header parameter %q is not supported", name), ValidationErrorUnverifiable)
+		}
+		if err := handler(value); err != nil {
+			return &ValidationError{Inner: err, Errors: ValidationErrorUnverifiable}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package jwt_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/form3tech-oss/jwt-go"
+)
+
+func TestParser_WithCriticalHeader(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	sign := func(header map[string]interface{}) string {
+		token := jwt.New(jwt.SigningMethodHS256)
+		for k, v := range header {
+			token.Header[k] = v
+		}
+		tokenString, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tokenString
+	}
+	errNoLongerSupported := errors.New("exp header is no longer supported")
+	checkExp := func(value interface{}) error {
+		if value != "v1" {
+			return errNoLongerSupported
+		}
+		return nil
+	}
+
+	var critTestData = []struct {
+		name    string
+		header  map[string]interface{}
+		options []jwt.ParserOption
+		errors  uint32
+	}{
+		{"no crit", nil, nil, 0},
+		{"not understood", map[string]interface{}{"crit": []string{"exp"}, "exp": "v1"}, nil, jwt.ValidationErrorUnverifiable},
+		{"understood", map[string]interface{}{"crit": []string{"exp"}, "exp": "v1"}, []jwt.ParserOption{jwt.WithCriticalHeader("exp", checkExp)}, 0},
+		{"handler fails", map[string]interface{}{"crit": []string{"exp"}, "exp": "v0"}, []jwt.ParserOption{jwt.WithCriticalHeader("exp", checkExp)}, jwt.ValidationErrorUnverifiable},
+		{"one of two understood", map[string]interface{}{"crit": []string{"exp", "b64"}, "exp": "v1", "b64": true}, []jwt.ParserOption{jwt.WithCriticalHeader("exp", checkExp)}, jwt.ValidationErrorUnverifiable},
+		{"listed but missing", map[string]interface{}{"crit": []string{"exp"}}, []jwt.ParserOption{jwt.WithCriticalHeader("exp", checkExp)}, jwt.ValidationErrorMalformed},
+		{"registered parameter", map[string]interface{}{"crit": []string{"kid"}, "kid": "1"}, nil, jwt.ValidationErrorMalformed},
+		{"empty", map[string]interface{}{"crit": []string{}}, nil, jwt.ValidationErrorMalformed},
+		{"not an array", map[string]interface{}{"crit": "exp", "exp": "v1"}, []jwt.ParserOption{jwt.WithCriticalHeader("exp", checkExp)}, jwt.ValidationErrorMalformed},
+	}
+
+	for _, data := range critTestData {
+		token, err := jwt.NewParser(data.options...).Parse(sign(data.header), keyFunc)
+		if data.errors == 0 {
+			if err != nil || !token.Valid {
+				t.Errorf("[%v] Error while verifying token: %v", data.name, err)
+			}
+			continue
+		}
+		if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != data.errors {
+			t.Errorf("[%v] Expecting error bits %v, got %v", data.name, data.errors, err)
+		}
+	}
+
+	_, err := jwt.NewParser(jwt.WithCriticalHeader("exp", checkExp)).Parse(sign(map[string]interface{}{"crit": []string{"exp"}, "exp": "v0"}), keyFunc)
+	if ve, ok := err.(*jwt.ValidationError); !ok || ve.Inner != errNoLongerSupported {
+		t.Errorf("Expecting the handler error as Inner error, got %v", err)
+	}
+
+	// The key lookup of ParseWithClaimsAsync checks crit too
+	asyncKeyFunc := func(*jwt.Token) func() (interface{}, error) {
+		return func() (interface{}, error) { return key, nil }
+	}
+	_, err = jwt.ParseWithClaimsAsync(sign(map[string]interface{}{"crit": []string{"exp"}, "exp": "v1"}), jwt.MapClaims{}, asyncKeyFunc)
+	if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorUnverifiable {
+		t.Errorf("Expecting ValidationErrorUnverifiable from ParseWithClaimsAsync, got %v", err)
+	}
+}
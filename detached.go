@@ -0,0 +1,245 @@
+package jwt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"hash"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// Implemented by signing methods that can verify a signing input written
+// incrementally to a hash, so a detached payload never has to be buffered
+type streamVerifier interface {
+	verifier(key interface{}) (hash.Hash, func(sig []byte) error, error)
+}
+
+// Parse, validate, and return a token whose payload is detached (RFC 7515,
+// appendix F).  headerSig is the compact serialization with an empty
+// payload, "header..signature", and payload supplies the JSON claims.
+//
+// The payload is read once and encoded into the signing input as it is
+// decoded, so for HMAC, RSA, RSA-PSS and ECDSA it is never held in memory
+// as a whole.  Other signing methods fall back to buffering it, as does a
+// parser with WithPerIssuerMethods, whose check needs the iss claim before
+// the Keyfunc is called.
+//
+// Streaming needs the key before the first byte of the payload is hashed,
+// so keyFunc is then called before the claims are decoded and sees them
+// unset; it must pick the key from the header, e.g. its kid.  Only with a
+// buffered payload are the claims decoded before keyFunc is called.
+func ParseDetachedReader(headerSig string, payload io.Reader, claims Claims, keyFunc Keyfunc) (*Token, error) {
+	return new(Parser).ParseDetachedReader(headerSig, payload, claims, keyFunc)
+}
+
+// Parser form of ParseDetachedReader
+func (p *Parser) ParseDetachedReader(headerSig string, payload io.Reader, claims Claims, keyFunc Keyfunc) (*Token, error) {
+	parts := strings.Split(headerSig, ".")
+	if len(parts) != 3 {
+		return nil, NewValidationError("token contains an invalid number of segments", ValidationErrorMalformed)
+	}
+	if parts[1] != "" {
+		return nil, NewValidationError("token payload is not detached", ValidationErrorMalformed)
+	}
+
+	token := &Token{Raw: headerSig, Claims: claims, Signature: parts[2]}
+	if p.paddingAllowed {
+		token.Signature = strings.TrimRight(token.Signature, "=")
+	}
+
+	err := p.decodeHeader(token, parts[0])
+	if err != nil {
+		return token, err
+	}
+
+	// The issuer of the token picks its algs, so the claims come first
+	var data []byte
+	if p.issuerMethods != nil {
+		if data, err = ioutil.ReadAll(payload); err != nil {
+			return token, newSegmentError(SegmentClaims, err)
+		}
+		if err = p.decodeDetached(token, bytes.NewReader(data)); err != nil {
+			return token, err
+		}
+	}
+
+	key, err := p.resolveKey(token, keyFunc)
+	if err != nil {
+		return token, err
+	}
+
+	var check func(sig []byte) error
+	var buffered *strings.Builder
+	if data != nil {
+		buffered = &strings.Builder{}
+		buffered.WriteString(parts[0] + "." + EncodeSegment(data))
+	} else {
+		// Write the signing input, header "." base64url(payload), while the
+		// claims are decoded from the same read
+		var hasher io.Writer
+		// The keys of a set are each tried over the buffered signing input
+		sv, ok := token.Method.(streamVerifier)
+		if _, isSet := key.(VerificationKeySet); ok && !isSet {
+			var h hash.Hash
+			if h, check, err = sv.verifier(key); err != nil {
+				return token, &ValidationError{Inner: err, Errors: ValidationErrorSignatureInvalid}
+			}
+			hasher = h
+		} else {
+			buffered = &strings.Builder{}
+			hasher = buffered
+		}
+		io.WriteString(hasher, parts[0]+".")
+		enc := base64.NewEncoder(base64.RawURLEncoding, hasher)
+		tee := io.TeeReader(payload, enc)
+
+		if err = p.decodeDetached(token, tee); err != nil {
+			return token, err
+		}
+		// The signature covers the payload exactly as supplied, including any
+		// bytes after the JSON value
+		if _, err = io.Copy(ioutil.Discard, tee); err != nil {
+			return token, newSegmentError(SegmentClaims, err)
+		}
+		enc.Close()
+	}
+
+	// Perform validation.  As in ParseWithClaims, an empty signature is only
+	// acceptable for 'none'.
+	if token.Signature == "" && token.Method.Alg() != SigningMethodNone.Alg() {
+		err = ErrSignatureInvalid
+	} else if check != nil {
+		var sig []byte
+		if sig, err = DecodeSegment(token.Signature); err == nil {
+			err = check(sig)
+		}
+	} else {
+		err = verifyWithKey(token, buffered.String(), key)
+	}
+
+	if err = p.validate(token, parts, err); err != nil {
+		return token, err
+	}
+	return token, nil
+}
+
+// Decodes the header segment seg of token into its Header, and looks up the
+// signing method named by its alg
+func (p *Parser) decodeHeader(token *Token, seg string) error {
+	headerBytes, err := p.decodeSegment(seg)
+	if err != nil {
+		return newSegmentError(SegmentHeader, err)
+	}
+	if p.lenientJSON {
+		headerBytes = trimJSON(headerBytes)
+	}
+	if err = p.json().Unmarshal(headerBytes, &token.Header); err != nil {
+		return newSegmentError(SegmentHeader, err)
+	}
+
+	// Lookup signature method
+	if method, ok := token.Header["alg"].(string); ok {
+		if token.Method = GetSigningMethod(method); token.Method == nil {
+			return methodUnavailable(method)
+		}
+	} else {
+		return NewValidationError("signing method (alg) is unspecified.", ValidationErrorUnverifiable)
+	}
+	return nil
+}
+
+// Decodes the claims of token, a token with a detached payload, from r
+func (p *Parser) decodeDetached(token *Token, r io.Reader) error {
+	dec := p.json().NewDecoder(r)
+	if p.UseJSONNumber {
+		dec.UseNumber()
+	}
+	// JSON Decode.  Special case for map type to avoid weird pointer behavior
+	var err error
+	if c, ok := token.Claims.(MapClaims); ok {
+		err = dec.Decode(&c)
+	} else {
+		err = dec.Decode(&token.Claims)
+	}
+	if err != nil {
+		return newSegmentError(SegmentClaims, err)
+	}
+	return nil
+}
+
+// Verifies a JWS signature given as its three base64url encoded parts, e.g.
+// produced by an external tool over a known header and payload, without
+// assembling a compact token.  The signing method is that named by the alg
+// of the decoded header, and keyFunc receives a token holding the header.
+// The payload is not decoded, so it need not be JSON, and no claims are
+// validated.
+func VerifyCompactDetached(protectedHeaderB64, payloadB64, signatureB64 string, keyFunc Keyfunc) error {
+	return new(Parser).VerifyCompactDetached(protectedHeaderB64, payloadB64, signatureB64, keyFunc)
+}
+
+// Parser form of VerifyCompactDetached.  ValidMethods, WithExpectedMethod and
+// the other signing method checks of the parser apply.
+func (p *Parser) VerifyCompactDetached(protectedHeaderB64, payloadB64, signatureB64 string, keyFunc Keyfunc) error {
+	token := &Token{Signature: signatureB64}
+	if p.paddingAllowed {
+		token.Signature = strings.TrimRight(token.Signature, "=")
+	}
+
+	if err := p.decodeHeader(token, protectedHeaderB64); err != nil {
+		return err
+	}
+
+	key, err := p.resolveKey(token, keyFunc)
+	if err != nil {
+		return err
+	}
+	if token.Signature == "" && token.Method.Alg() != SigningMethodNone.Alg() {
+		return &ValidationError{Inner: ErrSignatureInvalid, Errors: ValidationErrorSignatureInvalid}
+	}
+	if err := verifyWithKey(token, protectedHeaderB64+"."+payloadB64, key); err != nil {
+		return &ValidationError{Inner: err, Errors: ValidationErrorSignatureInvalid}
+	}
+	return nil
+}
+
+// Signs payload, which need not be JSON, and returns the compact
+// serialization with the payload detached, "header..signature", RFC 7515
+// appendix F.  The payload travels separately, e.g. as an HTTP body with the
+// detached signature in a header such as x-jws-signature.  header holds any
+// header parameters besides alg, which is always that of method, and may be
+// nil.  See VerifyDetached.
+func SignDetached(method SigningMethod, header map[string]interface{}, payload []byte, key interface{}) (string, error) {
+	h := map[string]interface{}{}
+	for k, v := range header {
+		h[k] = v
+	}
+	h["alg"] = method.Alg()
+	headerJSON, err := DefaultJSONCodec.Marshal(h)
+	if err != nil {
+		return "", err
+	}
+	protected := EncodeSegment(headerJSON)
+	sig, err := method.Sign(protected+"."+EncodeSegment(payload), key)
+	if err != nil {
+		return "", err
+	}
+	return protected + ".." + sig, nil
+}
+
+// Verifies detached, the "header..signature" produced by SignDetached or
+// any other RFC 7515 appendix F implementation, over payload.  As with
+// VerifyCompactDetached, keyFunc receives a token holding the header and no
+// claims are validated.
+func VerifyDetached(detached string, payload []byte, keyFunc Keyfunc) error {
+	return new(Parser).VerifyDetached(detached, payload, keyFunc)
+}
+
+// Parser form of VerifyDetached
+func (p *Parser) VerifyDetached(detached string, payload []byte, keyFunc Keyfunc) error {
+	parts := strings.Split(detached, ".")
+	if len(parts) != 3 || parts[1] != "" {
+		return NewValidationError("detached signature must be of the form header..signature", ValidationErrorMalformed)
+	}
+	return p.VerifyCompactDetached(parts[0], EncodeSegment(payload), parts[2], keyFunc)
+}
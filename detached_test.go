@@ -0,0 +1,249 @@
+package jwt_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/form3tech-oss/jwt-go"
+	"github.com/form3tech-oss/jwt-go/test"
+)
+
+// Signs claims and returns the detached "header..signature" form along with
+// the JSON payload
+func makeDetachedToken(t *testing.T, method jwt.SigningMethod, key interface{}, claims jwt.MapClaims) (string, []byte) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := jwt.EncodeSegment([]byte(`{"alg":"` + method.Alg() + `","typ":"JWT"}`))
+	sig, err := method.Sign(header+"."+jwt.EncodeSegment(payload), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return header + ".." + sig, payload
+}
+
+func TestParseDetachedReader(t *testing.T) {
+	hmacKey := []byte("secret")
+	hmacKeyFunc := func(*jwt.Token) (interface{}, error) { return hmacKey, nil }
+	rsaKeyFunc := func(*jwt.Token) (interface{}, error) {
+		return test.LoadRSAPublicKeyFromDisk("test/sample_key.pub"), nil
+	}
+
+	// A few megabytes, streamed through the reader in small chunks
+	claims := jwt.MapClaims{"sub": "report", "data": strings.Repeat("0123456789abcdef", 1<<18)}
+
+	headerSig, payload := makeDetachedToken(t, jwt.SigningMethodHS256, hmacKey, claims)
+	token, err := jwt.ParseDetachedReader(headerSig, &chunkedReader{bytes.NewReader(payload), 4096}, jwt.MapClaims{}, hmacKeyFunc)
+	if err != nil || !token.Valid {
+		t.Fatalf("[HS256] Expecting a valid token, got %v", err)
+	}
+	if token.Claims.(jwt.MapClaims)["sub"] != "report" {
+		t.Errorf("[HS256] Claims were not decoded: %v", token.Claims.(jwt.MapClaims)["sub"])
+	}
+
+	// Bytes after the JSON value are covered by the signature too
+	tampered := append(append([]byte{}, payload...), ' ')
+	if _, err := jwt.ParseDetachedReader(headerSig, bytes.NewReader(tampered), jwt.MapClaims{}, hmacKeyFunc); err == nil {
+		t.Errorf("[HS256] Expecting a tampered payload to be rejected")
+	} else if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorSignatureInvalid {
+		t.Errorf("[HS256] Expecting ValidationErrorSignatureInvalid, got %v", err)
+	}
+
+	headerSig, payload = makeDetachedToken(t, jwt.SigningMethodRS256, test.LoadRSAPrivateKeyFromDisk("test/sample_key"), claims)
+	token, err = jwt.ParseDetachedReader(headerSig, &chunkedReader{bytes.NewReader(payload), 4096}, jwt.MapClaims{}, rsaKeyFunc)
+	if err != nil || !token.Valid {
+		t.Errorf("[RS256] Expecting a valid token, got %v", err)
+	}
+
+	// A token with an attached payload is not a detached token
+	full := strings.Replace(headerSig, "..", "."+jwt.EncodeSegment(payload)+".", 1)
+	if _, err := jwt.ParseDetachedReader(full, bytes.NewReader(payload), jwt.MapClaims{}, rsaKeyFunc); err == nil {
+		t.Errorf("Expecting an attached payload to be rejected")
+	} else if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorMalformed {
+		t.Errorf("Expecting ValidationErrorMalformed, got %v", err)
+	}
+}
+
+func TestParser_ParseDetachedReaderPerIssuerMethods(t *testing.T) {
+	hmacKey := []byte("secret")
+	parser := jwt.NewParser(jwt.WithPerIssuerMethods(map[string][]string{
+		"https://hmac.example.com": {"HS256"},
+		jwt.DefaultIssuer:          {"RS256"},
+	}))
+
+	var detachedIssuerTestData = []struct {
+		name   string
+		iss    string
+		errors uint32
+	}{
+		{"allowed", "https://hmac.example.com", 0},
+		// Not judged by the algs of DefaultIssuer as if iss were unknown
+		{"other issuer", "https://other.example.com", jwt.ValidationErrorSignatureInvalid},
+	}
+	for _, data := range detachedIssuerTestData {
+		headerSig, payload := makeDetachedToken(t, jwt.SigningMethodHS256, hmacKey, jwt.MapClaims{"iss": data.iss})
+		keyFunc := func(token *jwt.Token) (interface{}, error) {
+			if token.Claims.(jwt.MapClaims)["iss"] != data.iss {
+				t.Errorf("[%v] Expecting the claims to be decoded before the Keyfunc", data.name)
+			}
+			return hmacKey, nil
+		}
+		token, err := parser.ParseDetachedReader(headerSig, bytes.NewReader(payload), jwt.MapClaims{}, keyFunc)
+		if data.errors == 0 {
+			if err != nil || !token.Valid {
+				t.Errorf("[%v] Expecting a valid token, got %v", data.name, err)
+			}
+			continue
+		}
+		if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != data.errors {
+			t.Errorf("[%v] Expecting error bits %v, got %v", data.name, data.errors, err)
+		}
+	}
+}
+
+// A streamed payload is hashed as it is decoded, so the Keyfunc is called
+// before the claims are, while a buffered one is decoded first
+func TestParser_ParseDetachedReaderKeyfuncClaims(t *testing.T) {
+	hmacKey := []byte("secret")
+	headerSig, payload := makeDetachedToken(t, jwt.SigningMethodHS256, hmacKey, jwt.MapClaims{"iss": "https://hmac.example.com"})
+
+	var detachedKeyfuncTestData = []struct {
+		name    string
+		parser  *jwt.Parser
+		decoded bool
+	}{
+		{"streamed", jwt.NewParser(), false},
+		{"buffered", jwt.NewParser(jwt.WithPerIssuerMethods(map[string][]string{"https://hmac.example.com": {"HS256"}})), true},
+	}
+	for _, data := range detachedKeyfuncTestData {
+		claims := jwt.MapClaims{}
+		keyFunc := func(token *jwt.Token) (interface{}, error) {
+			if _, decoded := claims["iss"]; decoded != data.decoded {
+				t.Errorf("[%v] Expecting the claims decoded before the Keyfunc to be %v", data.name, data.decoded)
+			}
+			return hmacKey, nil
+		}
+		token, err := data.parser.ParseDetachedReader(headerSig, bytes.NewReader(payload), claims, keyFunc)
+		if err != nil || !token.Valid || claims["iss"] != "https://hmac.example.com" {
+			t.Errorf("[%v] Expecting a valid token with its claims, got %v", data.name, err)
+		}
+	}
+}
+
+// Returns at most n bytes per Read
+type chunkedReader struct {
+	r *bytes.Reader
+	n int
+}
+
+func (c *chunkedReader) Read(p []byte) (int, error) {
+	if len(p) > c.n {
+		p = p[:c.n]
+	}
+	return c.r.Read(p)
+}
+
+func TestVerifyCompactDetached(t *testing.T) {
+	rsaKey := test.LoadRSAPublicKeyFromDisk("test/sample_key.pub")
+	payload := "eyJpc3MiOiJvcGVuc3NsIiwic3ViIjoiaW50ZXJvcCJ9" // {"iss":"openssl","sub":"interop"}
+
+	// Signatures computed over "header.payload" with openssl dgst, e.g.
+	// openssl dgst -sha256 -sign test/sample_key
+	var compactDetachedTestData = []struct {
+		name      string
+		header    string
+		signature string
+		key       interface{}
+		valid     bool
+	}{
+		{
+			"RS256",
+			"eyJhbGciOiJSUzI1NiIsImtpZCI6ImV4dCJ9", // {"alg":"RS256","kid":"ext"}
+			"DplWffZCddzy_6bTtx8uJ4vPsejjCjBKHPH9ksRxXjh1DP-OtH4sAuDkGly03QmKv6kvQzfUsH6jajc832x2jrJNRs9iH-OoADweL9zoDiPaYXb2L1cIFsX2_aYGO_Dij1sNPpW3FNMmZpBaTOBPkxHmCnPcLM82vR2JmD3Z7TyHdaGjZCe3un37SNB8_tqicizjT66ab-VV56NKNzBSBb6yfZXjY-JBC_CH-ytZGzMkuCglhFuGLzviDgCmyhs9tworiGGWLJiqUsD37X9RSNUovO2IXyxX-GXdZr6q85izmxuj9_mzR-4g5Z8OPAmEha4vCzK87A8BP8oPdO-WNA",
+			rsaKey,
+			true,
+		},
+		{
+			"HS256",
+			"eyJhbGciOiJIUzI1NiJ9", // {"alg":"HS256"}
+			"KQBCSI3tt-WKgbFde37BhV4fULd6XoxU4xx00fUDkWs",
+			[]byte("interop secret"),
+			true,
+		},
+		{
+			"HS256, wrong key",
+			"eyJhbGciOiJIUzI1NiJ9",
+			"KQBCSI3tt-WKgbFde37BhV4fULd6XoxU4xx00fUDkWs",
+			[]byte("other secret"),
+			false,
+		},
+		{
+			"RS256, other header",
+			"eyJhbGciOiJSUzI1NiJ9", // {"alg":"RS256"}
+			"DplWffZCddzy_6bTtx8uJ4vPsejjCjBKHPH9ksRxXjh1DP-OtH4sAuDkGly03QmKv6kvQzfUsH6jajc832x2jrJNRs9iH-OoADweL9zoDiPaYXb2L1cIFsX2_aYGO_Dij1sNPpW3FNMmZpBaTOBPkxHmCnPcLM82vR2JmD3Z7TyHdaGjZCe3un37SNB8_tqicizjT66ab-VV56NKNzBSBb6yfZXjY-JBC_CH-ytZGzMkuCglhFuGLzviDgCmyhs9tworiGGWLJiqUsD37X9RSNUovO2IXyxX-GXdZr6q85izmxuj9_mzR-4g5Z8OPAmEha4vCzK87A8BP8oPdO-WNA",
+			rsaKey,
+			false,
+		},
+	}
+
+	for _, data := range compactDetachedTestData {
+		keyFunc := func(*jwt.Token) (interface{}, error) { return data.key, nil }
+		err := jwt.VerifyCompactDetached(data.header, payload, data.signature, keyFunc)
+		if data.valid && err != nil {
+			t.Errorf("[%v] Error while verifying signature: %v", data.name, err)
+		}
+		if !data.valid {
+			if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorSignatureInvalid {
+				t.Errorf("[%v] Expecting ValidationErrorSignatureInvalid, got %v", data.name, err)
+			}
+		}
+	}
+}
+
+func TestSignDetached(t *testing.T) {
+	payload := []byte(`{"Data":{"Initiation":{"InstructedAmount":{"Amount":"10.00"}}}}`)
+	privateKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	publicKey := test.LoadRSAPublicKeyFromDisk("test/sample_key.pub")
+
+	detached, err := jwt.SignDetached(jwt.SigningMethodRS256, map[string]interface{}{"kid": "ext", "alg": "none"}, payload, privateKey)
+	if err != nil {
+		t.Fatalf("Error while signing: %v", err)
+	}
+	parts := strings.Split(detached, ".")
+	if len(parts) != 3 || parts[1] != "" {
+		t.Fatalf("Expecting header..signature, got %v", detached)
+	}
+
+	var kid, alg interface{}
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		kid, alg = token.Header["kid"], token.Header["alg"]
+		return publicKey, nil
+	}
+	if err := jwt.VerifyDetached(detached, payload, keyFunc); err != nil {
+		t.Errorf("Error while verifying: %v", err)
+	}
+	if kid != "ext" || alg != "RS256" {
+		t.Errorf("Expecting kid ext and alg RS256, got %v and %v", kid, alg)
+	}
+
+	var detachedTestData = []struct {
+		name     string
+		detached string
+		payload  []byte
+		errors   uint32
+	}{
+		{"other payload", detached, []byte(`{}`), jwt.ValidationErrorSignatureInvalid},
+		{"embedded payload", parts[0] + "." + jwt.EncodeSegment(payload) + "." + parts[2], payload, jwt.ValidationErrorMalformed},
+		{"missing signature", parts[0] + "..", payload, jwt.ValidationErrorSignatureInvalid},
+		{"too many segments", detached + ".", payload, jwt.ValidationErrorMalformed},
+	}
+	for _, data := range detachedTestData {
+		err := jwt.VerifyDetached(data.detached, data.payload, keyFunc)
+		if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != data.errors {
+			t.Errorf("[%v] Expecting error bits %v, got %v", data.name, data.errors, err)
+		}
+	}
+}
@@ -0,0 +1,8 @@
+// Utility package for OAuth 2.0 Demonstrating Proof of Possession, DPoP,
+// RFC 9449.
+//
+// Clients sign a proof JWT per request with NewProof, binding it to the HTTP
+// method and URI and, when calling a resource server, to the access token.
+// Servers check incoming proofs with Verify, and compare the thumbprint of
+// the proof key with the cnf claim of the access token with Proof.Binds.
+package dpop
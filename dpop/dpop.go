@@ -0,0 +1,260 @@
+package dpop
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/form3tech-oss/jwt-go"
+)
+
+// The typ header of a DPoP proof
+const ProofType = "dpop+jwt"
+
+// The maximum age of a proof accepted by Verify, unless WithMaxAge is given
+const DefaultMaxAge = 5 * time.Minute
+
+// Errors
+var (
+	ErrInvalidType    = errors.New("dpop: proof typ is not dpop+jwt")
+	ErrMissingJWK     = errors.New("dpop: proof has no jwk header")
+	ErrInvalidJWK     = errors.New("dpop: proof jwk is not a public key")
+	ErrHTMMismatch    = errors.New("dpop: proof htm does not match the request method")
+	ErrHTUMismatch    = errors.New("dpop: proof htu does not match the request URI")
+	ErrATHMismatch    = errors.New("dpop: proof ath does not match the access token")
+	ErrNonceMismatch  = errors.New("dpop: proof nonce does not match")
+	ErrClaimNotString = errors.New("dpop: proof claim is not a string")
+)
+
+// The asymmetric signing methods accepted for proofs by default
+var DefaultMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+type options struct {
+	accessToken    string
+	nonce          string
+	maxAge         time.Duration
+	skew           time.Duration
+	methods        []string
+	replayDetector jwt.ReplayDetector
+}
+
+// Configures NewProof and Verify
+type Option func(*options)
+
+// Binds the proof to accessToken with the ath claim.  For Verify, the proof
+// must carry the hash of accessToken.
+func WithAccessToken(accessToken string) Option {
+	return func(o *options) {
+		o.accessToken = accessToken
+	}
+}
+
+// Sets the nonce claim to the nonce the server provided in its DPoP-Nonce
+// header.  For Verify, the proof must carry nonce.
+func WithNonce(nonce string) Option {
+	return func(o *options) {
+		o.nonce = nonce
+	}
+}
+
+// Verify only: accept proofs issued at most d ago, DefaultMaxAge by default
+func WithMaxAge(d time.Duration) Option {
+	return func(o *options) {
+		o.maxAge = d
+	}
+}
+
+// Verify only: tolerate clocks of clients that are up to d ahead
+func WithAllowedSkew(d time.Duration) Option {
+	return func(o *options) {
+		o.skew = d
+	}
+}
+
+// Verify only: accept proofs signed with one of methods, rather than
+// DefaultMethods
+func WithMethods(methods ...string) Option {
+	return func(o *options) {
+		o.methods = methods
+	}
+}
+
+// Verify only: reject proofs whose jti was seen before, as RFC 9449 section
+// 11.1 recommends.  See jwt.NewMemoryReplayDetector.
+func WithReplayDetector(detector jwt.ReplayDetector) Option {
+	return func(o *options) {
+		o.replayDetector = detector
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{maxAge: DefaultMaxAge, methods: DefaultMethods}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Builds a DPoP proof for a request with the method htm to the URI htu,
+// signed by key with method.  The public key of key is embedded in the jwk
+// header, and every proof gets a random jti.
+func NewProof(method jwt.SigningMethod, key crypto.Signer, htm, htu string, opts ...Option) (string, error) {
+	o := newOptions(opts)
+	jwkBytes, err := jwt.MarshalJWK(key.Public())
+	if err != nil {
+		return "", err
+	}
+	var jwk map[string]interface{}
+	if err := json.Unmarshal(jwkBytes, &jwk); err != nil {
+		return "", err
+	}
+
+	claims := jwt.MapClaims{"htm": htm, "htu": htu, "iat": jwt.TimeFunc().Unix()}
+	if err := claims.SetRandomJTI(); err != nil {
+		return "", err
+	}
+	if o.accessToken != "" {
+		claims["ath"] = accessTokenHash(o.accessToken)
+	}
+	if o.nonce != "" {
+		claims["nonce"] = o.nonce
+	}
+
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["typ"] = ProofType
+	token.Header["jwk"] = jwk
+	return token.SignedString(key)
+}
+
+// A verified DPoP proof
+type Proof struct {
+	Token      *jwt.Token  // The proof, with its claims as jwt.MapClaims
+	Key        interface{} // The public key of the jwk header
+	Thumbprint string      // RFC 7638 thumbprint of Key
+}
+
+// Reports whether the access token with claims is bound to the key of the
+// proof, by the jkt member of its cnf claim
+func (p *Proof) Binds(claims jwt.MapClaims) bool {
+	return claims.VerifyCnfThumbprint(p.Thumbprint)
+}
+
+// Verifies proof, the value of the DPoP header of a request with the method
+// htm to the URI htu, following RFC 9449 section 4.3: the typ header, a
+// public jwk header the signature verifies with, the jti, htm, htu and iat
+// claims, and those required by WithAccessToken and WithNonce.  The query and
+// fragment of htu are ignored.
+//
+// Errors of the proof are *jwt.ValidationError.  A failed claim is reported in a
+// jwt.ClaimErrors, as its Inner error, e.g. with ErrHTUMismatch.
+func Verify(proof, htm, htu string, opts ...Option) (*Proof, error) {
+	o := newOptions(opts)
+	target, err := normalizeHTU(htu)
+	if err != nil {
+		return nil, err
+	}
+
+	checks := []jwt.ValidatorOption{
+		jwt.RequireClaims("jti", "htm", "htu", "iat"),
+		jwt.WithClaimValidator("htm", matchClaim(htm, ErrHTMMismatch)),
+		jwt.WithClaimValidator("htu", func(value interface{}) error {
+			s, ok := value.(string)
+			if !ok {
+				return ErrClaimNotString
+			}
+			if normalized, err := normalizeHTU(s); err != nil || normalized != target {
+				return ErrHTUMismatch
+			}
+			return nil
+		}),
+	}
+	if o.accessToken != "" {
+		checks = append(checks, jwt.RequireClaims("ath"), jwt.WithClaimValidator("ath", matchClaim(accessTokenHash(o.accessToken), ErrATHMismatch)))
+	}
+	if o.nonce != "" {
+		checks = append(checks, jwt.RequireClaims("nonce"), jwt.WithClaimValidator("nonce", matchClaim(o.nonce, ErrNonceMismatch)))
+	}
+
+	parserOptions := []jwt.ParserOption{
+		jwt.WithValidMethods(o.methods),
+		jwt.WithValidator(jwt.NewValidator(checks...)),
+		jwt.WithMaxAge(o.maxAge),
+		jwt.WithAllowedSkew(o.skew),
+	}
+	if o.replayDetector != nil {
+		parserOptions = append(parserOptions, jwt.WithReplayDetector(o.replayDetector))
+	}
+
+	result := &Proof{}
+	token, err := jwt.NewParser(parserOptions...).Parse(proof, func(token *jwt.Token) (interface{}, error) {
+		if typ, _ := token.Header["typ"].(string); !strings.EqualFold(typ, ProofType) {
+			return nil, ErrInvalidType
+		}
+		key, err := publicKey(token)
+		if err != nil {
+			return nil, err
+		}
+		if result.Thumbprint, err = jwt.JWKThumbprint(key); err != nil {
+			return nil, err
+		}
+		result.Key = key
+		return key, nil
+	})
+	result.Token = token
+	return result, err
+}
+
+// The embedded jwk of a proof is trusted as is: the proof only binds the
+// request to that key, and Proof.Thumbprint ties the key to an access token
+var embeddedJWK = jwt.EmbeddedJWKKeyfunc(func(interface{}) error { return nil })
+
+// The public key of the jwk header of token, refusing private and symmetric
+// keys
+func publicKey(token *jwt.Token) (interface{}, error) {
+	key, err := embeddedJWK(token)
+	switch err {
+	case nil:
+		return key, nil
+	case jwt.ErrJWKMissing:
+		return nil, ErrMissingJWK
+	case jwt.ErrJWKInvalid:
+		return nil, ErrInvalidJWK
+	}
+	return nil, err
+}
+
+// A claim check requiring the string expected
+func matchClaim(expected string, mismatch error) func(interface{}) error {
+	return func(value interface{}) error {
+		s, ok := value.(string)
+		if !ok {
+			return ErrClaimNotString
+		}
+		if s != expected {
+			return mismatch
+		}
+		return nil
+	}
+}
+
+// The ath claim for accessToken: its base64url encoded SHA-256 hash
+func accessTokenHash(accessToken string) string {
+	sum := sha256.Sum256([]byte(accessToken))
+	return jwt.EncodeSegment(sum[:])
+}
+
+// htu without query and fragment, with the scheme and host in lower case
+func normalizeHTU(htu string) (string, error) {
+	u, err := url.Parse(htu)
+	if err != nil {
+		return "", err
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.RawQuery, u.ForceQuery, u.Fragment = "", false, ""
+	return u.String(), nil
+}
@@ -0,0 +1,125 @@
+package dpop
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/form3tech-oss/jwt-go"
+	"github.com/form3tech-oss/jwt-go/test"
+)
+
+func TestVerify(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey := test.LoadRSAPrivateKeyFromDisk("../test/sample_key")
+	const htu = "https://server.example.com/token"
+	newProof := func(method jwt.SigningMethod, signer crypto.Signer, htm, htu string, opts ...Option) string {
+		proof, err := NewProof(method, signer, htm, htu, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return proof
+	}
+	sign := func(header, claims map[string]interface{}) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims(claims))
+		for k, v := range header {
+			token.Header[k] = v
+		}
+		s, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return s
+	}
+	jwk := func(k interface{}) map[string]interface{} {
+		data, _ := jwt.MarshalJWK(k)
+		var members map[string]interface{}
+		json.Unmarshal(data, &members)
+		return members
+	}
+	now := time.Now().Unix()
+
+	var verifyTestData = []struct {
+		name    string
+		proof   string
+		htm     string
+		htu     string
+		options []Option
+		cause   error
+		errors  uint32
+	}{
+		{"valid", newProof(jwt.SigningMethodES256, key, "POST", htu), "POST", htu, nil, nil, 0},
+		{"RSA key", newProof(jwt.SigningMethodRS256, rsaKey, "POST", htu), "POST", htu, nil, nil, 0},
+		{"query is ignored", newProof(jwt.SigningMethodES256, key, "GET", "https://Server.example.com/token"), "GET", htu + "?x=1#f", nil, nil, 0},
+		{"access token", newProof(jwt.SigningMethodES256, key, "GET", htu, WithAccessToken("at")), "GET", htu, []Option{WithAccessToken("at")}, nil, 0},
+		{"nonce", newProof(jwt.SigningMethodES256, key, "GET", htu, WithNonce("n")), "GET", htu, []Option{WithNonce("n")}, nil, 0},
+		{"wrong method", newProof(jwt.SigningMethodES256, key, "POST", htu), "GET", htu, nil, ErrHTMMismatch, jwt.ValidationErrorClaimsInvalid},
+		{"wrong URI", newProof(jwt.SigningMethodES256, key, "POST", htu), "POST", "https://server.example.com/other", nil, ErrHTUMismatch, jwt.ValidationErrorClaimsInvalid},
+		{"other access token", newProof(jwt.SigningMethodES256, key, "GET", htu, WithAccessToken("other")), "GET", htu, []Option{WithAccessToken("at")}, ErrATHMismatch, jwt.ValidationErrorClaimsInvalid},
+		{"no ath", newProof(jwt.SigningMethodES256, key, "GET", htu), "GET", htu, []Option{WithAccessToken("at")}, jwt.ErrClaimMissing, jwt.ValidationErrorClaimsInvalid},
+		{"wrong nonce", newProof(jwt.SigningMethodES256, key, "GET", htu, WithNonce("old")), "GET", htu, []Option{WithNonce("n")}, ErrNonceMismatch, jwt.ValidationErrorClaimsInvalid},
+		{"too old", sign(map[string]interface{}{"typ": ProofType, "jwk": jwk(&key.PublicKey)}, map[string]interface{}{"jti": "1", "htm": "GET", "htu": htu, "iat": now - 600}), "GET", htu, nil, nil, jwt.ValidationErrorIssuedAt},
+		{"no jti", sign(map[string]interface{}{"typ": ProofType, "jwk": jwk(&key.PublicKey)}, map[string]interface{}{"htm": "GET", "htu": htu, "iat": now}), "GET", htu, nil, jwt.ErrClaimMissing, jwt.ValidationErrorId},
+		{"wrong typ", sign(map[string]interface{}{"typ": "JWT", "jwk": jwk(&key.PublicKey)}, map[string]interface{}{"jti": "1", "htm": "GET", "htu": htu, "iat": now}), "GET", htu, nil, ErrInvalidType, jwt.ValidationErrorUnverifiable},
+		{"no jwk", sign(map[string]interface{}{"typ": ProofType}, map[string]interface{}{"jti": "1", "htm": "GET", "htu": htu, "iat": now}), "GET", htu, nil, ErrMissingJWK, jwt.ValidationErrorUnverifiable},
+		{"private jwk", sign(map[string]interface{}{"typ": ProofType, "jwk": map[string]interface{}{"kty": "EC", "d": "secret"}}, map[string]interface{}{"jti": "1", "htm": "GET", "htu": htu, "iat": now}), "GET", htu, nil, ErrInvalidJWK, jwt.ValidationErrorUnverifiable},
+	}
+
+	for _, data := range verifyTestData {
+		proof, err := Verify(data.proof, data.htm, data.htu, data.options...)
+		if data.errors == 0 {
+			if err != nil || !proof.Token.Valid {
+				t.Errorf("[%v] Error while verifying proof: %v", data.name, err)
+			}
+			continue
+		}
+		ve, ok := err.(*jwt.ValidationError)
+		if !ok || ve.Errors != data.errors {
+			t.Errorf("[%v] Expecting error bits %v, got %v", data.name, data.errors, err)
+			continue
+		}
+		if data.cause == nil {
+			continue
+		}
+		cause := ve.Inner
+		if errs, ok := ve.Inner.(jwt.ClaimErrors); ok {
+			cause = errs[0].Err
+		}
+		if cause != data.cause {
+			t.Errorf("[%v] Expecting %v, got %v", data.name, data.cause, ve.Inner)
+		}
+	}
+}
+
+func TestVerify_binding(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	proof, _ := NewProof(jwt.SigningMethodES256, key, "GET", "https://rs.example.com/", WithAccessToken("at"))
+	replays := jwt.NewMemoryReplayDetector(time.Minute)
+
+	verified, err := Verify(proof, "GET", "https://rs.example.com/", WithAccessToken("at"), WithReplayDetector(replays))
+	if err != nil {
+		t.Fatalf("Error while verifying proof: %v", err)
+	}
+	thumbprint, _ := jwt.JWKThumbprint(&key.PublicKey)
+	if verified.Thumbprint != thumbprint {
+		t.Errorf("Expecting the thumbprint of the proof key")
+	}
+	if !verified.Binds(jwt.MapClaims{"cnf": map[string]interface{}{"jkt": thumbprint}}) {
+		t.Errorf("Expecting the access token to be bound to the proof key")
+	}
+	if verified.Binds(jwt.MapClaims{"cnf": map[string]interface{}{"jkt": "other"}}) {
+		t.Errorf("Expecting an access token bound to another key to be refused")
+	}
+
+	_, err = Verify(proof, "GET", "https://rs.example.com/", WithAccessToken("at"), WithReplayDetector(replays))
+	if ve, ok := err.(*jwt.ValidationError); !ok || ve.Inner != jwt.ErrTokenReplayed {
+		t.Errorf("Expecting the replayed proof to be rejected, got %v", err)
+	}
+}
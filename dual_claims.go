@@ -0,0 +1,81 @@
+package jwt
+
+import (
+	"bytes"
+)
+
+// Decodes the claims segment into a typed Claims and a MapClaims at once
+type dualClaims struct {
+	typed           Claims
+	claims          MapClaims
+	useNumber       bool
+	disallowUnknown bool // Applies to the typed claims, as for ParseWithClaims
+	codec           JSONCodec
+}
+
+// Returns dualClaims decoding as p does, for the typed claims to be set
+func (p *Parser) newDualClaims() *dualClaims {
+	return &dualClaims{claims: MapClaims{}, useNumber: p.UseJSONNumber, disallowUnknown: p.disallowUnknown, codec: p.json()}
+}
+
+func (d *dualClaims) UnmarshalJSON(data []byte) error {
+	var err error
+	// Special case for map type to avoid weird pointer behavior
+	if c, ok := d.typed.(MapClaims); ok {
+		err = d.decode(data, &c, false)
+	} else if u, ok := d.typed.(*untypedClaims); ok {
+		err = d.decode(data, u.v, d.disallowUnknown)
+	} else {
+		err = d.decode(data, d.typed, d.disallowUnknown)
+	}
+	if err != nil {
+		return err
+	}
+	return d.decode(data, &d.claims, false)
+}
+
+func (d *dualClaims) decode(data []byte, v interface{}, disallowUnknown bool) error {
+	dec := d.codec.NewDecoder(bytes.NewReader(data))
+	if d.useNumber {
+		dec.UseNumber()
+	}
+	if disallowUnknown {
+		dec.DisallowUnknownFields()
+	}
+	return dec.Decode(v)
+}
+
+// The typed claims are the ones validated
+func (d *dualClaims) Valid() error {
+	return d.typed.Valid()
+}
+
+// Claims decoded into v, a value of a type without a Valid method, which are
+// validated as the MapClaims decoded alongside them.  See ParseInto.
+type untypedClaims struct {
+	v    interface{}
+	dual *dualClaims
+}
+
+func (u *untypedClaims) Valid() error {
+	return u.dual.claims.Valid()
+}
+
+// Like ParseWithClaims, but also returns the claims as MapClaims, for
+// generic checks next to the typed claims used by business logic.  Both are
+// decoded from the same claims segment, and the signature is verified once.
+// The Valid method of typed is the one run.
+func ParseWithDualClaims(tokenString string, typed Claims, keyFunc Keyfunc) (*Token, MapClaims, error) {
+	return new(Parser).ParseWithDualClaims(tokenString, typed, keyFunc)
+}
+
+// Parser form of ParseWithDualClaims
+func (p *Parser) ParseWithDualClaims(tokenString string, typed Claims, keyFunc Keyfunc) (*Token, MapClaims, error) {
+	dual := p.newDualClaims()
+	dual.typed = typed
+	token, err := p.ParseWithClaims(tokenString, dual, keyFunc)
+	if token != nil && token.Claims == Claims(dual) {
+		token.Claims = typed
+	}
+	return token, dual.claims, err
+}
@@ -0,0 +1,70 @@
+package jwt_test
+
+import (
+	"testing"
+
+	"github.com/form3tech-oss/jwt-go"
+)
+
+type dualTestClaims struct {
+	jwt.StandardClaims
+	Roles []string `json:"roles"`
+}
+
+func TestParseWithDualClaims(t *testing.T) {
+	key := []byte("secret")
+	calls := 0
+	keyFunc := func(*jwt.Token) (interface{}, error) {
+		calls++
+		return key, nil
+	}
+	tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":    "user",
+		"roles":  []string{"admin"},
+		"tenant": "acme",
+	}).SignedString(key)
+
+	typed := &dualTestClaims{}
+	token, claims, err := jwt.NewParser(jwt.WithMaxAudiences(1)).ParseWithDualClaims(tokenString, typed, keyFunc)
+	if err != nil || !token.Valid {
+		t.Fatalf("Error while parsing token: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expecting a single Keyfunc call, got %d", calls)
+	}
+	if token.Claims != typed {
+		t.Errorf("Expecting token.Claims to be the typed claims, got %T", token.Claims)
+	}
+
+	if typed.Subject != "user" || len(typed.Roles) != 1 || typed.Roles[0] != "admin" {
+		t.Errorf("Typed claims were not populated: %+v", typed)
+	}
+	if claims["sub"] != typed.Subject || claims["tenant"] != "acme" {
+		t.Errorf("Map claims were not populated consistently: %v", claims)
+	}
+	if roles, _ := claims["roles"].([]interface{}); len(roles) != 1 || roles[0] != "admin" {
+		t.Errorf("Map claims roles differ: %v", claims["roles"])
+	}
+}
+
+// WithDisallowUnknownClaims applies to the typed claims, while the map
+// claims take any claim
+func TestParseWithDualClaims_disallowUnknown(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	parser := jwt.NewParser(jwt.WithDisallowUnknownClaims())
+
+	known, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "user", "roles": []string{"admin"}}).SignedString(key)
+	if _, claims, err := parser.ParseWithDualClaims(known, &dualTestClaims{}, keyFunc); err != nil || claims["sub"] != "user" {
+		t.Errorf("[known] Error while parsing token: %v", err)
+	}
+
+	unknown, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "user", "role": "admin"}).SignedString(key)
+	_, _, err := parser.ParseWithDualClaims(unknown, &dualTestClaims{}, keyFunc)
+	if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorMalformed {
+		t.Errorf("[unknown] Expecting ValidationErrorMalformed, got %v", err)
+	}
+	if _, _, err := parser.ParseWithDualClaims(unknown, jwt.MapClaims{}, keyFunc); err != nil {
+		t.Errorf("[unknown, map claims] Error while parsing token: %v", err)
+	}
+}
@@ -3,8 +3,11 @@ package jwt
 import (
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
+	"encoding/asn1"
 	"errors"
+	"hash"
 	"math/big"
 )
 
@@ -42,7 +45,7 @@ func init() {
 		return SigningMethodES384
 	})
 
-	// ES512
+	// ES512 uses the P-521 curve, so each of r and s is 66 bytes long
 	SigningMethodES512 = &SigningMethodECDSA{"ES512", crypto.SHA512, 66, 521}
 	RegisterSigningMethod(SigningMethodES512.Alg(), func() SigningMethod {
 		return SigningMethodES512
@@ -56,23 +59,96 @@ func (m *SigningMethodECDSA) Alg() string {
 // Implements the Verify method from SigningMethod
 // For this verify method, key must be an ecdsa.PublicKey struct
 func (m *SigningMethodECDSA) Verify(signingString, signature string, key interface{}) error {
-	var err error
-
 	// Decode the signature
-	var sig []byte
-	if sig, err = DecodeSegment(signature); err != nil {
+	sb, sig, err := decodeSignature(signature)
+	defer putSignature(sb)
+	if err != nil {
+		return err
+	}
+
+	ecdsaKey, err := m.publicKey(key)
+	if err != nil {
 		return err
 	}
 
+	// As for SigningMethodRSA, the hasher comes from a pool
+	hasher := getHash(m.Hash)
+	defer putHash(m.Hash, hasher)
+	return m.verifySum(ecdsaKey, hasher.sumString(signingString), sig)
+}
+
+// Returns the hash the signing input is written to, and a function verifying
+// the decoded signature against its sum
+func (m *SigningMethodECDSA) verifier(key interface{}) (hash.Hash, func(sig []byte) error, error) {
+	ecdsaKey, err := m.publicKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	hasher := m.Hash.New()
+
+	return hasher, func(sig []byte) error {
+		return m.verifySum(ecdsaKey, hasher.Sum(nil), sig)
+	}, nil
+}
+
+// Returns the verification key, checking it suits this method
+func (m *SigningMethodECDSA) publicKey(key interface{}) (*ecdsa.PublicKey, error) {
 	// Get the key
 	var ecdsaKey *ecdsa.PublicKey
 	switch k := key.(type) {
 	case *ecdsa.PublicKey:
 		ecdsaKey = k
 	default:
-		return ErrInvalidKeyType
+		return nil, ErrInvalidKeyType
 	}
 
+	// The key must be on the curve of this method, e.g. P-521 for ES512
+	if err := m.checkCurve(ecdsaKey.Curve); err != nil {
+		return nil, err
+	}
+
+	// Can we use the specified hashing method?
+	if !m.Hash.Available() {
+		return nil, ErrHashUnavailable
+	}
+	return ecdsaKey, nil
+}
+
+// Checks that curve is the one of this method: P-256, P-384 and P-521 by
+// identity for 256, 384 and 521 CurveBits, as other curves, such as
+// secp256k1, share their sizes.  Curves of other sizes are matched by size.
+func (m *SigningMethodECDSA) checkCurve(curve elliptic.Curve) error {
+	if m == es256k {
+		// SigningMethodES256K checked the curve against its own
+		return nil
+	}
+	if expected := standardCurve(m.CurveBits); expected != nil {
+		if curve != expected {
+			return ErrInvalidKey
+		}
+		return nil
+	}
+	if curve.Params().BitSize != m.CurveBits {
+		return ErrInvalidKey
+	}
+	return nil
+}
+
+// The NIST curve of bits, if there is one
+func standardCurve(bits int) elliptic.Curve {
+	switch bits {
+	case 256:
+		return elliptic.P256()
+	case 384:
+		return elliptic.P384()
+	case 521:
+		return elliptic.P521()
+	}
+	return nil
+}
+
+// Verifies the decoded signature sig, r || s, against the digest sum
+func (m *SigningMethodECDSA) verifySum(ecdsaKey *ecdsa.PublicKey, sum, sig []byte) error {
 	if len(sig) != 2*m.KeySize {
 		return ErrECDSAVerification
 	}
@@ -80,15 +156,8 @@ func (m *SigningMethodECDSA) Verify(signingString, signature string, key interfa
 	r := big.NewInt(0).SetBytes(sig[:m.KeySize])
 	s := big.NewInt(0).SetBytes(sig[m.KeySize:])
 
-	// Create hasher
-	if !m.Hash.Available() {
-		return ErrHashUnavailable
-	}
-	hasher := m.Hash.New()
-	hasher.Write([]byte(signingString))
-
 	// Verify the signature
-	if verifystatus := ecdsa.Verify(ecdsaKey, hasher.Sum(nil), r, s); verifystatus == true {
+	if verifystatus := ecdsa.Verify(ecdsaKey, sum, r, s); verifystatus == true {
 		return nil
 	} else {
 		return ErrECDSAVerification
@@ -96,13 +165,21 @@ func (m *SigningMethodECDSA) Verify(signingString, signature string, key interfa
 }
 
 // Implements the Sign method from SigningMethod
-// For this signing method, key must be an ecdsa.PrivateKey struct
+// For this signing method, key must be an ecdsa.PrivateKey struct, or any
+// crypto.Signer with an *ecdsa.PublicKey, such as an HSM or KMS backed key.
 func (m *SigningMethodECDSA) Sign(signingString string, key interface{}) (string, error) {
 	// Get the key
-	var ecdsaKey *ecdsa.PrivateKey
+	var signer crypto.Signer
+	var publicKey *ecdsa.PublicKey
 	switch k := key.(type) {
 	case *ecdsa.PrivateKey:
-		ecdsaKey = k
+		signer, publicKey = k, &k.PublicKey
+	case crypto.Signer:
+		var ok bool
+		if publicKey, ok = k.Public().(*ecdsa.PublicKey); !ok {
+			return "", ErrInvalidKeyType
+		}
+		signer = k
 	default:
 		return "", ErrInvalidKeyType
 	}
@@ -116,12 +193,11 @@ func (m *SigningMethodECDSA) Sign(signingString string, key interface{}) (string
 	hasher.Write([]byte(signingString))
 
 	// Sign the string and return r, s
-	if r, s, err := ecdsa.Sign(rand.Reader, ecdsaKey, hasher.Sum(nil)); err == nil {
-		curveBits := ecdsaKey.Curve.Params().BitSize
-
-		if m.CurveBits != curveBits {
-			return "", ErrInvalidKey
+	if r, s, err := signECDSA(signer, hasher.Sum(nil), m.Hash); err == nil {
+		if err := m.checkCurve(publicKey.Curve); err != nil {
+			return "", err
 		}
+		curveBits := publicKey.Curve.Params().BitSize
 
 		keyBytes := curveBits / 8
 		if curveBits%8 > 0 {
@@ -146,3 +222,25 @@ func (m *SigningMethodECDSA) Sign(signingString string, key interface{}) (string
 		return "", err
 	}
 }
+
+// Signs digest, returning the r and s values of the signature.  Private keys
+// are used directly, other signers return an ASN.1 encoded signature.
+func signECDSA(signer crypto.Signer, digest []byte, hash crypto.Hash) (r, s *big.Int, err error) {
+	if k, ok := signer.(*ecdsa.PrivateKey); ok {
+		return ecdsa.Sign(rand.Reader, k, digest)
+	}
+
+	der, err := signer.Sign(rand.Reader, digest, hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	var sig struct {
+		R, S *big.Int
+	}
+	if rest, err := asn1.Unmarshal(der, &sig); err != nil {
+		return nil, nil, err
+	} else if len(rest) != 0 {
+		return nil, nil, ErrECDSAVerification
+	}
+	return sig.R, sig.S, nil
+}
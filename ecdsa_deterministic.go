@@ -0,0 +1,139 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"math/big"
+)
+
+// Implements an ECDSA signing method whose nonce is derived from the private
+// key and the message, RFC 6979, instead of being random.  Signing the same
+// input with the same key then always yields the same signature, as needed
+// for reproducible test vectors.  Expects *ecdsa.PrivateKey for signing, and
+// verifies like the wrapped method.
+//
+// The alg is that of the wrapped method, e.g. ES256, so a parsed token uses
+// the plain ECDSA method.  It is never registered; it must be chosen
+// explicitly.
+//
+// WARNING: signing is not constant time.  Unlike crypto/ecdsa, the nonce and
+// s are computed with math/big, whose arithmetic on the private key and the
+// nonce takes time depending on their values, so an attacker who can time
+// many signatures may recover the key.  Only use it where signing times
+// can't be observed, e.g. to produce test vectors or fixtures offline, and
+// never to sign with a production key in a service.
+type SigningMethodDeterministicECDSA struct {
+	*SigningMethodECDSA
+}
+
+// Creates a SigningMethodDeterministicECDSA for method
+func NewSigningMethodDeterministicECDSA(method *SigningMethodECDSA) *SigningMethodDeterministicECDSA {
+	return &SigningMethodDeterministicECDSA{method}
+}
+
+// Deterministic returns the variant of m signing with RFC 6979 nonces, e.g.
+// SigningMethodES256.Deterministic() for reproducible ES256 signatures.  Its
+// signing is not constant time, so keep it to test vectors and fixtures,
+// never a production key; see SigningMethodDeterministicECDSA.
+func (m *SigningMethodECDSA) Deterministic() *SigningMethodDeterministicECDSA {
+	return NewSigningMethodDeterministicECDSA(m)
+}
+
+// Implements the Sign method from SigningMethod
+// For this signing method, key must be an *ecdsa.PrivateKey
+func (m *SigningMethodDeterministicECDSA) Sign(signingString string, key interface{}) (string, error) {
+	privateKey, ok := key.(*ecdsa.PrivateKey)
+	if !ok {
+		return "", ErrInvalidKeyType
+	}
+	if err := m.checkCurve(privateKey.Curve); err != nil {
+		return "", err
+	}
+	if !m.Hash.Available() {
+		return "", ErrHashUnavailable
+	}
+
+	hasher := m.Hash.New()
+	hasher.Write([]byte(signingString))
+	r, s := m.sign(privateKey, hasher.Sum(nil))
+
+	out := make([]byte, 2*m.KeySize)
+	rBytes, sBytes := r.Bytes(), s.Bytes()
+	copy(out[m.KeySize-len(rBytes):m.KeySize], rBytes)
+	copy(out[2*m.KeySize-len(sBytes):], sBytes)
+	return EncodeSegment(out), nil
+}
+
+// Signs digest with the nonces of RFC 6979 section 3.2.  Variable time, see
+// SigningMethodDeterministicECDSA.
+func (m *SigningMethodDeterministicECDSA) sign(key *ecdsa.PrivateKey, digest []byte) (r, s *big.Int) {
+	curve := key.Curve
+	n := curve.Params().N
+	qlen := n.BitLen()
+	rlen := (qlen + 7) / 8
+
+	// Leftmost qlen bits of b as an integer
+	bits2int := func(b []byte) *big.Int {
+		v := new(big.Int).SetBytes(b)
+		if excess := len(b)*8 - qlen; excess > 0 {
+			v.Rsh(v, uint(excess))
+		}
+		return v
+	}
+	int2octets := func(v *big.Int) []byte {
+		out := make([]byte, rlen)
+		b := v.Bytes()
+		copy(out[rlen-len(b):], b)
+		return out
+	}
+
+	e := bits2int(digest)
+	x := int2octets(key.D)
+	h1 := int2octets(new(big.Int).Mod(e, n))
+
+	mac := func(k []byte, data ...[]byte) []byte {
+		h := hmac.New(m.Hash.New, k)
+		for _, d := range data {
+			h.Write(d)
+		}
+		return h.Sum(nil)
+	}
+
+	size := m.Hash.Size()
+	v := make([]byte, size)
+	k := make([]byte, size)
+	for i := range v {
+		v[i] = 0x01
+	}
+	k = mac(k, v, []byte{0x00}, x, h1)
+	v = mac(k, v)
+	k = mac(k, v, []byte{0x01}, x, h1)
+	v = mac(k, v)
+
+	for {
+		var t []byte
+		for len(t) < rlen {
+			v = mac(k, v)
+			t = append(t, v...)
+		}
+		nonce := bits2int(t[:rlen])
+
+		if nonce.Sign() > 0 && nonce.Cmp(n) < 0 {
+			rx, _ := curve.ScalarBaseMult(int2octets(nonce))
+			r = new(big.Int).Mod(rx, n)
+			if r.Sign() != 0 {
+				// s = nonce^-1 * (e + r * d) mod n
+				s = new(big.Int).Mul(r, key.D)
+				s.Add(s, e)
+				s.Mul(s, new(big.Int).ModInverse(nonce, n))
+				s.Mod(s, n)
+				if s.Sign() != 0 {
+					return r, s
+				}
+			}
+		}
+
+		k = mac(k, v, []byte{0x00})
+		v = mac(k, v)
+	}
+}
@@ -0,0 +1,100 @@
+package jwt_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/form3tech-oss/jwt-go"
+	"github.com/form3tech-oss/jwt-go/test"
+)
+
+// The P-256 key of RFC 6979 appendix A.2.5
+func rfc6979P256Key() *ecdsa.PrivateKey {
+	d, _ := new(big.Int).SetString("C9AFA9D845BA75166B5C215767B1D6934E50C3DB36E89B127B8A622B120F6721", 16)
+	key := &ecdsa.PrivateKey{D: d}
+	key.Curve = elliptic.P256()
+	key.X, key.Y = key.Curve.ScalarBaseMult(d.Bytes())
+	return key
+}
+
+func TestDeterministicECDSA_RFC6979Vectors(t *testing.T) {
+	key := rfc6979P256Key()
+	method := jwt.SigningMethodES256.Deterministic()
+
+	var rfc6979TestData = []struct {
+		message string
+		r, s    string
+	}{
+		{
+			"sample",
+			"EFD48B2AACB6A8FD1140DD9CD45E81D69D2C877B56AAF991C34D0EA84EAF3716",
+			"F7CB1C942D657C41D436C7A1B6E29F65F3E900DBB9AFF4064DC4AB2F843ACDA8",
+		},
+		{
+			"test",
+			"F1ABB023518351CD71D881567B1EA663ED3EFCF6C5132B354F28D3B0B7D38367",
+			"019F4113742A2B14BD25926B49C649155F267E60D3814B4C0CC84250E46F0083",
+		},
+	}
+
+	for _, data := range rfc6979TestData {
+		sig, err := method.Sign(data.message, key)
+		if err != nil {
+			t.Fatalf("[%v] Error signing: %v", data.message, err)
+		}
+		raw, _ := jwt.DecodeSegment(sig)
+		if got := strings.ToUpper(hex.EncodeToString(raw)); got != data.r+data.s {
+			t.Errorf("[%v] Expecting r||s %v, got %v", data.message, data.r+data.s, got)
+		}
+		if err := jwt.SigningMethodES256.Verify(data.message, sig, &key.PublicKey); err != nil {
+			t.Errorf("[%v] Error verifying: %v", data.message, err)
+		}
+	}
+}
+
+func TestDeterministicECDSA(t *testing.T) {
+	var deterministicTestData = []struct {
+		method  *jwt.SigningMethodECDSA
+		keyFile string
+	}{
+		{jwt.SigningMethodES256, "test/ec256-private.pem"},
+		{jwt.SigningMethodES384, "test/ec384-private.pem"},
+		{jwt.SigningMethodES512, "test/ec512-private.pem"},
+	}
+
+	for _, data := range deterministicTestData {
+		key := test.LoadECPrivateKeyFromDisk(data.keyFile)
+		method := jwt.NewSigningMethodDeterministicECDSA(data.method)
+		claims := jwt.MapClaims{"foo": "bar"}
+
+		first, err := jwt.NewWithClaims(method, claims).SignedString(key)
+		if err != nil {
+			t.Fatalf("[%v] Error signing token: %v", data.method.Alg(), err)
+		}
+		second, _ := jwt.NewWithClaims(method, claims).SignedString(key)
+		if first != second {
+			t.Errorf("[%v] Expecting identical signatures, got %v and %v", data.method.Alg(), first, second)
+		}
+
+		token, err := jwt.Parse(first, func(*jwt.Token) (interface{}, error) { return &key.PublicKey, nil })
+		if err != nil || !token.Valid {
+			t.Errorf("[%v] Error while parsing token: %v", data.method.Alg(), err)
+		}
+
+		// The randomized method differs between signings
+		random1, _ := jwt.NewWithClaims(data.method, claims).SignedString(key)
+		random2, _ := jwt.NewWithClaims(data.method, claims).SignedString(key)
+		if random1 == random2 {
+			t.Errorf("[%v] Expecting randomized signatures to differ", data.method.Alg())
+		}
+	}
+
+	other := test.LoadECPrivateKeyFromDisk("test/ec384-private.pem")
+	if _, err := jwt.NewSigningMethodDeterministicECDSA(jwt.SigningMethodES256).Sign("foo", other); err != jwt.ErrInvalidKey {
+		t.Errorf("[wrong curve] Expecting ErrInvalidKey, got %v", err)
+	}
+}
@@ -2,11 +2,14 @@ package jwt_test
 
 import (
 	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/pem"
 	"io/ioutil"
 	"strings"
 	"testing"
 
 	"github.com/form3tech-oss/jwt-go"
+	"github.com/form3tech-oss/jwt-go/test"
 )
 
 var ecdsaTestData = []struct {
@@ -75,6 +78,83 @@ func TestECDSAVerify(t *testing.T) {
 	}
 }
 
+func TestECDSAKeyParsingEncodings(t *testing.T) {
+	key := test.LoadECPrivateKeyFromDisk("test/ec256-private.pem")
+	sec1, _ := x509.MarshalECPrivateKey(key)
+	pkcs8, _ := x509.MarshalPKCS8PrivateKey(key)
+	pkix, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+
+	privateKeys := map[string][]byte{
+		"SEC1":  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: sec1}),
+		"PKCS8": pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}),
+	}
+	publicKey, err := jwt.ParseECPublicKeyFromPEM(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pkix}))
+	if err != nil {
+		t.Fatalf("Failed to parse public key: %v", err)
+	}
+
+	parts := strings.Split(ecdsaTestData[0].tokenString, ".")
+	for name, privatePEM := range privateKeys {
+		privateKey, err := jwt.ParseECPrivateKeyFromPEM(privatePEM)
+		if err != nil {
+			t.Errorf("[%v] Failed to parse private key: %v", name, err)
+			continue
+		}
+		sig, err := jwt.SigningMethodES256.Sign(strings.Join(parts[0:2], "."), privateKey)
+		if err != nil {
+			t.Errorf("[%v] Error signing token: %v", name, err)
+			continue
+		}
+		if err := jwt.SigningMethodES256.Verify(strings.Join(parts[0:2], "."), sig, publicKey); err != nil {
+			t.Errorf("[%v] Error while verifying key: %v", name, err)
+		}
+	}
+}
+
+func TestECDSAMismatchedKeyType(t *testing.T) {
+	rsaKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	parts := strings.Split(ecdsaTestData[0].tokenString, ".")
+
+	if _, err := jwt.SigningMethodES256.Sign(strings.Join(parts[0:2], "."), rsaKey); err != jwt.ErrInvalidKeyType {
+		t.Errorf("Expecting ErrInvalidKeyType when signing with an RSA key, got %v", err)
+	}
+	if err := jwt.SigningMethodES256.Verify(strings.Join(parts[0:2], "."), parts[2], &rsaKey.PublicKey); err != jwt.ErrInvalidKeyType {
+		t.Errorf("Expecting ErrInvalidKeyType when verifying with an RSA key, got %v", err)
+	}
+}
+
+func TestECDSAES512RoundTrip(t *testing.T) {
+	privateKey := test.LoadECPrivateKeyFromDisk("test/ec512-private.pem")
+	publicKey := test.LoadECPublicKeyFromDisk("test/ec512-public.pem")
+	if name := privateKey.Curve.Params().Name; name != "P-521" {
+		t.Fatalf("Expecting a P-521 test key, got %v", name)
+	}
+
+	signingString := "eyJ0eXAiOiJKV1QiLCJhbGciOiJFUzUxMiJ9.eyJmb28iOiJiYXIifQ"
+	sig, err := jwt.SigningMethodES512.Sign(signingString, privateKey)
+	if err != nil {
+		t.Fatalf("Error signing token: %v", err)
+	}
+	decoded, _ := jwt.DecodeSegment(sig)
+	if len(decoded) != 132 {
+		t.Errorf("Expecting a 132 byte signature, got %d", len(decoded))
+	}
+	if err := jwt.SigningMethodES512.Verify(signingString, sig, publicKey); err != nil {
+		t.Errorf("Error while verifying signature: %v", err)
+	}
+
+	// Known answer from ecdsaTestData
+	parts := strings.Split(ecdsaTestData[2].tokenString, ".")
+	if err := jwt.SigningMethodES512.Verify(strings.Join(parts[0:2], "."), parts[2], publicKey); err != nil {
+		t.Errorf("Error while verifying known ES512 signature: %v", err)
+	}
+
+	// A key on another curve is rejected outright
+	if err := jwt.SigningMethodES512.Verify(signingString, sig, test.LoadECPublicKeyFromDisk("test/ec384-public.pem")); err != jwt.ErrInvalidKey {
+		t.Errorf("Expecting ErrInvalidKey for a P-384 key, got %v", err)
+	}
+}
+
 func TestECDSASign(t *testing.T) {
 	for _, data := range ecdsaTestData {
 		var err error
@@ -98,3 +178,12 @@ func TestECDSASign(t *testing.T) {
 		}
 	}
 }
+
+func BenchmarkES256Signing(b *testing.B) {
+	benchmarkSigning(b, jwt.SigningMethodES256, test.LoadECPrivateKeyFromDisk("test/ec256-private.pem"))
+}
+
+func BenchmarkES256Verifying(b *testing.B) {
+	key := test.LoadECPrivateKeyFromDisk("test/ec256-private.pem")
+	benchmarkVerifying(b, jwt.SigningMethodES256, key, &key.PublicKey)
+}
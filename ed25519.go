@@ -0,0 +1,175 @@
+//go:build go1.13
+// +build go1.13
+
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+)
+
+var (
+	ErrEd25519Verification = errors.New("ed25519: verification error")
+	ErrNotEdPrivateKey     = errors.New("Key is not a valid Ed25519 private key")
+	ErrNotEdPublicKey      = errors.New("Key is not a valid Ed25519 public key")
+)
+
+// Implements the EdDSA signing method with the Ed25519 curve, RFC 8037.
+// Expects ed25519.PrivateKey, or any crypto.Signer with an ed25519.PublicKey,
+// for signing and ed25519.PublicKey for validation.
+type SigningMethodEd25519 struct{}
+
+// Specific instance for EdDSA
+var (
+	SigningMethodEdDSA *SigningMethodEd25519
+)
+
+func init() {
+	SigningMethodEdDSA = &SigningMethodEd25519{}
+	RegisterSigningMethod(SigningMethodEdDSA.Alg(), func() SigningMethod {
+		return SigningMethodEdDSA
+	})
+}
+
+func (m *SigningMethodEd25519) Alg() string {
+	return "EdDSA"
+}
+
+// Implements the Verify method from SigningMethod
+// For this verify method, key must be an ed25519.PublicKey
+func (m *SigningMethodEd25519) Verify(signingString, signature string, key interface{}) error {
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return ErrInvalidKeyType
+	}
+	if len(publicKey) != ed25519.PublicKeySize {
+		return ErrInvalidKey
+	}
+
+	sig, err := DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(publicKey, []byte(signingString), sig) {
+		return ErrEd25519Verification
+	}
+	return nil
+}
+
+// Implements the Sign method from SigningMethod
+// For this signing method, key must be an ed25519.PrivateKey, or any
+// crypto.Signer with an ed25519.PublicKey, such as an HSM or KMS backed key.
+func (m *SigningMethodEd25519) Sign(signingString string, key interface{}) (string, error) {
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		if len(k) != ed25519.PrivateKeySize {
+			return "", ErrInvalidKey
+		}
+		return EncodeSegment(ed25519.Sign(k, []byte(signingString))), nil
+	case crypto.Signer:
+		if _, ok := k.Public().(ed25519.PublicKey); !ok {
+			return "", ErrInvalidKeyType
+		}
+		// Ed25519 signs the message itself, which a zero hash selects
+		sig, err := k.Sign(rand.Reader, []byte(signingString), crypto.Hash(0))
+		if err != nil {
+			return "", err
+		}
+		return EncodeSegment(sig), nil
+	}
+	return "", ErrInvalidKeyType
+}
+
+// Parse PEM encoded PKCS8 Ed25519 private key
+func ParseEdPrivateKeyFromPEM(key []byte) (ed25519.PrivateKey, error) {
+	var block *pem.Block
+	if block, _ = pem.Decode(key); block == nil {
+		return nil, ErrKeyMustBePEMEncoded
+	}
+
+	parsedKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	pkey, ok := parsedKey.(ed25519.PrivateKey)
+	if !ok {
+		return nil, ErrNotEdPrivateKey
+	}
+	return pkey, nil
+}
+
+// Parse PEM encoded PKIX Ed25519 public key, or the key of a certificate
+func ParseEdPublicKeyFromPEM(key []byte) (ed25519.PublicKey, error) {
+	var block *pem.Block
+	if block, _ = pem.Decode(key); block == nil {
+		return nil, ErrKeyMustBePEMEncoded
+	}
+
+	parsedKey, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		cert, certErr := x509.ParseCertificate(block.Bytes)
+		if certErr != nil {
+			return nil, err
+		}
+		parsedKey = cert.PublicKey
+	}
+	pkey, ok := parsedKey.(ed25519.PublicKey)
+	if !ok {
+		return nil, ErrNotEdPublicKey
+	}
+	return pkey, nil
+}
+
+// Reports whether key is an Ed25519 public or private key
+func isEd25519Key(key interface{}) bool {
+	switch key.(type) {
+	case ed25519.PublicKey, ed25519.PrivateKey:
+		return true
+	}
+	return false
+}
+
+// The signing method of an Ed25519 key
+func ed25519Method() SigningMethod {
+	return SigningMethodEdDSA
+}
+
+// Parses an Ed25519 public key from PEM, for the key sources accepting any
+// type of public key
+func parseEdPublicKeyFromPEM(data []byte) (interface{}, error) {
+	return ParseEdPublicKeyFromPEM(data)
+}
+
+// The members of the JWK of an Ed25519 public key, RFC 8037 section 2
+func okpJWKMembers(key interface{}) (map[string]interface{}, bool) {
+	k, ok := key.(ed25519.PublicKey)
+	if !ok || len(k) != ed25519.PublicKeySize {
+		return nil, false
+	}
+	return map[string]interface{}{
+		"kty": "OKP",
+		"crv": "Ed25519",
+		"x":   EncodeSegment(k),
+	}, true
+}
+
+// Parses the public key members of a decoded JWK of the OKP key type, RFC 8037
+// section 2.  Only the Ed25519 curve is supported.
+func parseOKPJWK(jwk map[string]interface{}) (interface{}, error) {
+	if jwk["crv"] != "Ed25519" {
+		return nil, ErrJWKInvalid
+	}
+	x, ok := jwk["x"].(string)
+	if !ok {
+		return nil, ErrJWKInvalid
+	}
+	b, err := DecodeSegment(x)
+	if err != nil || len(b) != ed25519.PublicKeySize {
+		return nil, ErrJWKInvalid
+	}
+	return ed25519.PublicKey(b), nil
+}
@@ -0,0 +1,27 @@
+//go:build !go1.13
+// +build !go1.13
+
+package jwt
+
+// Ed25519 needs crypto/ed25519, added in Go 1.13.  Without it no key is an
+// Ed25519 key, and OKP JWKs are unrecognized.
+
+func isEd25519Key(key interface{}) bool {
+	return false
+}
+
+func ed25519Method() SigningMethod {
+	return nil
+}
+
+func parseEdPublicKeyFromPEM(data []byte) (interface{}, error) {
+	return nil, ErrKeyMustBePEMEncoded
+}
+
+func okpJWKMembers(key interface{}) (map[string]interface{}, bool) {
+	return nil, false
+}
+
+func parseOKPJWK(jwk map[string]interface{}) (interface{}, error) {
+	return nil, ErrJWKInvalid
+}
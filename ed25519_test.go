@@ -0,0 +1,208 @@
+//go:build go1.13
+// +build go1.13
+
+package jwt_test
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/form3tech-oss/jwt-go"
+	"github.com/form3tech-oss/jwt-go/test"
+)
+
+// The example of RFC 8037 appendix A.4
+var ed25519RFCTestData = struct {
+	privateKey string
+	publicKey  string
+	jws        string
+}{
+	"nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A",
+	"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo",
+	"eyJhbGciOiJFZERTQSJ9.RXhhbXBsZSBvZiBFZDI1NTE5IHNpZ25pbmc.hgyY0il_MGCjP0JzlnLWG1PPOt7-09PGcvMg3AIbQR6dWbhijcNR4ki4iylGjg5BhVsPt9g7sVvpAr_MuM0KAg",
+}
+
+func loadEd25519Keys(t *testing.T) (ed25519.PrivateKey, ed25519.PublicKey) {
+	privateData, _ := ioutil.ReadFile("test/ed25519-private.pem")
+	privateKey, err := jwt.ParseEdPrivateKeyFromPEM(privateData)
+	if err != nil {
+		t.Fatalf("Unable to parse Ed25519 private key: %v", err)
+	}
+	publicData, _ := ioutil.ReadFile("test/ed25519-public.pem")
+	publicKey, err := jwt.ParseEdPublicKeyFromPEM(publicData)
+	if err != nil {
+		t.Fatalf("Unable to parse Ed25519 public key: %v", err)
+	}
+	return privateKey, publicKey
+}
+
+func TestEd25519RFC8037(t *testing.T) {
+	seed, _ := jwt.DecodeSegment(ed25519RFCTestData.privateKey)
+	privateKey := ed25519.NewKeyFromSeed(seed)
+	publicKey, _ := jwt.DecodeSegment(ed25519RFCTestData.publicKey)
+
+	parts := strings.Split(ed25519RFCTestData.jws, ".")
+	signingString := strings.Join(parts[0:2], ".")
+	if err := jwt.SigningMethodEdDSA.Verify(signingString, parts[2], ed25519.PublicKey(publicKey)); err != nil {
+		t.Errorf("Error while verifying the RFC 8037 signature: %v", err)
+	}
+
+	// Ed25519 signatures are deterministic
+	sig, err := jwt.SigningMethodEdDSA.Sign(signingString, privateKey)
+	if err != nil || sig != parts[2] {
+		t.Errorf("Expecting the RFC 8037 signature, got %v (%v)", sig, err)
+	}
+}
+
+func TestEd25519SignVerify(t *testing.T) {
+	privateKey, publicKey := loadEd25519Keys(t)
+	keyFunc := func(*jwt.Token) (interface{}, error) { return publicKey, nil }
+
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodEdDSA, jwt.MapClaims{"foo": "bar"}).SignedString(privateKey)
+	if err != nil {
+		t.Fatalf("Error signing token: %v", err)
+	}
+	token, err := jwt.Parse(tokenString, keyFunc)
+	if err != nil || !token.Valid || token.Method != jwt.SigningMethodEdDSA {
+		t.Errorf("Expecting a valid EdDSA token, got %v", err)
+	}
+
+	// A tampered signature or a key of another type doesn't verify
+	parts := strings.Split(tokenString, ".")
+	if err := jwt.SigningMethodEdDSA.Verify(strings.Join(parts[0:2], "."), parts[2][:10]+"AAAA"+parts[2][14:], publicKey); err != jwt.ErrEd25519Verification {
+		t.Errorf("Expecting ErrEd25519Verification, got %v", err)
+	}
+	if err := jwt.SigningMethodEdDSA.Verify(strings.Join(parts[0:2], "."), parts[2], []byte("secret")); err != jwt.ErrInvalidKeyType {
+		t.Errorf("Expecting ErrInvalidKeyType, got %v", err)
+	}
+	if _, err := jwt.SigningMethodEdDSA.Sign("signing string", publicKey); err != jwt.ErrInvalidKeyType {
+		t.Errorf("Expecting ErrInvalidKeyType signing with a public key, got %v", err)
+	}
+}
+
+func TestEd25519KeyHelpers(t *testing.T) {
+	_, publicKey := loadEd25519Keys(t)
+
+	if methods := jwt.AllowedMethodsForKey(publicKey); len(methods) != 1 || methods[0] != "EdDSA" {
+		t.Errorf("Expecting EdDSA to be allowed for an Ed25519 key, got %v", methods)
+	}
+
+	ecData, _ := ioutil.ReadFile("test/ec256-public.pem")
+	if _, err := jwt.ParseEdPublicKeyFromPEM(ecData); err != jwt.ErrNotEdPublicKey {
+		t.Errorf("Expecting ErrNotEdPublicKey for an EC key, got %v", err)
+	}
+	if _, err := jwt.ParseEdPrivateKeyFromPEM([]byte("not PEM")); err != jwt.ErrKeyMustBePEMEncoded {
+		t.Errorf("Expecting ErrKeyMustBePEMEncoded, got %v", err)
+	}
+
+	// PEM key sources accept Ed25519 keys
+	publicData, _ := ioutil.ReadFile("test/ed25519-public.pem")
+	store, err := jwt.NewKeyStore(map[string][]byte{"ed-1": publicData})
+	if err != nil || store.Len() != 1 {
+		t.Errorf("Expecting the key store to hold the Ed25519 key, got %v", err)
+	}
+
+	// As do JWK sets, with the OKP key type
+	set, _ := json.Marshal(map[string]interface{}{"keys": []interface{}{
+		map[string]interface{}{"kty": "OKP", "crv": "Ed25519", "kid": "ed-1", "x": jwt.EncodeSegment(publicKey)},
+		map[string]interface{}{"kty": "OKP", "crv": "X25519", "kid": "x-1", "x": jwt.EncodeSegment(publicKey)},
+	}})
+	jwks, err := jwt.ParseJWKSet(set)
+	if err != nil {
+		t.Fatalf("Error parsing JWK set: %v", err)
+	}
+	for kid, known := range map[string]bool{"ed-1": true, "x-1": false} {
+		token := &jwt.Token{Header: map[string]interface{}{"kid": kid}, Method: jwt.SigningMethodEdDSA}
+		key, err := jwks.Keyfunc(token)
+		if k, ok := key.(ed25519.PublicKey); known && (err != nil || !ok || !bytes.Equal(k, publicKey)) {
+			t.Errorf("[%v] Expecting the Ed25519 key, got %v (%v)", kid, key, err)
+		}
+		if !known && err != jwt.ErrKidUnknown {
+			t.Errorf("[%v] Expecting ErrKidUnknown, got %v", kid, err)
+		}
+	}
+}
+
+func TestEd25519Signer(t *testing.T) {
+	privateKey, publicKey := loadEd25519Keys(t)
+	signer := opaqueSigner{privateKey}
+
+	method, err := jwt.SigningMethodFromSigner(signer)
+	if err != nil || method != jwt.SigningMethodEdDSA {
+		t.Fatalf("Expecting EdDSA for an Ed25519 signer, got %v (%v)", method, err)
+	}
+
+	// Signatures are deterministic, so the signer matches the plain key
+	signed, err := jwt.NewWithClaims(method, jwt.MapClaims{"foo": "bar"}).SignedString(signer)
+	if err != nil {
+		t.Fatalf("Error signing token: %v", err)
+	}
+	plain, _ := jwt.NewWithClaims(method, jwt.MapClaims{"foo": "bar"}).SignedString(privateKey)
+	if signed != plain {
+		t.Errorf("Expecting the signer to sign as the private key does")
+	}
+	if _, err := jwt.Parse(signed, func(*jwt.Token) (interface{}, error) { return publicKey, nil }); err != nil {
+		t.Errorf("Error while verifying token: %v", err)
+	}
+
+	// A signer of another key type is rejected
+	rsaSigner := opaqueSigner{test.LoadRSAPrivateKeyFromDisk("test/sample_key")}
+	if _, err := jwt.SigningMethodEdDSA.Sign("signing string", rsaSigner); err != jwt.ErrInvalidKeyType {
+		t.Errorf("Expecting ErrInvalidKeyType for an RSA signer, got %v", err)
+	}
+}
+
+func TestEd25519JWK(t *testing.T) {
+	publicKey, _ := jwt.DecodeSegment(ed25519RFCTestData.publicKey)
+
+	encoded, err := jwt.MarshalJWK(ed25519.PublicKey(publicKey))
+	if err != nil || string(encoded) != `{"crv":"Ed25519","kty":"OKP","x":"`+ed25519RFCTestData.publicKey+`"}` {
+		t.Errorf("Expecting the OKP JWK, got %s (%v)", encoded, err)
+	}
+	key, err := jwt.ParseJWK(encoded)
+	if k, ok := key.(ed25519.PublicKey); err != nil || !ok || !bytes.Equal(k, publicKey) {
+		t.Errorf("Expecting the round tripped Ed25519 key, got %v (%v)", key, err)
+	}
+
+	// The thumbprint of RFC 8037 appendix A.3
+	if jkt, err := jwt.JWKThumbprint(ed25519.PublicKey(publicKey)); err != nil || jkt != "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k" {
+		t.Errorf("Expecting the RFC 8037 thumbprint, got %v (%v)", jkt, err)
+	}
+}
+
+// Registering Ed448 keeps Ed25519 tokens verifying under the shared EdDSA alg
+func TestRegisterEd448KeepsEd25519(t *testing.T) {
+	previous := jwt.GetSigningMethod("EdDSA")
+	defer jwt.RegisterSigningMethod("EdDSA", func() jwt.SigningMethod { return previous })
+	ed448 := jwt.RegisterEd448(fakeEd448{})
+
+	privateKey, publicKey := loadEd25519Keys(t)
+	seed := jwt.Ed448PrivateKey(bytes.Repeat([]byte{7}, jwt.Ed448SeedSize))
+	var registeredTestData = []struct {
+		name       string
+		method     jwt.SigningMethod
+		signKey    interface{}
+		verifyKey  interface{}
+		otherCurve interface{}
+	}{
+		{"Ed25519", jwt.SigningMethodEdDSA, privateKey, publicKey, fakeEd448{}.publicKey(bytes.Repeat([]byte{8}, jwt.Ed448SeedSize))},
+		{"Ed448", ed448, seed, fakeEd448{}.publicKey(seed), publicKey},
+	}
+	for _, data := range registeredTestData {
+		tokenString, err := jwt.NewWithClaims(data.method, jwt.MapClaims{"foo": "bar"}).SignedString(data.signKey)
+		if err != nil {
+			t.Fatalf("[%v] Error signing token: %v", data.name, err)
+		}
+		token, err := jwt.Parse(tokenString, func(*jwt.Token) (interface{}, error) { return data.verifyKey, nil })
+		if err != nil || !token.Valid {
+			t.Errorf("[%v] Error while verifying token: %v", data.name, err)
+		}
+		if _, err := jwt.Parse(tokenString, func(*jwt.Token) (interface{}, error) { return data.otherCurve, nil }); err == nil {
+			t.Errorf("[%v] Expecting a key of the other curve not to verify", data.name)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package jwt
+
+import (
+	"errors"
+)
+
+// Sizes of Ed448 keys and signatures, RFC 8032
+const (
+	Ed448PublicKeySize  = 57
+	Ed448SeedSize       = 57
+	Ed448PrivateKeySize = 114 // Seed followed by the public key
+	Ed448SignatureSize  = 114
+)
+
+var (
+	ErrEd448Verification = errors.New("ed448: verification error")
+	ErrEd448NoBackend    = errors.New("ed448: no backend configured")
+)
+
+// An Ed448 public key, as used by SigningMethodEd448
+type Ed448PublicKey []byte
+
+// An Ed448 private key, either the 57 byte seed or the 114 byte seed and
+// public key, as used by SigningMethodEd448
+type Ed448PrivateKey []byte
+
+// The Ed448 implementation backing SigningMethodEd448.  The standard library
+// has none, so it must be supplied, e.g. by wrapping a third party package.
+type Ed448Backend interface {
+	Sign(privateKey Ed448PrivateKey, message []byte) ([]byte, error)
+	Verify(publicKey Ed448PublicKey, message, signature []byte) bool
+}
+
+// Implements the EdDSA signing method with the Ed448 curve, RFC 8037.
+// Expects Ed448PrivateKey for signing and Ed448PublicKey for validation.
+type SigningMethodEd448 struct {
+	Backend Ed448Backend
+}
+
+// Creates SigningMethodEd448 with backend and registers it for the EdDSA alg,
+// so that parsed tokens use it, alongside the EdDSA method registered so
+// far, SigningMethodEdDSA for Ed25519 by default.  The alg doesn't name the
+// curve, so the registered method picks one by the type of the key:
+// Ed448PublicKey and Ed448PrivateKey keys use Ed448, any other key the
+// method registered before.
+func RegisterEd448(backend Ed448Backend) *SigningMethodEd448 {
+	m := &SigningMethodEd448{backend}
+	methods := &edDSAMethods{ed448: m, other: GetSigningMethod(m.Alg())}
+	RegisterSigningMethod(m.Alg(), func() SigningMethod {
+		return methods
+	})
+	return m
+}
+
+// The EdDSA method registered by RegisterEd448, dispatching on the key type
+// between Ed448 and the EdDSA method registered before it
+type edDSAMethods struct {
+	ed448 *SigningMethodEd448
+	other SigningMethod // nil if there was none, e.g. without crypto/ed25519
+}
+
+func (m *edDSAMethods) Alg() string {
+	return "EdDSA"
+}
+
+// Returns the method for key
+func (m *edDSAMethods) method(key interface{}) SigningMethod {
+	switch key.(type) {
+	case Ed448PublicKey, Ed448PrivateKey:
+		return m.ed448
+	}
+	if m.other == nil {
+		return m.ed448
+	}
+	return m.other
+}
+
+func (m *edDSAMethods) Verify(signingString, signature string, key interface{}) error {
+	return m.method(key).Verify(signingString, signature, key)
+}
+
+func (m *edDSAMethods) Sign(signingString string, key interface{}) (string, error) {
+	return m.method(key).Sign(signingString, key)
+}
+
+func (m *SigningMethodEd448) Alg() string {
+	return "EdDSA"
+}
+
+// Implements the Verify method from SigningMethod
+// For this verify method, key must be an Ed448PublicKey
+func (m *SigningMethodEd448) Verify(signingString, signature string, key interface{}) error {
+	publicKey, ok := key.(Ed448PublicKey)
+	if !ok {
+		return ErrInvalidKeyType
+	}
+	if len(publicKey) != Ed448PublicKeySize {
+		return ErrInvalidKey
+	}
+	if m.Backend == nil {
+		return ErrEd448NoBackend
+	}
+
+	sig, err := DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+	if len(sig) != Ed448SignatureSize {
+		return ErrEd448Verification
+	}
+
+	if !m.Backend.Verify(publicKey, []byte(signingString), sig) {
+		return ErrEd448Verification
+	}
+	return nil
+}
+
+// Implements the Sign method from SigningMethod
+// For this signing method, key must be an Ed448PrivateKey
+func (m *SigningMethodEd448) Sign(signingString string, key interface{}) (string, error) {
+	privateKey, ok := key.(Ed448PrivateKey)
+	if !ok {
+		return "", ErrInvalidKeyType
+	}
+	if len(privateKey) != Ed448SeedSize && len(privateKey) != Ed448PrivateKeySize {
+		return "", ErrInvalidKey
+	}
+	if m.Backend == nil {
+		return "", ErrEd448NoBackend
+	}
+
+	sig, err := m.Backend.Sign(privateKey, []byte(signingString))
+	if err != nil {
+		return "", err
+	}
+	return EncodeSegment(sig), nil
+}
@@ -0,0 +1,80 @@
+package jwt_test
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"strings"
+	"testing"
+
+	"github.com/form3tech-oss/jwt-go"
+)
+
+// A stand-in for a real Ed448 implementation.  The "public key" is the
+// reversed seed and the "signature" a keyed hash, which is enough to exercise
+// the plumbing of SigningMethodEd448.
+type fakeEd448 struct{}
+
+func (fakeEd448) publicKey(seed []byte) jwt.Ed448PublicKey {
+	pub := make(jwt.Ed448PublicKey, len(seed))
+	for i, b := range seed {
+		pub[len(seed)-1-i] = b
+	}
+	return pub
+}
+
+func (fakeEd448) sig(pub jwt.Ed448PublicKey, message []byte) []byte {
+	first := sha512.Sum512(append(append([]byte{}, pub...), message...))
+	second := sha512.Sum512(first[:])
+	return append(first[:], second[:jwt.Ed448SignatureSize-sha512.Size]...)
+}
+
+func (f fakeEd448) Sign(privateKey jwt.Ed448PrivateKey, message []byte) ([]byte, error) {
+	return f.sig(f.publicKey(privateKey[:jwt.Ed448SeedSize]), message), nil
+}
+
+func (f fakeEd448) Verify(publicKey jwt.Ed448PublicKey, message, signature []byte) bool {
+	return bytes.Equal(signature, f.sig(publicKey, message))
+}
+
+func TestEd448(t *testing.T) {
+	method := &jwt.SigningMethodEd448{Backend: fakeEd448{}}
+	seed := jwt.Ed448PrivateKey(bytes.Repeat([]byte{7}, jwt.Ed448SeedSize))
+	seed[0] = 1
+	publicKey := fakeEd448{}.publicKey(seed)
+
+	if alg := method.Alg(); alg != "EdDSA" {
+		t.Errorf("Expecting alg EdDSA, got %v", alg)
+	}
+
+	tokenString, err := jwt.NewWithClaims(method, jwt.MapClaims{"foo": "bar"}).SignedString(seed)
+	if err != nil {
+		t.Fatalf("Error signing token: %v", err)
+	}
+	parts := strings.Split(tokenString, ".")
+	sig, _ := jwt.DecodeSegment(parts[2])
+	if len(sig) != jwt.Ed448SignatureSize {
+		t.Errorf("Expecting a %d byte signature, got %d", jwt.Ed448SignatureSize, len(sig))
+	}
+
+	signingString := strings.Join(parts[0:2], ".")
+	if err := method.Verify(signingString, parts[2], publicKey); err != nil {
+		t.Errorf("Error while verifying token: %v", err)
+	}
+	if err := method.Verify(signingString+"x", parts[2], publicKey); err != jwt.ErrEd448Verification {
+		t.Errorf("Expecting ErrEd448Verification for a modified token, got %v", err)
+	}
+
+	// Key checks
+	if err := method.Verify(signingString, parts[2], publicKey[1:]); err != jwt.ErrInvalidKey {
+		t.Errorf("Expecting ErrInvalidKey for a short public key, got %v", err)
+	}
+	if err := method.Verify(signingString, parts[2], []byte(publicKey)); err != jwt.ErrInvalidKeyType {
+		t.Errorf("Expecting ErrInvalidKeyType for a []byte key, got %v", err)
+	}
+	if _, err := method.Sign(signingString, seed[1:]); err != jwt.ErrInvalidKey {
+		t.Errorf("Expecting ErrInvalidKey for a short private key, got %v", err)
+	}
+	if _, err := new(jwt.SigningMethodEd448).Sign(signingString, seed); err != jwt.ErrEd448NoBackend {
+		t.Errorf("Expecting ErrEd448NoBackend, got %v", err)
+	}
+}
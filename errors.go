@@ -2,6 +2,9 @@ package jwt
 
 import (
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 )
 
 // Error constants
@@ -9,6 +12,44 @@ var (
 	ErrInvalidKey      = errors.New("key is invalid")
 	ErrInvalidKeyType  = errors.New("key is of invalid type")
 	ErrHashUnavailable = errors.New("the requested hash function is unavailable")
+	ErrNilKey          = errors.New("keyfunc returned a nil key without an error")
+	ErrKeySetEmpty     = errors.New("keyfunc returned an empty VerificationKeySet")
+	ErrTokenIsJWE      = errors.New("token is an encrypted JWE, which needs a parser with WithDecryptionKey")
+	ErrTokenNotValid   = errors.New("token was not parsed or did not validate")
+	ErrClaimsNotObject = errors.New("token claims are not a JSON object")
+	ErrTrailingData    = errors.New("segment has data after its JSON value")
+
+	ErrAllowedSkewClamped = errors.New("allowed skew exceeds the maximum and was clamped, see WithMaxAllowedSkew")
+
+	ErrHashClaimMissing  = errors.New("token has no at_hash or c_hash claim to verify")
+	ErrHashClaimMismatch = errors.New("token at_hash or c_hash claim does not match")
+
+	ErrCnfMissing    = errors.New("token has no cnf claim")
+	ErrCnfJWKMissing = errors.New("token cnf claim has no jwk")
+
+	ErrClaimMissing        = errors.New("required claim is missing")
+	ErrClaimNotNumericDate = errors.New("claim is not a numeric date")
+
+	ErrX5CMissing = errors.New("token has no x5c certificate chain")
+	ErrX5CNoRoots = errors.New("x5c verification has no root certificates")
+)
+
+// The sentinel errors of the ValidationError bits, so that errors.Is reports
+// whether a ValidationError has a bit set, e.g. errors.Is(err,
+// ErrTokenExpired).  ValidationErrorSignatureInvalid is ErrSignatureInvalid.
+var (
+	ErrTokenMalformed        = errors.New("token is malformed")
+	ErrTokenUnverifiable     = errors.New("token is unverifiable")
+	ErrTokenInvalidAudience  = errors.New("token has invalid audience")
+	ErrTokenExpired          = errors.New("token is expired")
+	ErrTokenUsedBeforeIssued = errors.New("token used before issued")
+	ErrTokenInvalidIssuer    = errors.New("token has invalid issuer")
+	ErrTokenNotValidYet      = errors.New("token is not valid yet")
+	ErrTokenInvalidId        = errors.New("token has invalid id")
+	ErrTokenInvalidClaims    = errors.New("token has invalid claims")
+	ErrTokenRevoked          = errors.New("token has been revoked")
+	ErrTokenReplayed         = errors.New("token has already been used")
+	ErrMethodDeprecated      = errors.New("token signing method is deprecated")
 )
 
 // The errors that might occur when parsing and validating a token
@@ -25,8 +66,22 @@ const (
 	ValidationErrorNotValidYet   // NBF validation failed
 	ValidationErrorId            // JTI validation failed
 	ValidationErrorClaimsInvalid // Generic claims validation error
+	ValidationErrorRevoked       // Token was revoked, see WithRevocationChecker
+	ValidationErrorDeprecated    // Signing method is deprecated, see WithDeprecatedMethods
 )
 
+// The errors that are never downgraded by WithWarnOnly
+const validationErrorFatal = ValidationErrorMalformed | ValidationErrorUnverifiable | ValidationErrorSignatureInvalid
+
+// The claim validation errors that depend on the current time
+const validationErrorTime = ValidationErrorExpired | ValidationErrorIssuedAt | ValidationErrorNotValidYet
+
+// MessageFunc, when set, supplies the message returned by ValidationError.Error
+// for a single error bit, e.g. to localize "Token is expired".  It is called
+// once per set bit, lowest first, and the first non-empty result wins.
+// Returning "" keeps the default English message.  The bitmask is unaffected.
+var MessageFunc func(bit uint32) string
+
 // Helper for constructing a ValidationError with a string error message
 func NewValidationError(errorText string, errorFlags uint32) *ValidationError {
 	return &ValidationError{
@@ -35,15 +90,167 @@ func NewValidationError(errorText string, errorFlags uint32) *ValidationError {
 	}
 }
 
+// The segments of a compact token.  Used to report which part of a
+// malformed token could not be decoded.
+type Segment int
+
+const (
+	SegmentNone      Segment = iota // Error is not tied to a single segment
+	SegmentHeader                   // First segment, the JOSE header
+	SegmentClaims                   // Second segment, the claims payload
+	SegmentSignature                // Third segment, the signature
+)
+
+// Position of the segment in the dot-separated token, -1 for SegmentNone
+func (s Segment) Index() int {
+	return int(s) - 1
+}
+
+func (s Segment) String() string {
+	switch s {
+	case SegmentHeader:
+		return "header"
+	case SegmentClaims:
+		return "claims"
+	case SegmentSignature:
+		return "signature"
+	}
+	return "none"
+}
+
 // The error from Parse if token is not valid
 type ValidationError struct {
-	Inner  error  // stores the error returned by external dependencies, i.e.: KeyFunc
-	Errors uint32 // bitfield.  see ValidationError... constants
-	text   string // errors that do not have a valid error just have text
+	Inner   error   // stores the error returned by external dependencies, i.e.: KeyFunc
+	Errors  uint32  // bitfield.  see ValidationError... constants
+	Segment Segment // segment that failed to decode, for ValidationErrorMalformed
+	text    string  // errors that do not have a valid error just have text
+}
+
+// Helper for constructing a ValidationErrorMalformed for a segment that could
+// not be decoded or unmarshalled
+func newSegmentError(seg Segment, err error) *ValidationError {
+	return &ValidationError{
+		Inner:   &segmentError{seg, err},
+		Errors:  ValidationErrorMalformed,
+		Segment: seg,
+	}
+}
+
+// Wraps a decode error with the segment it occurred in
+type segmentError struct {
+	segment Segment
+	err     error
+}
+
+func (e *segmentError) Error() string {
+	return fmt.Sprintf("token %v segment (index %d) is malformed: %v", e.segment, e.segment.Index(), e.err)
+}
+
+func (e *segmentError) Unwrap() error {
+	return e.err
+}
+
+// The cause of the ValidationErrorMalformed for a segment longer than
+// allowed by WithMaxSegmentLength
+type SegmentLengthError struct {
+	Length int // Length of the encoded segment
+	Max    int // Maximum length configured for the segment
+}
+
+func (e *SegmentLengthError) Error() string {
+	return fmt.Sprintf("encoded length %d exceeds the maximum of %d", e.Length, e.Max)
+}
+
+// The cause of the ValidationErrorMalformed for a token longer than allowed by
+// WithMaxTokenSize, or DefaultMaxTokenSize
+type TokenSizeError struct {
+	Size int // Length of the token as transmitted
+	Max  int // Maximum length configured
+}
+
+func (e *TokenSizeError) Error() string {
+	return fmt.Sprintf("token length %d exceeds the maximum of %d", e.Size, e.Max)
+}
+
+// The cause of the ValidationErrorIssuedAt for a token older than allowed by
+// WithMaxAge, whether or not its exp claim passed too.  When both bounds are
+// exceeded it is reported only if the max age ran out first.
+type MaxAgeError struct {
+	Age    time.Duration // Time since the iat claim, or the nbf claim
+	MaxAge time.Duration // Maximum age configured with WithMaxAge
+}
+
+func (e *MaxAgeError) Error() string {
+	return fmt.Sprintf("token was issued %v ago, more than %v", e.Age, e.MaxAge)
+}
+
+// The cause of the ValidationErrorClaimsInvalid for a token whose exp claim
+// is further in the future than allowed by WithMaxExpiry
+type MaxExpiryError struct {
+	ExpiresAt time.Time     // The exp claim, in UTC
+	MaxExpiry time.Duration // Maximum time until exp configured with WithMaxExpiry
+}
+
+func (e *MaxExpiryError) Error() string {
+	return fmt.Sprintf("token expires at %v, more than %v from now", e.ExpiresAt, e.MaxExpiry)
+}
+
+// The cause of the ValidationErrorMalformed for a token whose typ or cty
+// header is not one of those given to WithExpectedType or
+// WithExpectedContentType
+type HeaderTypeError struct {
+	Header   string      // "typ" or "cty"
+	Value    interface{} // Value of the header, nil if it is missing
+	Expected []string    // The values accepted
+}
+
+func (e *HeaderTypeError) Error() string {
+	if e.Value == nil {
+		return fmt.Sprintf("token has no %v header, expecting one of %v", e.Header, strings.Join(e.Expected, ", "))
+	}
+	return fmt.Sprintf("token %v header %#v is invalid, expecting one of %v", e.Header, e.Value, strings.Join(e.Expected, ", "))
+}
+
+// A claim that failed a check of a Validator
+type ClaimError struct {
+	Claim string // Name of the claim, e.g. "exp"
+	Err   error  // Why the claim failed, e.g. ErrClaimMissing
+}
+
+func (e *ClaimError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Claim, e.Err)
+}
+
+// Returns Err, so errors.Is matches e.g. ErrClaimMissing
+func (e *ClaimError) Unwrap() error {
+	return e.Err
+}
+
+// Every claim that failed the checks of a Validator, in the order checked.
+// It is the Inner error of the ValidationError of a parser using
+// WithValidator.
+type ClaimErrors []*ClaimError
+
+func (e ClaimErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
 }
 
 // Validation error is an error type
 func (e ValidationError) Error() string {
+	if MessageFunc != nil {
+		for bit := uint32(1); bit != 0 && bit <= e.Errors; bit <<= 1 {
+			if e.Errors&bit == 0 {
+				continue
+			}
+			if msg := MessageFunc(bit); msg != "" {
+				return msg
+			}
+		}
+	}
 	if e.Inner != nil {
 		return e.Inner.Error()
 	} else if e.text != "" {
@@ -53,6 +260,78 @@ func (e ValidationError) Error() string {
 	}
 }
 
+// The sentinel errors of the error bits, see ValidationError.Is
+var validationErrorSentinels = map[uint32]error{
+	ValidationErrorMalformed:        ErrTokenMalformed,
+	ValidationErrorUnverifiable:     ErrTokenUnverifiable,
+	ValidationErrorSignatureInvalid: ErrSignatureInvalid,
+	ValidationErrorAudience:         ErrTokenInvalidAudience,
+	ValidationErrorExpired:          ErrTokenExpired,
+	ValidationErrorIssuedAt:         ErrTokenUsedBeforeIssued,
+	ValidationErrorIssuer:           ErrTokenInvalidIssuer,
+	ValidationErrorNotValidYet:      ErrTokenNotValidYet,
+	ValidationErrorId:               ErrTokenInvalidId,
+	ValidationErrorClaimsInvalid:    ErrTokenInvalidClaims,
+	ValidationErrorRevoked:          ErrTokenRevoked,
+	ValidationErrorDeprecated:       ErrMethodDeprecated,
+}
+
+// Reports whether target is the sentinel error of one of the bits set, for
+// errors.Is.  The bitmask stays the source of truth; this is only another
+// way to test it.
+func (e ValidationError) Is(target error) bool {
+	for bit, sentinel := range validationErrorSentinels {
+		if e.Errors&bit != 0 && target == sentinel {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the Inner error, so errors.Is and errors.As also match the cause,
+// e.g. a *MaxAgeError or the error returned by the Keyfunc
+func (e ValidationError) Unwrap() error {
+	return e.Inner
+}
+
+// The stable codes of the error bits, see ValidationError.Codes
+var validationErrorCodes = map[uint32]string{
+	ValidationErrorMalformed:        "token_malformed",
+	ValidationErrorUnverifiable:     "token_unverifiable",
+	ValidationErrorSignatureInvalid: "signature_invalid",
+	ValidationErrorAudience:         "audience_mismatch",
+	ValidationErrorExpired:          "token_expired",
+	ValidationErrorIssuedAt:         "issued_at_invalid",
+	ValidationErrorIssuer:           "issuer_mismatch",
+	ValidationErrorNotValidYet:      "token_not_yet_valid",
+	ValidationErrorId:               "id_invalid",
+	ValidationErrorClaimsInvalid:    "claims_invalid",
+	ValidationErrorRevoked:          "token_revoked",
+	ValidationErrorDeprecated:       "method_deprecated",
+}
+
+// Returns a stable code for each error bit set, lowest bit first, for API
+// responses that clients can branch on.  Unlike the messages, the codes never
+// change: "token_malformed", "token_unverifiable", "signature_invalid",
+// "audience_mismatch", "token_expired", "issued_at_invalid",
+// "issuer_mismatch", "token_not_yet_valid", "id_invalid", "claims_invalid",
+// "token_revoked" and "method_deprecated", in the order of the
+// ValidationError... constants.  Unknown bits are reported as "unknown".
+func (e ValidationError) Codes() []string {
+	var codes []string
+	for bit := uint32(1); bit != 0 && bit <= e.Errors; bit <<= 1 {
+		if e.Errors&bit == 0 {
+			continue
+		}
+		if code, ok := validationErrorCodes[bit]; ok {
+			codes = append(codes, code)
+		} else {
+			codes = append(codes, "unknown")
+		}
+	}
+	return codes
+}
+
 // No errors
 func (e *ValidationError) valid() bool {
 	return e.Errors == 0
@@ -0,0 +1,68 @@
+//go:build go1.13
+// +build go1.13
+
+package jwt_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/form3tech-oss/jwt-go"
+)
+
+func TestValidationError_Is(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	now := time.Now().Unix()
+	sign := func(claims jwt.MapClaims) string {
+		tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+		return tokenString
+	}
+
+	var isTestData = []struct {
+		name        string
+		tokenString string
+		parser      *jwt.Parser
+		is          []error
+		isNot       []error
+	}{
+		{"expired", sign(jwt.MapClaims{"exp": now - 100}), nil, []error{jwt.ErrTokenExpired}, []error{jwt.ErrTokenNotValidYet, jwt.ErrSignatureInvalid}},
+		{"not valid yet", sign(jwt.MapClaims{"nbf": now + 100}), nil, []error{jwt.ErrTokenNotValidYet}, []error{jwt.ErrTokenExpired}},
+		{"expired and not valid yet", sign(jwt.MapClaims{"exp": now - 100, "nbf": now + 100}), nil, []error{jwt.ErrTokenExpired, jwt.ErrTokenNotValidYet}, nil},
+		{"used before issued", sign(jwt.MapClaims{"iat": now + 100}), nil, []error{jwt.ErrTokenUsedBeforeIssued}, nil},
+		{"wrong key", func() string { s, _ := jwt.New(jwt.SigningMethodHS256).SignedString([]byte("other")); return s }(), nil, []error{jwt.ErrSignatureInvalid}, []error{jwt.ErrTokenMalformed}},
+		{"malformed", "not a token", nil, []error{jwt.ErrTokenMalformed}, nil},
+		{"issuer", sign(jwt.MapClaims{"iss": "https://other"}), jwt.NewParser(jwt.WithIssuer("https://issuer")), []error{jwt.ErrTokenInvalidIssuer}, nil},
+		{"audience", sign(jwt.MapClaims{"aud": "https://other"}), jwt.NewParser(jwt.WithAudience("https://api")), []error{jwt.ErrTokenInvalidAudience}, nil},
+	}
+
+	for _, data := range isTestData {
+		parser := data.parser
+		if parser == nil {
+			parser = new(jwt.Parser)
+		}
+		_, err := parser.Parse(data.tokenString, keyFunc)
+		for _, target := range data.is {
+			if !errors.Is(err, target) {
+				t.Errorf("[%v] Expecting errors.Is(%v), got %v", data.name, target, err)
+			}
+		}
+		for _, target := range data.isNot {
+			if errors.Is(err, target) {
+				t.Errorf("[%v] Expecting not errors.Is(%v), got %v", data.name, target, err)
+			}
+		}
+	}
+
+	// The cause is matched as well
+	_, err := jwt.NewParser(jwt.WithMaxAge(time.Minute)).Parse(sign(jwt.MapClaims{"iat": now - 3600}), keyFunc)
+	var maxAgeErr *jwt.MaxAgeError
+	if !errors.As(err, &maxAgeErr) || maxAgeErr.MaxAge != time.Minute {
+		t.Errorf("Expecting errors.As to find the MaxAgeError, got %v", err)
+	}
+	keyErr := errors.New("no key")
+	if _, err := jwt.Parse(sign(jwt.MapClaims{}), func(*jwt.Token) (interface{}, error) { return nil, keyErr }); !errors.Is(err, keyErr) || !errors.Is(err, jwt.ErrTokenUnverifiable) {
+		t.Errorf("Expecting the Keyfunc error and ErrTokenUnverifiable, got %v", err)
+	}
+}
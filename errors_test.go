@@ -0,0 +1,68 @@
+package jwt_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/form3tech-oss/jwt-go"
+)
+
+func TestValidationError_MessageFunc(t *testing.T) {
+	defer func() { jwt.MessageFunc = nil }()
+
+	claims := jwt.MapClaims{"exp": float64(time.Now().Unix() - 100)}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	key := []byte("secret")
+	tokenString, _ := token.SignedString(key)
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+
+	_, err := jwt.Parse(tokenString, keyFunc)
+	if err == nil || err.Error() != "Token is expired" {
+		t.Fatalf("Expecting the default message, got %v", err)
+	}
+
+	jwt.MessageFunc = func(bit uint32) string {
+		if bit == jwt.ValidationErrorExpired {
+			return "Le jeton a expiré"
+		}
+		return ""
+	}
+
+	_, err = jwt.Parse(tokenString, keyFunc)
+	if err == nil || err.Error() != "Le jeton a expiré" {
+		t.Errorf("Expecting the custom message, got %v", err)
+	}
+	if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorExpired {
+		t.Errorf("Expecting only ValidationErrorExpired, got %v", err)
+	}
+
+	// Bits without a custom message keep the default one
+	_, err = jwt.Parse("bad", keyFunc)
+	if err == nil || err.Error() != "token contains an invalid number of segments" {
+		t.Errorf("Expecting the default malformed message, got %v", err)
+	}
+}
+
+func TestValidationError_Codes(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	claims := jwt.MapClaims{"exp": float64(time.Now().Unix() - 100), "aud": "other"}
+	tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+
+	_, err := jwt.NewParser(jwt.WithAudiencePattern("svc")).Parse(tokenString, keyFunc)
+	ve, ok := err.(*jwt.ValidationError)
+	if !ok {
+		t.Fatalf("Expecting a ValidationError, got %v", err)
+	}
+	if codes := ve.Codes(); !reflect.DeepEqual(codes, []string{"audience_mismatch", "token_expired"}) {
+		t.Errorf("Expecting audience_mismatch and token_expired, got %v", codes)
+	}
+
+	if codes := jwt.NewValidationError("", jwt.ValidationErrorSignatureInvalid|1<<31).Codes(); !reflect.DeepEqual(codes, []string{"signature_invalid", "unknown"}) {
+		t.Errorf("Expecting signature_invalid and unknown, got %v", codes)
+	}
+	if codes := (jwt.ValidationError{}).Codes(); len(codes) != 0 {
+		t.Errorf("Expecting no codes without errors, got %v", codes)
+	}
+}
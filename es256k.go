@@ -0,0 +1,79 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"errors"
+)
+
+var (
+	ErrES256KNoCurve = errors.New("es256k: no secp256k1 curve configured")
+)
+
+// Implements the ES256K signing method, ECDSA with the secp256k1 curve and
+// SHA-256, RFC 8812.  The standard library has no secp256k1 curve, so it must
+// be supplied, e.g. the S256 curve of a third party package.  Expects
+// *ecdsa.PrivateKey, or a crypto.Signer, for signing and *ecdsa.PublicKey for
+// validation, on that very curve: keys are matched by identity of Curve.
+type SigningMethodES256K struct {
+	Curve elliptic.Curve
+}
+
+// Creates SigningMethodES256K with curve and registers it for the ES256K
+// alg, so that parsed tokens use it
+func RegisterES256K(curve elliptic.Curve) *SigningMethodES256K {
+	m := &SigningMethodES256K{curve}
+	RegisterSigningMethod(m.Alg(), func() SigningMethod {
+		return m
+	})
+	return m
+}
+
+// Signs and verifies once the curve of the key is checked
+var es256k = &SigningMethodECDSA{"ES256K", crypto.SHA256, 32, 256}
+
+func (m *SigningMethodES256K) Alg() string {
+	return "ES256K"
+}
+
+// Implements the Verify method from SigningMethod
+// For this verify method, key must be an *ecdsa.PublicKey on Curve
+func (m *SigningMethodES256K) Verify(signingString, signature string, key interface{}) error {
+	publicKey, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return ErrInvalidKeyType
+	}
+	if err := m.checkCurve(publicKey); err != nil {
+		return err
+	}
+	return es256k.Verify(signingString, signature, publicKey)
+}
+
+// Implements the Sign method from SigningMethod
+// For this signing method, key must be an *ecdsa.PrivateKey on Curve, or any
+// crypto.Signer with such a public key
+func (m *SigningMethodES256K) Sign(signingString string, key interface{}) (string, error) {
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return "", ErrInvalidKeyType
+	}
+	publicKey, ok := signer.Public().(*ecdsa.PublicKey)
+	if !ok {
+		return "", ErrInvalidKeyType
+	}
+	if err := m.checkCurve(publicKey); err != nil {
+		return "", err
+	}
+	return es256k.Sign(signingString, key)
+}
+
+func (m *SigningMethodES256K) checkCurve(key *ecdsa.PublicKey) error {
+	if m.Curve == nil {
+		return ErrES256KNoCurve
+	}
+	if key.Curve != m.Curve {
+		return ErrInvalidKey
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package jwt_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/form3tech-oss/jwt-go"
+)
+
+// A stand-in for a real secp256k1 curve.  A copy of the P-256 parameters is a
+// curve of its own to crypto/ecdsa, which is enough to exercise the plumbing
+// of SigningMethodES256K.
+var fakeSecp256k1 = func() elliptic.Curve {
+	params := *elliptic.P256().Params()
+	params.Name = "secp256k1 stand-in"
+	return &params
+}()
+
+func TestES256K(t *testing.T) {
+	method := jwt.RegisterES256K(fakeSecp256k1)
+	if jwt.GetSigningMethod("ES256K") != method {
+		t.Errorf("Expecting ES256K to be registered")
+	}
+	privateKey, err := ecdsa.GenerateKey(fakeSecp256k1, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokenString, err := jwt.NewWithClaims(method, jwt.MapClaims{"iss": "did:ethr:0xb9c5714089478a327f09197987f16f9e5d936e8a"}).SignedString(privateKey)
+	if err != nil {
+		t.Fatalf("Error signing token: %v", err)
+	}
+	parts := strings.Split(tokenString, ".")
+	if sig, _ := jwt.DecodeSegment(parts[2]); len(sig) != 64 {
+		t.Errorf("Expecting a 64 byte signature, got %d", len(sig))
+	}
+	token, err := jwt.Parse(tokenString, func(*jwt.Token) (interface{}, error) { return &privateKey.PublicKey, nil })
+	if err != nil || !token.Valid {
+		t.Errorf("Error while verifying token: %v", err)
+	}
+
+	signingString := strings.Join(parts[0:2], ".")
+	if err := method.Verify(signingString+"x", parts[2], &privateKey.PublicKey); err != jwt.ErrECDSAVerification {
+		t.Errorf("Expecting ErrECDSAVerification for a modified token, got %v", err)
+	}
+
+	// Key checks.  A P-256 key has the same size, but is on another curve.
+	p256Key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err := method.Verify(signingString, parts[2], &p256Key.PublicKey); err != jwt.ErrInvalidKey {
+		t.Errorf("Expecting ErrInvalidKey for a P-256 key, got %v", err)
+	}
+	if _, err := method.Sign(signingString, p256Key); err != jwt.ErrInvalidKey {
+		t.Errorf("Expecting ErrInvalidKey for a P-256 key, got %v", err)
+	}
+	if err := method.Verify(signingString, parts[2], []byte("secret")); err != jwt.ErrInvalidKeyType {
+		t.Errorf("Expecting ErrInvalidKeyType for a []byte key, got %v", err)
+	}
+	if _, err := new(jwt.SigningMethodES256K).Sign(signingString, privateKey); err != jwt.ErrES256KNoCurve {
+		t.Errorf("Expecting ErrES256KNoCurve, got %v", err)
+	}
+
+	// Nor does ES256 accept a secp256k1 key for its size, which maps to ES256K
+	if _, err := jwt.SigningMethodES256.Sign(signingString, privateKey); err != jwt.ErrInvalidKey {
+		t.Errorf("Expecting ErrInvalidKey from ES256 for a secp256k1 key, got %v", err)
+	}
+	if err := jwt.SigningMethodES256.Verify(signingString, parts[2], &privateKey.PublicKey); err != jwt.ErrInvalidKey {
+		t.Errorf("Expecting ErrInvalidKey from ES256 for a secp256k1 key, got %v", err)
+	}
+	if methods := jwt.AllowedMethodsForKey(&privateKey.PublicKey); len(methods) != 1 || methods[0] != "ES256K" {
+		t.Errorf("Expecting ES256K to be allowed for a secp256k1 key, got %v", methods)
+	}
+	if m, err := jwt.SigningMethodFromSigner(privateKey); err != nil || m != method {
+		t.Errorf("Expecting ES256K for a secp256k1 signer, got %v (%v)", m, err)
+	}
+}
@@ -0,0 +1,131 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"encoding/base64"
+	"hash"
+	"sync"
+)
+
+// Hashers reused across Verify calls, by crypto.Hash, to save allocating a
+// hasher and its buffers for every token on hot verification paths
+var hashPools [crypto.BLAKE2b_512 + 1]sync.Pool
+
+// A pooled hasher, with room for the signing input and the digest so that
+// neither has to be allocated per call
+type pooledHash struct {
+	hash.Hash
+	input []byte
+	sum   []byte
+	pool  *sync.Pool
+}
+
+// Returns a reset hasher for h from the pool, or a new one.  h must be
+// available.  Return it with putHash once its digest is no longer used.
+func getHash(h crypto.Hash) *pooledHash {
+	if int(h) < len(hashPools) {
+		if ph, ok := hashPools[h].Get().(*pooledHash); ok {
+			ph.Reset()
+			return ph
+		}
+	}
+	return &pooledHash{Hash: h.New()}
+}
+
+func putHash(h crypto.Hash, ph *pooledHash) {
+	if int(h) < len(hashPools) {
+		hashPools[h].Put(ph)
+	}
+}
+
+// Returns the digest of s.  The result is only valid until the hasher is
+// returned to the pool.
+func (ph *pooledHash) sumString(s string) []byte {
+	// Copying into the reused buffer avoids the allocation of []byte(s)
+	ph.input = append(ph.input[:0], s...)
+	ph.Write(ph.input)
+	ph.sum = ph.Sum(ph.sum[:0])
+	return ph.sum
+}
+
+// Writes s as io.StringWriter, through the reused buffer
+func (ph *pooledHash) WriteString(s string) (int, error) {
+	ph.input = append(ph.input[:0], s...)
+	return ph.Write(ph.input)
+}
+
+// MACs reused across Verify calls, by hash and then by key, so that
+// verifying with the same secret needn't allocate the hashers and pads of
+// hmac.New for every token
+var (
+	macPoolsMu sync.RWMutex
+	macPools   = make(map[crypto.Hash]map[string]*sync.Pool)
+)
+
+// The most secrets MACs are pooled for, so that a Keyfunc deriving a key per
+// token cannot grow the pools without bound.  Further secrets get a fresh
+// MAC on every call.
+const maxMACPoolKeys = 64
+
+// Returns a reset crypto/hmac MAC for h and key, from the pool for key if
+// there is one.  h must be available.  Return it with putMAC once its sum is
+// no longer used.
+func getMAC(h crypto.Hash, key []byte) *pooledHash {
+	macPoolsMu.RLock()
+	pool := macPools[h][string(key)]
+	macPoolsMu.RUnlock()
+
+	if pool == nil {
+		macPoolsMu.Lock()
+		if pool = macPools[h][string(key)]; pool == nil && len(macPools[h]) < maxMACPoolKeys {
+			if macPools[h] == nil {
+				macPools[h] = make(map[string]*sync.Pool)
+			}
+			pool = new(sync.Pool)
+			macPools[h][string(key)] = pool
+		}
+		macPoolsMu.Unlock()
+	}
+
+	if pool != nil {
+		if ph, ok := pool.Get().(*pooledHash); ok {
+			ph.Reset()
+			return ph
+		}
+	}
+	return &pooledHash{Hash: hmac.New(h.New, key), pool: pool}
+}
+
+func putMAC(ph *pooledHash) {
+	if ph.pool != nil {
+		ph.pool.Put(ph)
+	}
+}
+
+// Buffers signatures are decoded into, reused across Verify calls
+var signaturePool sync.Pool
+
+type signatureBuffer struct {
+	encoded []byte
+	decoded []byte
+}
+
+// Decodes signature as DecodeSegment does, into a buffer from the pool.
+// The result is only valid until the buffer is returned with putSignature.
+func decodeSignature(signature string) (*signatureBuffer, []byte, error) {
+	sb, ok := signaturePool.Get().(*signatureBuffer)
+	if !ok {
+		sb = new(signatureBuffer)
+	}
+	sb.encoded = append(sb.encoded[:0], signature...)
+	if n := base64.RawURLEncoding.DecodedLen(len(signature)); cap(sb.decoded) < n {
+		sb.decoded = make([]byte, n)
+	}
+	n, err := base64.RawURLEncoding.Decode(sb.decoded[:cap(sb.decoded)], sb.encoded)
+	return sb, sb.decoded[:n], err
+}
+
+func putSignature(sb *signatureBuffer) {
+	signaturePool.Put(sb)
+}
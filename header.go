@@ -0,0 +1,70 @@
+package jwt
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Typed view of the JOSE header parameters registered in
+// https://tools.ietf.org/html/rfc7515#section-4.1.  Parameters without a
+// field of their own are collected in Extra.
+type JOSEHeader struct {
+	Alg   string                 `json:"alg,omitempty"`
+	Typ   string                 `json:"typ,omitempty"`
+	Cty   string                 `json:"cty,omitempty"`
+	Kid   string                 `json:"kid,omitempty"`
+	Crit  []string               `json:"crit,omitempty"`
+	Jku   string                 `json:"jku,omitempty"`
+	Jwk   map[string]interface{} `json:"jwk,omitempty"`
+	X5c   []string               `json:"x5c,omitempty"`
+	X5t   string                 `json:"x5t,omitempty"`
+	Extra map[string]interface{} `json:"-"`
+}
+
+var joseHeaderParams = map[string]bool{
+	"alg": true, "typ": true, "cty": true, "kid": true, "crit": true,
+	"jku": true, "jwk": true, "x5c": true, "x5t": true,
+}
+
+func (h *JOSEHeader) UnmarshalJSON(data []byte) error {
+	// Decode the registered parameters through an alias without this method
+	type joseHeader JOSEHeader
+	if err := json.Unmarshal(data, (*joseHeader)(h)); err != nil {
+		return err
+	}
+
+	var all map[string]interface{}
+	if err := json.Unmarshal(data, &all); err != nil {
+		return err
+	}
+	h.Extra = nil
+	for k, v := range all {
+		if joseHeaderParams[k] {
+			continue
+		}
+		if h.Extra == nil {
+			h.Extra = make(map[string]interface{})
+		}
+		h.Extra[k] = v
+	}
+	return nil
+}
+
+// Decodes only the header segment of tokenString into a JOSEHeader.  Nothing
+// is verified, the header is exactly as untrusted as the token it came from.
+func ParseJOSEHeader(tokenString string) (*JOSEHeader, error) {
+	parts := strings.Split(tokenString, ".")
+	if len(parts) < 2 {
+		return nil, NewValidationError("token contains an invalid number of segments", ValidationErrorMalformed)
+	}
+
+	headerBytes, err := DecodeSegment(parts[0])
+	if err != nil {
+		return nil, newSegmentError(SegmentHeader, err)
+	}
+	header := new(JOSEHeader)
+	if err := DefaultJSONCodec.Unmarshal(headerBytes, header); err != nil {
+		return nil, newSegmentError(SegmentHeader, err)
+	}
+	return header, nil
+}
@@ -0,0 +1,40 @@
+package jwt_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/form3tech-oss/jwt-go"
+)
+
+func TestParseJOSEHeader(t *testing.T) {
+	header := `{"alg":"RS256","typ":"JWT","kid":"key-1","crit":["exp"],"exp":1500000000,"x5c":["MIIB"],"custom":"value"}`
+	tokenString := jwt.EncodeSegment([]byte(header)) + ".eyJmb28iOiJiYXIifQ.sig"
+
+	got, err := jwt.ParseJOSEHeader(tokenString)
+	if err != nil {
+		t.Fatalf("Error parsing header: %v", err)
+	}
+
+	want := &jwt.JOSEHeader{
+		Alg:  "RS256",
+		Typ:  "JWT",
+		Kid:  "key-1",
+		Crit: []string{"exp"},
+		X5c:  []string{"MIIB"},
+		Extra: map[string]interface{}{
+			"exp":    float64(1500000000),
+			"custom": "value",
+		},
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("Expecting %+v, got %+v", want, got)
+	}
+}
+
+func TestParseJOSEHeader_malformed(t *testing.T) {
+	_, err := jwt.ParseJOSEHeader("not-base64!.payload.sig")
+	if ve, ok := err.(*jwt.ValidationError); !ok || ve.Segment != jwt.SegmentHeader {
+		t.Errorf("Expecting a header segment error, got %v", err)
+	}
+}
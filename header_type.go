@@ -0,0 +1,40 @@
+package jwt
+
+import (
+	"strings"
+)
+
+// Checks the typ and cty headers of token against those accepted with
+// WithExpectedType and WithExpectedContentType
+func (p *Parser) checkTypes(token *Token) error {
+	if p.expectedTypes != nil && !matchesMediaType(token.Header["typ"], p.expectedTypes) {
+		return &ValidationError{Inner: &HeaderTypeError{"typ", token.Header["typ"], p.expectedTypes}, Errors: ValidationErrorMalformed}
+	}
+	if p.expectedCty != nil && !matchesMediaType(token.Header["cty"], p.expectedCty) {
+		return &ValidationError{Inner: &HeaderTypeError{"cty", token.Header["cty"], p.expectedCty}, Errors: ValidationErrorMalformed}
+	}
+	return nil
+}
+
+// Reports whether value, a typ or cty header, is one of types, or missing
+// and types includes "".  Media types compare case-insensitively, with or
+// without their "application/" prefix.
+func matchesMediaType(value interface{}, types []string) bool {
+	s, ok := value.(string)
+	if value != nil && !ok {
+		return false
+	}
+	for _, typ := range types {
+		if strings.EqualFold(trimApplication(s), trimApplication(typ)) {
+			return true
+		}
+	}
+	return false
+}
+
+func trimApplication(mediaType string) string {
+	if len(mediaType) > len("application/") && strings.EqualFold(mediaType[:len("application/")], "application/") {
+		return mediaType[len("application/"):]
+	}
+	return mediaType
+}
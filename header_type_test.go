@@ -0,0 +1,62 @@
+package jwt_test
+
+import (
+	"testing"
+
+	"github.com/form3tech-oss/jwt-go"
+)
+
+func TestParser_WithExpectedType(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	sign := func(header map[string]interface{}) string {
+		token := jwt.New(jwt.SigningMethodHS256)
+		delete(token.Header, "typ")
+		for name, value := range header {
+			token.Header[name] = value
+		}
+		tokenString, _ := token.SignedString(key)
+		return tokenString
+	}
+
+	var expectedTypeTestData = []struct {
+		name        string
+		tokenString string
+		options     []jwt.ParserOption
+		valid       bool
+	}{
+		{"JWT", sign(map[string]interface{}{"typ": "JWT"}), []jwt.ParserOption{jwt.WithExpectedType("JWT")}, true},
+		{"case-insensitive", sign(map[string]interface{}{"typ": "jwt"}), []jwt.ParserOption{jwt.WithExpectedType("JWT")}, true},
+		{"application prefix", sign(map[string]interface{}{"typ": "application/at+JWT"}), []jwt.ParserOption{jwt.WithExpectedType("at+jwt")}, true},
+		{"one of several", sign(map[string]interface{}{"typ": "at+jwt"}), []jwt.ParserOption{jwt.WithExpectedType("JWT", "at+jwt")}, true},
+		{"other type", sign(map[string]interface{}{"typ": "JWT"}), []jwt.ParserOption{jwt.WithExpectedType("at+jwt")}, false},
+		{"missing", sign(nil), []jwt.ParserOption{jwt.WithExpectedType("JWT")}, false},
+		{"missing allowed", sign(nil), []jwt.ParserOption{jwt.WithExpectedType("JWT", "")}, true},
+		{"not a string", sign(map[string]interface{}{"typ": 1}), []jwt.ParserOption{jwt.WithExpectedType("JWT")}, false},
+		{"cty", sign(map[string]interface{}{"typ": "JWT", "cty": "jwt"}), []jwt.ParserOption{jwt.WithExpectedContentType("JWT")}, true},
+		{"cty missing", sign(map[string]interface{}{"typ": "JWT"}), []jwt.ParserOption{jwt.WithExpectedContentType("JWT")}, false},
+		{"no expectation", sign(map[string]interface{}{"typ": "dpop+jwt"}), nil, true},
+	}
+
+	for _, data := range expectedTypeTestData {
+		called := false
+		_, err := jwt.NewParser(data.options...).Parse(data.tokenString, func(token *jwt.Token) (interface{}, error) {
+			called = true
+			return keyFunc(token)
+		})
+		if data.valid && err != nil {
+			t.Errorf("[%v] Expecting no error, got %v", data.name, err)
+		}
+		if !data.valid {
+			ve, ok := err.(*jwt.ValidationError)
+			if !ok || ve.Errors != jwt.ValidationErrorMalformed {
+				t.Errorf("[%v] Expecting ValidationErrorMalformed, got %v", data.name, err)
+			} else if _, ok := ve.Inner.(*jwt.HeaderTypeError); !ok {
+				t.Errorf("[%v] Expecting a HeaderTypeError, got %v", data.name, ve.Inner)
+			}
+			if called {
+				t.Errorf("[%v] Expecting the Keyfunc not to be called", data.name)
+			}
+		}
+	}
+}
@@ -4,10 +4,13 @@ import (
 	"crypto"
 	"crypto/hmac"
 	"errors"
+	"hash"
+	"io"
 )
 
 // Implements the HMAC-SHA family of signing methods signing methods
-// Expects key type of []byte for both signing and validation
+// Expects key type of []byte for both signing and validation.  A string key is
+// accepted too, the secret being its UTF-8 encoding
 type SigningMethodHMAC struct {
 	Name string
 	Hash crypto.Hash
@@ -47,40 +50,57 @@ func (m *SigningMethodHMAC) Alg() string {
 
 // Verify the signature of HSXXX tokens.  Returns nil if the signature is valid.
 func (m *SigningMethodHMAC) Verify(signingString, signature string, key interface{}) error {
-	// Verify the key is the right type
-	keyBytes, ok := key.([]byte)
-	if !ok {
-		return ErrInvalidKeyType
+	hasher, check, err := m.verifier(key)
+	if err != nil {
+		return err
 	}
 
 	// Decode signature, for comparison
-	sig, err := DecodeSegment(signature)
+	sb, sig, err := decodeSignature(signature)
+	defer putSignature(sb)
 	if err != nil {
 		return err
 	}
 
+	io.WriteString(hasher, signingString)
+	return check(sig)
+}
+
+// Returns the MAC the signing input is written to, and a function comparing
+// its sum against the decoded signature.  Verify is hot in gateways, so the
+// MAC comes from a pool for the key and is returned to it by the function.
+func (m *SigningMethodHMAC) verifier(key interface{}) (hash.Hash, func(sig []byte) error, error) {
+	// Verify the key is the right type
+	keyBytes, ok := hmacKeyBytes(key)
+	if !ok {
+		return nil, nil, ErrInvalidKeyType
+	}
+
 	// Can we use the specified hashing method?
 	if !m.Hash.Available() {
-		return ErrHashUnavailable
+		return nil, nil, ErrHashUnavailable
 	}
 
 	// This signing method is symmetric, so we validate the signature
 	// by reproducing the signature from the signing string and key, then
 	// comparing that against the provided signature.
-	hasher := hmac.New(m.Hash.New, keyBytes)
-	hasher.Write([]byte(signingString))
-	if !hmac.Equal(sig, hasher.Sum(nil)) {
-		return ErrSignatureInvalid
-	}
+	mac := getMAC(m.Hash, keyBytes)
+	return mac, func(sig []byte) error {
+		defer putMAC(mac)
+		mac.sum = mac.Sum(mac.sum[:0])
+		if !hmac.Equal(sig, mac.sum) {
+			return ErrSignatureInvalid
+		}
 
-	// No validation errors.  Signature is good.
-	return nil
+		// No validation errors.  Signature is good.
+		return nil
+	}, nil
 }
 
 // Implements the Sign method from SigningMethod for this signing method.
-// Key must be []byte
+// Key must be []byte or string
 func (m *SigningMethodHMAC) Sign(signingString string, key interface{}) (string, error) {
-	if keyBytes, ok := key.([]byte); ok {
+	if keyBytes, ok := hmacKeyBytes(key); ok {
 		if !m.Hash.Available() {
 			return "", ErrHashUnavailable
 		}
@@ -93,3 +113,14 @@ func (m *SigningMethodHMAC) Sign(signingString string, key interface{}) (string,
 
 	return "", ErrInvalidKeyType
 }
+
+// Returns the secret of an HMAC key given as []byte or string
+func hmacKeyBytes(key interface{}) ([]byte, bool) {
+	switch k := key.(type) {
+	case []byte:
+		return k, true
+	case string:
+		return []byte(k), true
+	}
+	return nil, false
+}
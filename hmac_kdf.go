@@ -0,0 +1,133 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"encoding/binary"
+	"errors"
+)
+
+var (
+	ErrKDFSaltTooShort   = errors.New("kdf salt must be at least 8 bytes")
+	ErrKDFTooFewRounds   = errors.New("kdf iterations must be at least 1000")
+	ErrKDFInvalidKeySize = errors.New("kdf key length must be positive")
+)
+
+// Parameters of the PBKDF2 key derivation (RFC 8018) run by
+// SigningMethodHMACKDF
+type KDFConfig struct {
+	Salt       []byte      // At least 8 bytes, unique per deployment
+	Iterations int         // At least 1000
+	Hash       crypto.Hash // PRF hash.  Defaults to the hash of the HMAC method
+	KeyLength  int         // Length of the derived key.  Defaults to the size of Hash
+}
+
+// Implements an HMAC-SHA signing method whose key is derived with PBKDF2 from
+// a passphrase, so the raw HMAC key never has to be stored.  Expects the
+// passphrase as []byte or string for both signing and validation.
+//
+// The alg is that of the wrapped method, e.g. HS256, so a parsed token uses
+// the plain HMAC method; have the Keyfunc return DeriveKey(passphrase).
+type SigningMethodHMACKDF struct {
+	*SigningMethodHMAC
+	KDF KDFConfig
+}
+
+// Creates a SigningMethodHMACKDF for method after checking kdf
+func NewSigningMethodHMACKDF(method *SigningMethodHMAC, kdf KDFConfig) (*SigningMethodHMACKDF, error) {
+	m := &SigningMethodHMACKDF{method, kdf}
+	if err := m.checkKDF(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (m *SigningMethodHMACKDF) checkKDF() error {
+	if len(m.KDF.Salt) < 8 {
+		return ErrKDFSaltTooShort
+	}
+	if m.KDF.Iterations < 1000 {
+		return ErrKDFTooFewRounds
+	}
+	if m.KDF.KeyLength < 0 {
+		return ErrKDFInvalidKeySize
+	}
+	if !m.kdfHash().Available() {
+		return ErrHashUnavailable
+	}
+	return nil
+}
+
+func (m *SigningMethodHMACKDF) kdfHash() crypto.Hash {
+	if m.KDF.Hash != 0 {
+		return m.KDF.Hash
+	}
+	return m.Hash
+}
+
+// Derives the HMAC key from passphrase, given as []byte or string
+func (m *SigningMethodHMACKDF) DeriveKey(passphrase interface{}) ([]byte, error) {
+	password, ok := hmacKeyBytes(passphrase)
+	if !ok {
+		return nil, ErrInvalidKeyType
+	}
+	if err := m.checkKDF(); err != nil {
+		return nil, err
+	}
+
+	hash := m.kdfHash()
+	keyLen := m.KDF.KeyLength
+	if keyLen == 0 {
+		keyLen = hash.Size()
+	}
+	return pbkdf2(hash, password, m.KDF.Salt, m.KDF.Iterations, keyLen), nil
+}
+
+// Implements the Verify method from SigningMethod for this signing method.
+func (m *SigningMethodHMACKDF) Verify(signingString, signature string, key interface{}) error {
+	derived, err := m.DeriveKey(key)
+	if err != nil {
+		return err
+	}
+	return m.SigningMethodHMAC.Verify(signingString, signature, derived)
+}
+
+// Implements the Sign method from SigningMethod for this signing method.
+func (m *SigningMethodHMACKDF) Sign(signingString string, key interface{}) (string, error) {
+	derived, err := m.DeriveKey(key)
+	if err != nil {
+		return "", err
+	}
+	return m.SigningMethodHMAC.Sign(signingString, derived)
+}
+
+// PBKDF2 with HMAC as the PRF, RFC 8018 section 5.2
+func pbkdf2(hash crypto.Hash, password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(hash.New, password)
+	size := prf.Size()
+	blocks := (keyLen + size - 1) / size
+
+	key := make([]byte, 0, blocks*size)
+	var counter [4]byte
+	u := make([]byte, size)
+	for block := 1; block <= blocks; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(counter[:], uint32(block))
+		prf.Write(counter[:])
+		u = prf.Sum(u[:0])
+
+		t := make([]byte, size)
+		copy(t, u)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLen]
+}
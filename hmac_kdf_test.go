@@ -0,0 +1,68 @@
+package jwt_test
+
+import (
+	"crypto"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/form3tech-oss/jwt-go"
+)
+
+func TestHMACKDF(t *testing.T) {
+	kdf := jwt.KDFConfig{Salt: []byte("0123456789abcdef"), Iterations: 1000}
+	method, err := jwt.NewSigningMethodHMACKDF(jwt.SigningMethodHS256, kdf)
+	if err != nil {
+		t.Fatalf("Error creating signing method: %v", err)
+	}
+
+	// Known answers, matching other PBKDF2 implementations
+	key, _ := method.DeriveKey("correct horse battery")
+	if got := hex.EncodeToString(key); got != "05500b18c982fcaffc10b12b739560271c8f140a40ac8651090b2a8c4819589e" {
+		t.Errorf("Unexpected derived key %v", got)
+	}
+	long := &jwt.SigningMethodHMACKDF{SigningMethodHMAC: jwt.SigningMethodHS256, KDF: jwt.KDFConfig{Salt: kdf.Salt, Iterations: 1000, Hash: crypto.SHA512, KeyLength: 80}}
+	key, _ = long.DeriveKey([]byte("correct horse battery"))
+	if got := hex.EncodeToString(key); got != "ec5fd8d6b9d65d7bab2c27c5bc09b28f25ed6c006183305304d726fffc259ef1813b3ece131960445b39ab0698e4fcc1dff5a347b3f8caffc0083419a435c87d639927adb6d3164c87f4e3f10f41590d" {
+		t.Errorf("Unexpected multi-block derived key %v", got)
+	}
+
+	tokenString, err := jwt.NewWithClaims(method, jwt.MapClaims{"foo": "bar"}).SignedString("correct horse battery")
+	if err != nil {
+		t.Fatalf("Error signing token: %v", err)
+	}
+	parts := strings.Split(tokenString, ".")
+	signingString := strings.Join(parts[0:2], ".")
+	if err := method.Verify(signingString, parts[2], "correct horse battery"); err != nil {
+		t.Errorf("Error while verifying token: %v", err)
+	}
+	if err := method.Verify(signingString, parts[2], "wrong horse battery"); err != jwt.ErrSignatureInvalid {
+		t.Errorf("Expecting ErrSignatureInvalid for a wrong passphrase, got %v", err)
+	}
+
+	// Parsed tokens use the plain HMAC method with the derived key
+	_, err = jwt.Parse(tokenString, func(*jwt.Token) (interface{}, error) {
+		return method.DeriveKey("correct horse battery")
+	})
+	if err != nil {
+		t.Errorf("Error while parsing token: %v", err)
+	}
+}
+
+func TestHMACKDF_config(t *testing.T) {
+	var kdfConfigTestData = []struct {
+		name string
+		kdf  jwt.KDFConfig
+		err  error
+	}{
+		{"short salt", jwt.KDFConfig{Salt: []byte("salt"), Iterations: 1000}, jwt.ErrKDFSaltTooShort},
+		{"few rounds", jwt.KDFConfig{Salt: []byte("0123456789abcdef"), Iterations: 10}, jwt.ErrKDFTooFewRounds},
+		{"negative length", jwt.KDFConfig{Salt: []byte("0123456789abcdef"), Iterations: 1000, KeyLength: -1}, jwt.ErrKDFInvalidKeySize},
+	}
+
+	for _, data := range kdfConfigTestData {
+		if _, err := jwt.NewSigningMethodHMACKDF(jwt.SigningMethodHS256, data.kdf); err != data.err {
+			t.Errorf("[%v] Expecting %v, got %v", data.name, data.err, err)
+		}
+	}
+}
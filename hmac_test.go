@@ -1,6 +1,7 @@
 package jwt_test
 
 import (
+	"fmt"
 	"github.com/form3tech-oss/jwt-go"
 	"io/ioutil"
 	"strings"
@@ -89,3 +90,97 @@ func BenchmarkHS384Signing(b *testing.B) {
 func BenchmarkHS512Signing(b *testing.B) {
 	benchmarkSigning(b, jwt.SigningMethodHS512, hmacTestKey)
 }
+
+func BenchmarkHS256Verifying(b *testing.B) {
+	benchmarkVerifying(b, jwt.SigningMethodHS256, hmacTestKey, hmacTestKey)
+}
+
+func BenchmarkHS384Verifying(b *testing.B) {
+	benchmarkVerifying(b, jwt.SigningMethodHS384, hmacTestKey, hmacTestKey)
+}
+
+func BenchmarkHS512Verifying(b *testing.B) {
+	benchmarkVerifying(b, jwt.SigningMethodHS512, hmacTestKey, hmacTestKey)
+}
+
+func TestHMACStringKey(t *testing.T) {
+	for _, key := range []interface{}{"my secret", []byte("my secret")} {
+		tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"foo": "bar"}).SignedString(key)
+		if err != nil {
+			t.Errorf("[%T] Error signing token: %v", key, err)
+			continue
+		}
+
+		// Verify with the other form of the same secret
+		for _, verifyKey := range []interface{}{"my secret", []byte("my secret")} {
+			token, err := jwt.Parse(tokenString, func(*jwt.Token) (interface{}, error) { return verifyKey, nil })
+			if err != nil || !token.Valid {
+				t.Errorf("[%T signed, %T verified] Error while verifying token: %v", key, verifyKey, err)
+			}
+		}
+	}
+}
+
+func TestHMACFamilySameSecret(t *testing.T) {
+	secret := []byte("my secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return secret, nil }
+	parser := &jwt.Parser{ValidMethods: []string{"HS256", "HS384", "HS512"}}
+
+	for _, method := range []*jwt.SigningMethodHMAC{jwt.SigningMethodHS256, jwt.SigningMethodHS384, jwt.SigningMethodHS512} {
+		tokenString, _ := jwt.NewWithClaims(method, jwt.MapClaims{"foo": "bar"}).SignedString(secret)
+		token, err := parser.Parse(tokenString, keyFunc)
+		if err != nil || !token.Valid {
+			t.Errorf("[%v] Error while verifying token: %v", method.Alg(), err)
+			continue
+		}
+		if token.Method != method {
+			t.Errorf("[%v] Expecting the token to use %v, got %v", method.Alg(), method.Alg(), token.Method.Alg())
+		}
+
+		// The signature is over the hash of the alg, not that of another
+		parts := strings.Split(tokenString, ".")
+		for _, other := range []*jwt.SigningMethodHMAC{jwt.SigningMethodHS256, jwt.SigningMethodHS384, jwt.SigningMethodHS512} {
+			if other != method && other.Verify(strings.Join(parts[0:2], "."), parts[2], secret) == nil {
+				t.Errorf("[%v] Expecting %v not to verify the signature", method.Alg(), other.Alg())
+			}
+		}
+	}
+
+	tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS512, jwt.MapClaims{"foo": "bar"}).SignedString(secret)
+	_, err := (&jwt.Parser{ValidMethods: []string{"HS256"}}).Parse(tokenString, keyFunc)
+	if err == nil || !strings.Contains(err.Error(), "HS512") || !strings.Contains(err.Error(), "HS256") {
+		t.Errorf("[not allowed] Expecting an error naming HS512 and HS256, got %v", err)
+	}
+
+	header := jwt.EncodeSegment([]byte(`{"alg":"HS1","typ":"JWT"}`))
+	_, err = jwt.Parse(header+".e30.c2ln", keyFunc)
+	if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorUnverifiable || !strings.Contains(err.Error(), "HS1") {
+		t.Errorf("[unsupported] Expecting ValidationErrorUnverifiable naming HS1, got %v", err)
+	}
+}
+
+// MACs are pooled per secret, so each secret must only verify its own
+// signatures, past the number of secrets pooled and when the caller reuses
+// the key's buffer for another secret
+func TestHMACPooledSecrets(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		secret := []byte(fmt.Sprintf("secret %d", i))
+		tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"foo": "bar"}).SignedString(secret)
+		parts := strings.Split(tokenString, ".")
+		signingString := strings.Join(parts[0:2], ".")
+
+		for j := 0; j < 2; j++ {
+			if err := jwt.SigningMethodHS256.Verify(signingString, parts[2], secret); err != nil {
+				t.Errorf("[secret %d] Error while verifying token: %v", i, err)
+			}
+		}
+		other := []byte(fmt.Sprintf("secret %d", i+1))
+		if err := jwt.SigningMethodHS256.Verify(signingString, parts[2], other); err != jwt.ErrSignatureInvalid {
+			t.Errorf("[secret %d] Expecting ErrSignatureInvalid with another secret, got %v", i, err)
+		}
+		copy(secret, other)
+		if err := jwt.SigningMethodHS256.Verify(signingString, parts[2], secret); err != jwt.ErrSignatureInvalid {
+			t.Errorf("[secret %d] Expecting ErrSignatureInvalid once the key is overwritten, got %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package jwt
+
+import (
+	"time"
+)
+
+// Callbacks a parser makes while parsing a token, to feed metrics and logs,
+// e.g. a counter of failures by error bit, without wrapping every call site.
+// Every field is optional.  They are called by the goroutine parsing the
+// token and must be safe for concurrent use if the parser is shared.  The
+// callbacks must not modify the token.  See WithHooks.
+type Hooks struct {
+	// Called as a parse starts, before anything is decoded
+	OnParseStart func()
+
+	// Called once the Keyfunc returned, with the time it took, even if it
+	// failed.  token is the unverified token the key was looked up for.
+	OnKeyfuncDuration func(token *Token, d time.Duration)
+
+	// Called for a token that is valid, as the parse returns it
+	OnVerifySuccess func(token *Token)
+
+	// Called with the error of a parse that failed, as it returns.  token is
+	// nil if the token couldn't be split or decoded; otherwise its claims
+	// must not be trusted unless token.SignatureValid is set.
+	OnValidationFailure func(token *Token, err error)
+}
+
+func (h *Hooks) parseStart() {
+	if h.OnParseStart != nil {
+		h.OnParseStart()
+	}
+}
+
+func (h *Hooks) keyfuncDuration(token *Token, start time.Time) {
+	if h.OnKeyfuncDuration != nil {
+		h.OnKeyfuncDuration(token, time.Since(start))
+	}
+}
+
+// Calls OnVerifySuccess or OnValidationFailure with the result of a parse
+func (h *Hooks) parseDone(token *Token, err error) {
+	if err == nil && h.OnVerifySuccess != nil {
+		h.OnVerifySuccess(token)
+	} else if err != nil && h.OnValidationFailure != nil {
+		h.OnValidationFailure(token, err)
+	}
+}
@@ -0,0 +1,89 @@
+package jwt_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/form3tech-oss/jwt-go"
+	"github.com/form3tech-oss/jwt-go/test"
+)
+
+func TestParser_WithHooks(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	valid, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"foo": "bar"}).SignedString(key)
+	expired, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"exp": float64(1)}).SignedString(key)
+	errNoKey := errors.New("no key")
+
+	var hooksTestData = []struct {
+		name        string
+		tokenString string
+		keyFunc     jwt.Keyfunc
+		success     bool
+		keyfunc     bool
+		token       bool
+	}{
+		{"valid", valid, keyFunc, true, true, true},
+		{"expired", expired, keyFunc, false, true, true},
+		{"keyfunc error", valid, func(*jwt.Token) (interface{}, error) { return nil, errNoKey }, false, true, true},
+		{"malformed", "not a token", keyFunc, false, false, false},
+	}
+
+	for _, data := range hooksTestData {
+		var starts, successes, failures, keyfuncs int
+		var failedToken *jwt.Token
+		var failure error
+		parser := jwt.NewParser(jwt.WithHooks(jwt.Hooks{
+			OnParseStart:      func() { starts++ },
+			OnKeyfuncDuration: func(token *jwt.Token, d time.Duration) { keyfuncs++ },
+			OnVerifySuccess:   func(token *jwt.Token) { successes++ },
+			OnValidationFailure: func(token *jwt.Token, err error) {
+				failures++
+				failedToken, failure = token, err
+			},
+		}))
+
+		_, err := parser.Parse(data.tokenString, data.keyFunc)
+		if starts != 1 {
+			t.Errorf("[%v] Expecting OnParseStart once, got %d", data.name, starts)
+		}
+		if data.success && (successes != 1 || failures != 0) {
+			t.Errorf("[%v] Expecting OnVerifySuccess only, got %d successes and %d failures", data.name, successes, failures)
+		}
+		if !data.success && (successes != 0 || failures != 1 || failure != err) {
+			t.Errorf("[%v] Expecting OnValidationFailure with %v, got %d successes and %d failures with %v", data.name, err, successes, failures, failure)
+		}
+		if !data.success && (failedToken != nil) != data.token {
+			t.Errorf("[%v] Expecting a token in OnValidationFailure: %v, got %v", data.name, data.token, failedToken)
+		}
+		if (keyfuncs == 1) != data.keyfunc {
+			t.Errorf("[%v] Expecting OnKeyfuncDuration: %v, got %d calls", data.name, data.keyfunc, keyfuncs)
+		}
+	}
+
+	// The async parse makes the same callbacks
+	var successes int
+	parser := jwt.NewParser(jwt.WithHooks(jwt.Hooks{OnVerifySuccess: func(*jwt.Token) { successes++ }}))
+	if _, err := parser.ParseWithClaimsAsync(valid, jwt.MapClaims{}, func(*jwt.Token) func() (interface{}, error) {
+		return func() (interface{}, error) { return key, nil }
+	}); err != nil || successes != 1 {
+		t.Errorf("[async] Expecting OnVerifySuccess once, got %d, %v", successes, err)
+	}
+
+	// A nested token is one parse, whether decrypted by the parser or with
+	// ParseNested
+	rsaKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	jwe, _ := jwt.EncryptToken(valid, jwt.JWEAlgRSAOAEP256, jwt.JWEEncA256GCM, &rsaKey.PublicKey)
+	var starts int
+	successes = 0
+	hooks := jwt.WithHooks(jwt.Hooks{OnParseStart: func() { starts++ }, OnVerifySuccess: func(*jwt.Token) { successes++ }})
+	if _, err := jwt.NewParser(hooks, jwt.WithDecryptionKey(rsaKey)).Parse(jwe, keyFunc); err != nil || starts != 1 || successes != 1 {
+		t.Errorf("[jwe] Expecting the callbacks once, got %d starts and %d successes, %v", starts, successes, err)
+	}
+	starts, successes = 0, 0
+	outerKeyFunc := func(*jwt.Token) (interface{}, error) { return rsaKey, nil }
+	if _, _, err := jwt.NewParser(hooks).ParseNested(jwe, jwt.MapClaims{}, outerKeyFunc, keyFunc); err != nil || starts != 1 || successes != 1 {
+		t.Errorf("[nested] Expecting the callbacks once, got %d starts and %d successes, %v", starts, successes, err)
+	}
+}
@@ -0,0 +1,56 @@
+package jwt
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// The JSON (de)serialization used to encode and decode tokens, e.g. to swap
+// encoding/json for a faster drop-in library.  See DefaultJSONCodec and
+// WithJSONCodec.
+//
+// Implementations must follow the encoding/json conventions: honor the
+// json.Marshaler and json.Unmarshaler interfaces and struct tags, and decode
+// numbers into interface{} values as json.Number once UseNumber was called
+// on a decoder.
+type JSONCodec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+	NewDecoder(r io.Reader) JSONDecoder
+}
+
+// A streaming decoder created by a JSONCodec.  *json.Decoder implements it.
+type JSONDecoder interface {
+	Decode(v interface{}) error
+	UseNumber()
+	DisallowUnknownFields()
+}
+
+// The JSONCodec used when signing tokens, by parsers not configured with
+// WithJSONCodec, by ParseJOSEHeader, and for the claims of Token.MarshalJSON
+// and Token.UnmarshalJSON.  Defaults to encoding/json.  Set it once, before tokens are
+// signed or parsed.
+var DefaultJSONCodec JSONCodec = stdJSONCodec{}
+
+// The JSONCodec backed by encoding/json
+type stdJSONCodec struct{}
+
+func (stdJSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (stdJSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (stdJSONCodec) NewDecoder(r io.Reader) JSONDecoder {
+	return json.NewDecoder(r)
+}
+
+// The JSONCodec of the parser
+func (p *Parser) json() JSONCodec {
+	if p.jsonCodec != nil {
+		return p.jsonCodec
+	}
+	return DefaultJSONCodec
+}
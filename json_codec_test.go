@@ -0,0 +1,100 @@
+package jwt_test
+
+import (
+	"encoding/json"
+	"io"
+	"sync/atomic"
+	"testing"
+
+	"github.com/form3tech-oss/jwt-go"
+)
+
+// A JSONCodec counting the calls it forwards to encoding/json
+type recordingCodec struct {
+	marshals, unmarshals, decoders int32
+}
+
+func (c *recordingCodec) Marshal(v interface{}) ([]byte, error) {
+	atomic.AddInt32(&c.marshals, 1)
+	return json.Marshal(v)
+}
+
+func (c *recordingCodec) Unmarshal(data []byte, v interface{}) error {
+	atomic.AddInt32(&c.unmarshals, 1)
+	return json.Unmarshal(data, v)
+}
+
+func (c *recordingCodec) NewDecoder(r io.Reader) jwt.JSONDecoder {
+	atomic.AddInt32(&c.decoders, 1)
+	return json.NewDecoder(r)
+}
+
+func TestParser_WithJSONCodec(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"foo": "bar", "n": json.Number("12345678901234567890")}).SignedString(key)
+
+	codec := &recordingCodec{}
+	parser := jwt.NewParser(jwt.WithJSONCodec(codec))
+	parser.UseJSONNumber = true
+	token, err := parser.Parse(tokenString, keyFunc)
+	if err != nil || !token.Valid {
+		t.Fatalf("Error while parsing token: %v", err)
+	}
+	if codec.unmarshals == 0 || codec.decoders == 0 {
+		t.Errorf("Expecting the header and claims to be decoded by the codec, got %+v", codec)
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+	if claims["foo"] != "bar" {
+		t.Errorf("Expecting foo to be bar, got %v", claims["foo"])
+	}
+	if n, ok := claims["n"].(json.Number); !ok || n.String() != "12345678901234567890" {
+		t.Errorf("Expecting n to be decoded as a json.Number, got %T %v", claims["n"], claims["n"])
+	}
+
+	// Decoded into a typed struct through the codec too
+	codec = &recordingCodec{}
+	standard := &jwt.StandardClaims{}
+	if _, err := jwt.NewParser(jwt.WithJSONCodec(codec)).ParseWithClaims(tokenString, standard, keyFunc); err != nil {
+		t.Errorf("[struct] Error while parsing token: %v", err)
+	}
+	if codec.decoders == 0 {
+		t.Errorf("[struct] Expecting the claims to be decoded by the codec")
+	}
+}
+
+func TestDefaultJSONCodec(t *testing.T) {
+	codec := &recordingCodec{}
+	defer func(c jwt.JSONCodec) { jwt.DefaultJSONCodec = c }(jwt.DefaultJSONCodec)
+	jwt.DefaultJSONCodec = codec
+
+	key := []byte("secret")
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"foo": "bar"}).SignedString(key)
+	if err != nil {
+		t.Fatalf("Error signing token: %v", err)
+	}
+	if codec.marshals != 2 {
+		t.Errorf("Expecting the header and claims to be encoded by the codec, got %v calls", codec.marshals)
+	}
+	if _, err := jwt.Parse(tokenString, func(*jwt.Token) (interface{}, error) { return key, nil }); err != nil {
+		t.Errorf("Error while parsing token: %v", err)
+	}
+	if codec.unmarshals == 0 {
+		t.Errorf("Expecting parsers without a codec to use the default one")
+	}
+
+	// As do the helpers outside of the parser
+	codec.unmarshals, codec.marshals, codec.decoders = 0, 0, 0
+	if _, err := jwt.ParseJOSEHeader(tokenString); err != nil || codec.unmarshals != 1 {
+		t.Errorf("Expecting ParseJOSEHeader to decode with the codec, got %v and %+v", err, codec)
+	}
+	token := jwt.New(jwt.SigningMethodHS256)
+	data, err := json.Marshal(token)
+	if err != nil || codec.marshals != 1 {
+		t.Errorf("Expecting Token.MarshalJSON to encode the claims with the codec, got %v and %+v", err, codec)
+	}
+	if err := json.Unmarshal(data, new(jwt.Token)); err != nil || codec.decoders != 1 {
+		t.Errorf("Expecting Token.UnmarshalJSON to decode the claims with the codec, got %v and %+v", err, codec)
+	}
+}
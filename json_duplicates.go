@@ -0,0 +1,63 @@
+package jwt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Stops checkDuplicateKeys at invalid JSON, which is left to the decoder
+var errInvalidJSON = errors.New("invalid JSON")
+
+// Returns an error if an object in the JSON document data repeats a member
+// name, at any depth
+func checkDuplicateKeys(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := checkDuplicateKeysValue(dec); err != errInvalidJSON {
+		return err
+	}
+	return nil
+}
+
+// Consumes one value from dec, checking objects for repeated member names
+func checkDuplicateKeysValue(dec *json.Decoder) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return errInvalidJSON
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		seen := map[string]bool{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return errInvalidJSON
+			}
+			name, _ := key.(string)
+			if seen[name] {
+				return fmt.Errorf("duplicate member %q", name)
+			}
+			seen[name] = true
+			if err := checkDuplicateKeysValue(dec); err != nil {
+				return err
+			}
+		}
+	case json.Delim('['):
+		for dec.More() {
+			if err := checkDuplicateKeysValue(dec); err != nil {
+				return err
+			}
+		}
+	default:
+		return nil
+	}
+
+	// The closing delimiter
+	if _, err := dec.Token(); err != nil {
+		return errInvalidJSON
+	}
+	return nil
+}
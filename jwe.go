@@ -0,0 +1,349 @@
+package jwt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"io"
+	"strings"
+)
+
+// The key management algorithms, the alg header of a JWE, RFC 7518 section 4
+const (
+	JWEAlgRSAOAEP    = "RSA-OAEP"     // RSAES OAEP with SHA-1, for an *rsa.PublicKey
+	JWEAlgRSAOAEP256 = "RSA-OAEP-256" // RSAES OAEP with SHA-256, for an *rsa.PublicKey
+	JWEAlgECDHES     = "ECDH-ES"      // Direct ECDH key agreement, for an *ecdsa.PublicKey
+)
+
+// The content encryption algorithms, the enc header of a JWE, RFC 7518
+// section 5.3
+const (
+	JWEEncA128GCM = "A128GCM"
+	JWEEncA192GCM = "A192GCM"
+	JWEEncA256GCM = "A256GCM"
+)
+
+var (
+	ErrJWEUnsupportedAlg = errors.New("jwe: unsupported key management algorithm (alg)")
+	ErrJWEUnsupportedEnc = errors.New("jwe: unsupported content encryption algorithm (enc)")
+	ErrJWEMalformed      = errors.New("jwe: token is not a compact JWE")
+	ErrJWEDecryption     = errors.New("jwe: decryption failed")
+	ErrJWEUnsigned       = errors.New("jwe: encrypted claims carry no signature, see WithUnsignedEncryptedClaims")
+)
+
+// Encrypts claims into a compact JWE, RFC 7516, for the holder of the private
+// key of key: an *rsa.PublicKey for the RSA-OAEP algs, or an
+// *ecdsa.PublicKey for ECDH-ES.  enc is one of the AES GCM algorithms.
+//
+// The claims are encrypted, not signed.  Anyone with key can encrypt claims
+// for its holder, so when the recipient must know who issued them, sign the
+// token first and encrypt it with EncryptToken instead.  Parsers only accept
+// such tokens with WithUnsignedEncryptedClaims.
+func EncryptClaims(claims Claims, alg, enc string, key interface{}) (string, error) {
+	payload, err := DefaultJSONCodec.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	return encryptJWE(map[string]interface{}{"typ": "JWT"}, payload, alg, enc, key)
+}
+
+// Encrypts tokenString, a signed token, into a compact JWE as EncryptClaims
+// does, with the cty header "JWT" marking the nested token, RFC 7519 section
+// 5.2.  A parser with WithDecryptionKey decrypts it and verifies the nested
+// token with its Keyfunc.
+func EncryptToken(tokenString string, alg, enc string, key interface{}) (string, error) {
+	return encryptJWE(map[string]interface{}{"cty": "JWT"}, []byte(tokenString), alg, enc, key)
+}
+
+// Decrypts a compact JWE with key, an *rsa.PrivateKey for the RSA-OAEP algs
+// or an *ecdsa.PrivateKey for ECDH-ES, returning its protected header and
+// plaintext.  Any failure to decrypt or authenticate the ciphertext is
+// reported as ErrJWEDecryption, without telling which step failed.
+func DecryptJWE(jwe string, key interface{}) (map[string]interface{}, []byte, error) {
+	return decryptJWE(jwe, key, DefaultJSONCodec)
+}
+
+func encryptJWE(header map[string]interface{}, plaintext []byte, alg, enc string, key interface{}) (string, error) {
+	keySize, ok := jweKeySize(enc)
+	if !ok {
+		return "", ErrJWEUnsupportedEnc
+	}
+	header["alg"], header["enc"] = alg, enc
+
+	var cek, encryptedKey []byte
+	switch alg {
+	case JWEAlgRSAOAEP, JWEAlgRSAOAEP256:
+		publicKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return "", ErrInvalidKeyType
+		}
+		cek = make([]byte, keySize)
+		if _, err := io.ReadFull(rand.Reader, cek); err != nil {
+			return "", err
+		}
+		var err error
+		if encryptedKey, err = rsa.EncryptOAEP(oaepHash(alg), rand.Reader, publicKey, cek, nil); err != nil {
+			return "", err
+		}
+	case JWEAlgECDHES:
+		publicKey, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return "", ErrInvalidKeyType
+		}
+		epk, err := ecdsa.GenerateKey(publicKey.Curve, rand.Reader)
+		if err != nil {
+			return "", err
+		}
+		if header["epk"], err = jwkMembers(&epk.PublicKey); err != nil {
+			return "", err
+		}
+		cek = ecdhESKey(epk, publicKey, enc, nil, nil, keySize)
+	default:
+		return "", ErrJWEUnsupportedAlg
+	}
+
+	headerJSON, err := DefaultJSONCodec.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	protected := EncodeSegment(headerJSON)
+
+	gcm, err := newJWEGCM(cek)
+	if err != nil {
+		return "", err
+	}
+	iv := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return "", err
+	}
+	// The ASCII of the encoded protected header is the additional data
+	sealed := gcm.Seal(nil, iv, plaintext, []byte(protected))
+	ciphertext, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+
+	return strings.Join([]string{protected, EncodeSegment(encryptedKey), EncodeSegment(iv), EncodeSegment(ciphertext), EncodeSegment(tag)}, "."), nil
+}
+
+func decryptJWE(jwe string, key interface{}, codec JSONCodec) (map[string]interface{}, []byte, error) {
+	parts := strings.Split(jwe, ".")
+	if len(parts) != 5 {
+		return nil, nil, ErrJWEMalformed
+	}
+	var segments [5][]byte
+	for i, part := range parts {
+		var err error
+		if segments[i], err = DecodeSegment(part); err != nil {
+			return nil, nil, ErrJWEMalformed
+		}
+	}
+	var header map[string]interface{}
+	if err := codec.Unmarshal(segments[0], &header); err != nil {
+		return nil, nil, ErrJWEMalformed
+	}
+	// Neither compression nor any critical extension is supported
+	if _, ok := header["zip"]; ok {
+		return header, nil, ErrJWEUnsupportedAlg
+	}
+	if _, ok := header["crit"]; ok {
+		return header, nil, ErrJWEUnsupportedAlg
+	}
+
+	enc, _ := header["enc"].(string)
+	keySize, ok := jweKeySize(enc)
+	if !ok {
+		return header, nil, ErrJWEUnsupportedEnc
+	}
+
+	var cek []byte
+	switch alg, _ := header["alg"].(string); alg {
+	case JWEAlgRSAOAEP, JWEAlgRSAOAEP256:
+		privateKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return header, nil, ErrInvalidKeyType
+		}
+		var err error
+		cek, err = rsa.DecryptOAEP(oaepHash(alg), rand.Reader, privateKey, segments[1], nil)
+		if err != nil || len(cek) != keySize {
+			// Carry on with a random key, RFC 7516 section 11.5, so that a
+			// bad key fails exactly like a bad ciphertext
+			cek = make([]byte, keySize)
+			if _, err := io.ReadFull(rand.Reader, cek); err != nil {
+				return header, nil, err
+			}
+		}
+	case JWEAlgECDHES:
+		privateKey, ok := key.(*ecdsa.PrivateKey)
+		if !ok {
+			return header, nil, ErrInvalidKeyType
+		}
+		if len(segments[1]) != 0 {
+			return header, nil, ErrJWEMalformed
+		}
+		jwk, ok := header["epk"].(map[string]interface{})
+		if !ok || jwk["kty"] != "EC" {
+			return header, nil, ErrJWEMalformed
+		}
+		// parseJWK rejects points off the curve, which would leak the key
+		epk, err := parseJWK(jwk)
+		if err != nil || epk.(*ecdsa.PublicKey).Curve != privateKey.Curve {
+			return header, nil, ErrJWEMalformed
+		}
+		apu, err := headerBytes(header, "apu")
+		if err != nil {
+			return header, nil, ErrJWEMalformed
+		}
+		apv, err := headerBytes(header, "apv")
+		if err != nil {
+			return header, nil, ErrJWEMalformed
+		}
+		cek = ecdhESKey(privateKey, epk.(*ecdsa.PublicKey), enc, apu, apv, keySize)
+	default:
+		return header, nil, ErrJWEUnsupportedAlg
+	}
+
+	gcm, err := newJWEGCM(cek)
+	if err != nil {
+		return header, nil, err
+	}
+	if len(segments[2]) != gcm.NonceSize() || len(segments[4]) != gcm.Overhead() {
+		return header, nil, ErrJWEDecryption
+	}
+	plaintext, err := gcm.Open(nil, segments[2], append(segments[3], segments[4]...), []byte(parts[0]))
+	if err != nil {
+		return header, nil, ErrJWEDecryption
+	}
+	return header, plaintext, nil
+}
+
+// Decrypts a token detected as a JWE by a parser with WithDecryptionKey.  A
+// nested token is verified with keyFunc, like any other.  Encrypted claims
+// have no signature, so they are refused unless the parser was configured
+// with WithUnsignedEncryptedClaims; keyFunc is then not called and only the
+// claims are validated.
+func (p *Parser) parseJWE(tokenString string, claims Claims, keyFunc Keyfunc) (*Token, error) {
+	header, plaintext, err := decryptJWE(tokenString, p.decryptionKey, p.json())
+	if err != nil {
+		if err == ErrJWEMalformed {
+			return nil, &ValidationError{Inner: err, Errors: ValidationErrorMalformed}
+		}
+		return nil, &ValidationError{Inner: err, Errors: ValidationErrorUnverifiable}
+	}
+
+	if cty, _ := header["cty"].(string); strings.EqualFold(cty, "JWT") {
+		nested := string(plaintext)
+		if strings.Count(nested, ".") != 2 {
+			return nil, NewValidationError("nested token is not a signed JWT", ValidationErrorMalformed)
+		}
+		return p.parseToken(nested, nil, claims, keyFunc)
+	}
+
+	token := &Token{Raw: tokenString, Header: header, Claims: claims}
+	if !p.unsignedJWEAllowed {
+		return token, &ValidationError{Inner: ErrJWEUnsigned, Errors: ValidationErrorUnverifiable}
+	}
+	if !isJSONObject(plaintext) {
+		return token, newSegmentError(SegmentClaims, ErrClaimsNotObject)
+	}
+	dec := p.json().NewDecoder(strings.NewReader(string(plaintext)))
+	if p.UseJSONNumber {
+		dec.UseNumber()
+	}
+	// Special case for map type to avoid weird pointer behavior
+	if c, ok := claims.(MapClaims); ok {
+		err = dec.Decode(&c)
+	} else {
+		err = dec.Decode(&claims)
+	}
+	if err != nil {
+		return token, newSegmentError(SegmentClaims, err)
+	}
+
+	if !p.SkipClaimsValidation {
+		// The plaintext stands in for the claims segment.  SignatureValid stays
+		// false, there is no signature.
+		if vErr := p.validateClaims(token, []string{"", EncodeSegment(plaintext), ""}); !vErr.valid() {
+			return token, vErr
+		}
+	}
+	token.Valid = true
+	return token, nil
+}
+
+// The key size in bytes of an enc algorithm
+func jweKeySize(enc string) (int, bool) {
+	switch enc {
+	case JWEEncA128GCM:
+		return 16, true
+	case JWEEncA192GCM:
+		return 24, true
+	case JWEEncA256GCM:
+		return 32, true
+	}
+	return 0, false
+}
+
+func oaepHash(alg string) hash.Hash {
+	if alg == JWEAlgRSAOAEP256 {
+		return sha256.New()
+	}
+	return sha1.New()
+}
+
+func newJWEGCM(cek []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Decodes the base64url encoded header name, nil if absent
+func headerBytes(header map[string]interface{}, name string) ([]byte, error) {
+	raw, ok := header[name]
+	if !ok {
+		return nil, nil
+	}
+	s, ok := raw.(string)
+	if !ok {
+		return nil, ErrJWEMalformed
+	}
+	return DecodeSegment(s)
+}
+
+// Derives the content encryption key of ECDH-ES from the shared secret of
+// privateKey and publicKey with the Concat KDF, RFC 7518 section 4.6.2
+func ecdhESKey(privateKey *ecdsa.PrivateKey, publicKey *ecdsa.PublicKey, enc string, apu, apv []byte, keySize int) []byte {
+	x, _ := privateKey.Curve.ScalarMult(publicKey.X, publicKey.Y, privateKey.D.Bytes())
+	size := (privateKey.Curve.Params().BitSize + 7) / 8
+	z := make([]byte, size)
+	copy(z[size-len(x.Bytes()):], x.Bytes())
+
+	lengthPrefixed := func(b []byte) []byte {
+		out := make([]byte, 4+len(b))
+		binary.BigEndian.PutUint32(out, uint32(len(b)))
+		copy(out[4:], b)
+		return out
+	}
+	var otherInfo []byte
+	otherInfo = append(otherInfo, lengthPrefixed([]byte(enc))...)
+	otherInfo = append(otherInfo, lengthPrefixed(apu)...)
+	otherInfo = append(otherInfo, lengthPrefixed(apv)...)
+	otherInfo = append(otherInfo, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(otherInfo[len(otherInfo)-4:], uint32(keySize*8))
+
+	var key []byte
+	for counter := uint32(1); len(key) < keySize; counter++ {
+		h := sha256.New()
+		binary.Write(h, binary.BigEndian, counter)
+		h.Write(z)
+		h.Write(otherInfo)
+		key = h.Sum(key)
+	}
+	return key[:keySize]
+}
@@ -0,0 +1,150 @@
+package jwt_test
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/form3tech-oss/jwt-go"
+	"github.com/form3tech-oss/jwt-go/test"
+)
+
+func TestEncryptClaims(t *testing.T) {
+	rsaKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	var jweTestData = []struct {
+		name       string
+		alg        string
+		enc        string
+		publicKey  interface{}
+		privateKey interface{}
+	}{
+		{"RSA-OAEP", jwt.JWEAlgRSAOAEP, jwt.JWEEncA128GCM, &rsaKey.PublicKey, rsaKey},
+		{"RSA-OAEP-256", jwt.JWEAlgRSAOAEP256, jwt.JWEEncA256GCM, &rsaKey.PublicKey, rsaKey},
+		{"ECDH-ES P-256", jwt.JWEAlgECDHES, jwt.JWEEncA128GCM, test.LoadECPublicKeyFromDisk("test/ec256-public.pem"), test.LoadECPrivateKeyFromDisk("test/ec256-private.pem")},
+		{"ECDH-ES P-384", jwt.JWEAlgECDHES, jwt.JWEEncA192GCM, test.LoadECPublicKeyFromDisk("test/ec384-public.pem"), test.LoadECPrivateKeyFromDisk("test/ec384-private.pem")},
+		{"ECDH-ES P-521", jwt.JWEAlgECDHES, jwt.JWEEncA256GCM, test.LoadECPublicKeyFromDisk("test/ec512-public.pem"), test.LoadECPrivateKeyFromDisk("test/ec512-private.pem")},
+	}
+
+	for _, data := range jweTestData {
+		jwe, err := jwt.EncryptClaims(jwt.MapClaims{"sub": "alice"}, data.alg, data.enc, data.publicKey)
+		if err != nil {
+			t.Errorf("[%v] Error encrypting claims: %v", data.name, err)
+			continue
+		}
+		if n := strings.Count(jwe, "."); n != 4 {
+			t.Errorf("[%v] Expecting five segments, got %v", data.name, n+1)
+		}
+
+		header, plaintext, err := jwt.DecryptJWE(jwe, data.privateKey)
+		if err != nil || header["alg"] != data.alg || header["enc"] != data.enc || string(plaintext) != `{"sub":"alice"}` {
+			t.Errorf("[%v] Expecting the claims back, got %s and %v (%v)", data.name, plaintext, header, err)
+		}
+
+		token, err := jwt.NewParser(jwt.WithDecryptionKey(data.privateKey), jwt.WithUnsignedEncryptedClaims()).Parse(jwe, nil)
+		if err != nil || !token.Valid || token.Claims.(jwt.MapClaims)["sub"] != "alice" {
+			t.Errorf("[%v] Error while parsing token: %v", data.name, err)
+		}
+
+		// Tampering with any segment fails to decrypt
+		parts := strings.Split(jwe, ".")
+		parts[3] = jwt.EncodeSegment([]byte(`{"sub":"mallory"}`))
+		if _, _, err := jwt.DecryptJWE(strings.Join(parts, "."), data.privateKey); err != jwt.ErrJWEDecryption {
+			t.Errorf("[%v] Expecting ErrJWEDecryption for a tampered ciphertext, got %v", data.name, err)
+		}
+	}
+
+	if _, err := jwt.EncryptClaims(jwt.MapClaims{}, "A128KW", jwt.JWEEncA128GCM, &rsaKey.PublicKey); err != jwt.ErrJWEUnsupportedAlg {
+		t.Errorf("Expecting ErrJWEUnsupportedAlg, got %v", err)
+	}
+	if _, err := jwt.EncryptClaims(jwt.MapClaims{}, jwt.JWEAlgRSAOAEP, "A128CBC-HS256", &rsaKey.PublicKey); err != jwt.ErrJWEUnsupportedEnc {
+		t.Errorf("Expecting ErrJWEUnsupportedEnc, got %v", err)
+	}
+	if _, err := jwt.EncryptClaims(jwt.MapClaims{}, jwt.JWEAlgECDHES, jwt.JWEEncA128GCM, &rsaKey.PublicKey); err != jwt.ErrInvalidKeyType {
+		t.Errorf("Expecting ErrInvalidKeyType, got %v", err)
+	}
+}
+
+func TestParser_WithDecryptionKey(t *testing.T) {
+	rsaKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	ecKey := test.LoadECPrivateKeyFromDisk("test/ec256-private.pem")
+	now := time.Now().Unix()
+
+	// A signed token nested in a JWE is verified with the Keyfunc
+	signed := test.MakeSampleToken(jwt.MapClaims{"sub": "alice", "exp": now + 100}, rsaKey)
+	jwe, _ := jwt.EncryptToken(signed, jwt.JWEAlgECDHES, jwt.JWEEncA256GCM, &ecKey.PublicKey)
+	parser := jwt.NewParser(jwt.WithDecryptionKey(ecKey))
+	token, err := parser.Parse(jwe, defaultKeyFunc)
+	if err != nil || !token.Valid || token.Method != jwt.SigningMethodRS256 || token.Raw != signed {
+		t.Errorf("[nested] Error while parsing token: %v", err)
+	}
+	if _, err := parser.Parse(jwe, func(*jwt.Token) (interface{}, error) { return &ecKey.PublicKey, nil }); err == nil {
+		t.Errorf("[nested] Expecting the nested signature to be verified")
+	}
+
+	// Encrypted claims are validated
+	jwe, _ = jwt.EncryptClaims(jwt.MapClaims{"sub": "alice", "exp": now - 100}, jwt.JWEAlgRSAOAEP256, jwt.JWEEncA128GCM, &rsaKey.PublicKey)
+	if _, err := jwt.NewParser(jwt.WithDecryptionKey(rsaKey), jwt.WithUnsignedEncryptedClaims()).Parse(jwe, nil); err == nil || err.(*jwt.ValidationError).Errors != jwt.ValidationErrorExpired {
+		t.Errorf("[expired] Expecting ValidationErrorExpired, got %v", err)
+	}
+	claims := &jwt.StandardClaims{}
+	jwe, _ = jwt.EncryptClaims(jwt.MapClaims{"sub": "alice"}, jwt.JWEAlgRSAOAEP256, jwt.JWEEncA128GCM, &rsaKey.PublicKey)
+	if _, err := jwt.NewParser(jwt.WithDecryptionKey(rsaKey), jwt.WithUnsignedEncryptedClaims(), jwt.WithIssuer("https://issuer")).ParseWithClaims(jwe, claims, nil); err == nil || err.(*jwt.ValidationError).Errors != jwt.ValidationErrorIssuer || claims.Subject != "alice" {
+		t.Errorf("[issuer] Expecting ValidationErrorIssuer, got %v", err)
+	}
+
+	// Anyone with the public key can encrypt claims, so they are refused
+	// unless unsigned claims are allowed, and never have a valid signature
+	forged, _ := jwt.EncryptClaims(jwt.MapClaims{"sub": "admin"}, jwt.JWEAlgRSAOAEP256, jwt.JWEEncA128GCM, &rsaKey.PublicKey)
+	if token, err := jwt.NewParser(jwt.WithDecryptionKey(rsaKey)).Parse(forged, nil); err == nil || err.(*jwt.ValidationError).Inner != jwt.ErrJWEUnsigned || token.Valid {
+		t.Errorf("[unsigned] Expecting ErrJWEUnsigned, got %v", err)
+	}
+	if token, err := jwt.NewParser(jwt.WithDecryptionKey(rsaKey), jwt.WithUnsignedEncryptedClaims()).Parse(forged, nil); err != nil || !token.Valid || token.SignatureValid {
+		t.Errorf("[unsigned allowed] Expecting a valid token without a valid signature, got %v", err)
+	}
+
+	// The wrong key can't decrypt, and without the option JWEs are refused
+	if _, err := jwt.NewParser(jwt.WithDecryptionKey(ecKey)).Parse(jwe, nil); err == nil || err.(*jwt.ValidationError).Errors != jwt.ValidationErrorUnverifiable {
+		t.Errorf("[wrong key] Expecting ValidationErrorUnverifiable, got %v", err)
+	}
+	if _, err := jwt.Parse(jwe, nil); err == nil || err.(*jwt.ValidationError).Inner != jwt.ErrTokenIsJWE {
+		t.Errorf("[no key] Expecting ErrTokenIsJWE, got %v", err)
+	}
+}
+
+// The ECDH-ES example of RFC 7518 appendix C, whose derived key encrypts a
+// JWE here as another implementation would
+func TestDecryptJWE_ECDHESInterop(t *testing.T) {
+	b := func(s string) *big.Int {
+		d, _ := jwt.DecodeSegment(s)
+		return new(big.Int).SetBytes(d)
+	}
+	bob := &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{Curve: elliptic.P256(), X: b("weNJy2HscCSM6AEDTDg04biOvhFhyyWvOHQfeF_PxMQ"), Y: b("e8lnCO-AlStT-NJVX-crhB7QRYhiix03illJOVAOyck")},
+		D:         b("VEmDZpDXXK8p8N0Cndsxs924q6nS1RXFASRl6BfUqdw"),
+	}
+	header, _ := json.Marshal(map[string]interface{}{
+		"alg": "ECDH-ES",
+		"enc": "A128GCM",
+		"apu": "QWxpY2U",
+		"apv": "Qm9i",
+		"epk": map[string]interface{}{"kty": "EC", "crv": "P-256", "x": "gI0GAILBdu7T53akrFmMyGcsF3n5dO7MmwNBHKW5SV0", "y": "SLW_xSffzlPWrHEVI30DHM_4egVwt3NQqeUD7nMFpps"},
+	})
+	protected := jwt.EncodeSegment(header)
+
+	key, _ := jwt.DecodeSegment("VqqN6vgjbSBcIijNcacQGg")
+	block, _ := aes.NewCipher(key)
+	gcm, _ := cipher.NewGCM(block)
+	iv := make([]byte, gcm.NonceSize())
+	sealed := gcm.Seal(nil, iv, []byte("Live long and prosper."), []byte(protected))
+	jwe := strings.Join([]string{protected, "", jwt.EncodeSegment(iv), jwt.EncodeSegment(sealed[:len(sealed)-16]), jwt.EncodeSegment(sealed[len(sealed)-16:])}, ".")
+
+	if _, plaintext, err := jwt.DecryptJWE(jwe, bob); err != nil || string(plaintext) != "Live long and prosper." {
+		t.Errorf("Expecting the plaintext, got %q (%v)", plaintext, err)
+	}
+}
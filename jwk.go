@@ -0,0 +1,323 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"math/big"
+)
+
+var (
+	ErrJWKMissing     = errors.New("token has no jwk header")
+	ErrJWKInvalid     = errors.New("jwk is not a valid public key")
+	ErrJWKAlgMismatch = errors.New("token alg does not match the alg of its jwk")
+	ErrJWKUntrusted   = errors.New("jwk is not trusted")
+)
+
+// Returns a Keyfunc that verifies a token against the public key embedded in
+// its jwk header.  RSA, EC and Ed25519 keys are supported.
+//
+// Anyone can embed a key in a token they signed themselves, so the embedded
+// key proves nothing on its own.  Every key is passed to trust, as an
+// *rsa.PublicKey, *ecdsa.PublicKey or ed25519.PublicKey, before it is used; trust must return
+// nil only for keys the caller has reason to accept, e.g. by comparing them
+// to a pinned key with TrustJWKThumbprints.  A nil trust accepts no key, and
+// a jwk holding private key material is always refused.
+func EmbeddedJWKKeyfunc(trust func(jwk interface{}) error) Keyfunc {
+	return func(token *Token) (interface{}, error) {
+		raw, ok := token.Header["jwk"]
+		if !ok {
+			return nil, ErrJWKMissing
+		}
+		jwk, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, ErrJWKInvalid
+		}
+		if _, ok := jwk["d"]; ok {
+			return nil, ErrJWKInvalid
+		}
+		if err := checkJWKAlg(jwk, token); err != nil {
+			return nil, err
+		}
+		key, err := parseJWK(jwk)
+		if err != nil {
+			return nil, err
+		}
+		if trust == nil {
+			return nil, ErrJWKUntrusted
+		}
+		if err := trust(key); err != nil {
+			return nil, err
+		}
+		return key, nil
+	}
+}
+
+// Returns a trust policy for EmbeddedJWKKeyfunc accepting only the keys whose
+// RFC 7638 thumbprint, see JWKThumbprint, is one of thumbprints, and refusing
+// any other with ErrJWKUntrusted
+func TrustJWKThumbprints(thumbprints ...string) func(jwk interface{}) error {
+	pinned := make(map[string]bool, len(thumbprints))
+	for _, thumbprint := range thumbprints {
+		pinned[thumbprint] = true
+	}
+	return func(jwk interface{}) error {
+		thumbprint, err := JWKThumbprint(jwk)
+		if err != nil || !pinned[thumbprint] {
+			return ErrJWKUntrusted
+		}
+		return nil
+	}
+}
+
+// Returns a trust policy for EmbeddedJWKKeyfunc accepting only keys of the
+// JWK key types ktys, "RSA", "EC" or "OKP", and refusing any other with
+// ErrJWKUntrusted.  It checks the type only, so it suits tokens that bind
+// the key some other way, such as DPoP proofs bound via a cnf claim; combine
+// it with a check of the key for anything else.
+func TrustJWKTypes(ktys ...string) func(jwk interface{}) error {
+	return func(jwk interface{}) error {
+		members, err := jwkMembers(jwk)
+		if err != nil {
+			return ErrJWKUntrusted
+		}
+		for _, kty := range ktys {
+			if members["kty"] == kty {
+				return nil
+			}
+		}
+		return ErrJWKUntrusted
+	}
+}
+
+// Parses a single JSON Web Key, RFC 7517, into an *rsa.PublicKey,
+// *ecdsa.PublicKey or ed25519.PublicKey, or into the []byte secret of a
+// symmetric key of the oct key type, for use with HMAC.  Members other than
+// the key material, such as kid and alg, are ignored.
+func ParseJWK(data []byte) (interface{}, error) {
+	var jwk map[string]interface{}
+	if err := DefaultJSONCodec.Unmarshal(data, &jwk); err != nil {
+		return nil, err
+	}
+	if jwk["kty"] == "oct" {
+		k, ok := jwk["k"].(string)
+		if !ok || k == "" {
+			return nil, ErrJWKInvalid
+		}
+		secret, err := DecodeSegment(k)
+		if err != nil {
+			return nil, ErrJWKInvalid
+		}
+		return secret, nil
+	}
+	return parseJWK(jwk)
+}
+
+// Encodes key as a JSON Web Key holding only the members of its key
+// material.  key must be an *rsa.PublicKey, an *ecdsa.PublicKey on one of the
+// P-256, P-384 or P-521 curves, an ed25519.PublicKey, or a []byte HMAC secret.
+func MarshalJWK(key interface{}) ([]byte, error) {
+	jwk, err := jwkMembers(key)
+	if err != nil {
+		return nil, err
+	}
+	return DefaultJSONCodec.Marshal(jwk)
+}
+
+// Returns the RFC 7638 thumbprint of key: the base64url encoded SHA-256 hash
+// of its JWK members, in lexicographic order and without whitespace.  key is
+// one of the types accepted by MarshalJWK.  Thumbprints are commonly used as
+// kid, and as the jkt of a cnf claim.
+func JWKThumbprint(key interface{}) (string, error) {
+	jwk, err := jwkMembers(key)
+	if err != nil {
+		return "", err
+	}
+	// encoding/json sorts map keys, giving the canonical form regardless
+	// of DefaultJSONCodec
+	data, err := json.Marshal(jwk)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return EncodeSegment(sum[:]), nil
+}
+
+// The required members of the JWK of key, RFC 7638 section 3.2
+func jwkMembers(key interface{}) (map[string]interface{}, error) {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return map[string]interface{}{
+			"kty": "RSA",
+			"n":   EncodeSegment(k.N.Bytes()),
+			"e":   EncodeSegment(big.NewInt(int64(k.E)).Bytes()),
+		}, nil
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256(), elliptic.P384(), elliptic.P521():
+		default:
+			return nil, ErrInvalidKey
+		}
+		// Coordinates are padded to the size of the curve
+		size := (k.Curve.Params().BitSize + 7) / 8
+		x, y := make([]byte, size), make([]byte, size)
+		copy(x[size-len(k.X.Bytes()):], k.X.Bytes())
+		copy(y[size-len(k.Y.Bytes()):], k.Y.Bytes())
+		return map[string]interface{}{
+			"kty": "EC",
+			"crv": k.Curve.Params().Name,
+			"x":   EncodeSegment(x),
+			"y":   EncodeSegment(y),
+		}, nil
+	case []byte:
+		if len(k) == 0 {
+			return nil, ErrInvalidKey
+		}
+		return map[string]interface{}{
+			"kty": "oct",
+			"k":   EncodeSegment(k),
+		}, nil
+	}
+	if jwk, ok := okpJWKMembers(key); ok {
+		return jwk, nil
+	}
+	return nil, ErrInvalidKeyType
+}
+
+// A JSON Web Key Set, RFC 7517 section 5, holding the public keys of an
+// issuer by kid
+type JWKSet struct {
+	keys map[string]jwkSetEntry
+}
+
+type jwkSetEntry struct {
+	jwk map[string]interface{}
+	key interface{}
+}
+
+// Parses a JSON Web Key Set.  Keys without a kid, or of a type other than
+// RSA, EC or OKP with the Ed25519 curve, are skipped, as required for
+// unrecognized keys.
+func ParseJWKSet(data []byte) (*JWKSet, error) {
+	var set struct {
+		Keys []map[string]interface{} `json:"keys"`
+	}
+	if err := DefaultJSONCodec.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	s := &JWKSet{keys: make(map[string]jwkSetEntry, len(set.Keys))}
+	for _, jwk := range set.Keys {
+		kid, ok := jwk["kid"].(string)
+		if !ok {
+			continue
+		}
+		key, err := parseJWK(jwk)
+		if err != nil {
+			continue
+		}
+		s.keys[kid] = jwkSetEntry{jwk, key}
+	}
+	return s, nil
+}
+
+// A Keyfunc selecting the key named by the kid header of the token.  When the
+// JWK declares an alg, the token must use exactly that alg.
+func (s *JWKSet) Keyfunc(token *Token) (interface{}, error) {
+	return lookupJWK(s.keys, token)
+}
+
+// Returns the key of keys named by the kid header of token, checking token
+// uses the alg of its JWK, if it declares one
+func lookupJWK(keys map[string]jwkSetEntry, token *Token) (interface{}, error) {
+	raw, ok := token.Header["kid"]
+	if !ok {
+		return nil, ErrKidMissing
+	}
+	kid, ok := raw.(string)
+	if !ok {
+		return nil, ErrKidInvalid
+	}
+	entry, ok := keys[kid]
+	if !ok {
+		return nil, ErrKidUnknown
+	}
+	if err := checkJWKAlg(entry.jwk, token); err != nil {
+		return nil, err
+	}
+	return entry.key, nil
+}
+
+// A JWK may restrict its key to a single algorithm with its alg member
+func checkJWKAlg(jwk map[string]interface{}, token *Token) error {
+	alg, ok := jwk["alg"]
+	if !ok {
+		return nil
+	}
+	if s, ok := alg.(string); !ok || token.Method == nil || s != token.Method.Alg() {
+		return ErrJWKAlgMismatch
+	}
+	return nil
+}
+
+// Parses the public key members of a decoded JWK
+func parseJWK(jwk map[string]interface{}) (interface{}, error) {
+	switch jwk["kty"] {
+	case "RSA":
+		n, err := jwkInt(jwk, "n")
+		if err != nil {
+			return nil, err
+		}
+		e, err := jwkInt(jwk, "e")
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, ErrJWKInvalid
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch jwk["crv"] {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, ErrJWKInvalid
+		}
+		x, err := jwkInt(jwk, "x")
+		if err != nil {
+			return nil, err
+		}
+		y, err := jwkInt(jwk, "y")
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, ErrJWKInvalid
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		return parseOKPJWK(jwk)
+	}
+	return nil, ErrJWKInvalid
+}
+
+// Decodes a base64url encoded, big-endian integer member of a JWK
+func jwkInt(jwk map[string]interface{}, name string) (*big.Int, error) {
+	s, ok := jwk[name].(string)
+	if !ok || s == "" {
+		return nil, ErrJWKInvalid
+	}
+	b, err := DecodeSegment(s)
+	if err != nil {
+		return nil, ErrJWKInvalid
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,328 @@
+package jwt_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/form3tech-oss/jwt-go"
+	"github.com/form3tech-oss/jwt-go/test"
+)
+
+// Encodes a public key into the members of a JWK
+func makeSampleJWK(key interface{}) map[string]interface{} {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		return map[string]interface{}{
+			"kty": "RSA",
+			"n":   jwt.EncodeSegment(k.N.Bytes()),
+			"e":   jwt.EncodeSegment(big.NewInt(int64(k.E)).Bytes()),
+		}
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		x, y := make([]byte, size), make([]byte, size)
+		copy(x[size-len(k.X.Bytes()):], k.X.Bytes())
+		copy(y[size-len(k.Y.Bytes()):], k.Y.Bytes())
+		return map[string]interface{}{
+			"kty": "EC",
+			"crv": k.Curve.Params().Name,
+			"x":   jwt.EncodeSegment(x),
+			"y":   jwt.EncodeSegment(y),
+		}
+	}
+	panic("unsupported key type")
+}
+
+func TestEmbeddedJWKKeyfunc(t *testing.T) {
+	ecPrivateKey := test.LoadECPrivateKeyFromDisk("test/ec384-private.pem")
+	ecPublicKey := test.LoadECPublicKeyFromDisk("test/ec384-public.pem")
+	errUntrusted := errors.New("untrusted key")
+
+	var embeddedJWKTestData = []struct {
+		name       string
+		method     jwt.SigningMethod
+		privateKey interface{}
+		publicKey  interface{}
+		trusted    bool
+	}{
+		{"RSA trusted", jwt.SigningMethodRS256, test.LoadRSAPrivateKeyFromDisk("test/sample_key"), jwtTestDefaultKey, true},
+		{"RSA untrusted", jwt.SigningMethodRS256, test.LoadRSAPrivateKeyFromDisk("test/sample_key"), jwtTestDefaultKey, false},
+		{"EC trusted", jwt.SigningMethodES384, ecPrivateKey, ecPublicKey, true},
+		{"EC untrusted", jwt.SigningMethodES384, ecPrivateKey, ecPublicKey, false},
+	}
+
+	for _, data := range embeddedJWKTestData {
+		token := jwt.NewWithClaims(data.method, jwt.MapClaims{"foo": "bar"})
+		token.Header["jwk"] = makeSampleJWK(data.publicKey)
+		tokenString, err := token.SignedString(data.privateKey)
+		if err != nil {
+			t.Fatalf("[%v] Error signing token: %v", data.name, err)
+		}
+
+		var trusted interface{}
+		keyfunc := jwt.EmbeddedJWKKeyfunc(func(jwk interface{}) error {
+			trusted = jwk
+			if !data.trusted {
+				return errUntrusted
+			}
+			return nil
+		})
+
+		parsed, err := jwt.Parse(tokenString, keyfunc)
+		if data.trusted && (err != nil || !parsed.Valid) {
+			t.Errorf("[%v] Error while verifying token: %v", data.name, err)
+		}
+		if !data.trusted {
+			if ve, ok := err.(*jwt.ValidationError); !ok || ve.Inner != errUntrusted {
+				t.Errorf("[%v] Expecting untrusted key error, got %v", data.name, err)
+			}
+		}
+		if trusted == nil {
+			t.Errorf("[%v] Trust callback was not invoked", data.name)
+		}
+	}
+}
+
+func TestEmbeddedJWKKeyfunc_missing(t *testing.T) {
+	tokenString := test.MakeSampleToken(jwt.MapClaims{"foo": "bar"}, test.LoadRSAPrivateKeyFromDisk("test/sample_key"))
+	_, err := jwt.Parse(tokenString, jwt.EmbeddedJWKKeyfunc(func(interface{}) error { return nil }))
+	if ve, ok := err.(*jwt.ValidationError); !ok || ve.Inner != jwt.ErrJWKMissing {
+		t.Errorf("Expecting ErrJWKMissing, got %v", err)
+	}
+}
+
+func TestEmbeddedJWKKeyfunc_policies(t *testing.T) {
+	rsaPrivateKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	thumbprint, _ := jwt.JWKThumbprint(jwtTestDefaultKey)
+	sign := func(jwk map[string]interface{}) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"foo": "bar"})
+		token.Header["jwk"] = jwk
+		tokenString, _ := token.SignedString(rsaPrivateKey)
+		return tokenString
+	}
+	publicJWK := makeSampleJWK(jwtTestDefaultKey)
+	privateJWK := makeSampleJWK(jwtTestDefaultKey)
+	privateJWK["d"] = jwt.EncodeSegment(rsaPrivateKey.D.Bytes())
+
+	var policyTestData = []struct {
+		name        string
+		tokenString string
+		trust       func(jwk interface{}) error
+		inner       error
+	}{
+		{"pinned thumbprint", sign(publicJWK), jwt.TrustJWKThumbprints("other", thumbprint), nil},
+		{"other thumbprint", sign(publicJWK), jwt.TrustJWKThumbprints("other"), jwt.ErrJWKUntrusted},
+		{"allowed type", sign(publicJWK), jwt.TrustJWKTypes("EC", "RSA"), nil},
+		{"other type", sign(publicJWK), jwt.TrustJWKTypes("OKP"), jwt.ErrJWKUntrusted},
+		{"no policy", sign(publicJWK), nil, jwt.ErrJWKUntrusted},
+		{"private key", sign(privateJWK), jwt.TrustJWKThumbprints(thumbprint), jwt.ErrJWKInvalid},
+	}
+
+	for _, data := range policyTestData {
+		_, err := jwt.Parse(data.tokenString, jwt.EmbeddedJWKKeyfunc(data.trust))
+		if data.inner == nil {
+			if err != nil {
+				t.Errorf("[%v] Error while verifying token: %v", data.name, err)
+			}
+			continue
+		}
+		if ve, ok := err.(*jwt.ValidationError); !ok || ve.Inner != data.inner {
+			t.Errorf("[%v] Expecting %v, got %v", data.name, data.inner, err)
+		}
+	}
+}
+
+func TestJWKSet_Keyfunc(t *testing.T) {
+	rsaPrivateKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	rsaJWK := makeSampleJWK(test.LoadRSAPublicKeyFromDisk("test/sample_key.pub"))
+	rsaJWK["kid"] = "rsa-1"
+	rsaJWK["alg"] = "RS256"
+	anyAlgJWK := makeSampleJWK(test.LoadRSAPublicKeyFromDisk("test/sample_key.pub"))
+	anyAlgJWK["kid"] = "rsa-any"
+
+	data, _ := json.Marshal(map[string]interface{}{"keys": []interface{}{
+		rsaJWK,
+		anyAlgJWK,
+		map[string]interface{}{"kty": "oct", "kid": "secret", "k": "c2VjcmV0"},
+	}})
+	set, err := jwt.ParseJWKSet(data)
+	if err != nil {
+		t.Fatalf("Error parsing key set: %v", err)
+	}
+
+	var jwkSetTestData = []struct {
+		name   string
+		method jwt.SigningMethod
+		kid    string
+		err    error
+	}{
+		{"declared alg", jwt.SigningMethodRS256, "rsa-1", nil},
+		{"other alg", jwt.SigningMethodPS256, "rsa-1", jwt.ErrJWKAlgMismatch},
+		{"no declared alg", jwt.SigningMethodPS256, "rsa-any", nil},
+		{"skipped key type", jwt.SigningMethodHS256, "secret", jwt.ErrKidUnknown},
+	}
+
+	for _, data := range jwkSetTestData {
+		token := jwt.NewWithClaims(data.method, jwt.MapClaims{"foo": "bar"})
+		token.Header["kid"] = data.kid
+		var key interface{} = rsaPrivateKey
+		if data.method == jwt.SigningMethodHS256 {
+			key = []byte("secret")
+		}
+		tokenString, err := token.SignedString(key)
+		if err != nil {
+			t.Fatalf("[%v] Error signing token: %v", data.name, err)
+		}
+
+		_, err = jwt.Parse(tokenString, set.Keyfunc)
+		if data.err == nil && err != nil {
+			t.Errorf("[%v] Error while parsing token: %v", data.name, err)
+		}
+		if data.err != nil {
+			if ve, ok := err.(*jwt.ValidationError); !ok || ve.Inner != data.err {
+				t.Errorf("[%v] Expecting %v, got %v", data.name, data.err, err)
+			}
+		}
+	}
+}
+
+func TestMapClaims_ConfirmationKey(t *testing.T) {
+	rsaPublicKey := test.LoadRSAPublicKeyFromDisk("test/sample_key.pub")
+	// The thumbprint of the example key of RFC 7638 section 3.1
+	jkt := "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"
+
+	// Round trip the claims through a token, so that cnf is decoded as JSON
+	tokenString := test.MakeSampleToken(jwt.MapClaims{
+		"sub": "alice",
+		"cnf": map[string]interface{}{"jwk": makeSampleJWK(rsaPublicKey), "jkt": jkt},
+	}, test.LoadRSAPrivateKeyFromDisk("test/sample_key"))
+	token, err := jwt.Parse(tokenString, func(*jwt.Token) (interface{}, error) { return rsaPublicKey, nil })
+	if err != nil {
+		t.Fatalf("Error parsing token: %v", err)
+	}
+	claims := token.Claims.(jwt.MapClaims)
+
+	key, err := claims.ConfirmationKey()
+	if err != nil {
+		t.Fatalf("Expecting the cnf jwk to parse, got %v", err)
+	}
+	if k, ok := key.(*rsa.PublicKey); !ok || k.N.Cmp(rsaPublicKey.N) != 0 || k.E != rsaPublicKey.E {
+		t.Errorf("Expecting the cnf jwk to be the RSA key, got %v", key)
+	}
+	if !claims.VerifyCnfThumbprint(jkt) {
+		t.Errorf("Expecting the jkt thumbprint to match")
+	}
+	if claims.VerifyCnfThumbprint("other") {
+		t.Errorf("Expecting another thumbprint not to match")
+	}
+
+	var confirmationTestData = []struct {
+		name   string
+		claims jwt.MapClaims
+		err    error
+	}{
+		{"no cnf", jwt.MapClaims{"sub": "alice"}, jwt.ErrCnfMissing},
+		{"only jkt", jwt.MapClaims{"cnf": map[string]interface{}{"jkt": jkt}}, jwt.ErrCnfJWKMissing},
+		{"invalid jwk", jwt.MapClaims{"cnf": map[string]interface{}{"jwk": "key"}}, jwt.ErrJWKInvalid},
+		{"unsupported kty", jwt.MapClaims{"cnf": map[string]interface{}{"jwk": map[string]interface{}{"kty": "oct"}}}, jwt.ErrJWKInvalid},
+	}
+	for _, data := range confirmationTestData {
+		if key, err := data.claims.ConfirmationKey(); key != nil || err != data.err {
+			t.Errorf("[%v] Expecting %v, got %v and %v", data.name, data.err, key, err)
+		}
+		if data.claims.VerifyCnfThumbprint("") {
+			t.Errorf("[%v] Expecting an empty thumbprint not to match", data.name)
+		}
+	}
+}
+
+func TestParseJWK(t *testing.T) {
+	rsaPublicKey := test.LoadRSAPublicKeyFromDisk("test/sample_key.pub")
+	var jwkTestData = []struct {
+		name string
+		key  interface{}
+	}{
+		{"RSA", rsaPublicKey},
+		{"P-256", test.LoadECPublicKeyFromDisk("test/ec256-public.pem")},
+		{"P-384", test.LoadECPublicKeyFromDisk("test/ec384-public.pem")},
+		{"P-521", test.LoadECPublicKeyFromDisk("test/ec512-public.pem")},
+		{"oct", []byte("secret")},
+	}
+
+	for _, data := range jwkTestData {
+		encoded, err := jwt.MarshalJWK(data.key)
+		if err != nil {
+			t.Errorf("[%v] Error marshaling JWK: %v", data.name, err)
+			continue
+		}
+		if data.name != "oct" {
+			var jwk map[string]interface{}
+			json.Unmarshal(encoded, &jwk)
+			if !reflect.DeepEqual(jwk, makeSampleJWK(data.key)) {
+				t.Errorf("[%v] Expecting the JWK members %v, got %v", data.name, makeSampleJWK(data.key), jwk)
+			}
+		}
+		key, err := jwt.ParseJWK(encoded)
+		if err != nil {
+			t.Errorf("[%v] Error parsing JWK: %v", data.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(key, data.key) {
+			t.Errorf("[%v] Expecting the round tripped key to be equal, got %v", data.name, key)
+		}
+	}
+
+	// Members other than the key material are ignored
+	key, err := jwt.ParseJWK([]byte(`{"kty":"oct","k":"c2VjcmV0","kid":"hmac-1","alg":"HS256"}`))
+	if s, ok := key.([]byte); err != nil || !ok || string(s) != "secret" {
+		t.Errorf("Expecting the oct secret, got %v (%v)", key, err)
+	}
+
+	var invalidJWKTestData = []struct {
+		name string
+		data string
+	}{
+		{"unknown kty", `{"kty":"foo"}`},
+		{"oct without k", `{"kty":"oct"}`},
+		{"unknown curve", `{"kty":"EC","crv":"P-192","x":"AQ","y":"AQ"}`},
+		{"RSA without e", `{"kty":"RSA","n":"AQ"}`},
+	}
+	for _, data := range invalidJWKTestData {
+		if key, err := jwt.ParseJWK([]byte(data.data)); key != nil || err != jwt.ErrJWKInvalid {
+			t.Errorf("[%v] Expecting ErrJWKInvalid, got %v and %v", data.name, key, err)
+		}
+	}
+	if _, err := jwt.ParseJWK([]byte("not JSON")); err == nil {
+		t.Errorf("Expecting an error for malformed JSON")
+	}
+	if _, err := jwt.MarshalJWK("secret"); err != jwt.ErrInvalidKeyType {
+		t.Errorf("Expecting ErrInvalidKeyType marshaling a string, got %v", err)
+	}
+	if _, err := jwt.MarshalJWK(test.LoadRSAPrivateKeyFromDisk("test/sample_key")); err != jwt.ErrInvalidKeyType {
+		t.Errorf("Expecting ErrInvalidKeyType marshaling a private key, got %v", err)
+	}
+}
+
+func TestJWKThumbprint(t *testing.T) {
+	// The example key of RFC 7638 section 3.1
+	n, _ := jwt.DecodeSegment("0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw")
+	key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: 65537}
+	if jkt, err := jwt.JWKThumbprint(key); err != nil || jkt != "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs" {
+		t.Errorf("Expecting the RFC 7638 thumbprint, got %v (%v)", jkt, err)
+	}
+
+	// The thumbprint doesn't depend on the JSON codec
+	defer func(c jwt.JSONCodec) { jwt.DefaultJSONCodec = c }(jwt.DefaultJSONCodec)
+	jwt.DefaultJSONCodec = nil
+	if jkt, err := jwt.JWKThumbprint(key); err != nil || jkt != "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs" {
+		t.Errorf("[no codec] Expecting the RFC 7638 thumbprint, got %v (%v)", jkt, err)
+	}
+
+	if _, err := jwt.JWKThumbprint(42); err != jwt.ErrInvalidKeyType {
+		t.Errorf("Expecting ErrInvalidKeyType, got %v", err)
+	}
+}
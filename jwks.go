@@ -0,0 +1,311 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+var (
+	ErrJWKSInsecureURL = errors.New("jwks: url must use https")
+	ErrJWKSClosed      = errors.New("jwks: closed")
+)
+
+// How often a JWKS fetches its JWK set in the background, unless set with
+// WithJWKSRefreshInterval
+const DefaultJWKSRefreshInterval = time.Hour
+
+// How often a token naming an unknown kid causes the JWK set to be fetched
+// again, at most
+const jwksMinRefreshInterval = time.Minute
+
+// How long a fetch may take, with the default HTTP client or any other
+const jwksFetchTimeout = 30 * time.Second
+
+// The size of the largest response read, for a JWK set or a discovery
+// document
+const jwksMaxResponseSize = 1 << 20
+
+// Configures a JWKS.  See NewJWKS.
+type JWKSOption func(*JWKS)
+
+// WithJWKSRefreshInterval sets how often the JWK set is fetched in the
+// background, DefaultJWKSRefreshInterval by default.  An interval of 0 turns
+// the background refresh off, leaving only the refresh on an unknown kid.
+func WithJWKSRefreshInterval(d time.Duration) JWKSOption {
+	return func(k *JWKS) {
+		k.interval = d
+	}
+}
+
+// WithJWKSHTTPClient sets the client the JWK set is fetched with, e.g. to
+// set trusted roots.  By default the client uses http.DefaultTransport with
+// a 30 second timeout; a fetch never takes longer, whatever the client.
+func WithJWKSHTTPClient(client *http.Client) JWKSOption {
+	return func(k *JWKS) {
+		k.client = client
+	}
+}
+
+// WithJWKSRefreshErrorHandler sets a function called with the error of every
+// background refresh that fails.  The keys fetched last stay in use.
+func WithJWKSRefreshErrorHandler(f func(error)) JWKSOption {
+	return func(k *JWKS) {
+		k.onRefreshError = f
+	}
+}
+
+// A remote JSON Web Key Set, fetched over HTTPS and cached, whose Keyfunc
+// selects keys by kid as JWKSet.Keyfunc does.  The set is fetched again in
+// the background every refresh interval, and when a token names a kid that
+// isn't in it, at most once a minute, so that rotated keys are picked up.
+// Every successful fetch replaces the whole set, evicting the keys the issuer
+// no longer publishes; a failed one keeps the previous keys.
+//
+// A JWKS is safe for concurrent use.  Call Close to stop the background
+// refresh.
+type JWKS struct {
+	url            string
+	client         *http.Client
+	interval       time.Duration
+	onRefreshError func(error)
+
+	mu        sync.Mutex
+	set       *JWKSet
+	attempted time.Time // When the last fetch started
+	fetching  *flight   // The fetch in progress, if any
+	fetches   sync.WaitGroup
+
+	life      context.Context // Done once closed, bounds fetches and the background refresh
+	stop      context.CancelFunc
+	stopped   chan struct{}
+	closeOnce sync.Once
+}
+
+// Creates a JWKS for the JWK set at jwksURL, which must be an https URL, and
+// fetches it.  ctx only bounds the initial fetch; the background refresh runs
+// until Close is called.
+func NewJWKS(ctx context.Context, jwksURL string, opts ...JWKSOption) (*JWKS, error) {
+	if err := checkJWKSURL(jwksURL); err != nil {
+		return nil, err
+	}
+
+	k := newJWKS(jwksURL)
+	k.interval = DefaultJWKSRefreshInterval
+	for _, opt := range opts {
+		opt(k)
+	}
+	if err := k.Refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	if k.interval > 0 {
+		k.stopped = make(chan struct{})
+		go k.refreshLoop(k.life)
+	}
+	return k, nil
+}
+
+// Checks that jwksURL is an https URL
+func checkJWKSURL(jwksURL string) error {
+	return checkHTTPSURL("jwks", jwksURL, ErrJWKSInsecureURL)
+}
+
+// Checks that rawURL is an https URL, returning insecure if it is not.  Parse
+// errors are prefixed with pkg.
+func checkHTTPSURL(pkg, rawURL string, insecure error) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%v: %v", pkg, err)
+	}
+	if u.Scheme != "https" {
+		return insecure
+	}
+	return nil
+}
+
+// A JWKS without background refresh, for a URL that has been checked
+func newJWKS(jwksURL string) *JWKS {
+	k := &JWKS{url: jwksURL, client: defaultHTTPClient()}
+	k.life, k.stop = context.WithCancel(context.Background())
+	return k
+}
+
+// The client of fetches, unless set with WithJWKSHTTPClient
+func defaultHTTPClient() *http.Client {
+	return &http.Client{Timeout: jwksFetchTimeout}
+}
+
+// A fetch in progress, whose outcome all the callers that asked for a fetch
+// meanwhile wait for
+type flight struct {
+	done chan struct{}
+	err  error
+}
+
+func newFlight() *flight {
+	return &flight{done: make(chan struct{})}
+}
+
+// Waits for the fetch to finish, or for ctx to be done
+func (f *flight) wait(ctx context.Context) error {
+	select {
+	case <-f.done:
+		return f.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Records the outcome of the fetch and releases its waiters
+func (f *flight) finish(err error) {
+	f.err = err
+	close(f.done)
+}
+
+func (k *JWKS) refreshLoop(ctx context.Context) {
+	defer close(k.stopped)
+	ticker := time.NewTicker(k.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := k.Refresh(ctx); err != nil && ctx.Err() == nil && k.onRefreshError != nil {
+				k.onRefreshError(err)
+			}
+		}
+	}
+}
+
+// Stops the background refresh and any fetch in progress, and waits for
+// them to return.  The cached keys remain usable, but are no longer
+// refreshed: Refresh fails with ErrJWKSClosed.
+func (k *JWKS) Close() {
+	k.closeOnce.Do(func() {
+		// Under mu, so that no fetch starts once fetches is waited for
+		k.mu.Lock()
+		k.stop()
+		k.mu.Unlock()
+		if k.stopped != nil {
+			<-k.stopped
+		}
+		k.fetches.Wait()
+	})
+}
+
+// Fetches the JWK set now, or waits for the fetch in progress.  On error the
+// cached keys are left unchanged.  If ctx is done first, Refresh returns
+// ctx.Err() and the fetch goes on for the other callers.
+func (k *JWKS) Refresh(ctx context.Context) error {
+	return k.refresh(ctx, true)
+}
+
+// Waits for the fetch in progress, or starts one, unless force is false and
+// the last one started within jwksMinRefreshInterval
+func (k *JWKS) refresh(ctx context.Context, force bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	k.mu.Lock()
+	f := k.fetching
+	if f == nil {
+		if k.life.Err() != nil {
+			k.mu.Unlock()
+			return ErrJWKSClosed
+		}
+		if !force && TimeFunc().Sub(k.attempted) < jwksMinRefreshInterval {
+			k.mu.Unlock()
+			return nil
+		}
+		f = newFlight()
+		k.fetching = f
+		k.attempted = TimeFunc()
+		k.fetches.Add(1)
+		go k.fetch(f)
+	}
+	k.mu.Unlock()
+	return f.wait(ctx)
+}
+
+// Fetches the JWK set for the waiters of f.  The fetch is not bound by the
+// context of any of them, so that one giving up doesn't fail the others.
+func (k *JWKS) fetch(f *flight) {
+	defer k.fetches.Done()
+	ctx, cancel := context.WithTimeout(k.life, jwksFetchTimeout)
+	defer cancel()
+
+	var set *JWKSet
+	data, err := fetchURL(ctx, k.client, k.url)
+	if err != nil {
+		err = fmt.Errorf("jwks: %v", err)
+	} else if set, err = ParseJWKSet(data); err != nil {
+		err = fmt.Errorf("jwks: decoding %v: %v", k.url, err)
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if err == nil {
+		k.set = set
+	}
+	k.fetching = nil
+	f.finish(err)
+}
+
+// The keys fetched last
+func (k *JWKS) keys() *JWKSet {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.set
+}
+
+// A Keyfunc selecting the key named by the kid header of the token from the
+// cached set.  An unknown kid causes the set to be fetched again, unless that
+// was tried within the last minute.
+func (k *JWKS) Keyfunc(token *Token) (interface{}, error) {
+	return k.KeyfuncCtx(context.Background(), token)
+}
+
+// A KeyfuncCtx for ParseWithContext, like Keyfunc, except that ctx bounds
+// the wait for the set to be fetched for an unknown kid
+func (k *JWKS) KeyfuncCtx(ctx context.Context, token *Token) (interface{}, error) {
+	key, err := k.keys().Keyfunc(token)
+	if err != ErrKidUnknown {
+		return key, err
+	}
+	if err := k.refresh(ctx, false); err != nil {
+		return nil, err
+	}
+	return k.keys().Keyfunc(token)
+}
+
+// Fetches url, failing unless the response is a 200
+func fetchURL(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %v: %v", url, err)
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("fetching %v: %v", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %v: %v", url, resp.Status)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, jwksMaxResponseSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("fetching %v: %v", url, err)
+	}
+	if len(data) > jwksMaxResponseSize {
+		return nil, fmt.Errorf("fetching %v: response is larger than %d bytes", url, jwksMaxResponseSize)
+	}
+	return data, nil
+}
@@ -0,0 +1,291 @@
+package jwt_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/form3tech-oss/jwt-go"
+	"github.com/form3tech-oss/jwt-go/test"
+)
+
+// Serves a JWK set over TLS whose keys and status can be changed
+type jwksServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	keys    []interface{}
+	status  int
+	fetches int
+}
+
+func newJWKSServer(keys ...interface{}) *jwksServer {
+	s := &jwksServer{keys: keys, status: http.StatusOK}
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.fetches++
+		if s.status != http.StatusOK {
+			w.WriteHeader(s.status)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": s.keys})
+	}))
+	return s
+}
+
+func (s *jwksServer) set(status int, keys ...interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status, s.keys = status, keys
+}
+
+func (s *jwksServer) fetchCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fetches
+}
+
+func TestJWKS(t *testing.T) {
+	key := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	jwk := func(kid string) map[string]interface{} {
+		jwk := makeSampleJWK(&key.PublicKey)
+		jwk["kid"] = kid
+		return jwk
+	}
+	signed := func(kid string) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"sub": "alice"})
+		token.Header["kid"] = kid
+		tokenString, _ := token.SignedString(key)
+		return tokenString
+	}
+
+	server := newJWKSServer(jwk("rsa-1"))
+	defer server.Close()
+
+	jwks, err := jwt.NewJWKS(context.Background(), server.URL, jwt.WithJWKSHTTPClient(server.Client()), jwt.WithJWKSRefreshInterval(0))
+	if err != nil {
+		t.Fatalf("Error creating JWKS: %v", err)
+	}
+	defer jwks.Close()
+
+	if _, err := jwt.Parse(signed("rsa-1"), jwks.Keyfunc); err != nil {
+		t.Errorf("[rsa-1] Error while parsing token: %v", err)
+	}
+	if _, err := jwt.ParseWithContext(context.Background(), signed("rsa-1"), jwks.KeyfuncCtx); err != nil {
+		t.Errorf("[rsa-1, context] Error while parsing token: %v", err)
+	}
+
+	// An unknown kid refetches the keys, at most once a minute
+	fetches := server.fetchCount()
+	for i := 0; i < 3; i++ {
+		if _, err := jwt.Parse(signed("rsa-2"), jwks.Keyfunc); err == nil || err.(*jwt.ValidationError).Inner != jwt.ErrKidUnknown {
+			t.Errorf("[rsa-2] Expecting ErrKidUnknown, got %v", err)
+		}
+	}
+	if n := server.fetchCount() - fetches; n != 0 {
+		t.Errorf("Expecting no refetch right after the initial fetch, got %v", n)
+	}
+
+	defer func() { jwt.TimeFunc = time.Now }()
+	jwt.TimeFunc = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	server.set(http.StatusOK, jwk("rsa-2"))
+	if _, err := jwt.Parse(signed("rsa-2"), jwks.Keyfunc); err != nil {
+		t.Errorf("[rotated rsa-2] Error while parsing token: %v", err)
+	}
+	// The refetched set replaced the old one
+	if _, err := jwt.Parse(signed("rsa-1"), jwks.Keyfunc); err == nil || err.(*jwt.ValidationError).Inner != jwt.ErrKidUnknown {
+		t.Errorf("[evicted rsa-1] Expecting ErrKidUnknown, got %v", err)
+	}
+
+	// A failed refresh keeps the cached keys
+	server.set(http.StatusInternalServerError)
+	if err := jwks.Refresh(context.Background()); err == nil {
+		t.Errorf("Expecting an error refreshing from a failing server")
+	}
+	if _, err := jwt.Parse(signed("rsa-2"), jwks.Keyfunc); err != nil {
+		t.Errorf("[cached rsa-2] Error while parsing token: %v", err)
+	}
+
+	// Only https URLs are accepted
+	if _, err := jwt.NewJWKS(context.Background(), "http://example.com/keys"); err != jwt.ErrJWKSInsecureURL {
+		t.Errorf("[http] Expecting ErrJWKSInsecureURL, got %v", err)
+	}
+	if _, err := jwt.NewJWKS(context.Background(), server.URL, jwt.WithJWKSHTTPClient(server.Client())); err == nil {
+		t.Errorf("[failing server] Expecting an error for the initial fetch")
+	}
+}
+
+func TestJWKS_backgroundRefresh(t *testing.T) {
+	key := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	jwk := makeSampleJWK(&key.PublicKey)
+	jwk["kid"] = "rsa-1"
+
+	server := newJWKSServer(jwk)
+	defer server.Close()
+
+	errs := make(chan error, 1)
+	jwks, err := jwt.NewJWKS(context.Background(), server.URL,
+		jwt.WithJWKSHTTPClient(server.Client()),
+		jwt.WithJWKSRefreshInterval(10*time.Millisecond),
+		jwt.WithJWKSRefreshErrorHandler(func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		}))
+	if err != nil {
+		t.Fatalf("Error creating JWKS: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for server.fetchCount() < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := server.fetchCount(); n < 3 {
+		t.Errorf("Expecting the set to be refreshed in the background, got %v fetches", n)
+	}
+
+	server.set(http.StatusInternalServerError)
+	select {
+	case <-errs:
+	case <-time.After(5 * time.Second):
+		t.Errorf("Expecting the failed refresh to be reported")
+	}
+
+	// No fetches once closed
+	jwks.Close()
+	jwks.Close()
+	fetches := server.fetchCount()
+	time.Sleep(50 * time.Millisecond)
+	if n := server.fetchCount() - fetches; n != 0 {
+		t.Errorf("Expecting no refresh after Close, got %v", n)
+	}
+}
+
+func TestJWKS_sharedFetch(t *testing.T) {
+	key := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	jwk := makeSampleJWK(&key.PublicKey)
+
+	var mu sync.Mutex
+	fetches := 0
+	release := make(chan struct{})
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetches++
+		n := fetches
+		mu.Unlock()
+		if n > 1 {
+			<-release
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []interface{}{jwk}})
+	}))
+	defer server.Close()
+
+	jwks, err := jwt.NewJWKS(context.Background(), server.URL, jwt.WithJWKSHTTPClient(server.Client()), jwt.WithJWKSRefreshInterval(0))
+	if err != nil {
+		t.Fatalf("Error creating JWKS: %v", err)
+	}
+	defer jwks.Close()
+
+	// Callers refreshing while a fetch is in progress wait for it
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- jwks.Refresh(context.Background())
+		}()
+	}
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		mu.Lock()
+		n := fetches
+		mu.Unlock()
+		if n == 2 {
+			break
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Error while refreshing: %v", err)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if fetches != 2 {
+		t.Errorf("Expecting the refreshes to share one fetch, got %v fetches", fetches-1)
+	}
+}
+
+func TestJWKS_responseSize(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"keys":[],"padding":"`))
+		w.Write(make([]byte, 1<<20))
+	}))
+	defer server.Close()
+
+	if _, err := jwt.NewJWKS(context.Background(), server.URL, jwt.WithJWKSHTTPClient(server.Client())); err == nil {
+		t.Errorf("Expecting an error for a response larger than 1 MiB")
+	}
+}
+
+func TestJWKS_KeyfuncCtxCancel(t *testing.T) {
+	key := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	jwk := makeSampleJWK(&key.PublicKey)
+	jwk["kid"] = "rsa-1"
+
+	var mu sync.Mutex
+	fetches := 0
+	release := make(chan struct{})
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetches++
+		n := fetches
+		mu.Unlock()
+		if n > 1 {
+			<-release
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []interface{}{jwk}})
+	}))
+	defer server.Close()
+	defer close(release)
+
+	jwks, err := jwt.NewJWKS(context.Background(), server.URL, jwt.WithJWKSHTTPClient(server.Client()), jwt.WithJWKSRefreshInterval(0))
+	if err != nil {
+		t.Fatalf("Error creating JWKS: %v", err)
+	}
+	defer jwks.Close()
+
+	defer func() { jwt.TimeFunc = time.Now }()
+	jwt.TimeFunc = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"sub": "alice"})
+	token.Header["kid"] = "rsa-2"
+	tokenString, _ := token.SignedString(key)
+
+	// A caller whose context is done stops waiting for the stalled fetch
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = jwt.ParseWithContext(ctx, tokenString, jwks.KeyfuncCtx)
+	if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorUnverifiable || ve.Inner != context.DeadlineExceeded {
+		t.Errorf("Expecting ValidationErrorUnverifiable with context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expecting the wait to end with the context, took %v", elapsed)
+	}
+
+	// As does one whose context is done already, while the fetch goes on
+	done, cancelDone := context.WithCancel(context.Background())
+	cancelDone()
+	if _, err := jwks.KeyfuncCtx(done, token); err != context.Canceled {
+		t.Errorf("Expecting context.Canceled, got %v", err)
+	}
+}
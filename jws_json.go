@@ -0,0 +1,269 @@
+package jwt
+
+import (
+	"bytes"
+	"strings"
+)
+
+// How many signatures of a general JSON JWS must verify for the token to be
+// valid.  See ParseGeneralJSON.
+type SignaturePolicy int
+
+const (
+	RequireAllSignatures SignaturePolicy = iota // Every signature must verify
+	RequireAnySignature                         // At least one signature must verify
+)
+
+// The outcome of verifying one entry of the signatures array of a general
+// JSON JWS
+type SignatureResult struct {
+	Header map[string]interface{} // The protected header, merged with the unprotected one
+	Method SigningMethod          // Signing method named by the header, nil if unavailable
+	Valid  bool                   // Whether the signature verified
+	Err    error                  // Why the signature did not verify
+}
+
+// The general JWS JSON serialization, RFC 7515 section 7.2.1
+type generalJSON struct {
+	Payload    string          `json:"payload"`
+	Signatures []jsonSignature `json:"signatures"`
+}
+
+// One signature of a JSON JWS.  The flattened serialization, RFC 7515
+// section 7.2.2, is the payload with the members of a single signature.
+type jsonSignature struct {
+	Protected string                 `json:"protected"`
+	Header    map[string]interface{} `json:"header,omitempty"`
+	Signature string                 `json:"signature"`
+}
+
+type flattenedJSON struct {
+	Payload string `json:"payload"`
+	jsonSignature
+}
+
+// One of the signers of a general JSON JWS.  See Token.SignedGeneralJSON.
+type JSONSigner struct {
+	Method    SigningMethod          // Signs, and names the alg of the protected header
+	Key       interface{}            // Passed to Method.Sign
+	Protected map[string]interface{} // Further members of the protected header, besides alg
+	Header    map[string]interface{} // The unprotected header, may be nil
+}
+
+// Parse, validate, and return a token in the general JWS JSON serialization,
+// which carries one payload signed by several parties.  keyFunc is called
+// once per signature, with a token holding that signature's header, and
+// policy decides whether all or any of the signatures must verify.
+//
+// The results for each signature are returned in the order of the signatures
+// array, also when err is non-nil.  The returned token has the header of the
+// first signature that verified, or of the first signature if none did.
+func ParseGeneralJSON(data []byte, claims Claims, keyFunc Keyfunc, policy SignaturePolicy) (*Token, []SignatureResult, error) {
+	return new(Parser).ParseGeneralJSON(data, claims, keyFunc, policy)
+}
+
+// Parser form of ParseGeneralJSON
+func (p *Parser) ParseGeneralJSON(data []byte, claims Claims, keyFunc Keyfunc, policy SignaturePolicy) (*Token, []SignatureResult, error) {
+	if err := p.checkTokenSize(len(data)); err != nil {
+		return nil, nil, err
+	}
+	var jws generalJSON
+	if err := p.json().Unmarshal(data, &jws); err != nil {
+		return nil, nil, &ValidationError{Inner: err, Errors: ValidationErrorMalformed}
+	}
+	return p.parseJSON(data, jws, claims, keyFunc, policy)
+}
+
+// Parse, validate, and return a token in the flattened JWS JSON
+// serialization, which carries a single signature and its header as members
+// of the top-level object.  keyFunc is used as for Parse.
+func ParseFlattenedJSON(data []byte, claims Claims, keyFunc Keyfunc) (*Token, error) {
+	return new(Parser).ParseFlattenedJSON(data, claims, keyFunc)
+}
+
+// Parser form of ParseFlattenedJSON
+func (p *Parser) ParseFlattenedJSON(data []byte, claims Claims, keyFunc Keyfunc) (*Token, error) {
+	if err := p.checkTokenSize(len(data)); err != nil {
+		return nil, err
+	}
+	var jws flattenedJSON
+	if err := p.json().Unmarshal(data, &jws); err != nil {
+		return nil, &ValidationError{Inner: err, Errors: ValidationErrorMalformed}
+	}
+	if jws.Protected == "" {
+		return nil, NewValidationError("token contains no protected header", ValidationErrorMalformed)
+	}
+	general := generalJSON{Payload: jws.Payload, Signatures: []jsonSignature{jws.jsonSignature}}
+	token, _, err := p.parseJSON(data, general, claims, keyFunc, RequireAllSignatures)
+	return token, err
+}
+
+// Verifies and validates a decoded JSON JWS, either form, whose raw
+// serialization is data
+func (p *Parser) parseJSON(data []byte, jws generalJSON, claims Claims, keyFunc Keyfunc, policy SignaturePolicy) (*Token, []SignatureResult, error) {
+	if len(jws.Signatures) == 0 {
+		return nil, nil, NewValidationError("token contains no signatures", ValidationErrorMalformed)
+	}
+
+	token := &Token{Raw: string(data), Claims: claims}
+
+	// parse Claims
+	claimBytes, err := p.decodeSegment(jws.Payload)
+	if err != nil {
+		return token, nil, newSegmentError(SegmentClaims, err)
+	}
+	if p.lenientJSON {
+		claimBytes = trimJSON(claimBytes)
+	}
+	if !isJSONObject(claimBytes) {
+		return token, nil, newSegmentError(SegmentClaims, ErrClaimsNotObject)
+	}
+	dec := p.json().NewDecoder(bytes.NewBuffer(claimBytes))
+	if p.UseJSONNumber {
+		dec.UseNumber()
+	}
+	// JSON Decode.  Special case for map type to avoid weird pointer behavior
+	if c, ok := token.Claims.(MapClaims); ok {
+		err = dec.Decode(&c)
+	} else {
+		err = dec.Decode(&claims)
+	}
+	if err != nil {
+		return token, nil, newSegmentError(SegmentClaims, err)
+	}
+
+	results := make([]SignatureResult, len(jws.Signatures))
+	var failure error
+	verified := 0
+	for i, s := range jws.Signatures {
+		sigToken, err := p.verifyGeneralJSONSignature(claims, jws.Payload, s.Protected, s.Header, s.Signature, keyFunc)
+		results[i] = SignatureResult{Header: sigToken.Header, Method: sigToken.Method, Valid: err == nil, Err: err}
+		if err == nil {
+			if verified == 0 {
+				token.Header, token.Method, token.Signature = sigToken.Header, sigToken.Method, sigToken.Signature
+			}
+			verified++
+		} else if failure == nil {
+			failure = err
+		}
+	}
+	if verified == 0 {
+		token.Header, token.Method = results[0].Header, results[0].Method
+	}
+
+	var sigErr error
+	if (policy == RequireAllSignatures && failure != nil) || verified == 0 {
+		sigErr = failure
+	}
+
+	if err = p.validate(token, []string{"", jws.Payload, ""}, sigErr); err != nil {
+		return token, results, err
+	}
+	return token, results, nil
+}
+
+// Verifies one entry of the signatures array.  The returned token holds the
+// entry's header and signing method, as far as they could be parsed.
+func (p *Parser) verifyGeneralJSONSignature(claims Claims, payload, protected string, unprotected map[string]interface{}, signature string, keyFunc Keyfunc) (*Token, error) {
+	token := &Token{Claims: claims, Signature: signature}
+	if p.paddingAllowed {
+		token.Signature = strings.TrimRight(token.Signature, "=")
+	}
+
+	// parse Header.  Members of the protected header take precedence.  Only
+	// the protected header is integrity protected, so alg is not taken from
+	// the unprotected one.
+	if err := p.decodeHeader(token, protected); err != nil {
+		return token, err
+	}
+	// crit must be integrity protected, RFC 7515 section 4.1.11
+	if _, ok := unprotected["crit"]; ok {
+		return token, NewValidationError("crit header is not protected", ValidationErrorMalformed)
+	}
+	for k, v := range unprotected {
+		if _, ok := token.Header[k]; !ok {
+			token.Header[k] = v
+		}
+	}
+
+	key, err := p.resolveKey(token, keyFunc)
+	if err != nil {
+		return token, err
+	}
+
+	if token.Signature == "" && token.Method.Alg() != SigningMethodNone.Alg() {
+		return token, ErrSignatureInvalid
+	}
+	if err := verifyWithKey(token, protected+"."+payload, key); err != nil {
+		return token, err
+	}
+	return token, nil
+}
+
+// Get the complete, signed token in the general JWS JSON serialization, with
+// one signature per signer over the claims of the token.  The header of the
+// token is not used; every signer has its own.  See ParseGeneralJSON.
+func (t *Token) SignedGeneralJSON(signers ...JSONSigner) ([]byte, error) {
+	if len(signers) == 0 {
+		return nil, NewValidationError("token contains no signatures", ValidationErrorMalformed)
+	}
+	payload, err := t.jsonPayload()
+	if err != nil {
+		return nil, err
+	}
+
+	jws := generalJSON{Payload: payload, Signatures: make([]jsonSignature, len(signers))}
+	for i, signer := range signers {
+		header := map[string]interface{}{}
+		for k, v := range signer.Protected {
+			header[k] = v
+		}
+		header["alg"] = signer.Method.Alg()
+		if jws.Signatures[i], err = signJSON(signer.Method, header, signer.Header, payload, signer.Key); err != nil {
+			return nil, err
+		}
+	}
+	return DefaultJSONCodec.Marshal(jws)
+}
+
+// Get the complete, signed token in the flattened JWS JSON serialization,
+// with the header of the token as its protected header.  See
+// ParseFlattenedJSON.
+func (t *Token) SignedFlattenedJSON(key interface{}) ([]byte, error) {
+	payload, err := t.jsonPayload()
+	if err != nil {
+		return nil, err
+	}
+	jws := flattenedJSON{Payload: payload}
+	if jws.jsonSignature, err = signJSON(t.Method, t.Header, nil, payload, key); err != nil {
+		return nil, err
+	}
+	return DefaultJSONCodec.Marshal(jws)
+}
+
+// The encoded claims of the token, as payload of a JSON JWS
+func (t *Token) jsonPayload() (string, error) {
+	claimBytes, err := DefaultJSONCodec.Marshal(t.Claims)
+	if err != nil {
+		return "", err
+	}
+	if t.canonicalClaims {
+		if claimBytes, err = canonicalJSON(claimBytes); err != nil {
+			return "", err
+		}
+	}
+	return EncodeSegment(claimBytes), nil
+}
+
+// Signs payload with one protected header, for a JSON JWS
+func signJSON(method SigningMethod, protected, unprotected map[string]interface{}, payload string, key interface{}) (jsonSignature, error) {
+	headerBytes, err := DefaultJSONCodec.Marshal(protected)
+	if err != nil {
+		return jsonSignature{}, err
+	}
+	s := jsonSignature{Protected: EncodeSegment(headerBytes), Header: unprotected}
+	if s.Signature, err = method.Sign(s.Protected+"."+payload, key); err != nil {
+		return jsonSignature{}, err
+	}
+	return s, nil
+}
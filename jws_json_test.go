@@ -0,0 +1,166 @@
+package jwt_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/form3tech-oss/jwt-go"
+	"github.com/form3tech-oss/jwt-go/test"
+)
+
+// Builds a general JSON JWS over claims, with one HS256 signature per kid
+func makeGeneralJSON(t *testing.T, claims jwt.MapClaims, keys map[string][]byte, kids ...string) []byte {
+	payloadBytes, _ := json.Marshal(claims)
+	payload := jwt.EncodeSegment(payloadBytes)
+
+	var signatures []map[string]interface{}
+	for _, kid := range kids {
+		protected := jwt.EncodeSegment([]byte(`{"alg":"HS256"}`))
+		sig, err := jwt.SigningMethodHS256.Sign(protected+"."+payload, keys[kid])
+		if err != nil {
+			t.Fatal(err)
+		}
+		signatures = append(signatures, map[string]interface{}{
+			"protected": protected,
+			"header":    map[string]interface{}{"kid": kid},
+			"signature": sig,
+		})
+	}
+
+	data, _ := json.Marshal(map[string]interface{}{"payload": payload, "signatures": signatures})
+	return data
+}
+
+func TestParseGeneralJSON(t *testing.T) {
+	signingKeys := map[string][]byte{"alice": []byte("alice-key"), "bob": []byte("bob-key")}
+	jws := makeGeneralJSON(t, jwt.MapClaims{"doc": "contract"}, signingKeys, "alice", "bob")
+
+	// The verifier holds the wrong key for bob
+	verifyKeys := map[string][]byte{"alice": []byte("alice-key"), "bob": []byte("not-bob-key")}
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if key, ok := verifyKeys[kid]; ok {
+			return key, nil
+		}
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+
+	var generalJSONTestData = []struct {
+		name   string
+		policy jwt.SignaturePolicy
+		valid  bool
+	}{
+		{"all valid", jwt.RequireAllSignatures, false},
+		{"any valid", jwt.RequireAnySignature, true},
+	}
+
+	for _, data := range generalJSONTestData {
+		token, results, err := jwt.ParseGeneralJSON(jws, jwt.MapClaims{}, keyFunc, data.policy)
+		if data.valid && err != nil {
+			t.Errorf("[%v] Error while parsing token: %v", data.name, err)
+		}
+		if !data.valid {
+			if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorSignatureInvalid {
+				t.Errorf("[%v] Expecting ValidationErrorSignatureInvalid, got %v", data.name, err)
+			}
+		}
+		if token.Valid != data.valid {
+			t.Errorf("[%v] Expecting Valid %v", data.name, data.valid)
+		}
+		if token.Claims.(jwt.MapClaims)["doc"] != "contract" {
+			t.Errorf("[%v] Claims were not decoded: %v", data.name, token.Claims)
+		}
+
+		if len(results) != 2 {
+			t.Fatalf("[%v] Expecting 2 results, got %d", data.name, len(results))
+		}
+		if !results[0].Valid || results[0].Header["kid"] != "alice" {
+			t.Errorf("[%v] Expecting alice's signature to verify: %+v", data.name, results[0])
+		}
+		if results[1].Valid || results[1].Err != jwt.ErrSignatureInvalid {
+			t.Errorf("[%v] Expecting bob's signature to fail: %+v", data.name, results[1])
+		}
+	}
+}
+
+func TestSignedGeneralJSON(t *testing.T) {
+	rsaKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	hmacKey := []byte("bob-key")
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"doc": "contract"})
+	data, err := token.SignedGeneralJSON(
+		jwt.JSONSigner{Method: jwt.SigningMethodRS256, Key: rsaKey, Protected: map[string]interface{}{"kid": "alice"}},
+		jwt.JSONSigner{Method: jwt.SigningMethodHS256, Key: hmacKey, Header: map[string]interface{}{"kid": "bob"}},
+	)
+	if err != nil {
+		t.Fatalf("Error while signing: %v", err)
+	}
+
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		switch token.Header["kid"] {
+		case "alice":
+			return test.LoadRSAPublicKeyFromDisk("test/sample_key.pub"), nil
+		case "bob":
+			return hmacKey, nil
+		}
+		return nil, fmt.Errorf("unknown kid %v", token.Header["kid"])
+	}
+	parsed, results, err := jwt.ParseGeneralJSON(data, jwt.MapClaims{}, keyFunc, jwt.RequireAllSignatures)
+	if err != nil {
+		t.Fatalf("Error while parsing: %v", err)
+	}
+	if parsed.Claims.(jwt.MapClaims)["doc"] != "contract" {
+		t.Errorf("Claims were not decoded: %v", parsed.Claims)
+	}
+	if len(results) != 2 || results[0].Method != jwt.SigningMethodRS256 || results[1].Method != jwt.SigningMethodHS256 {
+		t.Errorf("Expecting an RS256 and an HS256 signature: %+v", results)
+	}
+
+	if _, err := token.SignedGeneralJSON(); err == nil {
+		t.Errorf("Expecting an error without signers")
+	}
+}
+
+func TestParseFlattenedJSON(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"doc": "contract"})
+	token.Header["kid"] = "alice"
+	data, err := token.SignedFlattenedJSON(key)
+	if err != nil {
+		t.Fatalf("Error while signing: %v", err)
+	}
+
+	var jws map[string]interface{}
+	json.Unmarshal(data, &jws)
+	if _, ok := jws["signatures"]; ok {
+		t.Errorf("Expecting the flattened form, got %s", data)
+	}
+	tampered, _ := json.Marshal(map[string]interface{}{"payload": jwt.EncodeSegment([]byte(`{"doc":"forged"}`)), "protected": jws["protected"], "signature": jws["signature"]})
+
+	var flattenedJSONTestData = []struct {
+		name   string
+		data   []byte
+		errors uint32
+	}{
+		{"signed", data, 0},
+		{"tampered", tampered, jwt.ValidationErrorSignatureInvalid},
+		{"general", makeGeneralJSON(t, jwt.MapClaims{}, map[string][]byte{"alice": key}, "alice"), jwt.ValidationErrorMalformed},
+		{"not JSON", []byte("a.b.c"), jwt.ValidationErrorMalformed},
+	}
+
+	for _, data := range flattenedJSONTestData {
+		parsed, err := jwt.ParseFlattenedJSON(data.data, jwt.MapClaims{}, keyFunc)
+		if data.errors == 0 {
+			if err != nil {
+				t.Errorf("[%v] Error while parsing token: %v", data.name, err)
+			} else if !parsed.Valid || parsed.Header["kid"] != "alice" || parsed.Claims.(jwt.MapClaims)["doc"] != "contract" {
+				t.Errorf("[%v] Expecting a valid token with kid alice: %+v", data.name, parsed)
+			}
+			continue
+		}
+		if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != data.errors {
+			t.Errorf("[%v] Expecting error bits %v, got %v", data.name, data.errors, err)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+)
+
+var ErrAsymmetricKeyForHMAC = errors.New("keyfunc returned an asymmetric key for an HMAC token")
+
+// Reports whether key, handed to an HMAC verifier, is an asymmetric key or
+// the PEM or DER encoding of one.  HMAC with the encoded public key as the
+// secret is the classic algorithm confusion attack: anyone holding the
+// public key can then sign tokens.
+func isAsymmetricKey(key interface{}) bool {
+	switch k := key.(type) {
+	case *rsa.PublicKey, *rsa.PrivateKey, *ecdsa.PublicKey, *ecdsa.PrivateKey,
+		rsa.PublicKey, ecdsa.PublicKey, Ed448PublicKey, Ed448PrivateKey:
+		return true
+	case []byte:
+		return isEncodedAsymmetricKey(k)
+	case string:
+		return isEncodedAsymmetricKey([]byte(k))
+	}
+	return isEd25519Key(key)
+}
+
+func isEncodedAsymmetricKey(data []byte) bool {
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+	if _, err := x509.ParsePKIXPublicKey(data); err == nil {
+		return true
+	}
+	if _, err := x509.ParsePKCS1PublicKey(data); err == nil {
+		return true
+	}
+	if _, err := x509.ParseCertificate(data); err == nil {
+		return true
+	}
+	return false
+}
+
+// Refuses key, or any key of a VerificationKeySet, for an HMAC token if it is
+// asymmetric.  See WithAsymmetricKeyGuard.
+func checkHMACKey(token *Token, key interface{}) error {
+	if set, ok := key.(VerificationKeySet); ok {
+		for _, k := range set {
+			if err := checkHMACKey(token, k); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	switch token.Method.(type) {
+	case *SigningMethodHMAC, *SigningMethodHMACKDF:
+		if isAsymmetricKey(key) {
+			return &ValidationError{Inner: ErrAsymmetricKeyForHMAC, Errors: ValidationErrorUnverifiable}
+		}
+	}
+	return nil
+}
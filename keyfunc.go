@@ -0,0 +1,213 @@
+package jwt
+
+import (
+	"bytes"
+	"crypto"
+	"errors"
+	"time"
+)
+
+var (
+	ErrNoActiveKey = errors.New("no key was active when the token was issued")
+	ErrKidMissing  = errors.New("token header has no kid")
+	ErrKidInvalid  = errors.New("token header kid is not a string")
+	ErrKidUnknown  = errors.New("token header kid does not name a known key")
+
+	ErrIssuerMissing = errors.New("token has no iss claim")
+	ErrIssuerUnknown = errors.New("token iss claim does not name a known issuer")
+)
+
+// Returns a Keyfunc that selects the key named by the kid header parameter of
+// the token.  A kid that is not a string is rejected with ErrKidInvalid; see
+// WithLenientKid to accept numeric ones.
+func KeyIDKeyfunc(keys map[string]interface{}) Keyfunc {
+	return func(token *Token) (interface{}, error) {
+		raw, ok := token.Header["kid"]
+		if !ok {
+			return nil, ErrKidMissing
+		}
+		kid, ok := raw.(string)
+		if !ok {
+			return nil, ErrKidInvalid
+		}
+		key, ok := keys[kid]
+		if !ok {
+			return nil, ErrKidUnknown
+		}
+		return key, nil
+	}
+}
+
+// Returns a Keyfunc that returns key for any token whose signing method
+// suits it, as told by AllowedMethodsForKey.  Tokens of another family,
+// such as an HS256 token for an RSA public key, fail with
+// ErrInvalidKeyType, and an HMAC token is never verified with an asymmetric
+// key or its PEM or DER encoding, see WithAsymmetricKeyGuard.
+func StaticKeyfunc(key interface{}) Keyfunc {
+	allowed := AllowedMethodsForKey(key)
+	return func(token *Token) (interface{}, error) {
+		if err := checkHMACKey(token, key); err != nil {
+			return nil, err
+		}
+		if allowed == nil {
+			// A key type this package has no signing method for
+			return key, nil
+		}
+		for _, alg := range allowed {
+			if alg == token.Method.Alg() {
+				return key, nil
+			}
+		}
+		return nil, &ValidationError{Inner: ErrInvalidKeyType, Errors: ValidationErrorUnverifiable}
+	}
+}
+
+// Returns a Keyfunc that selects the key named by both the iss claim and the
+// kid header parameter of the token, from keys indexed by issuer and then by
+// kid.  In a federation two issuers may use the same kid, which this tells
+// apart.  Fails with ErrIssuerMissing or ErrIssuerUnknown for the issuer,
+// and as KeyIDKeyfunc does for the kid.
+func CompositeKeyfunc(keys map[string]map[string]interface{}) Keyfunc {
+	return func(token *Token) (interface{}, error) {
+		claims, err := toMapClaims(token.Claims)
+		if err != nil {
+			return nil, err
+		}
+		iss, _ := claims["iss"].(string)
+		if iss == "" {
+			return nil, ErrIssuerMissing
+		}
+		issuerKeys, ok := keys[iss]
+		if !ok {
+			return nil, ErrIssuerUnknown
+		}
+		return KeyIDKeyfunc(issuerKeys)(token)
+	}
+}
+
+// A verification key that is only valid during a limited period.  Used with
+// RotatingKeyfunc.
+type RotatingKey struct {
+	Key       interface{}
+	NotBefore time.Time // When the key was activated.  Zero if it always was
+	NotAfter  time.Time // When the key was retired.  Zero if it still is active
+}
+
+// Returns a Keyfunc that selects, from keys, the key that was active when the
+// token was issued.  The issuance time is the iat claim of the token, or the
+// current time if the token has none.  A key that has been retired is still
+// accepted for grace after its NotAfter time, so tokens issued shortly before
+// a rotation continue to verify until they would naturally be refreshed.
+//
+// When several keys qualify, the most recently activated one is returned.
+func RotatingKeyfunc(keys []RotatingKey, grace time.Duration) Keyfunc {
+	return func(token *Token) (interface{}, error) {
+		now := TimeFunc()
+
+		issued := now
+		if claims, err := toMapClaims(token.Claims); err == nil {
+			if iat, ok := claims.numericDate("iat"); ok {
+				issued = time.Unix(iat, 0)
+			}
+		}
+
+		var selected *RotatingKey
+		for i := range keys {
+			k := &keys[i]
+			if !k.NotBefore.IsZero() && issued.Before(k.NotBefore) {
+				continue
+			}
+			if !k.NotAfter.IsZero() && (issued.After(k.NotAfter) || now.After(k.NotAfter.Add(grace))) {
+				continue
+			}
+			if selected == nil || k.NotBefore.After(selected.NotBefore) {
+				selected = k
+			}
+		}
+
+		if selected == nil {
+			return nil, ErrNoActiveKey
+		}
+		return selected.Key, nil
+	}
+}
+
+// Returns a Keyfunc that selects, from keys, the first key the signature of
+// the token verifies with, for key rotation without a kid or a JWKS.  Keys
+// whose type does not suit the signing method of the token are skipped.  The
+// parser verifies the signature again with the selected key.  Fails with
+// ValidationErrorSignatureInvalid if no key verifies the signature.  Only
+// tokens parsed from the compact serialization are supported.
+func AnyOf(keys []crypto.PublicKey) Keyfunc {
+	return func(token *Token) (interface{}, error) {
+		header, payload, _ := token.Segments()
+		if header == "" {
+			return nil, NewValidationError("token was not parsed from the compact serialization", ValidationErrorUnverifiable)
+		}
+		signingString := header + "." + payload
+		for _, key := range keys {
+			if token.Method.Verify(signingString, token.Signature, key) == nil {
+				return key, nil
+			}
+		}
+		return nil, &ValidationError{Inner: ErrSignatureInvalid, Errors: ValidationErrorSignatureInvalid}
+	}
+}
+
+// Candidate verification keys a Keyfunc may return instead of a single key,
+// e.g. every current key of an issuer during a rotation when tokens have no
+// kid.  The parser tries the keys in order until one verifies the signature,
+// and records it as Token.VerificationKey; keys whose type does not suit the
+// signing method are skipped.  Unlike AnyOf, the signature is verified once
+// per key, and tokens in any serialization are supported.
+type VerificationKeySet []interface{}
+
+// Verifies the signature of token over signingString with key, or with each
+// key of a VerificationKeySet in turn, and records the key that verified it.
+// For a set, the error is that of the first key of a suitable type, or
+// ErrInvalidKeyType if there was none.
+func verifyWithKey(token *Token, signingString string, key interface{}) error {
+	set, ok := key.(VerificationKeySet)
+	if !ok {
+		if err := token.Method.Verify(signingString, token.Signature, key); err != nil {
+			return err
+		}
+		token.VerificationKey = key
+		return nil
+	}
+	if len(set) == 0 {
+		return ErrKeySetEmpty
+	}
+
+	err := ErrInvalidKeyType
+	for _, k := range set {
+		kErr := token.Method.Verify(signingString, token.Signature, k)
+		if kErr == nil {
+			token.VerificationKey = k
+			return nil
+		}
+		if err == ErrInvalidKeyType {
+			err = kErr
+		}
+	}
+	return err
+}
+
+// Returns claims as MapClaims, round tripping struct claims through JSON
+func toMapClaims(claims Claims) (MapClaims, error) {
+	if m, ok := claims.(MapClaims); ok {
+		return m, nil
+	}
+
+	data, err := DefaultJSONCodec.Marshal(claims)
+	if err != nil {
+		return nil, err
+	}
+	m := MapClaims{}
+	dec := DefaultJSONCodec.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,277 @@
+package jwt_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/form3tech-oss/jwt-go"
+	"github.com/form3tech-oss/jwt-go/test"
+)
+
+func TestRotatingKeyfunc(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	jwt.TimeFunc = func() time.Time { return now }
+	defer func() { jwt.TimeFunc = time.Now }()
+
+	oldKey, newKey := []byte("old"), []byte("new")
+	rotation := now.Add(-time.Hour)
+	keys := []jwt.RotatingKey{
+		{Key: oldKey, NotAfter: rotation},
+		{Key: newKey, NotBefore: rotation},
+	}
+
+	var rotatingTestData = []struct {
+		name  string
+		iat   time.Time
+		grace time.Duration
+		key   []byte
+		valid bool
+	}{
+		{"issued after rotation", now.Add(-time.Minute), 0, newKey, true},
+		{"issued before rotation, within grace", rotation.Add(-time.Minute), 2 * time.Hour, oldKey, true},
+		{"issued before rotation, after grace", rotation.Add(-time.Minute), 30 * time.Minute, oldKey, false},
+	}
+
+	for _, data := range rotatingTestData {
+		claims := jwt.MapClaims{"iat": float64(data.iat.Unix())}
+		tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(data.key)
+		if err != nil {
+			t.Fatalf("[%v] Error signing token: %v", data.name, err)
+		}
+
+		token, err := jwt.Parse(tokenString, jwt.RotatingKeyfunc(keys, data.grace))
+		if data.valid && (err != nil || !token.Valid) {
+			t.Errorf("[%v] Error while verifying token: %v", data.name, err)
+		}
+		if !data.valid {
+			if ve, ok := err.(*jwt.ValidationError); !ok || ve.Inner != jwt.ErrNoActiveKey {
+				t.Errorf("[%v] Expecting ErrNoActiveKey, got %v", data.name, err)
+			}
+		}
+	}
+}
+
+func TestKeyIDKeyfunc(t *testing.T) {
+	keys := map[string]interface{}{"42": []byte("key-42"), "main": []byte("key-main")}
+	keyFunc := jwt.KeyIDKeyfunc(keys)
+
+	sign := func(kid interface{}) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"foo": "bar"})
+		if kid != nil {
+			token.Header["kid"] = kid
+		}
+		tokenString, _ := token.SignedString([]byte("key-42"))
+		return tokenString
+	}
+
+	var keyIDTestData = []struct {
+		name   string
+		parser *jwt.Parser
+		kid    interface{}
+		err    error
+	}{
+		{"string kid", new(jwt.Parser), "42", nil},
+		{"numeric kid", new(jwt.Parser), 42, jwt.ErrKidInvalid},
+		{"numeric kid, lenient", jwt.NewParser(jwt.WithLenientKid()), 42, nil},
+		{"missing kid", new(jwt.Parser), nil, jwt.ErrKidMissing},
+		{"unknown kid", new(jwt.Parser), "7", jwt.ErrKidUnknown},
+	}
+
+	for _, data := range keyIDTestData {
+		_, err := data.parser.Parse(sign(data.kid), keyFunc)
+		if data.err == nil && err != nil {
+			t.Errorf("[%v] Error while parsing token: %v", data.name, err)
+		}
+		if data.err != nil {
+			if ve, ok := err.(*jwt.ValidationError); !ok || ve.Inner != data.err {
+				t.Errorf("[%v] Expecting %v, got %v", data.name, data.err, err)
+			}
+		}
+	}
+}
+
+func TestStaticKeyfunc(t *testing.T) {
+	rsaKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	ecKey := test.LoadECPrivateKeyFromDisk("test/ec256-private.pem")
+	publicKeyPEM := loadPEMBundle(t, "test/sample_key.pub")
+
+	var staticTestData = []struct {
+		name      string
+		method    jwt.SigningMethod
+		signKey   interface{}
+		verifyKey interface{}
+		err       error
+	}{
+		{"RS256 with RSA key", jwt.SigningMethodRS256, rsaKey, &rsaKey.PublicKey, nil},
+		{"PS256 with RSA key", jwt.SigningMethodPS256, rsaKey, &rsaKey.PublicKey, nil},
+		{"ES256 with EC key", jwt.SigningMethodES256, ecKey, &ecKey.PublicKey, nil},
+		{"HS256 with secret", jwt.SigningMethodHS256, []byte("secret"), []byte("secret"), nil},
+		{"HS256 with RSA key", jwt.SigningMethodHS256, []byte("secret"), &rsaKey.PublicKey, jwt.ErrAsymmetricKeyForHMAC},
+		{"HS256 with RSA key PEM", jwt.SigningMethodHS256, publicKeyPEM, publicKeyPEM, jwt.ErrAsymmetricKeyForHMAC},
+		{"ES256 with RSA key", jwt.SigningMethodES256, ecKey, &rsaKey.PublicKey, jwt.ErrInvalidKeyType},
+		{"RS256 with secret", jwt.SigningMethodRS256, rsaKey, []byte("secret"), jwt.ErrInvalidKeyType},
+	}
+
+	for _, data := range staticTestData {
+		tokenString, err := jwt.NewWithClaims(data.method, jwt.MapClaims{"foo": "bar"}).SignedString(data.signKey)
+		if err != nil {
+			t.Fatalf("[%v] Error signing token: %v", data.name, err)
+		}
+		_, err = jwt.Parse(tokenString, jwt.StaticKeyfunc(data.verifyKey))
+		if data.err == nil && err != nil {
+			t.Errorf("[%v] Error while parsing token: %v", data.name, err)
+		}
+		if data.err != nil {
+			if ve, ok := err.(*jwt.ValidationError); !ok || ve.Inner != data.err {
+				t.Errorf("[%v] Expecting %v, got %v", data.name, data.err, err)
+			}
+		}
+	}
+}
+
+func TestCompositeKeyfunc(t *testing.T) {
+	keys := map[string]map[string]interface{}{
+		"https://idp-a": {"1": []byte("key-a")},
+		"https://idp-b": {"1": []byte("key-b")},
+	}
+	keyFunc := jwt.CompositeKeyfunc(keys)
+
+	var compositeTestData = []struct {
+		name string
+		iss  string
+		kid  string
+		key  string
+		err  error
+	}{
+		{"issuer A", "https://idp-a", "1", "key-a", nil},
+		{"issuer B", "https://idp-b", "1", "key-b", nil},
+		{"issuer A with key of B", "https://idp-a", "1", "key-b", jwt.ErrSignatureInvalid},
+		{"unknown issuer", "https://idp-c", "1", "key-a", jwt.ErrIssuerUnknown},
+		{"missing issuer", "", "1", "key-a", jwt.ErrIssuerMissing},
+		{"unknown kid", "https://idp-a", "2", "key-a", jwt.ErrKidUnknown},
+		{"missing kid", "https://idp-a", "", "key-a", jwt.ErrKidMissing},
+	}
+
+	for _, data := range compositeTestData {
+		claims := jwt.MapClaims{}
+		if data.iss != "" {
+			claims["iss"] = data.iss
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		if data.kid != "" {
+			token.Header["kid"] = data.kid
+		}
+		tokenString, _ := token.SignedString([]byte(data.key))
+
+		_, err := jwt.Parse(tokenString, keyFunc)
+		if data.err == nil && err != nil {
+			t.Errorf("[%v] Error while parsing token: %v", data.name, err)
+		}
+		if data.err != nil {
+			if ve, ok := err.(*jwt.ValidationError); !ok || ve.Inner != data.err {
+				t.Errorf("[%v] Expecting %v, got %v", data.name, data.err, err)
+			}
+		}
+	}
+
+	// The keyfunc also reads the issuer of struct claims
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &jwt.StandardClaims{Issuer: "https://idp-b"})
+	token.Header["kid"] = "1"
+	tokenString, _ := token.SignedString([]byte("key-b"))
+	if _, err := jwt.ParseWithClaims(tokenString, &jwt.StandardClaims{}, keyFunc); err != nil {
+		t.Errorf("[struct claims] Error while parsing token: %v", err)
+	}
+}
+
+func TestAnyOf(t *testing.T) {
+	keys, err := jwt.ParsePublicKeysFromPEM(loadPEMBundle(t, "test/ec256-public.pem", "test/sample_key.pub"))
+	if err != nil {
+		t.Fatalf("Error parsing bundle: %v", err)
+	}
+	keyFunc := jwt.AnyOf(keys)
+
+	var anyOfTestData = []struct {
+		name   string
+		method jwt.SigningMethod
+		key    interface{}
+		valid  bool
+	}{
+		{"second key", jwt.SigningMethodRS256, test.LoadRSAPrivateKeyFromDisk("test/sample_key"), true},
+		{"first key", jwt.SigningMethodES256, test.LoadECPrivateKeyFromDisk("test/ec256-private.pem"), true},
+		{"unknown key", jwt.SigningMethodES384, test.LoadECPrivateKeyFromDisk("test/ec384-private.pem"), false},
+		{"public key as HMAC secret", jwt.SigningMethodHS256, loadPEMBundle(t, "test/sample_key.pub"), false},
+	}
+
+	for _, data := range anyOfTestData {
+		tokenString, err := jwt.NewWithClaims(data.method, jwt.MapClaims{"foo": "bar"}).SignedString(data.key)
+		if err != nil {
+			t.Fatalf("[%v] Error signing token: %v", data.name, err)
+		}
+		token, err := jwt.Parse(tokenString, keyFunc)
+		if data.valid && (err != nil || !token.Valid) {
+			t.Errorf("[%v] Error while parsing token: %v", data.name, err)
+		}
+		if !data.valid {
+			if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorSignatureInvalid {
+				t.Errorf("[%v] Expecting ValidationErrorSignatureInvalid, got %v", data.name, err)
+			}
+		}
+	}
+}
+
+func TestVerificationKeySet(t *testing.T) {
+	rsaKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	ecKey := test.LoadECPrivateKeyFromDisk("test/ec256-private.pem")
+	set := jwt.VerificationKeySet{[]byte("old"), &ecKey.PublicKey, &rsaKey.PublicKey, []byte("new")}
+
+	var keySetTestData = []struct {
+		name     string
+		method   jwt.SigningMethod
+		key      interface{}
+		set      jwt.VerificationKeySet
+		expected interface{}
+		errors   uint32
+	}{
+		{"first key", jwt.SigningMethodHS256, []byte("old"), set, set[0], 0},
+		{"last key", jwt.SigningMethodHS256, []byte("new"), set, set[3], 0},
+		{"skipped key types", jwt.SigningMethodRS256, rsaKey, set, set[2], 0},
+		{"ec key", jwt.SigningMethodES256, ecKey, set, set[1], 0},
+		{"unknown key", jwt.SigningMethodHS256, []byte("other"), set, nil, jwt.ValidationErrorSignatureInvalid},
+		{"empty set", jwt.SigningMethodHS256, []byte("old"), jwt.VerificationKeySet{}, nil, jwt.ValidationErrorSignatureInvalid},
+	}
+
+	for _, data := range keySetTestData {
+		tokenString, err := jwt.NewWithClaims(data.method, jwt.MapClaims{"foo": "bar"}).SignedString(data.key)
+		if err != nil {
+			t.Fatalf("[%v] Error signing token: %v", data.name, err)
+		}
+		token, err := jwt.Parse(tokenString, func(*jwt.Token) (interface{}, error) { return data.set, nil })
+		if data.errors == 0 {
+			if err != nil || !token.Valid {
+				t.Errorf("[%v] Error while parsing token: %v", data.name, err)
+			} else if !reflect.DeepEqual(token.VerificationKey, data.expected) {
+				t.Errorf("[%v] Expecting verification key %v, got %v", data.name, data.expected, token.VerificationKey)
+			}
+			continue
+		}
+		if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != data.errors {
+			t.Errorf("[%v] Expecting error bits %v, got %v", data.name, data.errors, err)
+		}
+		if token != nil && token.VerificationKey != nil {
+			t.Errorf("[%v] Expecting no verification key, got %v", data.name, token.VerificationKey)
+		}
+	}
+
+	// The error is that of a key of a suitable type
+	tokenString, _ := jwt.New(jwt.SigningMethodHS256).SignedString([]byte("other"))
+	if _, err := jwt.Parse(tokenString, func(*jwt.Token) (interface{}, error) { return set, nil }); err == nil || err.(*jwt.ValidationError).Inner != jwt.ErrSignatureInvalid {
+		t.Errorf("Expecting ErrSignatureInvalid, got %v", err)
+	}
+
+	// Asymmetric keys in a set are refused for HMAC tokens by the guard
+	parser := jwt.NewParser(jwt.WithAsymmetricKeyGuard())
+	if _, err := parser.Parse(tokenString, func(*jwt.Token) (interface{}, error) { return set, nil }); err == nil || err.(*jwt.ValidationError).Inner != jwt.ErrAsymmetricKeyForHMAC {
+		t.Errorf("Expecting ErrAsymmetricKeyForHMAC, got %v", err)
+	}
+}
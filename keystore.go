@@ -0,0 +1,83 @@
+package jwt
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// A set of verification keys, parsed once from PEM or JWK key material and
+// indexed by kid, for use as a Keyfunc.  Parsing PEM on every Keyfunc call is
+// comparatively expensive; a KeyStore does it only when keys are loaded.
+//
+// The keys can be replaced with Reload or ReloadJWKSet when they rotate.  A
+// reload is atomic: concurrent Keyfunc calls see either the old or the new
+// keys, never a mix.  A KeyStore is safe for concurrent use.
+type KeyStore struct {
+	keys atomic.Value // map[string]jwkSetEntry
+}
+
+// Creates a KeyStore from PEM encoded RSA, EC or Ed25519 public keys or
+// certificates, indexed by kid.  See Reload.
+func NewKeyStore(pemKeys map[string][]byte) (*KeyStore, error) {
+	s := &KeyStore{}
+	if err := s.Reload(pemKeys); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Replaces the keys of the store with pemKeys, PEM encoded RSA, EC or Ed25519
+// public keys or certificates indexed by kid.  If any key fails to parse, an error
+// naming its kid is returned and the store is left unchanged.
+func (s *KeyStore) Reload(pemKeys map[string][]byte) error {
+	keys := make(map[string]jwkSetEntry, len(pemKeys))
+	for kid, data := range pemKeys {
+		key, err := parsePublicKeyFromPEM(data)
+		if err != nil {
+			return fmt.Errorf("key %q: %v", kid, err)
+		}
+		keys[kid] = jwkSetEntry{key: key}
+	}
+	s.keys.Store(keys)
+	return nil
+}
+
+// Replaces the keys of the store with those of a JSON Web Key Set.  As with
+// ParseJWKSet, unrecognized keys are skipped, and a JWK declaring an alg only
+// verifies tokens using that alg.  On error the store is left unchanged.
+func (s *KeyStore) ReloadJWKSet(data []byte) error {
+	set, err := ParseJWKSet(data)
+	if err != nil {
+		return err
+	}
+	s.keys.Store(set.keys)
+	return nil
+}
+
+// Number of keys in the store
+func (s *KeyStore) Len() int {
+	keys, _ := s.keys.Load().(map[string]jwkSetEntry)
+	return len(keys)
+}
+
+// A Keyfunc selecting the key named by the kid header of the token, as
+// JWKSet.Keyfunc does
+func (s *KeyStore) Keyfunc(token *Token) (interface{}, error) {
+	keys, _ := s.keys.Load().(map[string]jwkSetEntry)
+	return lookupJWK(keys, token)
+}
+
+// Parses a PEM encoded RSA, EC or Ed25519 public key
+func parsePublicKeyFromPEM(data []byte) (interface{}, error) {
+	if key, err := ParseRSAPublicKeyFromPEM(data); err == nil {
+		return key, nil
+	}
+	if key, err := parseEdPublicKeyFromPEM(data); err == nil {
+		return key, nil
+	}
+	key, err := ParseECPublicKeyFromPEM(data)
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
@@ -0,0 +1,107 @@
+package jwt_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/form3tech-oss/jwt-go"
+	"github.com/form3tech-oss/jwt-go/test"
+)
+
+func loadKeyStorePEM(t testing.TB, kids map[string]string) map[string][]byte {
+	pemKeys := make(map[string][]byte, len(kids))
+	for kid, path := range kids {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Error reading %v: %v", path, err)
+		}
+		pemKeys[kid] = data
+	}
+	return pemKeys
+}
+
+func TestKeyStore(t *testing.T) {
+	rsaKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	ecKey := test.LoadECPrivateKeyFromDisk("test/ec256-private.pem")
+
+	store, err := jwt.NewKeyStore(loadKeyStorePEM(t, map[string]string{"rsa-1": "test/sample_key.pub"}))
+	if err != nil {
+		t.Fatalf("Error creating key store: %v", err)
+	}
+
+	rsaToken := jwt.New(jwt.SigningMethodRS256)
+	rsaToken.Header["kid"] = "rsa-1"
+	rsaString, _ := rsaToken.SignedString(rsaKey)
+	ecToken := jwt.New(jwt.SigningMethodES256)
+	ecToken.Header["kid"] = "ec-2"
+	ecString, _ := ecToken.SignedString(ecKey)
+
+	if _, err := jwt.Parse(rsaString, store.Keyfunc); err != nil {
+		t.Errorf("[rsa-1] Error while parsing token: %v", err)
+	}
+	if _, err := jwt.Parse(ecString, store.Keyfunc); err == nil || err.(*jwt.ValidationError).Inner != jwt.ErrKidUnknown {
+		t.Errorf("[ec-2] Expecting ErrKidUnknown before rotation, got %v", err)
+	}
+
+	// Rotate: rsa-1 is retired, ec-2 introduced
+	if err := store.Reload(loadKeyStorePEM(t, map[string]string{"ec-2": "test/ec256-public.pem"})); err != nil {
+		t.Fatalf("Error reloading key store: %v", err)
+	}
+	if _, err := jwt.Parse(ecString, store.Keyfunc); err != nil {
+		t.Errorf("[ec-2] Error while parsing token after rotation: %v", err)
+	}
+	if _, err := jwt.Parse(rsaString, store.Keyfunc); err == nil || err.(*jwt.ValidationError).Inner != jwt.ErrKidUnknown {
+		t.Errorf("[rsa-1] Expecting ErrKidUnknown after rotation, got %v", err)
+	}
+
+	// A failed reload keeps the current keys
+	if err := store.Reload(map[string][]byte{"broken": []byte("not a key")}); err == nil {
+		t.Errorf("Expecting an error reloading an invalid key")
+	}
+	if store.Len() != 1 {
+		t.Errorf("Expecting the failed reload to keep 1 key, got %v", store.Len())
+	}
+
+	// Keys from a JWK set
+	jwk := makeSampleJWK(&rsaKey.PublicKey)
+	jwk["kid"] = "rsa-1"
+	jwk["alg"] = "RS256"
+	data, _ := json.Marshal(map[string]interface{}{"keys": []interface{}{jwk}})
+	if err := store.ReloadJWKSet(data); err != nil {
+		t.Fatalf("Error reloading key store from JWK set: %v", err)
+	}
+	if _, err := jwt.Parse(rsaString, store.Keyfunc); err != nil {
+		t.Errorf("[jwk rsa-1] Error while parsing token: %v", err)
+	}
+}
+
+func BenchmarkKeyfuncParsePEM(b *testing.B) {
+	pemKeys := loadKeyStorePEM(b, map[string]string{"rsa-1": "test/sample_key.pub"})
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		return jwt.ParseRSAPublicKeyFromPEM(pemKeys[token.Header["kid"].(string)])
+	}
+	benchmarkKeyStoreParse(b, keyFunc)
+}
+
+func BenchmarkKeyfuncKeyStore(b *testing.B) {
+	store, err := jwt.NewKeyStore(loadKeyStorePEM(b, map[string]string{"rsa-1": "test/sample_key.pub"}))
+	if err != nil {
+		b.Fatal(err)
+	}
+	benchmarkKeyStoreParse(b, store.Keyfunc)
+}
+
+func benchmarkKeyStoreParse(b *testing.B, keyFunc jwt.Keyfunc) {
+	token := jwt.New(jwt.SigningMethodRS256)
+	token.Header["kid"] = "rsa-1"
+	tokenString, _ := token.SignedString(test.LoadRSAPrivateKeyFromDisk("test/sample_key"))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := jwt.Parse(tokenString, keyFunc); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -1,54 +1,410 @@
 package jwt
 
 import (
+	"crypto"
+	"crypto/rand"
+	"crypto/subtle"
 	"encoding/json"
 	"errors"
-	// "fmt"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Claims type that uses the map[string]interface{} for JSON decoding
 // This is the default claims type if you don't supply one
 type MapClaims map[string]interface{}
 
-// Compares the aud claim against cmp.
+// The registered claim names, in the order they are listed in
+// https://tools.ietf.org/html/rfc7519#section-4.1
+var standardClaimNames = []string{"iss", "sub", "aud", "exp", "nbf", "iat", "jti"}
+
+// Returns the names of the standard claims present in m, in the order
+// iss, sub, aud, exp, nbf, iat, jti.  Claims that are null or the empty
+// string are considered absent.
+func (m MapClaims) Present() []string {
+	var present []string
+	for _, name := range standardClaimNames {
+		switch v := m[name].(type) {
+		case nil:
+			continue
+		case string:
+			if v == "" {
+				continue
+			}
+		}
+		present = append(present, name)
+	}
+	return present
+}
+
+// Compares the aud claim against cmp.  The aud claim may be a single string
+// or an array of strings, also as decoded from JSON into a []interface{}.
 // If required is false, this method will return true if the value matches or is unset
 func (m MapClaims) VerifyAudience(cmp string, req bool) bool {
-	aud, ok := m["aud"].([]string)
+	aud := m.audiences()
+	if aud == nil {
+		return false
+	}
+
+	return verifyAud(aud, cmp, req)
+}
+
+// Compares the aud claim against several accepted values: passes if it
+// contains any of cmp or, if matchAll is true, every one of them.  An empty
+// cmp never passes.
+// If required is false, this method will also return true if the claim is unset
+func (m MapClaims) VerifyAudiences(cmp []string, matchAll bool, req bool) bool {
+	aud := m.audiences()
+	if len(aud) == 0 {
+		return !req
+	}
+	if len(cmp) == 0 {
+		return false
+	}
+
+	for _, c := range cmp {
+		matched := verifyAud(aud, c, true)
+		if matched && !matchAll {
+			return true
+		}
+		if !matched && matchAll {
+			return false
+		}
+	}
+	return matchAll
+}
+
+// Returns the entry of the aud claim that equals cmp, and whether one did.
+// The aud claim may be a single string or an array of strings.
+func (m MapClaims) MatchedAudience(cmp string) (matched string, ok bool) {
+	for _, a := range m.audiences() {
+		if subtle.ConstantTimeCompare([]byte(a), []byte(cmp)) != 0 {
+			return a, true
+		}
+	}
+	return "", false
+}
+
+// Reports whether aud matches pattern, in which each '*' stands for one or
+// more characters of a single host label or path segment.  Characters that
+// delimit those, such as '.', '/', ':' and '@', are never matched by '*'.
+func matchAudiencePattern(pattern, aud string) bool {
+	i := strings.IndexByte(pattern, '*')
+	if i < 0 {
+		return pattern == aud
+	}
+	if !strings.HasPrefix(aud, pattern[:i]) {
+		return false
+	}
+	aud, pattern = aud[i:], pattern[i+1:]
+
+	n := 0
+	for n < len(aud) && !strings.ContainsRune("./:@?#", rune(aud[n])) {
+		n++
+	}
+	for k := n; k >= 1; k-- {
+		if matchAudiencePattern(pattern, aud[k:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Compares the set of audiences in the aud claim against expected.  Passes
+// only when both contain exactly the same values, ignoring order and
+// duplicates.  If required is false, this method will also return true if the
+// claim is unset
+func (m MapClaims) VerifyAudienceExact(expected []string, req bool) bool {
+	aud := m.audiences()
+	if len(aud) == 0 {
+		return !req
+	}
+
+	want := make(map[string]bool, len(expected))
+	for _, e := range expected {
+		want[e] = true
+	}
+	got := make(map[string]bool, len(aud))
+	for _, a := range aud {
+		if !want[a] {
+			return false
+		}
+		got[a] = true
+	}
+	return len(got) == len(want)
+}
+
+// Returns the aud claim as a slice, whether it was decoded as a single
+// string, a []string or a []interface{} of strings
+func (m MapClaims) audiences() []string {
+	switch aud := m["aud"].(type) {
+	case string:
+		return []string{aud}
+	case []string:
+		return aud
+	case ClaimStrings:
+		return aud
+	case []interface{}:
+		auds := make([]string, 0, len(aud))
+		for _, a := range aud {
+			if s, ok := a.(string); ok {
+				auds = append(auds, s)
+			}
+		}
+		return auds
+	}
+	return nil
+}
+
+// Returns the client_id claim of an access token, the OAuth 2.0 client the
+// token was issued to, RFC 9068 section 2.2, or "" if there is none
+func (m MapClaims) ClientID() string {
+	clientID, _ := m["client_id"].(string)
+	return clientID
+}
+
+// Returns the OAuth2 scopes of the token, from the space-delimited scope
+// claim or, if there is none, the scp claim that some providers send as an
+// array or string.  Returns nil for a token without scopes.
+func (m MapClaims) Scopes() []string {
+	raw, ok := m["scope"]
 	if !ok {
-		strAud, ok := m["aud"].(string)
-		if !ok {
+		raw = m["scp"]
+	}
+	switch scope := raw.(type) {
+	case string:
+		return strings.Fields(scope)
+	case []string:
+		return scope
+	case []interface{}:
+		scopes := make([]string, 0, len(scope))
+		for _, s := range scope {
+			if str, ok := s.(string); ok {
+				scopes = append(scopes, str)
+			}
+		}
+		return scopes
+	}
+	return nil
+}
+
+// Reports whether s is one of the scopes returned by Scopes
+func (m MapClaims) HasScope(s string) bool {
+	for _, scope := range m.Scopes() {
+		if scope == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Compares the nonce claim of an OpenID Connect ID token against expected.
+// If required is false, this method will return true if the value matches or is unset
+func (m MapClaims) VerifyNonce(expected string, req bool) bool {
+	nonce, ok := m["nonce"].(string)
+	if !ok || nonce == "" {
+		return !req
+	}
+	return subtle.ConstantTimeCompare([]byte(nonce), []byte(expected)) != 0
+}
+
+// Compares the at_hash claim of an OpenID Connect ID token against the hash
+// of accessToken, the left-most half of its digest with the hash of alg, the
+// alg of the ID token, base64url encoded.  Fails with ErrHashClaimMissing if
+// req is true and the claim is unset, and with ErrHashClaimMismatch if it
+// differs.
+func (m MapClaims) VerifyAtHash(accessToken, alg string, req bool) error {
+	return m.verifyHashClaim("at_hash", accessToken, alg, req)
+}
+
+// Compares the c_hash claim of an OpenID Connect ID token against the hash
+// of the authorization code, as VerifyAtHash does for the access token
+func (m MapClaims) VerifyCHash(code, alg string, req bool) error {
+	return m.verifyHashClaim("c_hash", code, alg, req)
+}
+
+func (m MapClaims) verifyHashClaim(name, value, alg string, req bool) error {
+	claim, _ := m[name].(string)
+	if claim == "" {
+		if req {
+			return ErrHashClaimMissing
+		}
+		return nil
+	}
+	hash, ok := hashForAlg(alg)
+	if !ok || !hash.Available() {
+		return ErrHashUnavailable
+	}
+	h := hash.New()
+	h.Write([]byte(value))
+	sum := h.Sum(nil)
+	if subtle.ConstantTimeCompare([]byte(claim), []byte(EncodeSegment(sum[:len(sum)/2]))) == 0 {
+		return ErrHashClaimMismatch
+	}
+	return nil
+}
+
+// Returns the hash of the signing method of alg, SHA-512 for EdDSA
+func hashForAlg(alg string) (crypto.Hash, bool) {
+	switch m := GetSigningMethod(alg).(type) {
+	case *SigningMethodHMAC:
+		return m.Hash, true
+	case *SigningMethodRSA:
+		return m.Hash, true
+	case *SigningMethodRSAPSS:
+		return m.Hash, true
+	case *SigningMethodECDSA:
+		return m.Hash, true
+	}
+	if alg == "EdDSA" {
+		return crypto.SHA512, true
+	}
+	return 0, false
+}
+
+// Compares the acr claim, the authentication context class, against cmp.
+// If required is false, this method will return true if the value matches or is unset
+func (m MapClaims) VerifyACR(cmp string, req bool) bool {
+	acr, ok := m["acr"].(string)
+	if !ok || acr == "" {
+		return !req
+	}
+	return acr == cmp
+}
+
+// Returns the amr claim, the authentication methods used, as a slice.  Returns
+// nil for a token without an amr array.
+func (m MapClaims) amr() []string {
+	switch amr := m["amr"].(type) {
+	case []string:
+		return amr
+	case []interface{}:
+		methods := make([]string, 0, len(amr))
+		for _, a := range amr {
+			if s, ok := a.(string); ok {
+				methods = append(methods, s)
+			}
+		}
+		return methods
+	}
+	return nil
+}
+
+// Reports whether method, such as "mfa" or "otp", is listed in the amr claim
+func (m MapClaims) HasAMR(method string) bool {
+	for _, amr := range m.amr() {
+		if amr == method {
+			return true
+		}
+	}
+	return false
+}
+
+// Reports whether the amr claim lists all of methods, for step-up and
+// multi-factor policies.
+// If required is false, this method will also return true if the claim is unset
+func (m MapClaims) VerifyAMRContains(methods []string, req bool) bool {
+	if m.amr() == nil {
+		return !req
+	}
+	for _, method := range methods {
+		if !m.HasAMR(method) {
 			return false
 		}
-		aud = append(aud, strAud)
 	}
+	return true
+}
 
-	return verifyAud(aud, cmp, req)
+// Returns the confirmation key of a proof-of-possession token, RFC 7800: the
+// public key in the jwk member of the cnf claim, as an *rsa.PublicKey or
+// *ecdsa.PublicKey.  Fails with ErrCnfMissing for a token without a cnf
+// claim, and with ErrCnfJWKMissing if cnf confirms the key otherwise, e.g.
+// by its jkt thumbprint.
+func (m MapClaims) ConfirmationKey() (interface{}, error) {
+	cnf, ok := m["cnf"].(map[string]interface{})
+	if !ok {
+		return nil, ErrCnfMissing
+	}
+	raw, ok := cnf["jwk"]
+	if !ok {
+		return nil, ErrCnfJWKMissing
+	}
+	jwk, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, ErrJWKInvalid
+	}
+	return parseJWK(jwk)
+}
+
+// Compares the jkt member of the cnf claim, the RFC 7638 thumbprint of the
+// key a DPoP or mTLS bound token is bound to, against expected.  Returns
+// false for a token without a cnf claim or jkt.
+func (m MapClaims) VerifyCnfThumbprint(expected string) bool {
+	cnf, _ := m["cnf"].(map[string]interface{})
+	jkt, _ := cnf["jkt"].(string)
+	if jkt == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(jkt), []byte(expected)) != 0
 }
 
-// Compares the exp claim against cmp.
+// Compares the exp claim against cmp.  Fractional seconds are truncated.
 // If required is false, this method will return true if the value matches or is unset
 func (m MapClaims) VerifyExpiresAt(cmp int64, req bool) bool {
-	switch exp := m["exp"].(type) {
-	case float64:
-		return verifyExp(int64(exp), cmp, req)
-	case json.Number:
-		v, _ := exp.Int64()
-		return verifyExp(v, cmp, req)
+	if m.dateOutOfRange("exp") {
+		return false
+	}
+	exp, _ := m.numericDate("exp")
+	return verifyExp(exp, cmp, req)
+}
+
+// Reports whether the token expires within beforeExpiry of now, a Unix time,
+// so that it should be refreshed ahead of its expiry.  Also true once the
+// token expired.  Returns false for a token without an exp claim, which
+// never expires.
+func (m MapClaims) ShouldRefresh(now int64, beforeExpiry time.Duration) bool {
+	exp, ok := m.numericDate("exp")
+	if !ok {
+		return false
+	}
+	return exp-now <= int64(beforeExpiry/time.Second)
+}
+
+// Returns the iat, nbf and exp claims as UTC times, nil for those that are
+// unset, and the length of the validity window: from nbf, or iat for a
+// token without nbf, until exp.  valid is zero unless exp and one of nbf or
+// iat are set.
+func (m MapClaims) Lifetime() (issuedAt, notBefore, expiresAt *time.Time, valid time.Duration) {
+	claimTime := func(name string) *time.Time {
+		if v, ok := m.numericDate(name); ok {
+			t := time.Unix(v, 0).UTC()
+			return &t
+		}
+		return nil
+	}
+	issuedAt, notBefore, expiresAt = claimTime("iat"), claimTime("nbf"), claimTime("exp")
+
+	start := notBefore
+	if start == nil {
+		start = issuedAt
+	}
+	if start != nil && expiresAt != nil {
+		valid = expiresAt.Sub(*start)
 	}
-	return req == false
+	return issuedAt, notBefore, expiresAt, valid
 }
 
 // Compares the iat claim against cmp.
 // If required is false, this method will return true if the value matches or is unset
 func (m MapClaims) VerifyIssuedAt(cmp int64, req bool) bool {
-	switch iat := m["iat"].(type) {
-	case float64:
-		return verifyIat(int64(iat), cmp, req)
-	case json.Number:
-		v, _ := iat.Int64()
-		return verifyIat(v, cmp, req)
+	if m.dateOutOfRange("iat") {
+		return false
 	}
-	return req == false
+	iat, _ := m.numericDate("iat")
+	return verifyIat(iat, cmp, req)
 }
 
 // Compares the iss claim against cmp.
@@ -61,42 +417,327 @@ func (m MapClaims) VerifyIssuer(cmp string, req bool) bool {
 // Compares the nbf claim against cmp.
 // If required is false, this method will return true if the value matches or is unset
 func (m MapClaims) VerifyNotBefore(cmp int64, req bool) bool {
-	switch nbf := m["nbf"].(type) {
+	if m.dateOutOfRange("nbf") {
+		return false
+	}
+	nbf, _ := m.numericDate("nbf")
+	return verifyNbf(nbf, cmp, req)
+}
+
+// Compares the auth_time claim, the time the end user authenticated, against
+// cmp: passes if the user authenticated at most maxAge before cmp, as needed
+// for the OpenID Connect max_age request parameter and step-up
+// authentication.  If required is false, this method will return true if the
+// value is unset
+func (m MapClaims) VerifyAuthTime(cmp int64, maxAge time.Duration, req bool) bool {
+	authTime, ok := m.numericDate("auth_time")
+	if !ok {
+		return !req
+	}
+	return cmp-authTime <= int64(maxAge/time.Second)
+}
+
+// Sets the iat claim to the current time, as returned by TimeFunc
+func (m MapClaims) SetIssuedNow() {
+	m["iat"] = float64(TimeFunc().Unix())
+}
+
+// Sets the aud claim to audiences: a single string when there is one, unless
+// MarshalSingleStringAsArray is set, and an array otherwise.  The value is a
+// string or []string, the forms VerifyAudience and the JSON encoder both
+// handle.  With no audiences the claim is removed.
+func (m MapClaims) SetAudience(audiences ...string) {
+	switch {
+	case len(audiences) == 0:
+		delete(m, "aud")
+	case len(audiences) == 1 && !MarshalSingleStringAsArray:
+		m["aud"] = audiences[0]
+	default:
+		m["aud"] = append([]string(nil), audiences...)
+	}
+}
+
+// Sets the exp claim to d after the current time, as returned by TimeFunc
+func (m MapClaims) SetExpiry(d time.Duration) {
+	m["exp"] = float64(TimeFunc().Add(d).Unix())
+}
+
+// Sets the nbf claim to the current time, as returned by TimeFunc
+func (m MapClaims) SetNotBeforeNow() {
+	m["nbf"] = float64(TimeFunc().Unix())
+}
+
+// Sets the jti claim to 16 bytes from crypto/rand, base64url encoded, to give
+// each token a unique identifier for replay protection
+func (m MapClaims) SetRandomJTI() error {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	m["jti"] = EncodeSegment(b)
+	return nil
+}
+
+// Returns the value the RFC 6901 JSON Pointer pointer refers to, such as
+// "/resource_access/my-app/roles" or "/groups/0", and whether it was found.
+// Objects are traversed by member name, with "~1" and "~0" escaping '/' and
+// '~', and arrays by decimal index.  The empty pointer refers to m itself.
+func (m MapClaims) GetPath(pointer string) (interface{}, bool) {
+	if pointer == "" {
+		return m, true
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, false
+	}
+
+	var cur interface{} = map[string]interface{}(m)
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+		switch v := cur.(type) {
+		case map[string]interface{}:
+			next, ok := v[token]
+			if !ok {
+				return nil, false
+			}
+			cur = next
+		case MapClaims:
+			next, ok := v[token]
+			if !ok {
+				return nil, false
+			}
+			cur = next
+		case []interface{}:
+			// No leading zeros, and "-" names the nonexistent element past the end
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(v) || (len(token) > 1 && token[0] == '0') {
+				return nil, false
+			}
+			cur = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// Collects the roles found at the RFC 6901 JSON Pointer paths, so that one
+// call handles the shapes of several identity providers, such as
+// "/realm_access/roles" for Keycloak, "/https:~1~1example.com~1roles" for an
+// Auth0 namespaced claim or "/cognito:groups" for Cognito.  Each path may
+// refer to a string or an array of strings.  Roles are returned once each,
+// in the order they were found, and nil if there are none.
+func (m MapClaims) Roles(paths ...string) []string {
+	var roles []string
+	seen := make(map[string]bool)
+	add := func(role string) {
+		if !seen[role] {
+			seen[role] = true
+			roles = append(roles, role)
+		}
+	}
+	for _, path := range paths {
+		v, _ := m.GetPath(path)
+		switch v := v.(type) {
+		case string:
+			add(v)
+		case []string:
+			for _, role := range v {
+				add(role)
+			}
+		case []interface{}:
+			for _, role := range v {
+				if s, ok := role.(string); ok {
+					add(s)
+				}
+			}
+		}
+	}
+	return roles
+}
+
+// Returns the value of a numeric date claim, decoded as float64 or
+// json.Number, and whether it was present.  Fractional seconds are truncated
+// toward zero in both cases, so a claim such as 1700000000.9 compares the same
+// whether or not the parser uses json.Number.  A number outside the range of
+// int64 is not a date, see dateOutOfRange.
+func (m MapClaims) numericDate(name string) (int64, bool) {
+	date, ok, inRange := m.parseNumericDate(name)
+	return date, ok && inRange
+}
+
+// Reports whether a numeric date claim is a number outside the range of
+// int64, such as 1e300, which the Verify methods reject rather than treat as
+// unset
+func (m MapClaims) dateOutOfRange(name string) bool {
+	_, ok, inRange := m.parseNumericDate(name)
+	return ok && !inRange
+}
+
+// Returns the value of a numeric date claim as numericDate does, whether it
+// is a number and whether that number is within the range of int64
+func (m MapClaims) parseNumericDate(name string) (date int64, ok bool, inRange bool) {
+	var f float64
+	switch v := m[name].(type) {
 	case float64:
-		return verifyNbf(int64(nbf), cmp, req)
+		f = v
 	case json.Number:
-		v, _ := nbf.Int64()
-		return verifyNbf(v, cmp, req)
+		if n, err := v.Int64(); err == nil {
+			return n, true, true
+		}
+		// A number too large for a float64 is returned as an infinity
+		var err error
+		if f, err = v.Float64(); err != nil && !math.IsInf(f, 0) {
+			return 0, false, false
+		}
+	default:
+		return 0, false, false
+	}
+	// NaN fails both comparisons
+	if !(f >= math.MinInt64 && f < math.MaxInt64) {
+		return 0, true, false
+	}
+	return int64(f), true, true
+}
+
+// Returns a copy of m in which the exp, iat and nbf claims encoded as strings
+// of digits are replaced by their json.Number equivalent, so the Verify
+// methods treat them as numeric dates
+func (m MapClaims) withNumericDates() MapClaims {
+	claims := make(MapClaims, len(m))
+	for k, v := range m {
+		claims[k] = v
+	}
+	for _, k := range []string{"exp", "iat", "nbf"} {
+		if s, ok := m[k].(string); ok {
+			if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+				claims[k] = json.Number(s)
+			}
+		}
 	}
-	return req == false
+	return claims
 }
 
 // Validates time based claims "exp, iat, nbf".
 // There is no accounting for clock skew.
 // As well, if any of the above claims are not in the token, it will still
-// be considered a valid claim.
+// be considered a valid claim.  Without any of them TimeFunc is not called.
 func (m MapClaims) Valid() error {
+	if !m.hasTimeClaims() {
+		return nil
+	}
+	return m.ValidAt(TimeFunc())
+}
+
+// Validates the time based claims "exp, iat, nbf" as Valid does, but at the
+// instant t instead of now.  Use it to check whether a token issued ahead of
+// time, with an nbf in the future, will be valid at a scheduled activation
+// time.  The iat check is also evaluated at t.
+func (m MapClaims) ValidAt(t time.Time) error {
+	vErr := new(ValidationError)
+	at := t.Unix()
+
+	m.verifyTimes(at, at, vErr)
+
+	if vErr.valid() {
+		return nil
+	}
+
+	return vErr
+}
+
+// Runs only the standard validations named by claims, against the time now,
+// so validation can be split across pipeline stages.  exp, nbf and iat are
+// checked as by Valid.  iss, sub and aud have no expected value here, they
+// are checked to be a string, and for aud a string or an array of strings,
+// when present.  Failures are combined into one ValidationError; an unknown
+// name is an error of its own.
+func (m MapClaims) ValidateClaims(now int64, claims ...string) error {
 	vErr := new(ValidationError)
-	now := TimeFunc().Unix()
+	for _, name := range claims {
+		switch name {
+		case "exp":
+			if !m.VerifyExpiresAt(now, false) {
+				vErr.Inner = errors.New("Token is expired")
+				vErr.Errors |= ValidationErrorExpired
+			}
+		case "nbf":
+			if !m.VerifyNotBefore(now, false) {
+				vErr.Inner = errors.New("Token is not valid yet")
+				vErr.Errors |= ValidationErrorNotValidYet
+			}
+		case "iat":
+			if !m.VerifyIssuedAt(now, false) {
+				vErr.Inner = errors.New("Token used before issued")
+				vErr.Errors |= ValidationErrorIssuedAt
+			}
+		case "iss":
+			if _, ok := m["iss"].(string); !ok && m["iss"] != nil {
+				vErr.Inner = errors.New("Token issuer is not a string")
+				vErr.Errors |= ValidationErrorIssuer
+			}
+		case "sub":
+			if _, ok := m["sub"].(string); !ok && m["sub"] != nil {
+				vErr.Inner = errors.New("Token subject is not a string")
+				vErr.Errors |= ValidationErrorClaimsInvalid
+			}
+		case "aud":
+			if !m.wellFormedAudience() {
+				vErr.Inner = errors.New("Token audience is not a string or an array of strings")
+				vErr.Errors |= ValidationErrorAudience
+			}
+		default:
+			return fmt.Errorf("unknown claim %q", name)
+		}
+	}
+
+	if vErr.valid() {
+		return nil
+	}
+	return vErr
+}
+
+// Reports whether the aud claim is absent, a string or an array of strings
+func (m MapClaims) wellFormedAudience() bool {
+	switch aud := m["aud"].(type) {
+	case nil, string, []string, ClaimStrings:
+		return true
+	case []interface{}:
+		for _, a := range aud {
+			if _, ok := a.(string); !ok {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// Reports whether any of the time based claims is present
+func (m MapClaims) hasTimeClaims() bool {
+	for _, name := range []string{"exp", "iat", "nbf"} {
+		if _, ok := m[name]; ok {
+			return true
+		}
+	}
+	return false
+}
 
-	if m.VerifyExpiresAt(now, false) == false {
+// Runs the exp check against expNow and the iat and nbf checks against
+// nbfNow.  Callers tolerate clock skew by moving either instant.  Failures
+// are recorded in vErr.
+func (m MapClaims) verifyTimes(expNow int64, nbfNow int64, vErr *ValidationError) {
+	if m.VerifyExpiresAt(expNow, false) == false {
 		vErr.Inner = errors.New("Token is expired")
 		vErr.Errors |= ValidationErrorExpired
 	}
 
-	if m.VerifyIssuedAt(now, false) == false {
+	if m.VerifyIssuedAt(nbfNow, false) == false {
 		vErr.Inner = errors.New("Token used before issued")
 		vErr.Errors |= ValidationErrorIssuedAt
 	}
 
-	if m.VerifyNotBefore(now, false) == false {
+	if m.VerifyNotBefore(nbfNow, false) == false {
 		vErr.Inner = errors.New("Token is not valid yet")
 		vErr.Errors |= ValidationErrorNotValidYet
 	}
-
-	if vErr.valid() {
-		return nil
-	}
-
-	return vErr
 }
@@ -0,0 +1,115 @@
+package jwt
+
+import (
+	"encoding/json"
+	"math"
+	"time"
+)
+
+// Returns the claim called name if it is a string
+func (m MapClaims) GetString(name string) (string, bool) {
+	s, ok := m[name].(string)
+	return s, ok
+}
+
+// Returns the claim called name if it is a whole number that fits an int64,
+// decoded as float64 or json.Number, or set as an integer
+func (m MapClaims) GetInt64(name string) (int64, bool) {
+	switch v := m[name].(type) {
+	case float64:
+		if v != math.Trunc(v) || v < math.MinInt64 || v >= math.MaxInt64 {
+			return 0, false
+		}
+		return int64(v), true
+	case json.Number:
+		n, err := v.Int64()
+		return n, err == nil
+	case int:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	}
+	return 0, false
+}
+
+// Returns the claim called name if it is a number, decoded as float64 or
+// json.Number, or set as an integer
+func (m MapClaims) GetFloat64(name string) (float64, bool) {
+	switch v := m[name].(type) {
+	case float64:
+		return v, true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// Returns the claim called name as a UTC time if it is a numeric date,
+// seconds since the Unix epoch that fit an int64, keeping any fractional
+// second
+func (m MapClaims) GetTime(name string) (time.Time, bool) {
+	if n, ok := m.GetInt64(name); ok {
+		return time.Unix(n, 0).UTC(), true
+	}
+	f, ok := m.GetFloat64(name)
+	// NaN fails both comparisons
+	if !ok || !(f >= math.MinInt64 && f < math.MaxInt64) {
+		return time.Time{}, false
+	}
+	seconds, fraction := math.Modf(f)
+	return time.Unix(int64(seconds), int64(fraction*1e9)).UTC(), true
+}
+
+// Returns the claim called name as a slice if it is a string, which becomes
+// the only entry, or an array of strings, decoded as []interface{} or set as
+// []string or ClaimStrings.  An array holding anything but strings fails.
+func (m MapClaims) GetStringSlice(name string) ([]string, bool) {
+	switch v := m[name].(type) {
+	case string:
+		return []string{v}, true
+	case []string:
+		return v, true
+	case ClaimStrings:
+		return v, true
+	case []interface{}:
+		strs := make([]string, 0, len(v))
+		for _, entry := range v {
+			s, ok := entry.(string)
+			if !ok {
+				return nil, false
+			}
+			strs = append(strs, s)
+		}
+		return strs, true
+	}
+	return nil, false
+}
+
+// Returns the exp claim.  See GetTime.
+func (m MapClaims) GetExpirationTime() (time.Time, bool) {
+	return m.GetTime("exp")
+}
+
+// Returns the iat claim.  See GetTime.
+func (m MapClaims) GetIssuedAt() (time.Time, bool) {
+	return m.GetTime("iat")
+}
+
+// Returns the nbf claim.  See GetTime.
+func (m MapClaims) GetNotBefore() (time.Time, bool) {
+	return m.GetTime("nbf")
+}
+
+// Returns the aud claim, a single audience or an array.  See GetStringSlice.
+func (m MapClaims) GetAudience() ([]string, bool) {
+	return m.GetStringSlice("aud")
+}
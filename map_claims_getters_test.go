@@ -0,0 +1,101 @@
+package jwt_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/form3tech-oss/jwt-go"
+)
+
+func TestMapClaims_Getters(t *testing.T) {
+	claims := jwt.MapClaims{
+		"str":      "value",
+		"int":      float64(1500000000),
+		"fraction": 1500000000.5,
+		"number":   json.Number("1500000000"),
+		"go int":   42,
+		"huge":     1e20,
+		"aud":      []interface{}{"a", "b"},
+		"mixed":    []interface{}{"a", 1},
+		"exp":      float64(1500003600),
+		"iat":      json.Number("1500000000"),
+		"nbf":      "1500000000",
+	}
+
+	var stringTestData = []struct {
+		name     string
+		expected string
+		ok       bool
+	}{
+		{"str", "value", true},
+		{"int", "", false},
+		{"missing", "", false},
+	}
+	for _, data := range stringTestData {
+		if s, ok := claims.GetString(data.name); s != data.expected || ok != data.ok {
+			t.Errorf("[%v] Expecting GetString %q, %v, got %q, %v", data.name, data.expected, data.ok, s, ok)
+		}
+	}
+
+	var numberTestData = []struct {
+		name    string
+		int64   int64
+		intOK   bool
+		float64 float64
+		floatOK bool
+	}{
+		{"int", 1500000000, true, 1500000000, true},
+		{"fraction", 0, false, 1500000000.5, true},
+		{"number", 1500000000, true, 1500000000, true},
+		{"go int", 42, true, 42, true},
+		{"huge", 0, false, 1e20, true},
+		{"str", 0, false, 0, false},
+		{"missing", 0, false, 0, false},
+	}
+	for _, data := range numberTestData {
+		if n, ok := claims.GetInt64(data.name); n != data.int64 || ok != data.intOK {
+			t.Errorf("[%v] Expecting GetInt64 %v, %v, got %v, %v", data.name, data.int64, data.intOK, n, ok)
+		}
+		if f, ok := claims.GetFloat64(data.name); f != data.float64 || ok != data.floatOK {
+			t.Errorf("[%v] Expecting GetFloat64 %v, %v, got %v, %v", data.name, data.float64, data.floatOK, f, ok)
+		}
+	}
+
+	if tm, ok := claims.GetTime("fraction"); !ok || !tm.Equal(time.Unix(1500000000, 5e8)) || tm.Location() != time.UTC {
+		t.Errorf("[fraction] Expecting GetTime with the fractional second in UTC, got %v, %v", tm, ok)
+	}
+	if tm, ok := claims.GetTime("huge"); ok || !tm.IsZero() {
+		t.Errorf("[huge] Expecting GetTime to fail beyond the int64 range, got %v, %v", tm, ok)
+	}
+	if tm, ok := claims.GetExpirationTime(); !ok || !tm.Equal(time.Unix(1500003600, 0)) {
+		t.Errorf("[exp] Expecting GetExpirationTime, got %v, %v", tm, ok)
+	}
+	if tm, ok := claims.GetIssuedAt(); !ok || !tm.Equal(time.Unix(1500000000, 0)) {
+		t.Errorf("[iat] Expecting GetIssuedAt, got %v, %v", tm, ok)
+	}
+	if tm, ok := claims.GetNotBefore(); ok || !tm.IsZero() {
+		t.Errorf("[nbf] Expecting GetNotBefore to fail for a string, got %v, %v", tm, ok)
+	}
+
+	var sliceTestData = []struct {
+		name     string
+		expected []string
+		ok       bool
+	}{
+		{"aud", []string{"a", "b"}, true},
+		{"str", []string{"value"}, true},
+		{"mixed", nil, false},
+		{"int", nil, false},
+		{"missing", nil, false},
+	}
+	for _, data := range sliceTestData {
+		if s, ok := claims.GetStringSlice(data.name); !reflect.DeepEqual(s, data.expected) || ok != data.ok {
+			t.Errorf("[%v] Expecting GetStringSlice %v, %v, got %v, %v", data.name, data.expected, data.ok, s, ok)
+		}
+	}
+	if aud, ok := claims.GetAudience(); !ok || !reflect.DeepEqual(aud, []string{"a", "b"}) {
+		t.Errorf("[aud] Expecting GetAudience, got %v, %v", aud, ok)
+	}
+}
@@ -1,8 +1,14 @@
 package jwt
 
-import "testing"
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
 
-func Test_mapClaims_list_aud(t *testing.T){
+func Test_mapClaims_list_aud(t *testing.T) {
 	mapClaims := MapClaims{
 		"aud": []string{"foo"},
 	}
@@ -13,7 +19,8 @@ func Test_mapClaims_list_aud(t *testing.T){
 		t.Fatalf("Failed to verify claims, wanted: %v got %v", want, got)
 	}
 }
-func Test_mapClaims_string_aud(t *testing.T){
+
+func Test_mapClaims_string_aud(t *testing.T) {
 	mapClaims := MapClaims{
 		"aud": "foo",
 	}
@@ -25,7 +32,7 @@ func Test_mapClaims_string_aud(t *testing.T){
 	}
 }
 
-func Test_mapClaims_list_aud_no_match(t *testing.T){
+func Test_mapClaims_list_aud_no_match(t *testing.T) {
 	mapClaims := MapClaims{
 		"aud": []string{"bar"},
 	}
@@ -36,7 +43,8 @@ func Test_mapClaims_list_aud_no_match(t *testing.T){
 		t.Fatalf("Failed to verify claims, wanted: %v got %v", want, got)
 	}
 }
-func Test_mapClaims_string_aud_fail(t *testing.T){
+
+func Test_mapClaims_string_aud_fail(t *testing.T) {
 	mapClaims := MapClaims{
 		"aud": "bar",
 	}
@@ -48,9 +56,8 @@ func Test_mapClaims_string_aud_fail(t *testing.T){
 	}
 }
 
-func Test_mapClaims_string_aud_no_claim(t *testing.T){
-	mapClaims := MapClaims{
-	}
+func Test_mapClaims_string_aud_no_claim(t *testing.T) {
+	mapClaims := MapClaims{}
 	want := false
 	got := mapClaims.VerifyAudience("foo", true)
 
@@ -59,13 +66,652 @@ func Test_mapClaims_string_aud_no_claim(t *testing.T){
 	}
 }
 
-func Test_mapClaims_string_aud_no_claim_not_required(t *testing.T){
-	mapClaims := MapClaims{
-	}
+func Test_mapClaims_string_aud_no_claim_not_required(t *testing.T) {
+	mapClaims := MapClaims{}
 	want := false
 	got := mapClaims.VerifyAudience("foo", false)
 
 	if want != got {
 		t.Fatalf("Failed to verify claims, wanted: %v got %v", want, got)
 	}
-}
\ No newline at end of file
+}
+
+func Test_mapClaims_decoded_list_aud(t *testing.T) {
+	var mapClaims MapClaims
+	if err := json.Unmarshal([]byte(`{"aud":["bar","foo"]}`), &mapClaims); err != nil {
+		t.Fatalf("Error decoding claims: %v", err)
+	}
+	if _, ok := mapClaims["aud"].([]interface{}); !ok {
+		t.Fatalf("Expecting aud to decode as []interface{}, got %T", mapClaims["aud"])
+	}
+	if !mapClaims.VerifyAudience("foo", true) {
+		t.Errorf("Expecting foo to be one of the decoded audiences")
+	}
+	if mapClaims.VerifyAudience("baz", true) {
+		t.Errorf("Expecting baz not to be one of the decoded audiences")
+	}
+}
+
+func Test_mapClaims_verify_audiences(t *testing.T) {
+	var verifyAudiencesTestData = []struct {
+		name     string
+		aud      interface{}
+		cmp      []string
+		matchAll bool
+		req      bool
+		want     bool
+	}{
+		{"any, one matches", []interface{}{"a", "b"}, []string{"x", "b"}, false, true, true},
+		{"any, none match", []interface{}{"a", "b"}, []string{"x", "y"}, false, true, false},
+		{"all, all match", []interface{}{"a", "b", "c"}, []string{"a", "b"}, true, true, true},
+		{"all, one missing", []interface{}{"a", "b"}, []string{"a", "x"}, true, true, false},
+		{"string aud", "a", []string{"x", "a"}, false, true, true},
+		{"empty cmp", []string{"a"}, nil, true, true, false},
+		{"unset, required", nil, []string{"a"}, false, true, false},
+		{"unset, not required", nil, []string{"a"}, true, false, true},
+	}
+
+	for _, data := range verifyAudiencesTestData {
+		mapClaims := MapClaims{}
+		if data.aud != nil {
+			mapClaims["aud"] = data.aud
+		}
+		if got := mapClaims.VerifyAudiences(data.cmp, data.matchAll, data.req); got != data.want {
+			t.Errorf("[%v] Expecting %v, got %v", data.name, data.want, got)
+		}
+	}
+}
+
+func Test_mapClaims_set_time_claims(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	TimeFunc = func() time.Time { return now }
+	defer func() { TimeFunc = time.Now }()
+
+	mapClaims := MapClaims{}
+	mapClaims.SetIssuedNow()
+	mapClaims.SetNotBeforeNow()
+	mapClaims.SetExpiry(time.Hour)
+
+	want := MapClaims{
+		"iat": float64(now.Unix()),
+		"nbf": float64(now.Unix()),
+		"exp": float64(now.Add(time.Hour).Unix()),
+	}
+	if !reflect.DeepEqual(want, mapClaims) {
+		t.Fatalf("Failed to set time claims, wanted: %v got %v", want, mapClaims)
+	}
+	if err := mapClaims.Valid(); err != nil {
+		t.Fatalf("Claims with freshly set time claims should be valid, got %v", err)
+	}
+}
+
+func Test_mapClaims_present(t *testing.T) {
+	mapClaims := MapClaims{
+		"jti": "abc",
+		"exp": float64(1500000000),
+		"iss": "issuer",
+		"sub": "",
+		"aud": nil,
+		"foo": "bar",
+	}
+	want := []string{"iss", "exp", "jti"}
+	got := mapClaims.Present()
+
+	if !reflect.DeepEqual(want, got) {
+		t.Fatalf("Failed to list present claims, wanted: %v got %v", want, got)
+	}
+	if got := (MapClaims{}).Present(); len(got) != 0 {
+		t.Fatalf("Failed to list present claims, wanted none got %v", got)
+	}
+}
+
+func Test_mapClaims_matched_audience(t *testing.T) {
+	mapClaims := MapClaims{
+		"aud": []interface{}{"foo", "bar", "baz"},
+	}
+	if matched, ok := mapClaims.MatchedAudience("bar"); !ok || matched != "bar" {
+		t.Fatalf("Failed to match audience, wanted: bar got %v %v", matched, ok)
+	}
+	if matched, ok := mapClaims.MatchedAudience("qux"); ok {
+		t.Fatalf("Matched unexpected audience %v", matched)
+	}
+	if matched, ok := (MapClaims{"aud": "foo"}).MatchedAudience("foo"); !ok || matched != "foo" {
+		t.Fatalf("Failed to match single audience, wanted: foo got %v %v", matched, ok)
+	}
+}
+
+func Test_mapClaims_time_boundaries(t *testing.T) {
+	now := int64(1500000000)
+
+	if (MapClaims{"exp": float64(now)}).VerifyExpiresAt(now, true) {
+		t.Fatalf("Token should be expired at now == exp")
+	}
+	if !(MapClaims{"exp": float64(now + 1)}).VerifyExpiresAt(now, true) {
+		t.Fatalf("Token should not be expired before exp")
+	}
+	if !(MapClaims{"nbf": float64(now)}).VerifyNotBefore(now, true) {
+		t.Fatalf("Token should be valid at now == nbf")
+	}
+	if (MapClaims{"nbf": float64(now + 1)}).VerifyNotBefore(now, true) {
+		t.Fatalf("Token should not be valid before nbf")
+	}
+	if (&StandardClaims{ExpiresAt: now}).VerifyExpiresAt(now, true) {
+		t.Fatalf("StandardClaims should be expired at now == exp")
+	}
+	if !(&StandardClaims{NotBefore: now}).VerifyNotBefore(now, true) {
+		t.Fatalf("StandardClaims should be valid at now == nbf")
+	}
+}
+
+func Test_mapClaims_verify_audience_exact(t *testing.T) {
+	mapClaims := MapClaims{
+		"aud": []interface{}{"a", "b", "a"},
+	}
+	var exactTestData = []struct {
+		expected []string
+		want     bool
+	}{
+		{[]string{"b", "a"}, true},
+		{[]string{"a", "b", "b"}, true},
+		{[]string{"a"}, false},
+		{[]string{"a", "b", "c"}, false},
+		{nil, false},
+	}
+	for _, data := range exactTestData {
+		if got := mapClaims.VerifyAudienceExact(data.expected, true); got != data.want {
+			t.Errorf("Failed to verify audience set %v, wanted: %v got %v", data.expected, data.want, got)
+		}
+	}
+
+	if (MapClaims{}).VerifyAudienceExact([]string{"a"}, true) {
+		t.Errorf("Missing aud should fail when required")
+	}
+	if !(MapClaims{}).VerifyAudienceExact([]string{"a"}, false) {
+		t.Errorf("Missing aud should pass when not required")
+	}
+}
+
+func Test_valid_without_time_claims(t *testing.T) {
+	defer func() { TimeFunc = time.Now }()
+	TimeFunc = func() time.Time {
+		t.Fatal("TimeFunc called for claims without time claims")
+		return time.Time{}
+	}
+
+	if err := (MapClaims{"sub": "user", "aud": "service"}).Valid(); err != nil {
+		t.Errorf("MapClaims: unexpected error %v", err)
+	}
+	if err := (StandardClaims{Subject: "user"}).Valid(); err != nil {
+		t.Errorf("StandardClaims: unexpected error %v", err)
+	}
+}
+
+func Test_mapClaims_fractional_dates(t *testing.T) {
+	data := []byte(`{"exp":1700000000.9,"iat":1600000000.9,"nbf":1600000000.9}`)
+
+	var float MapClaims
+	if err := json.Unmarshal(data, &float); err != nil {
+		t.Fatal(err)
+	}
+	var number MapClaims
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&number); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, cmp := range []int64{1599999999, 1600000000, 1699999999, 1700000000, 1700000001} {
+		if a, b := float.VerifyExpiresAt(cmp, true), number.VerifyExpiresAt(cmp, true); a != b {
+			t.Errorf("exp at %d: float64 %v, json.Number %v", cmp, a, b)
+		}
+		if a, b := float.VerifyIssuedAt(cmp, true), number.VerifyIssuedAt(cmp, true); a != b {
+			t.Errorf("iat at %d: float64 %v, json.Number %v", cmp, a, b)
+		}
+		if a, b := float.VerifyNotBefore(cmp, true), number.VerifyNotBefore(cmp, true); a != b {
+			t.Errorf("nbf at %d: float64 %v, json.Number %v", cmp, a, b)
+		}
+	}
+
+	// Both truncate toward zero, so the token expires at 1700000000
+	if !number.VerifyExpiresAt(1699999999, true) || number.VerifyExpiresAt(1700000000, true) {
+		t.Errorf("Expecting exp to be truncated to 1700000000")
+	}
+}
+
+// Numbers beyond the range of int64 fail the checks rather than wrapping
+// around to an arbitrary date, decoded either way
+func Test_mapClaims_out_of_range_dates(t *testing.T) {
+	for _, claims := range []MapClaims{
+		{"exp": 1e300, "iat": 1e300, "nbf": 1e300},
+		{"exp": -1e300, "iat": -1e300, "nbf": -1e300},
+		{"exp": json.Number("1e300"), "iat": json.Number("1e300"), "nbf": json.Number("1e300")},
+		{"exp": json.Number("1e400"), "iat": json.Number("1e400"), "nbf": json.Number("1e400")},
+	} {
+		now := time.Now().Unix()
+		if claims.VerifyExpiresAt(now, false) || claims.VerifyIssuedAt(now, false) || claims.VerifyNotBefore(now, false) {
+			t.Errorf("[%v] Expecting the time checks to fail", claims["exp"])
+		}
+		if err := claims.Valid(); err == nil {
+			t.Errorf("[%v] Expecting Valid to fail", claims["exp"])
+		}
+	}
+}
+
+func Test_mapClaims_set_random_jti(t *testing.T) {
+	a, b := MapClaims{}, MapClaims{}
+	if err := a.SetRandomJTI(); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.SetRandomJTI(); err != nil {
+		t.Fatal(err)
+	}
+
+	jtiA, _ := a["jti"].(string)
+	jtiB, _ := b["jti"].(string)
+	if jtiA == "" || jtiB == "" {
+		t.Fatalf("Expecting non-empty jti values, got %q and %q", jtiA, jtiB)
+	}
+	if jtiA == jtiB {
+		t.Errorf("Expecting distinct jti values, got %q twice", jtiA)
+	}
+	if decoded, err := DecodeSegment(jtiA); err != nil || len(decoded) != 16 {
+		t.Errorf("Expecting 16 base64url encoded bytes, got %q", jtiA)
+	}
+}
+
+func Test_mapClaims_get_path(t *testing.T) {
+	var claims MapClaims
+	data := `{"sub":"user","resource_access":{"my-app":{"roles":["admin","viewer"]}},"a/b":{"m~n":1}}`
+	if err := json.Unmarshal([]byte(data), &claims); err != nil {
+		t.Fatal(err)
+	}
+
+	var getPathTestData = []struct {
+		pointer string
+		value   interface{}
+		found   bool
+	}{
+		{"/sub", "user", true},
+		{"/resource_access/my-app/roles", []interface{}{"admin", "viewer"}, true},
+		{"/resource_access/my-app/roles/1", "viewer", true},
+		{"/a~1b/m~0n", float64(1), true},
+		{"/resource_access/other-app/roles", nil, false},
+		{"/resource_access/my-app/roles/2", nil, false},
+		{"/resource_access/my-app/roles/01", nil, false},
+		{"/resource_access/my-app/roles/-", nil, false},
+		{"/sub/child", nil, false},
+		{"sub", nil, false},
+	}
+
+	for _, data := range getPathTestData {
+		value, found := claims.GetPath(data.pointer)
+		if found != data.found || !reflect.DeepEqual(value, data.value) {
+			t.Errorf("[%v] Expecting %v %v, got %v %v", data.pointer, data.value, data.found, value, found)
+		}
+	}
+	if value, found := claims.GetPath(""); !found || !reflect.DeepEqual(value, claims) {
+		t.Errorf("Expecting the empty pointer to refer to the claims")
+	}
+}
+
+func Test_mapClaims_scopes(t *testing.T) {
+	var scopesTestData = []struct {
+		name   string
+		claims MapClaims
+		scopes []string
+	}{
+		{"scope string", MapClaims{"scope": "read write"}, []string{"read", "write"}},
+		{"scope extra spaces", MapClaims{"scope": " read  write "}, []string{"read", "write"}},
+		{"scp array", MapClaims{"scp": []interface{}{"read", "write"}}, []string{"read", "write"}},
+		{"scp string", MapClaims{"scp": "read write"}, []string{"read", "write"}},
+		{"missing", MapClaims{}, nil},
+	}
+
+	for _, data := range scopesTestData {
+		if scopes := data.claims.Scopes(); !reflect.DeepEqual(scopes, data.scopes) {
+			t.Errorf("[%v] Expecting %v, got %v", data.name, data.scopes, scopes)
+		}
+		if has := data.claims.HasScope("write"); has != (data.scopes != nil) {
+			t.Errorf("[%v] HasScope(\"write\") returned %v", data.name, has)
+		}
+		if data.claims.HasScope("admin") {
+			t.Errorf("[%v] HasScope(\"admin\") returned true", data.name)
+		}
+	}
+}
+
+func Test_mapClaims_validate_claims(t *testing.T) {
+	now := int64(1500000000)
+	claims := MapClaims{"exp": float64(now - 10), "aud": float64(42), "iss": "issuer"}
+
+	var validateClaimsTestData = []struct {
+		name   string
+		claims []string
+		errors uint32
+	}{
+		{"only exp", []string{"exp"}, ValidationErrorExpired},
+		{"only aud", []string{"aud"}, ValidationErrorAudience},
+		{"only iss", []string{"iss"}, 0},
+		{"exp and aud", []string{"exp", "aud"}, ValidationErrorExpired | ValidationErrorAudience},
+		{"none", nil, 0},
+	}
+
+	for _, data := range validateClaimsTestData {
+		err := claims.ValidateClaims(now, data.claims...)
+		if data.errors == 0 {
+			if err != nil {
+				t.Errorf("[%v] Unexpected error %v", data.name, err)
+			}
+			continue
+		}
+		if ve, ok := err.(*ValidationError); !ok || ve.Errors != data.errors {
+			t.Errorf("[%v] Expecting errors %v, got %v", data.name, data.errors, err)
+		}
+	}
+
+	if err := (MapClaims{"aud": []interface{}{"a", "b"}}).ValidateClaims(now, "aud", "nbf"); err != nil {
+		t.Errorf("[well formed] Unexpected error %v", err)
+	}
+	if err := claims.ValidateClaims(now, "jti"); err == nil {
+		t.Errorf("[unknown] Expecting an error for an unknown claim")
+	} else if _, ok := err.(*ValidationError); ok {
+		t.Errorf("[unknown] Expecting a plain error, got %v", err)
+	}
+}
+
+func Test_mapClaims_valid_at(t *testing.T) {
+	activation := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	claims := MapClaims{
+		"iat": float64(time.Now().Unix()),
+		"nbf": float64(activation.Unix()),
+		"exp": float64(activation.Add(time.Hour).Unix()),
+	}
+
+	if err := claims.Valid(); err == nil {
+		t.Errorf("[now] Expecting the scheduled token to be not valid yet")
+	} else if ve := err.(*ValidationError); ve.Errors != ValidationErrorNotValidYet {
+		t.Errorf("[now] Expecting ValidationErrorNotValidYet, got %v", err)
+	}
+
+	var validAtTestData = []struct {
+		name   string
+		at     time.Time
+		errors uint32
+	}{
+		{"before activation", activation.Add(-time.Second), ValidationErrorNotValidYet},
+		{"at activation", activation, 0},
+		{"during window", activation.Add(30 * time.Minute), 0},
+		{"at expiry", activation.Add(time.Hour), ValidationErrorExpired},
+	}
+
+	for _, data := range validAtTestData {
+		err := claims.ValidAt(data.at)
+		if data.errors == 0 {
+			if err != nil {
+				t.Errorf("[%v] Unexpected error %v", data.name, err)
+			}
+			continue
+		}
+		if ve, ok := err.(*ValidationError); !ok || ve.Errors != data.errors {
+			t.Errorf("[%v] Expecting errors %v, got %v", data.name, data.errors, err)
+		}
+	}
+}
+
+func Test_mapClaims_verify_auth_time(t *testing.T) {
+	now := time.Now().Unix()
+
+	var authTimeTestData = []struct {
+		name     string
+		claims   MapClaims
+		required bool
+		valid    bool
+	}{
+		{"recent", MapClaims{"auth_time": float64(now - 60)}, true, true},
+		{"stale, json.Number", MapClaims{"auth_time": json.Number("1700000000")}, true, false},
+		{"exactly max age", MapClaims{"auth_time": float64(now - 300)}, true, true},
+		{"stale", MapClaims{"auth_time": float64(now - 301)}, true, false},
+		{"missing, required", MapClaims{}, true, false},
+		{"missing, not required", MapClaims{}, false, true},
+	}
+
+	for _, data := range authTimeTestData {
+		if valid := data.claims.VerifyAuthTime(now, 5*time.Minute, data.required); valid != data.valid {
+			t.Errorf("[%v] Expecting %v, got %v", data.name, data.valid, valid)
+		}
+	}
+	if !(MapClaims{"auth_time": json.Number("1700000000")}).VerifyAuthTime(1700000100, 5*time.Minute, true) {
+		t.Errorf("[json.Number] Expecting a recent auth_time to verify")
+	}
+}
+
+func Test_mapClaims_verify_acr_amr(t *testing.T) {
+	var amrTestData = []struct {
+		name     string
+		claims   MapClaims
+		required []string
+		req      bool
+		valid    bool
+	}{
+		{"pwd and mfa, mfa required", MapClaims{"amr": []interface{}{"pwd", "mfa"}}, []string{"mfa"}, true, true},
+		{"pwd and mfa, both required", MapClaims{"amr": []interface{}{"pwd", "mfa"}}, []string{"pwd", "mfa"}, true, true},
+		{"pwd only, mfa required", MapClaims{"amr": []interface{}{"pwd"}}, []string{"mfa"}, true, false},
+		{"pwd only, mfa required, not req", MapClaims{"amr": []interface{}{"pwd"}}, []string{"mfa"}, false, false},
+		{"[]string", MapClaims{"amr": []string{"pwd", "mfa"}}, []string{"mfa"}, true, true},
+		{"missing, req", MapClaims{}, []string{"mfa"}, true, false},
+		{"missing, not req", MapClaims{}, []string{"mfa"}, false, true},
+		{"not an array", MapClaims{"amr": "mfa"}, []string{"mfa"}, true, false},
+	}
+
+	for _, data := range amrTestData {
+		if valid := data.claims.VerifyAMRContains(data.required, data.req); valid != data.valid {
+			t.Errorf("[%v] Expecting %v, got %v", data.name, data.valid, valid)
+		}
+	}
+
+	claims := MapClaims{"amr": []interface{}{"pwd", "mfa"}, "acr": "urn:mace:incommon:iap:silver"}
+	if !claims.HasAMR("mfa") || claims.HasAMR("otp") {
+		t.Errorf("[HasAMR] Expecting mfa and not otp to be listed")
+	}
+	if !claims.VerifyACR("urn:mace:incommon:iap:silver", true) {
+		t.Errorf("[acr match] Expecting the acr claim to verify")
+	}
+	if claims.VerifyACR("urn:mace:incommon:iap:bronze", false) {
+		t.Errorf("[acr mismatch] Expecting the acr claim not to verify")
+	}
+	if (MapClaims{}).VerifyACR("0", true) || !(MapClaims{}).VerifyACR("0", false) {
+		t.Errorf("[acr missing] Expecting only a required acr claim to fail")
+	}
+}
+
+func Test_mapClaims_set_audience(t *testing.T) {
+	defer func(asArray bool) { MarshalSingleStringAsArray = asArray }(MarshalSingleStringAsArray)
+
+	var setAudienceTestData = []struct {
+		name      string
+		audiences []string
+		asArray   bool
+		json      string
+	}{
+		{"one", []string{"api"}, false, `{"aud":"api"}`},
+		{"one, as array", []string{"api"}, true, `{"aud":["api"]}`},
+		{"several", []string{"api", "web"}, false, `{"aud":["api","web"]}`},
+		{"none", nil, false, `{}`},
+	}
+
+	key := []byte("secret")
+	for _, data := range setAudienceTestData {
+		MarshalSingleStringAsArray = data.asArray
+		claims := MapClaims{"aud": "stale"}
+		claims.SetAudience(data.audiences...)
+
+		got, _ := json.Marshal(claims)
+		if string(got) != data.json {
+			t.Errorf("[%v] Expecting %v, got %v", data.name, data.json, string(got))
+		}
+		for _, aud := range data.audiences {
+			if !claims.VerifyAudience(aud, true) {
+				t.Errorf("[%v] Expecting %v to verify before signing", data.name, aud)
+			}
+		}
+
+		tokenString, _ := NewWithClaims(SigningMethodHS256, claims).SignedString(key)
+		parsed := MapClaims{}
+		if _, err := ParseWithClaims(tokenString, parsed, func(*Token) (interface{}, error) { return key, nil }); err != nil {
+			t.Fatalf("[%v] Error while parsing token: %v", data.name, err)
+		}
+		if auds := parsed.audiences(); !reflect.DeepEqual(auds, data.audiences) {
+			t.Errorf("[%v] Expecting %v after the round trip, got %v", data.name, data.audiences, auds)
+		}
+		if len(data.audiences) > 0 {
+			if _, ok := parsed.MatchedAudience(data.audiences[len(data.audiences)-1]); !ok {
+				t.Errorf("[%v] Expecting the audience to match after the round trip", data.name)
+			}
+		}
+	}
+}
+
+func Test_mapClaims_verify_nonce(t *testing.T) {
+	var nonceTestData = []struct {
+		name   string
+		claims MapClaims
+		req    bool
+		valid  bool
+	}{
+		{"matching", MapClaims{"nonce": "n-0S6_WzA2Mj"}, true, true},
+		{"matching, not req", MapClaims{"nonce": "n-0S6_WzA2Mj"}, false, true},
+		{"mismatching", MapClaims{"nonce": "n-other"}, true, false},
+		{"mismatching, not req", MapClaims{"nonce": "n-other"}, false, false},
+		{"missing", MapClaims{}, true, false},
+		{"missing, not req", MapClaims{}, false, true},
+	}
+
+	for _, data := range nonceTestData {
+		if valid := data.claims.VerifyNonce("n-0S6_WzA2Mj", data.req); valid != data.valid {
+			t.Errorf("[%v] Expecting %v, got %v", data.name, data.valid, valid)
+		}
+	}
+}
+
+func Test_mapClaims_should_refresh(t *testing.T) {
+	now := int64(1700000000)
+	var shouldRefreshTestData = []struct {
+		name         string
+		claims       MapClaims
+		beforeExpiry time.Duration
+		refresh      bool
+	}{
+		{"expires in 30s, 60s threshold", MapClaims{"exp": float64(now + 30)}, 60 * time.Second, true},
+		{"expires in 30s, 10s threshold", MapClaims{"exp": float64(now + 30)}, 10 * time.Second, false},
+		{"expires in 30s, 30s threshold", MapClaims{"exp": json.Number("1700000030")}, 30 * time.Second, true},
+		{"expired", MapClaims{"exp": float64(now - 30)}, 10 * time.Second, true},
+		{"no exp", MapClaims{}, time.Hour, false},
+	}
+
+	for _, data := range shouldRefreshTestData {
+		if refresh := data.claims.ShouldRefresh(now, data.beforeExpiry); refresh != data.refresh {
+			t.Errorf("[%v] Expecting %v, got %v", data.name, data.refresh, refresh)
+		}
+	}
+}
+
+func Test_mapClaims_lifetime(t *testing.T) {
+	now := int64(1700000000)
+	unix := func(v int64) *time.Time {
+		t := time.Unix(v, 0).UTC()
+		return &t
+	}
+	var lifetimeTestData = []struct {
+		name          string
+		claims        MapClaims
+		iat, nbf, exp *time.Time
+		valid         time.Duration
+	}{
+		{"all three", MapClaims{"iat": float64(now), "nbf": float64(now + 60), "exp": json.Number("1700003660")}, unix(now), unix(now + 60), unix(now + 3660), time.Hour},
+		{"iat and exp", MapClaims{"iat": float64(now), "exp": float64(now + 300)}, unix(now), nil, unix(now + 300), 5 * time.Minute},
+		{"only exp", MapClaims{"exp": float64(now)}, nil, nil, unix(now), 0},
+		{"none", MapClaims{"sub": "alice"}, nil, nil, nil, 0},
+	}
+
+	equal := func(a, b *time.Time) bool {
+		return a == nil && b == nil || a != nil && b != nil && a.Equal(*b) && a.Location() == time.UTC
+	}
+	for _, data := range lifetimeTestData {
+		iat, nbf, exp, valid := data.claims.Lifetime()
+		if !equal(iat, data.iat) || !equal(nbf, data.nbf) || !equal(exp, data.exp) {
+			t.Errorf("[%v] Expecting %v, %v and %v, got %v, %v and %v", data.name, data.iat, data.nbf, data.exp, iat, nbf, exp)
+		}
+		if valid != data.valid {
+			t.Errorf("[%v] Expecting validity window %v, got %v", data.name, data.valid, valid)
+		}
+	}
+}
+
+func Test_mapClaims_roles(t *testing.T) {
+	paths := []string{"/realm_access/roles", "/resource_access/my-app/roles", "/https:~1~1example.com~1roles", "/cognito:groups"}
+	var rolesTestData = []struct {
+		name   string
+		claims string
+		roles  []string
+	}{
+		{
+			"keycloak",
+			`{"realm_access":{"roles":["user","admin"]},"resource_access":{"my-app":{"roles":["admin","editor"]}}}`,
+			[]string{"user", "admin", "editor"},
+		},
+		{
+			"auth0",
+			`{"https://example.com/roles":["viewer","billing"]}`,
+			[]string{"viewer", "billing"},
+		},
+		{
+			"cognito, single group",
+			`{"cognito:groups":"ops"}`,
+			[]string{"ops"},
+		},
+		{
+			"none",
+			`{"realm_access":{"roles":[1,2]},"cognito:groups":{}}`,
+			nil,
+		},
+	}
+
+	for _, data := range rolesTestData {
+		claims := MapClaims{}
+		if err := json.Unmarshal([]byte(data.claims), &claims); err != nil {
+			t.Fatal(err)
+		}
+		if roles := claims.Roles(paths...); !reflect.DeepEqual(roles, data.roles) {
+			t.Errorf("[%v] Expecting %v, got %v", data.name, data.roles, roles)
+		}
+	}
+}
+
+func Test_mapClaims_verify_hash_claims(t *testing.T) {
+	// From the examples of OpenID Connect Core 1.0, appendix A
+	accessToken := "jHkWEdUXMU1BwAsC4vtUsZwnNvTIxEl0z9K3vx5KF0Y"
+	code := "Qcb0Orv1zh30vL1MPRsbm-diHiMwcLyZvn1arpZv-Jxf_11jnpEX3Tgfvk"
+	claims := MapClaims{"at_hash": "77QmUPtjPfzWtF2AnpK9RQ", "c_hash": "LDktKdoQak3Pk0cnXxCltA"}
+
+	var hashClaimTestData = []struct {
+		name   string
+		verify func() error
+		err    error
+	}{
+		{"at_hash", func() error { return claims.VerifyAtHash(accessToken, "RS256", true) }, nil},
+		{"c_hash", func() error { return claims.VerifyCHash(code, "RS256", true) }, nil},
+		{"at_hash, ES256", func() error { return claims.VerifyAtHash(accessToken, "ES256", true) }, nil},
+		{"at_hash, tampered", func() error { return claims.VerifyAtHash(accessToken+"x", "RS256", true) }, ErrHashClaimMismatch},
+		{"c_hash, tampered", func() error { return claims.VerifyCHash("other", "RS256", false) }, ErrHashClaimMismatch},
+		{"at_hash, other hash", func() error { return claims.VerifyAtHash(accessToken, "RS384", true) }, ErrHashClaimMismatch},
+		{"unknown alg", func() error { return claims.VerifyAtHash(accessToken, "XS256", true) }, ErrHashUnavailable},
+		{"missing, req", func() error { return MapClaims{}.VerifyAtHash(accessToken, "RS256", true) }, ErrHashClaimMissing},
+		{"missing, not req", func() error { return MapClaims{}.VerifyCHash(code, "RS256", false) }, nil},
+	}
+
+	for _, data := range hashClaimTestData {
+		if err := data.verify(); err != data.err {
+			t.Errorf("[%v] Expecting %v, got %v", data.name, data.err, err)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package jwt
+
+import (
+	"strings"
+)
+
+// Parses a nested JWT, RFC 7519 section 5.2: an outer token, signed or
+// encrypted, whose cty header is "JWT" and whose payload is another, signed
+// token.  See Parser.ParseNested.
+func ParseNested(tokenString string, claims Claims, outerKeyFunc, innerKeyFunc Keyfunc) (outer, inner *Token, err error) {
+	return new(Parser).ParseNested(tokenString, claims, outerKeyFunc, innerKeyFunc)
+}
+
+// Parses a nested JWT in two passes.  The outer token is verified first:
+// for a signed outer token outerKeyFunc returns its verification key, as for
+// Parse; for one in the compact JWE form it returns the private key to
+// decrypt it with.  The inner token is then parsed into claims and verified
+// with innerKeyFunc, and only its claims are validated; the outer token has
+// no claims of its own.
+//
+// Both tokens are returned, so that callers can check the outer header, e.g.
+// the kid of a federation operator, next to the inner claims.  inner is nil
+// if the outer token failed, in which case err is the outer error.
+func (p *Parser) ParseNested(tokenString string, claims Claims, outerKeyFunc, innerKeyFunc Keyfunc) (outer, inner *Token, err error) {
+	if p.hooks == nil {
+		return p.parseNested(tokenString, claims, outerKeyFunc, innerKeyFunc)
+	}
+	// One parse to the hooks, reported with the inner token if it was reached
+	p.hooks.parseStart()
+	outer, inner, err = p.parseNested(tokenString, claims, outerKeyFunc, innerKeyFunc)
+	if inner != nil {
+		p.hooks.parseDone(inner, err)
+	} else {
+		p.hooks.parseDone(outer, err)
+	}
+	return outer, inner, err
+}
+
+func (p *Parser) parseNested(tokenString string, claims Claims, outerKeyFunc, innerKeyFunc Keyfunc) (outer, inner *Token, err error) {
+	if err := p.checkTokenSize(len(tokenString)); err != nil {
+		return nil, nil, err
+	}
+	var payload []byte
+	if strings.Count(tokenString, ".") == 4 {
+		outer, payload, err = p.decryptOuter(tokenString, outerKeyFunc)
+	} else {
+		outer, payload, err = p.verifyOuter(tokenString, outerKeyFunc)
+	}
+	if err != nil {
+		return outer, nil, err
+	}
+	if cty, _ := outer.Header["cty"].(string); !strings.EqualFold(cty, "JWT") {
+		return outer, nil, NewValidationError("token is not a nested JWT, its cty header is not JWT", ValidationErrorMalformed)
+	}
+
+	nested := string(payload)
+	if strings.Count(nested, ".") != 2 {
+		return outer, nil, NewValidationError("nested token is not a signed JWT", ValidationErrorMalformed)
+	}
+	inner, err = p.parseToken(nested, nil, claims, innerKeyFunc)
+	return outer, inner, err
+}
+
+// Verifies the signature of an outer JWS, returning its payload
+func (p *Parser) verifyOuter(tokenString string, keyFunc Keyfunc) (*Token, []byte, error) {
+	parts := strings.Split(tokenString, ".")
+	if len(parts) != 3 {
+		return nil, nil, NewValidationError("token contains an invalid number of segments", ValidationErrorMalformed)
+	}
+
+	token := &Token{Raw: tokenString, Claims: MapClaims{}, Signature: parts[2]}
+	if err := p.decodeHeader(token, parts[0]); err != nil {
+		return token, nil, err
+	}
+	payload, err := p.decodeSegment(parts[1])
+	if err != nil {
+		return token, nil, newSegmentError(SegmentClaims, err)
+	}
+	key, err := p.resolveKey(token, keyFunc)
+	if err != nil {
+		return token, nil, err
+	}
+	if err := p.verifySignature(token, tokenString, parts, key); err != nil {
+		return token, nil, &ValidationError{Inner: err, Errors: ValidationErrorSignatureInvalid}
+	}
+	token.SignatureValid = true
+	token.Valid = true
+	return token, payload, nil
+}
+
+// Decrypts an outer JWE with the key returned by keyFunc, returning its
+// plaintext
+func (p *Parser) decryptOuter(tokenString string, keyFunc Keyfunc) (*Token, []byte, error) {
+	token := &Token{Raw: tokenString, Claims: MapClaims{}}
+	headerBytes, err := p.decodeSegment(tokenString[:strings.IndexByte(tokenString, '.')])
+	if err != nil {
+		return token, nil, newSegmentError(SegmentHeader, err)
+	}
+	if err := p.json().Unmarshal(headerBytes, &token.Header); err != nil {
+		return token, nil, newSegmentError(SegmentHeader, err)
+	}
+
+	// Encrypted tokens have no signing method, ValidMethods doesn't apply
+	if keyFunc == nil {
+		return token, nil, NewValidationError("no Keyfunc was provided.", ValidationErrorUnverifiable)
+	}
+	key, err := keyFunc(token)
+	if err != nil {
+		if ve, ok := err.(*ValidationError); ok {
+			return token, nil, ve
+		}
+		return token, nil, &ValidationError{Inner: err, Errors: ValidationErrorUnverifiable}
+	}
+
+	_, plaintext, err := decryptJWE(tokenString, key, p.json())
+	if err == ErrJWEMalformed {
+		return token, nil, &ValidationError{Inner: err, Errors: ValidationErrorMalformed}
+	} else if err != nil {
+		return token, nil, &ValidationError{Inner: err, Errors: ValidationErrorUnverifiable}
+	}
+	token.Valid = true
+	return token, plaintext, nil
+}
@@ -0,0 +1,73 @@
+package jwt_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/form3tech-oss/jwt-go"
+	"github.com/form3tech-oss/jwt-go/test"
+)
+
+// Signs inner as the payload of an outer ES256 token with header
+func makeNestedToken(t *testing.T, inner string, header map[string]interface{}) string {
+	header["alg"] = "ES256"
+	headerJSON, _ := json.Marshal(header)
+	signingString := jwt.EncodeSegment(headerJSON) + "." + jwt.EncodeSegment([]byte(inner))
+	sig, err := jwt.SigningMethodES256.Sign(signingString, test.LoadECPrivateKeyFromDisk("test/ec256-private.pem"))
+	if err != nil {
+		t.Fatalf("Error signing outer token: %v", err)
+	}
+	return signingString + "." + sig
+}
+
+func TestParseNested(t *testing.T) {
+	rsaKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	ecPublicKey := test.LoadECPublicKeyFromDisk("test/ec256-public.pem")
+	outerKeyFunc := func(*jwt.Token) (interface{}, error) { return ecPublicKey, nil }
+	now := time.Now().Unix()
+
+	innerString := test.MakeSampleToken(jwt.MapClaims{"sub": "alice", "exp": now + 100}, rsaKey)
+	nested := makeNestedToken(t, innerString, map[string]interface{}{"cty": "JWT", "kid": "operator-1"})
+
+	outer, inner, err := jwt.ParseNested(nested, jwt.MapClaims{}, outerKeyFunc, defaultKeyFunc)
+	if err != nil {
+		t.Fatalf("Error while parsing nested token: %v", err)
+	}
+	if !outer.Valid || outer.Method != jwt.SigningMethodES256 || outer.Header["kid"] != "operator-1" {
+		t.Errorf("Expecting the verified outer token, got %v", outer.Header)
+	}
+	if !inner.Valid || inner.Raw != innerString || inner.Claims.(jwt.MapClaims)["sub"] != "alice" {
+		t.Errorf("Expecting the verified inner token, got %v", inner.Claims)
+	}
+
+	// An outer JWE is decrypted with the key of the outer Keyfunc
+	jwe, _ := jwt.EncryptToken(innerString, jwt.JWEAlgRSAOAEP256, jwt.JWEEncA256GCM, &rsaKey.PublicKey)
+	outer, inner, err = jwt.ParseNested(jwe, jwt.MapClaims{}, func(*jwt.Token) (interface{}, error) { return rsaKey, nil }, defaultKeyFunc)
+	if err != nil || outer.Header["enc"] != jwt.JWEEncA256GCM || inner.Claims.(jwt.MapClaims)["sub"] != "alice" {
+		t.Errorf("[JWE] Error while parsing nested token: %v", err)
+	}
+
+	var nestedTestData = []struct {
+		name        string
+		tokenString string
+		outerKey    interface{}
+		errors      uint32
+		hasInner    bool
+	}{
+		{"wrong outer key", nested, &rsaKey.PublicKey, jwt.ValidationErrorSignatureInvalid, false},
+		{"no cty", makeNestedToken(t, innerString, map[string]interface{}{}), ecPublicKey, jwt.ValidationErrorMalformed, false},
+		{"claims payload", makeNestedToken(t, `{"sub":"alice"}`, map[string]interface{}{"cty": "JWT"}), ecPublicKey, jwt.ValidationErrorMalformed, false},
+		{"expired inner", makeNestedToken(t, test.MakeSampleToken(jwt.MapClaims{"exp": now - 100}, rsaKey), map[string]interface{}{"cty": "JWT"}), ecPublicKey, jwt.ValidationErrorExpired, true},
+		{"JWE with the wrong key", jwe, test.LoadECPrivateKeyFromDisk("test/ec256-private.pem"), jwt.ValidationErrorUnverifiable, false},
+	}
+	for _, data := range nestedTestData {
+		outer, inner, err := jwt.ParseNested(data.tokenString, jwt.MapClaims{}, func(*jwt.Token) (interface{}, error) { return data.outerKey, nil }, defaultKeyFunc)
+		if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != data.errors {
+			t.Errorf("[%v] Expecting error bits %v, got %v", data.name, data.errors, err)
+		}
+		if outer == nil || (inner != nil) != data.hasInner {
+			t.Errorf("[%v] Expecting the outer token, and the inner token only if the outer one verified", data.name)
+		}
+	}
+}
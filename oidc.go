@@ -0,0 +1,167 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrOIDCIssuerMismatch = errors.New("oidc: discovery document issuer does not match the issuer URL")
+	ErrOIDCNoJWKSURI      = errors.New("oidc: discovery document has no jwks_uri")
+	ErrOIDCInsecureIssuer = errors.New("oidc: issuer URL is not an https URL")
+)
+
+// The signing methods ValidateIDToken accepts.  Symmetric ones are left out,
+// the client secret is no key to verify a provider with.
+var IDTokenMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// The discoveries of the issuers ValidateIDToken was called with, in
+// progress or done
+var idTokenKeyfuncs = struct {
+	sync.Mutex
+	m map[string]*idTokenDiscovery
+}{m: make(map[string]*idTokenDiscovery)}
+
+// The discovery of an issuer, whose Keyfunc is set once it succeeded
+type idTokenDiscovery struct {
+	*flight
+	keyFunc Keyfunc
+}
+
+// Returns a Keyfunc for the tokens of an OpenID Connect issuer, such as
+// "https://accounts.example.com".  issuerURL must be an https URL or
+// ErrOIDCInsecureIssuer is returned.  It fetches the issuer's discovery
+// document from /.well-known/openid-configuration, checks that its issuer is
+// issuerURL, and fetches the JWK set its jwks_uri points to, which must be
+// an https URL too or ErrJWKSInsecureURL is returned.
+//
+// The Keyfunc is that of a JWKS for the jwks_uri, without the background
+// refresh: a kid that isn't in the cached set causes the set to be fetched
+// again, at most once a minute, so that rotated keys are picked up.  ctx
+// only bounds the initial fetches.  The Keyfunc doesn't check the iss claim
+// of tokens; do that as part of claims validation.
+func NewOIDCKeyfunc(ctx context.Context, issuerURL string) (Keyfunc, error) {
+	var discovery struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	// The discovery document is the trust anchor, so it must not be
+	// fetched over plain http
+	if err := checkHTTPSURL("oidc", issuerURL, ErrOIDCInsecureIssuer); err != nil {
+		return nil, err
+	}
+	configURL := strings.TrimSuffix(issuerURL, "/") + "/.well-known/openid-configuration"
+	data, err := fetchURL(ctx, defaultHTTPClient(), configURL)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: %v", err)
+	}
+	if err := DefaultJSONCodec.Unmarshal(data, &discovery); err != nil {
+		return nil, fmt.Errorf("oidc: decoding %v: %v", configURL, err)
+	}
+	if discovery.Issuer != issuerURL {
+		return nil, ErrOIDCIssuerMismatch
+	}
+	if discovery.JWKSURI == "" {
+		return nil, ErrOIDCNoJWKSURI
+	}
+	if err := checkJWKSURL(discovery.JWKSURI); err != nil {
+		return nil, err
+	}
+
+	k := newJWKS(discovery.JWKSURI)
+	if err := k.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return k.Keyfunc, nil
+}
+
+// Parses raw, an OpenID Connect ID token, and validates it as the relying
+// party clientID, following OpenID Connect Core section 3.1.3.7:
+//
+//   - the signature verifies with a key of issuer, found by discovery as with
+//     NewOIDCKeyfunc, with one of IDTokenMethods
+//   - iss is issuer and aud contains clientID
+//   - azp, if present, is clientID, and is present if aud has several entries
+//   - sub, exp and iat are present and exp and iat are valid
+//   - nonce matches, unless nonce is empty
+//
+// The Keyfunc of each issuer is kept for later calls, so discovery happens
+// once per process, shared by the calls made while it is in progress; ctx
+// bounds the wait for it.  options are applied after these
+// checks, e.g. WithMaxAuthAge for the max_age of the authentication request,
+// or WithAllowedSkew.  Failures are reported as a *ValidationError.
+func ValidateIDToken(ctx context.Context, raw, clientID, issuer, nonce string, options ...ParserOption) (*Token, error) {
+	keyFunc, err := idTokenKeyfunc(ctx, issuer)
+	if err != nil {
+		return nil, &ValidationError{Inner: err, Errors: ValidationErrorUnverifiable}
+	}
+
+	parserOptions := []ParserOption{
+		WithValidMethods(IDTokenMethods),
+		WithIssuer(issuer),
+		WithAudience(clientID),
+		WithExpirationRequired(),
+	}
+	if nonce != "" {
+		parserOptions = append(parserOptions, WithNonce(nonce))
+	}
+	token, err := NewParser(append(parserOptions, options...)...).Parse(raw, keyFunc)
+	if err != nil {
+		return token, err
+	}
+
+	claims := token.Claims.(MapClaims)
+	for _, name := range []string{"sub", "iat"} {
+		if _, ok := claims[name]; !ok {
+			token.Valid = false
+			return token, NewValidationError(fmt.Sprintf("ID token has no %v claim", name), ValidationErrorClaimsInvalid)
+		}
+	}
+	azp, hasAzp := claims["azp"]
+	if hasAzp && azp != clientID {
+		token.Valid = false
+		return token, NewValidationError(fmt.Sprintf("ID token azp %q is not %q", azp, clientID), ValidationErrorAudience)
+	}
+	if aud, _ := claims["aud"].([]interface{}); len(aud) > 1 && !hasAzp {
+		token.Valid = false
+		return token, NewValidationError("ID token with several audiences has no azp claim", ValidationErrorAudience)
+	}
+	return token, nil
+}
+
+// The Keyfunc of issuer, from the cache or by discovery.  Only the
+// discovery of issuer is waited for, other issuers are not held up by it.
+func idTokenKeyfunc(ctx context.Context, issuer string) (Keyfunc, error) {
+	idTokenKeyfuncs.Lock()
+	d, ok := idTokenKeyfuncs.m[issuer]
+	if !ok {
+		d = &idTokenDiscovery{flight: newFlight()}
+		idTokenKeyfuncs.m[issuer] = d
+		go d.discover(issuer)
+	}
+	idTokenKeyfuncs.Unlock()
+
+	if err := d.wait(ctx); err != nil {
+		return nil, err
+	}
+	return d.keyFunc, nil
+}
+
+// Runs the discovery of issuer for the callers waiting for d.  A failed
+// discovery is forgotten, so that the next call tries again.
+func (d *idTokenDiscovery) discover(issuer string) {
+	ctx, cancel := context.WithTimeout(context.Background(), jwksFetchTimeout)
+	defer cancel()
+	keyFunc, err := NewOIDCKeyfunc(ctx, issuer)
+
+	idTokenKeyfuncs.Lock()
+	defer idTokenKeyfuncs.Unlock()
+	if err != nil {
+		delete(idTokenKeyfuncs.m, issuer)
+	}
+	d.keyFunc = keyFunc
+	d.finish(err)
+}
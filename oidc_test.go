@@ -0,0 +1,238 @@
+package jwt_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/form3tech-oss/jwt-go"
+	"github.com/form3tech-oss/jwt-go/test"
+)
+
+// Has the default transport trust the certificate of server, as discovery
+// uses it.  Returns the function restoring it.
+func trustServer(server *httptest.Server) func() {
+	transport := http.DefaultTransport
+	http.DefaultTransport = server.Client().Transport
+	return func() { http.DefaultTransport = transport }
+}
+
+func TestNewOIDCKeyfunc(t *testing.T) {
+	key := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	jwk := makeSampleJWK(&key.PublicKey)
+	jwk["kid"] = "rsa-1"
+
+	var issuer string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"issuer": issuer, "jwks_uri": issuer + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []interface{}{jwk}})
+	})
+	server := httptest.NewTLSServer(mux)
+	defer server.Close()
+	defer trustServer(server)()
+	issuer = server.URL
+
+	keyFunc, err := jwt.NewOIDCKeyfunc(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Error creating keyfunc: %v", err)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"iss": issuer})
+	token.Header["kid"] = "rsa-1"
+	tokenString, _ := token.SignedString(key)
+	if _, err := jwt.Parse(tokenString, keyFunc); err != nil {
+		t.Errorf("[rsa-1] Error while parsing token: %v", err)
+	}
+
+	token.Header["kid"] = "rsa-2"
+	tokenString, _ = token.SignedString(key)
+	if _, err := jwt.Parse(tokenString, keyFunc); err == nil || err.(*jwt.ValidationError).Inner != jwt.ErrKidUnknown {
+		t.Errorf("[rsa-2] Expecting ErrKidUnknown, got %v", err)
+	}
+
+	// Once the refresh interval passed, an unknown kid refetches the keys
+	defer func() { jwt.TimeFunc = time.Now }()
+	jwt.TimeFunc = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	jwk["kid"] = "rsa-2"
+	if _, err := jwt.Parse(tokenString, keyFunc); err != nil {
+		t.Errorf("[rotated rsa-2] Error while parsing token: %v", err)
+	}
+
+	// The discovery document must name the issuer it was fetched from
+	if _, err := jwt.NewOIDCKeyfunc(context.Background(), server.URL+"/"); err != jwt.ErrOIDCIssuerMismatch {
+		t.Errorf("[mismatch] Expecting ErrOIDCIssuerMismatch, got %v", err)
+	}
+	if _, err := jwt.NewOIDCKeyfunc(context.Background(), server.URL+"/tenant"); err == nil {
+		t.Errorf("[not found] Expecting an error for a missing discovery document")
+	}
+
+	// The keys must be fetched over https too
+	plain := httptest.NewServer(mux)
+	defer plain.Close()
+	mux.HandleFunc("/plain/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"issuer": server.URL + "/plain", "jwks_uri": plain.URL + "/keys"})
+	})
+	if _, err := jwt.NewOIDCKeyfunc(context.Background(), server.URL+"/plain"); err != jwt.ErrJWKSInsecureURL {
+		t.Errorf("[http jwks_uri] Expecting ErrJWKSInsecureURL, got %v", err)
+	}
+
+	// And so must the discovery document, whatever it names
+	discovered := false
+	plainIssuer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		discovered = true
+		json.NewEncoder(w).Encode(map[string]string{"issuer": "http://" + r.Host, "jwks_uri": server.URL + "/keys"})
+	}))
+	defer plainIssuer.Close()
+	if _, err := jwt.NewOIDCKeyfunc(context.Background(), plainIssuer.URL); err != jwt.ErrOIDCInsecureIssuer || discovered {
+		t.Errorf("[http issuer] Expecting ErrOIDCInsecureIssuer before fetching, got %v", err)
+	}
+}
+
+func TestValidateIDToken(t *testing.T) {
+	key := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	jwk := makeSampleJWK(&key.PublicKey)
+	jwk["kid"] = "rsa-1"
+
+	var issuer string
+	discoveries := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		discoveries++
+		json.NewEncoder(w).Encode(map[string]string{"issuer": issuer, "jwks_uri": issuer + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []interface{}{jwk}})
+	})
+	server := httptest.NewTLSServer(mux)
+	defer server.Close()
+	defer trustServer(server)()
+	issuer = server.URL
+
+	now := time.Now().Unix()
+	idToken := func(modify func(jwt.MapClaims)) string {
+		claims := jwt.MapClaims{"iss": issuer, "sub": "248289761001", "aud": "s6BhdRkqt3", "exp": now + 300, "iat": now, "nonce": "n-0S6_WzA2Mj", "auth_time": now - 60}
+		if modify != nil {
+			modify(claims)
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "rsa-1"
+		tokenString, _ := token.SignedString(key)
+		return tokenString
+	}
+
+	// Signed with the client secret, which anyone registered could do
+	hmacToken, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"iss": issuer}).SignedString([]byte("s6BhdRkqt3"))
+
+	var idTokenTestData = []struct {
+		name    string
+		raw     string
+		nonce   string
+		options []jwt.ParserOption
+		errors  uint32
+	}{
+		{"valid", idToken(nil), "n-0S6_WzA2Mj", nil, 0},
+		{"no nonce requested", idToken(func(c jwt.MapClaims) { delete(c, "nonce") }), "", nil, 0},
+		{"azp of the client", idToken(func(c jwt.MapClaims) { c["aud"] = []string{"s6BhdRkqt3", "api"}; c["azp"] = "s6BhdRkqt3" }), "n-0S6_WzA2Mj", nil, 0},
+		{"other nonce", idToken(nil), "other", nil, jwt.ValidationErrorClaimsInvalid},
+		{"other issuer", idToken(func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }), "n-0S6_WzA2Mj", nil, jwt.ValidationErrorIssuer},
+		{"other audience", idToken(func(c jwt.MapClaims) { c["aud"] = "other-client" }), "n-0S6_WzA2Mj", nil, jwt.ValidationErrorAudience},
+		{"azp of another client", idToken(func(c jwt.MapClaims) { c["azp"] = "other-client" }), "n-0S6_WzA2Mj", nil, jwt.ValidationErrorAudience},
+		{"several audiences without azp", idToken(func(c jwt.MapClaims) { c["aud"] = []string{"s6BhdRkqt3", "api"} }), "n-0S6_WzA2Mj", nil, jwt.ValidationErrorAudience},
+		{"no sub", idToken(func(c jwt.MapClaims) { delete(c, "sub") }), "n-0S6_WzA2Mj", nil, jwt.ValidationErrorClaimsInvalid},
+		{"no exp", idToken(func(c jwt.MapClaims) { delete(c, "exp") }), "n-0S6_WzA2Mj", nil, jwt.ValidationErrorClaimsInvalid},
+		{"expired", idToken(func(c jwt.MapClaims) { c["exp"] = now - 100 }), "n-0S6_WzA2Mj", nil, jwt.ValidationErrorExpired},
+		{"login too long ago", idToken(nil), "n-0S6_WzA2Mj", []jwt.ParserOption{jwt.WithMaxAuthAge(time.Second)}, jwt.ValidationErrorClaimsInvalid},
+		{"HMAC", hmacToken, "", nil, jwt.ValidationErrorSignatureInvalid},
+	}
+
+	for _, data := range idTokenTestData {
+		token, err := jwt.ValidateIDToken(context.Background(), data.raw, "s6BhdRkqt3", issuer, data.nonce, data.options...)
+		if data.errors == 0 {
+			if err != nil || !token.Valid {
+				t.Errorf("[%v] Error while validating ID token: %v", data.name, err)
+			}
+			continue
+		}
+		if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != data.errors {
+			t.Errorf("[%v] Expecting error bits %v, got %v", data.name, data.errors, err)
+		}
+	}
+	if discoveries != 1 {
+		t.Errorf("Expecting a single discovery, got %d", discoveries)
+	}
+
+	if _, err := jwt.ValidateIDToken(context.Background(), idToken(nil), "s6BhdRkqt3", server.URL+"/tenant", ""); err == nil {
+		t.Errorf("Expecting an error for an issuer without discovery document")
+	}
+}
+
+func TestValidateIDToken_sharedDiscovery(t *testing.T) {
+	key := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	jwk := makeSampleJWK(&key.PublicKey)
+	jwk["kid"] = "rsa-1"
+
+	var issuer string
+	var mu sync.Mutex
+	discoveries := 0
+	release := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		discoveries++
+		mu.Unlock()
+		<-release
+		json.NewEncoder(w).Encode(map[string]string{"issuer": issuer, "jwks_uri": issuer + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []interface{}{jwk}})
+	})
+	server := httptest.NewTLSServer(mux)
+	defer server.Close()
+	defer trustServer(server)()
+	issuer = server.URL
+
+	now := time.Now().Unix()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"iss": issuer, "sub": "248289761001", "aud": "s6BhdRkqt3", "exp": now + 300, "iat": now})
+	token.Header["kid"] = "rsa-1"
+	idToken, _ := token.SignedString(key)
+
+	// A caller whose context is done stops waiting, the discovery goes on
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := jwt.ValidateIDToken(ctx, idToken, "s6BhdRkqt3", issuer, ""); err == nil || err.(*jwt.ValidationError).Inner != context.DeadlineExceeded {
+		t.Errorf("[deadline] Expecting context.DeadlineExceeded, got %v", err)
+	}
+
+	// Calls made meanwhile wait for the same discovery
+	var wg sync.WaitGroup
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := jwt.ValidateIDToken(context.Background(), idToken, "s6BhdRkqt3", issuer, "")
+			errs <- err
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Error while validating ID token: %v", err)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if discoveries != 1 {
+		t.Errorf("Expecting a single discovery, got %d", discoveries)
+	}
+}
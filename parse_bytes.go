@@ -0,0 +1,110 @@
+package jwt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"sync"
+)
+
+// Like Parse, but for a token held in a byte slice, e.g. a network buffer.
+// The header and claims are base64 decoded straight from the slice into a
+// single buffer, which saves allocations over the string API on hot paths.
+// token is copied once, into Token.Raw, so the slice may be reused once
+// ParseBytes returns.  The results are those of Parse(string(token)).
+func ParseBytes(token []byte, keyFunc Keyfunc) (*Token, error) {
+	return new(Parser).ParseBytes(token, keyFunc)
+}
+
+// Like ParseWithClaims, for a token held in a byte slice.  See ParseBytes.
+func ParseBytesWithClaims(token []byte, claims Claims, keyFunc Keyfunc) (*Token, error) {
+	return new(Parser).ParseBytesWithClaims(token, claims, keyFunc)
+}
+
+// Parser form of ParseBytes
+func (p *Parser) ParseBytes(token []byte, keyFunc Keyfunc) (*Token, error) {
+	return p.ParseBytesWithClaims(token, MapClaims{}, keyFunc)
+}
+
+// Parser form of ParseBytesWithClaims
+func (p *Parser) ParseBytesWithClaims(token []byte, claims Claims, keyFunc Keyfunc) (*Token, error) {
+	return p.parseWithClaims(string(token), token, claims, keyFunc)
+}
+
+// Decodes segment i of parts, taking the bytes from raw when it holds the
+// token parts were split from.  The segments decoded from raw share the
+// scratch buffer, which is consumed as they are.
+func (p *Parser) decodeRawSegment(raw []byte, parts []string, i int, scratch *[]byte) ([]byte, error) {
+	if raw == nil {
+		return p.decodeSegment(parts[i])
+	}
+	offset := 0
+	for _, part := range parts[:i] {
+		offset += len(part) + 1
+	}
+	seg := raw[offset : offset+len(parts[i])]
+	if p.paddingAllowed {
+		seg = bytes.TrimRight(seg, "=")
+	}
+	dec := *scratch
+	n, err := base64.RawURLEncoding.Decode(dec, seg)
+	*scratch = dec[n:]
+	return dec[:n:n], err
+}
+
+// Buffers the header and claims of tokens are decoded into, reused across
+// parses
+var segmentPool sync.Pool
+
+type segmentBuffer struct {
+	raw     []byte
+	scratch []byte
+}
+
+// Returns the bytes decodeRawSegment decodes the header and claims of parts
+// from, and its scratch buffer.  When nothing retains the decoded bytes, i.e.
+// the JSON codec is the standard one and no claim transformer sees them, the
+// scratch buffer comes from the pool, and so does a copy of the signing input
+// if raw is nil; return sb with putSegments once the claims are decoded.
+// Otherwise scratch is new, and raw nil if it was, for decodeSegment.
+func (p *Parser) segmentBuffers(tokenString string, raw []byte, parts []string) (sb *segmentBuffer, r, scratch []byte) {
+	n := base64.RawURLEncoding.DecodedLen(len(parts[0]) + len(parts[1]))
+	if _, ok := p.json().(stdJSONCodec); !ok || len(p.claimTransformers) > 0 || len(p.claimDecryptors) > 0 {
+		if raw == nil {
+			return nil, nil, nil
+		}
+		return nil, raw, make([]byte, n)
+	}
+
+	if sb, _ = segmentPool.Get().(*segmentBuffer); sb == nil {
+		sb = new(segmentBuffer)
+	}
+	if raw == nil {
+		sb.raw = append(sb.raw[:0], tokenString[:len(parts[0])+1+len(parts[1])]...)
+		raw = sb.raw
+	}
+	if cap(sb.scratch) < n {
+		sb.scratch = make([]byte, n)
+	}
+	return sb, raw, sb.scratch[:n]
+}
+
+func putSegments(sb *segmentBuffer) {
+	if sb != nil {
+		segmentPool.Put(sb)
+	}
+}
+
+// Splits tokenString at its dots into segments, returning false if it does
+// not have exactly three
+func splitToken(tokenString string, segments *[3]string) bool {
+	for i := 0; i < 2; i++ {
+		dot := strings.IndexByte(tokenString, '.')
+		if dot < 0 {
+			return false
+		}
+		segments[i], tokenString = tokenString[:dot], tokenString[dot+1:]
+	}
+	segments[2] = tokenString
+	return strings.IndexByte(tokenString, '.') < 0
+}
@@ -0,0 +1,90 @@
+package jwt_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/form3tech-oss/jwt-go"
+	"github.com/form3tech-oss/jwt-go/test"
+)
+
+func TestParseBytes(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	valid, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"foo": "bar", "aud": []interface{}{"a", "b"}}).SignedString(key)
+	expired, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"exp": float64(1)}).SignedString(key)
+	forged, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"foo": "bar"}).SignedString([]byte("other"))
+
+	var parseBytesTestData = []struct {
+		name        string
+		tokenString string
+		parser      *jwt.Parser
+	}{
+		{"valid", valid, new(jwt.Parser)},
+		{"expired", expired, new(jwt.Parser)},
+		{"invalid signature", forged, new(jwt.Parser)},
+		{"malformed", "foo.bar", new(jwt.Parser)},
+		{"bad base64", "a*b.c.d", new(jwt.Parser)},
+		{"padded", valid[:len(valid)-1] + "=", jwt.NewParser(jwt.WithPaddingAllowed())},
+		{"padded, not allowed", valid + "=", new(jwt.Parser)},
+		{"json number", valid, &jwt.Parser{UseJSONNumber: true}},
+	}
+
+	for _, data := range parseBytesTestData {
+		buf := []byte(data.tokenString)
+		fromBytes, bytesErr := data.parser.ParseBytes(buf, keyFunc)
+		fromString, stringErr := data.parser.Parse(data.tokenString, keyFunc)
+
+		if !reflect.DeepEqual(bytesErr, stringErr) {
+			t.Errorf("[%v] Expecting error %v, got %v", data.name, stringErr, bytesErr)
+		}
+		if !reflect.DeepEqual(fromBytes, fromString) {
+			t.Errorf("[%v] Expecting token %+v, got %+v", data.name, fromString, fromBytes)
+		}
+
+		// The token must not alias the buffer
+		for i := range buf {
+			buf[i] = '.'
+		}
+		if fromBytes != nil && fromBytes.Raw != data.tokenString {
+			t.Errorf("[%v] Token changed with the buffer: %v", data.name, fromBytes.Raw)
+		}
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	key := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"foo": "bar", "sub": "1234567890"}).SignedString(key)
+	buf := []byte(tokenString)
+	keyFunc := func(*jwt.Token) (interface{}, error) { return &key.PublicKey, nil }
+
+	b.Run("string", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := jwt.Parse(string(buf), keyFunc); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("bytes", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := jwt.ParseBytes(buf, keyFunc); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkParseHS256(b *testing.B) {
+	key := []byte("secret")
+	tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"foo": "bar", "sub": "1234567890"}).SignedString(key)
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := jwt.Parse(tokenString, keyFunc); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package jwt
+
+import "context"
+
+// A Keyfunc that is passed the context of the parse, so that key sources
+// such as a JWKS endpoint, a KMS or a database can honor its cancellation
+// and deadline.  See ParseWithContext.
+type KeyfuncCtx func(ctx context.Context, token *Token) (interface{}, error)
+
+// Like Parse, but passes ctx to keyFunc.  See Parser.ParseWithClaimsContext.
+func ParseWithContext(ctx context.Context, tokenString string, keyFunc KeyfuncCtx) (*Token, error) {
+	return new(Parser).ParseWithContext(ctx, tokenString, keyFunc)
+}
+
+// Parser form of ParseWithContext
+func (p *Parser) ParseWithContext(ctx context.Context, tokenString string, keyFunc KeyfuncCtx) (*Token, error) {
+	return p.ParseWithClaimsContext(ctx, tokenString, MapClaims{}, keyFunc)
+}
+
+// Like ParseWithClaims, but passes ctx to keyFunc, to the ReplayDetector of
+// WithReplayDetector and to the VerificationStore of WithVerificationStore.
+// If ctx is done before the key is looked up, keyFunc isn't called and the
+// parse fails with ValidationErrorUnverifiable and ctx.Err() as the Inner
+// error; keyFunc returning that error has the same result.
+func (p *Parser) ParseWithClaimsContext(ctx context.Context, tokenString string, claims Claims, keyFunc KeyfuncCtx) (*Token, error) {
+	// A copy carries ctx to the ReplayDetector and the VerificationStore, p
+	// may be in concurrent use
+	pc := *p
+	pc.ctx = ctx
+	return pc.ParseWithClaims(tokenString, claims, func(token *Token) (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		return keyFunc(ctx, token)
+	})
+}
+
+// The context of the parse, from ParseWithClaimsContext, or
+// context.Background()
+func (p *Parser) context() context.Context {
+	if p.ctx == nil {
+		return context.Background()
+	}
+	return p.ctx
+}
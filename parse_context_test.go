@@ -0,0 +1,56 @@
+package jwt_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/form3tech-oss/jwt-go"
+)
+
+type parseContextKey struct{}
+
+func TestParseWithContext(t *testing.T) {
+	key := []byte("secret")
+	tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"foo": "bar"}).SignedString(key)
+
+	// The Keyfunc receives the context of the parse
+	ctx := context.WithValue(context.Background(), parseContextKey{}, "request-1")
+	var got interface{}
+	token, err := jwt.ParseWithContext(ctx, tokenString, func(ctx context.Context, token *jwt.Token) (interface{}, error) {
+		got = ctx.Value(parseContextKey{})
+		return key, nil
+	})
+	if err != nil || !token.Valid {
+		t.Errorf("[valid] Error while parsing token: %v", err)
+	}
+	if got != "request-1" {
+		t.Errorf("[valid] Expecting the Keyfunc to receive the context, got %v", got)
+	}
+
+	// A done context fails the parse without calling the Keyfunc
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	called := false
+	_, err = jwt.ParseWithContext(cancelled, tokenString, func(context.Context, *jwt.Token) (interface{}, error) {
+		called = true
+		return key, nil
+	})
+	if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorUnverifiable || ve.Inner != context.Canceled {
+		t.Errorf("[cancelled] Expecting ValidationErrorUnverifiable with context.Canceled, got %v", err)
+	}
+	if called {
+		t.Errorf("[cancelled] Keyfunc should not be called")
+	}
+
+	// A Keyfunc can give up once the deadline passes
+	deadline, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = new(jwt.Parser).ParseWithClaimsContext(deadline, tokenString, &jwt.StandardClaims{}, func(ctx context.Context, token *jwt.Token) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	if ve, ok := err.(*jwt.ValidationError); !ok || ve.Inner != context.DeadlineExceeded {
+		t.Errorf("[deadline] Expecting context.DeadlineExceeded, got %v", err)
+	}
+}
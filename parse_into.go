@@ -0,0 +1,46 @@
+//go:build go1.18
+// +build go1.18
+
+package jwt
+
+// Parses, validates, and returns a token, with its claims decoded into a T,
+// typically a struct with a field per claim.  Unlike ParseWithClaims there's
+// no need for T to implement Claims:
+//
+//	type AppClaims struct {
+//		Scope string `json:"scope"`
+//	}
+//	token, claims, err := jwt.ParseInto[AppClaims](tokenString, keyFunc)
+//
+// If *T implements Claims, for example by embedding RegisteredClaims or
+// StandardClaims, its Valid method validates the claims and token.Claims is
+// the *T.  Otherwise exp, iat and nbf are validated as MapClaims would,
+// from the claims segment whatever the fields of T, and token.Claims holds
+// the claims as MapClaims.
+//
+// The parser is created from opts with NewParser.  The zero T is returned
+// when the token couldn't be decoded.
+func ParseInto[T any](tokenString string, keyFunc Keyfunc, opts ...ParserOption) (*Token, T, error) {
+	p := NewParser(opts...)
+	var claims T
+
+	dual := p.newDualClaims()
+	typed, ok := any(&claims).(Claims)
+	if !ok {
+		typed = &untypedClaims{v: &claims, dual: dual}
+	}
+	dual.typed = typed
+
+	token, err := p.ParseWithClaims(tokenString, dual, keyFunc)
+	if token == nil {
+		var zero T
+		return nil, zero, err
+	}
+	if token.Claims == Claims(dual) {
+		token.Claims = dual.claims
+		if ok {
+			token.Claims = typed
+		}
+	}
+	return token, claims, err
+}
@@ -0,0 +1,76 @@
+//go:build go1.18
+// +build go1.18
+
+package jwt_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/form3tech-oss/jwt-go"
+	"github.com/form3tech-oss/jwt-go/test"
+)
+
+type intoClaims struct {
+	Subject string   `json:"sub"`
+	Scope   []string `json:"scope"`
+}
+
+type registeredIntoClaims struct {
+	jwt.RegisteredClaims
+	Scope string `json:"scope"`
+}
+
+func TestParseInto(t *testing.T) {
+	privateKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	now := time.Now().Unix()
+
+	tokenString := test.MakeSampleToken(jwt.MapClaims{"sub": "alice", "scope": []string{"read", "write"}, "exp": now + 100}, privateKey)
+	token, claims, err := jwt.ParseInto[intoClaims](tokenString, defaultKeyFunc)
+	if err != nil || !token.Valid {
+		t.Fatalf("Error while parsing token: %v", err)
+	}
+	if claims.Subject != "alice" || len(claims.Scope) != 2 || claims.Scope[1] != "write" {
+		t.Errorf("Expecting the claims to be decoded, got %+v", claims)
+	}
+	if m, ok := token.Claims.(jwt.MapClaims); !ok || m["sub"] != "alice" {
+		t.Errorf("Expecting the token claims as MapClaims, got %T", token.Claims)
+	}
+
+	// The time claims are validated without fields for them
+	expired := test.MakeSampleToken(jwt.MapClaims{"sub": "alice", "exp": now - 100}, privateKey)
+	if _, claims, err := jwt.ParseInto[intoClaims](expired, defaultKeyFunc); err == nil || err.(*jwt.ValidationError).Errors != jwt.ValidationErrorExpired || claims.Subject != "alice" {
+		t.Errorf("Expecting ValidationErrorExpired, got %v and %+v", err, claims)
+	}
+
+	// Parser options apply
+	if _, _, err := jwt.ParseInto[intoClaims](tokenString, defaultKeyFunc, jwt.WithIssuer("https://issuer")); err == nil || err.(*jwt.ValidationError).Errors != jwt.ValidationErrorIssuer {
+		t.Errorf("Expecting ValidationErrorIssuer, got %v", err)
+	}
+
+	if token, claims, err := jwt.ParseInto[intoClaims]("not a token", defaultKeyFunc); token != nil || claims.Subject != "" || err == nil {
+		t.Errorf("Expecting a malformed token to fail, got %v", err)
+	}
+}
+
+func TestParseInto_registeredClaims(t *testing.T) {
+	privateKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	now := time.Now().Unix()
+
+	tokenString := test.MakeSampleToken(jwt.MapClaims{"sub": "alice", "scope": "read", "exp": now + 100}, privateKey)
+	token, claims, err := jwt.ParseInto[registeredIntoClaims](tokenString, defaultKeyFunc)
+	if err != nil {
+		t.Fatalf("Error while parsing token: %v", err)
+	}
+	if claims.Subject != "alice" || claims.Scope != "read" || claims.ExpiresAt == nil || claims.ExpiresAt.Unix() != now+100 {
+		t.Errorf("Expecting the claims to be decoded, got %+v", claims)
+	}
+	if _, ok := token.Claims.(*registeredIntoClaims); !ok {
+		t.Errorf("Expecting the token claims as *registeredIntoClaims, got %T", token.Claims)
+	}
+
+	notYet := test.MakeSampleToken(jwt.MapClaims{"nbf": now + 100}, privateKey)
+	if _, _, err := jwt.ParseInto[registeredIntoClaims](notYet, defaultKeyFunc); err == nil || err.(*jwt.ValidationError).Errors != jwt.ValidationErrorNotValidYet {
+		t.Errorf("Expecting ValidationErrorNotValidYet, got %v", err)
+	}
+}
@@ -2,30 +2,299 @@ package jwt
 
 import (
 	"bytes"
+	"context"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"reflect"
+	"strconv"
 	"strings"
+	"time"
 )
 
+// Parser holds the configuration used to parse and validate tokens.
+//
+// A Parser is safe for concurrent use by multiple goroutines once configured:
+// Parse, ParseWithClaims and ParseUnverified only read its fields, and all
+// per-token state lives in the returned Token.  Do not modify the exported
+// fields while the parser is in use.
 type Parser struct {
 	ValidMethods         []string // If populated, only these methods will be considered valid
 	UseJSONNumber        bool     // Use JSON Number format in JSON decoder
 	SkipClaimsValidation bool     // Skip claims validation during token parsing
+
+	allowedSkew          time.Duration // Clock skew tolerated by the time based claim checks. See WithAllowedSkew
+	maxAllowedSkew       time.Duration // Cap on allowedSkew, 0 for DefaultMaxAllowedSkew. See WithMaxAllowedSkew
+	lenientNumericDates  bool          // Accept time based claims encoded as strings of digits. See WithLenientNumericDates
+	inclusiveExpiry      bool          // Accept tokens at the exact second they expire. See WithInclusiveExpiry
+	lenientJSON          bool          // Trim a UTF-8 BOM and whitespace around decoded segments. See WithLenientJSON
+	maxAudiences         int           // Maximum number of entries in the aud claim, 0 for unlimited. See WithMaxAudiences
+	audience             string        // An entry the aud claim must have, if set. See WithAudience
+	audiencePattern      string        // Glob an entry of the aud claim must match, if set. See WithAudiencePattern
+	maxAge               time.Duration // Maximum time since iat or nbf, 0 for unlimited. See WithMaxAge
+	maxExpiry            time.Duration // Maximum time until exp, 0 for unlimited. See WithMaxExpiry
+	maxAuthAge           time.Duration // Maximum time since auth_time, 0 for unlimited. See WithMaxAuthAge
+	minLifetime          time.Duration // Minimum time from iat to exp. See WithIatExpWindow
+	maxLifetime          time.Duration // Maximum time from iat to exp, 0 for unlimited. See WithIatExpWindow
+	forcedTimeValidation bool          // Check exp, nbf and iat whatever the Claims type. See WithForcedTimeValidation
+	expirationRequired   bool          // Reject tokens without an exp claim. See WithExpirationRequired
+
+	onKeyResolved    func(*Token, interface{}) // Called with the key returned by the Keyfunc. See WithOnKeyResolved
+	hooks            *Hooks                    // Callbacks for metrics and logging. See WithHooks
+	paddingAllowed   bool                      // Accept '=' padding at the end of segments. See WithPaddingAllowed
+	maxSegmentLength [3]int                    // Maximum encoded length per segment, 0 for unlimited. See WithMaxSegmentLength
+	maxTokenSize     int                       // Maximum length of a token, 0 for DefaultMaxTokenSize. See WithMaxTokenSize
+
+	claimsOnInvalidSignature bool // Validate claims even if the signature is invalid. See WithClaimsValidationOnInvalidSignature
+	lenientKid               bool // Turn a numeric kid header into its string form. See WithLenientKid
+	noneRejected             bool // Reject the none signing method whatever the key. See WithNoneRejected
+	duplicateKeysRejected    bool // Reject header and claims with repeated member names. See WithDuplicateKeysRejected
+	strictDecoding           bool // Only accept canonical base64url segments holding a single JSON value. See WithStrictDecoding
+	asymmetricKeyGuard       bool // Refuse asymmetric keys for HMAC tokens. See WithAsymmetricKeyGuard
+
+	expectedMethod SigningMethod // The only signing method accepted, if set. See WithExpectedMethod
+
+	revocationChecker func(MapClaims) error // Reports whether the token was revoked. See WithRevocationChecker
+	replayDetector    ReplayDetector        // Records the jti of every valid token. See WithReplayDetector
+	validator         *Validator            // Checks each claim, reporting every failure. See WithValidator
+	verificationStore VerificationStore     // Token strings whose signature verified recently. See WithVerificationStore
+	verificationSpace string                // Prefixes the keys of verificationStore. See WithVerificationStore
+
+	audienceExtractor func(interface{}) []string // Reads the entries of a non-standard aud claim. See WithAudienceExtractor
+
+	issuer           string              // The iss claim required, if set. See WithIssuer
+	issuerNormalizer func(string) string // Applied to both issuers before comparing them. See WithIssuerNormalization
+	issuerMethods    map[string][]string // The algs accepted from each issuer. See WithPerIssuerMethods
+	nonce            string              // The nonce claim required, if set. See WithNonce
+
+	accessTokenProfile bool // Require the typ and claims of RFC 9068 access tokens. See WithAccessTokenProfile
+
+	claimTransformers map[string]func(interface{}) interface{} // Normalize claim values after decoding. See WithClaimTransformer
+	claimDecryptors   map[string]claimDecryptor                // Decrypt claim values after decoding. See WithClaimDecryptor
+	canonicalPayload  bool                                     // Verify the signature over the canonical claims. See WithCanonicalPayload
+	disallowUnknown   bool                                     // Reject claims struct claims have no field for. See WithDisallowUnknownClaims
+	critHandlers      map[string]func(interface{}) error       // Check the crit header parameters understood. See WithCriticalHeader
+	expectedTypes     []string                                 // The typ headers accepted, if set. See WithExpectedType
+	expectedCty       []string                                 // The cty headers accepted, if set. See WithExpectedContentType
+
+	jsonCodec          JSONCodec           // Encodes and decodes the header and claims. See WithJSONCodec
+	decryptionKey      interface{}         // Decrypts tokens in the compact JWE form. See WithDecryptionKey
+	unsignedJWEAllowed bool                // Accept encrypted claims that aren't a nested token. See WithUnsignedEncryptedClaims
+	x5cOptions         *x509.VerifyOptions // Verify the x5c chain and use the key of its leaf. See WithX5CVerification
+
+	deprecatedMethods []string // Signing methods flagged with ValidationErrorDeprecated. See WithDeprecatedMethods
+	warnOnly          uint32   // Error bits reported in Token.Warnings instead of failing. See WithWarnOnly
+
+	ctx context.Context // Of ParseWithClaimsContext, set on a copy of the parser
 }
 
 // Parse, validate, and return a token.
 // keyFunc will receive the parsed token and should return the key for validating.
-// If everything is kosher, err will be nil
+// The signature is verified first, and the claims are only validated once it
+// checked out.  If everything is kosher, err will be nil
 func (p *Parser) Parse(tokenString string, keyFunc Keyfunc) (*Token, error) {
 	return p.ParseWithClaims(tokenString, MapClaims{}, keyFunc)
 }
 
 func (p *Parser) ParseWithClaims(tokenString string, claims Claims, keyFunc Keyfunc) (*Token, error) {
-	token, parts, err := p.ParseUnverified(tokenString, claims)
+	return p.parseWithClaims(tokenString, nil, claims, keyFunc)
+}
+
+// ParseWithClaims, decoding the segments from raw instead when it holds the
+// bytes of tokenString.  See ParseBytesWithClaims.
+func (p *Parser) parseWithClaims(tokenString string, raw []byte, claims Claims, keyFunc Keyfunc) (*Token, error) {
+	if p.hooks == nil {
+		return p.parseToken(tokenString, raw, claims, keyFunc)
+	}
+	p.hooks.parseStart()
+	token, err := p.parseToken(tokenString, raw, claims, keyFunc)
+	p.hooks.parseDone(token, err)
+	return token, err
+}
+
+func (p *Parser) parseToken(tokenString string, raw []byte, claims Claims, keyFunc Keyfunc) (*Token, error) {
+	if err := p.checkTokenSize(len(tokenString)); err != nil {
+		return nil, err
+	}
+	if p.decryptionKey != nil && strings.Count(tokenString, ".") == 4 {
+		return p.parseJWE(tokenString, claims, keyFunc)
+	}
+
+	token, parts, err := p.parseUnverified(tokenString, raw, claims)
 	if err != nil {
 		return token, err
 	}
 
+	token.Signature = parts[2]
+	if p.paddingAllowed {
+		token.Signature = strings.TrimRight(token.Signature, "=")
+	}
+
+	if err = p.checkHeader(token); err != nil {
+		return token, err
+	}
+	if p.verifiedRecently(tokenString) {
+		// The signature verified recently, only the claims are checked again
+		err = nil
+	} else {
+		var key interface{}
+		if key, err = p.lookupKey(token, keyFunc); err != nil {
+			return token, err
+		}
+		err = p.verifySignature(token, tokenString, parts, key)
+	}
+
+	if err = p.validate(token, parts, err); err != nil {
+		return token, err
+	}
+	return token, nil
+}
+
+// Verifies the signature of token with key, recording tokenString in the
+// verification cache if it checked out
+func (p *Parser) verifySignature(token *Token, tokenString string, parts []string, key interface{}) error {
+	// Perform validation.  An empty signature is only acceptable for 'none',
+	// it must never reach the Verify method of a signing algorithm.
+	if token.Signature == "" && token.Method.Alg() != SigningMethodNone.Alg() {
+		return ErrSignatureInvalid
+	}
+	signingString := tokenString[:len(parts[0])+1+len(parts[1])]
+	if p.canonicalPayload {
+		claimBytes, err := p.decodeSegment(parts[1])
+		if err == nil {
+			claimBytes, err = canonicalJSON(claimBytes)
+		}
+		if err != nil {
+			return err
+		}
+		signingString = parts[0] + "." + EncodeSegment(claimBytes)
+	}
+	if err := verifyWithKey(token, signingString, key); err != nil {
+		return err
+	}
+	if p.verificationStore != nil {
+		p.recordVerified(token, tokenString, parts)
+	}
+	return nil
+}
+
+// Records the outcome sigErr of the signature check and, only once the
+// signature checked out, validates the claims.  Claims of a token with an
+// invalid signature are untrusted and are not looked at, unless the parser
+// was configured with WithClaimsValidationOnInvalidSignature.  Sets
+// token.Valid when both pass.
+func (p *Parser) validate(token *Token, parts []string, sigErr error) error {
+	return p.validateWithClaims(token, parts, sigErr, nil)
+}
+
+// Like validate, with the result of validateClaims computed ahead of time
+// by the caller, or nil to have it computed if needed
+func (p *Parser) validateWithClaims(token *Token, parts []string, sigErr error, cErr *ValidationError) error {
+	vErr := &ValidationError{}
+	if sigErr != nil {
+		vErr.Inner = sigErr
+		vErr.Errors |= ValidationErrorSignatureInvalid
+	} else {
+		token.SignatureValid = true
+	}
+
+	// Validate Claims
+	if !p.SkipClaimsValidation && (token.SignatureValid || p.claimsOnInvalidSignature) {
+		if cErr == nil {
+			cErr = p.validateClaims(token, parts)
+		}
+		if vErr.valid() {
+			vErr = cErr
+		} else {
+			// Keep the signature error as the cause
+			vErr.Errors |= cErr.Errors
+		}
+	}
+
+	if token.SignatureValid && p.isDeprecated(token.Method) {
+		if vErr.valid() {
+			vErr.text = fmt.Sprintf("signing method %v is deprecated", token.Method.Alg())
+		}
+		vErr.Errors |= ValidationErrorDeprecated
+	}
+
+	if p.allowedSkew > p.skew() && (token.SignatureValid || p.claimsOnInvalidSignature) {
+		token.Warnings = append(token.Warnings, ErrAllowedSkewClamped)
+	}
+
+	if warn := vErr.Errors & p.warnOnly &^ validationErrorFatal; warn != 0 {
+		if warn == vErr.Errors {
+			token.Warnings = append(token.Warnings, vErr)
+			vErr = &ValidationError{}
+		} else {
+			// The cause can't be attributed to a single bit, so it stays with the error
+			token.Warnings = append(token.Warnings, NewValidationError("token has warnings", warn))
+			vErr.Errors &^= warn
+		}
+	}
+
+	// Only a token that is valid otherwise, once warnings are set aside,
+	// uses up its jti
+	if p.replayDetector != nil && !p.SkipClaimsValidation && token.SignatureValid && vErr.valid() {
+		if rErr := p.checkReplay(token, parts); rErr != nil {
+			vErr = rErr
+		}
+	}
+
+	if vErr.valid() {
+		token.Valid = true
+		return nil
+	}
+	return vErr
+}
+
+// The number of entries of the aud claim, raw, before entries that are not
+// strings are dropped, or as extracted, whichever is more
+func audienceCount(raw interface{}, audiences []string) int {
+	n := len(audiences)
+	if aud, ok := raw.([]interface{}); ok && len(aud) > n {
+		n = len(aud)
+	}
+	return n
+}
+
+func (p *Parser) isDeprecated(method SigningMethod) bool {
+	for _, alg := range p.deprecatedMethods {
+		if alg == method.Alg() {
+			return true
+		}
+	}
+	return false
+}
+
+// Checks the signing method and header of token against the parser's policy
+// and looks up its verification key with keyFunc
+func (p *Parser) resolveKey(token *Token, keyFunc Keyfunc) (interface{}, error) {
+	if err := p.checkHeader(token); err != nil {
+		return nil, err
+	}
+	return p.lookupKey(token, keyFunc)
+}
+
+// Checks the signing method and header of token against the parser's policy
+func (p *Parser) checkHeader(token *Token) error {
+	if err := p.checkMethod(token); err != nil {
+		return err
+	}
+	if err := p.checkCrit(token); err != nil {
+		return err
+	}
+	return p.checkTypes(token)
+}
+
+// Checks the signing method of token against ValidMethods and
+// WithExpectedMethod, and normalizes the header for the key lookup
+func (p *Parser) checkMethod(token *Token) error {
+	if p.noneRejected && token.Method.Alg() == SigningMethodNone.Alg() {
+		return NewValidationError("signing method none is not allowed", ValidationErrorSignatureInvalid)
+	}
+
 	// Verify signing method is in the required set
 	if p.ValidMethods != nil {
 		var signingMethodValid = false
@@ -38,53 +307,377 @@ func (p *Parser) ParseWithClaims(tokenString string, claims Claims, keyFunc Keyf
 		}
 		if !signingMethodValid {
 			// signing method is not in the listed set
-			return token, NewValidationError(fmt.Sprintf("signing method %v is invalid", alg), ValidationErrorSignatureInvalid)
+			return NewValidationError(fmt.Sprintf("signing method %v is invalid, expecting one of %v", alg, strings.Join(p.ValidMethods, ", ")), ValidationErrorSignatureInvalid)
+		}
+	}
+
+	if p.expectedMethod != nil && token.Method.Alg() != p.expectedMethod.Alg() {
+		return NewValidationError(fmt.Sprintf("signing method %v is invalid, expecting %v", token.Method.Alg(), p.expectedMethod.Alg()), ValidationErrorSignatureInvalid)
+	}
+
+	if p.issuerMethods != nil {
+		if err := p.checkIssuerMethod(token); err != nil {
+			return err
+		}
+	}
+
+	if p.lenientKid {
+		if kid, ok := numericString(token.Header["kid"]); ok {
+			token.Header["kid"] = kid
+		}
+	}
+
+	return nil
+}
+
+// Checks the alg of token against the algs accepted from its issuer
+func (p *Parser) checkIssuerMethod(token *Token) error {
+	claims, err := p.mapClaims(token, token.segments[:])
+	if err != nil {
+		return &ValidationError{Inner: err, Errors: ValidationErrorMalformed}
+	}
+	iss, _ := claims["iss"].(string)
+	if p.issuerNormalizer != nil {
+		iss = p.issuerNormalizer(iss)
+	}
+	methods, ok := p.issuerMethods[iss]
+	if !ok {
+		if methods, ok = p.issuerMethods[DefaultIssuer]; !ok {
+			return NewValidationError(fmt.Sprintf("issuer %q is not trusted", iss), ValidationErrorIssuer)
+		}
+	}
+	alg := token.Method.Alg()
+	for _, m := range methods {
+		if m == alg {
+			return nil
+		}
+	}
+	return NewValidationError(fmt.Sprintf("signing method %v is invalid for issuer %q", alg, iss), ValidationErrorSignatureInvalid)
+}
+
+// Looks up the verification key of token with keyFunc
+func (p *Parser) lookupKey(token *Token, keyFunc Keyfunc) (interface{}, error) {
+	// The key of an embedded certificate chain replaces the Keyfunc's
+	if p.x5cOptions != nil {
+		key, err := p.x5cKey(token)
+		if err != nil {
+			return nil, err
+		}
+		if p.onKeyResolved != nil {
+			p.onKeyResolved(token, key)
 		}
+		return key, nil
 	}
 
 	// Lookup key
-	var key interface{}
 	if keyFunc == nil {
 		// keyFunc was not provided.  short circuiting validation
-		return token, NewValidationError("no Keyfunc was provided.", ValidationErrorUnverifiable)
+		return nil, NewValidationError("no Keyfunc was provided.", ValidationErrorUnverifiable)
 	}
-	if key, err = keyFunc(token); err != nil {
+	start := time.Now()
+	key, err := keyFunc(token)
+	if p.hooks != nil {
+		p.hooks.keyfuncDuration(token, start)
+	}
+	if err != nil {
 		// keyFunc returned an error
 		if ve, ok := err.(*ValidationError); ok {
-			return token, ve
+			return nil, ve
+		}
+		return nil, &ValidationError{Inner: err, Errors: ValidationErrorUnverifiable}
+	}
+	if isNilKey(key) {
+		// keyFunc returned neither a key nor an error, which is always a bug
+		return nil, &ValidationError{Inner: ErrNilKey, Errors: ValidationErrorUnverifiable}
+	}
+	if p.asymmetricKeyGuard {
+		if err := checkHMACKey(token, key); err != nil {
+			return nil, err
 		}
-		return token, &ValidationError{Inner: err, Errors: ValidationErrorUnverifiable}
 	}
+	if p.onKeyResolved != nil {
+		p.onKeyResolved(token, key)
+	}
+
+	return key, nil
+}
 
+// Runs the Valid method of the token claims, followed by the checks
+// configured on the parser.  Always returns a non-nil error, check valid().
+func (p *Parser) validateClaims(token *Token, parts []string) *ValidationError {
 	vErr := &ValidationError{}
 
-	// Validate Claims
-	if !p.SkipClaimsValidation {
-		if err := token.Claims.Valid(); err != nil {
-
-			// If the Claims Valid returned an error, check if it is a validation error,
-			// If it was another error type, create a ValidationError with a generic ClaimsInvalid flag set
-			if e, ok := err.(*ValidationError); !ok {
-				vErr = &ValidationError{Inner: err, Errors: ValidationErrorClaimsInvalid}
-			} else {
-				vErr = e
+	if err := token.Claims.Valid(); err != nil {
+		// If the Claims Valid returned an error, check if it is a validation error,
+		// If it was another error type, create a ValidationError with a generic ClaimsInvalid flag set
+		if e, ok := err.(*ValidationError); !ok {
+			vErr = &ValidationError{Inner: err, Errors: ValidationErrorClaimsInvalid}
+		} else {
+			// Copy, so the parser checks below don't alter an error owned by the claims
+			*vErr = *e
+		}
+	}
+
+	if !p.inspectsClaims() {
+		return vErr
+	}
+
+	claims, err := p.mapClaims(token, parts)
+	if err != nil {
+		return &ValidationError{Inner: err, Errors: ValidationErrorClaimsInvalid}
+	}
+	if p.lenientNumericDates {
+		claims = claims.withNumericDates()
+	}
+
+	if p.overridesTimeChecks() {
+		// Valid checked the time based claims strictly and without any skew.
+		// Discard those results and check them again with the parser's policy.
+		vErr.Errors &^= validationErrorTime
+		expNow, nbfNow := p.timeBounds()
+		claims.verifyTimes(expNow, nbfNow, vErr)
+	}
+
+	audiences := claims.audiences()
+	if p.audienceExtractor != nil {
+		audiences = p.audienceExtractor(claims["aud"])
+	}
+
+	if p.maxAudiences > 0 && audienceCount(claims["aud"], audiences) > p.maxAudiences {
+		vErr.Inner = fmt.Errorf("token has more than %d audiences", p.maxAudiences)
+		vErr.Errors |= ValidationErrorAudience
+	}
+
+	if p.audience != "" {
+		matched := false
+		for _, aud := range audiences {
+			if aud == p.audience {
+				matched = true
+				break
 			}
 		}
+		if !matched {
+			vErr.Inner = fmt.Errorf("token has no audience %q", p.audience)
+			vErr.Errors |= ValidationErrorAudience
+		}
 	}
 
-	// Perform validation
-	token.Signature = parts[2]
-	if err = token.Method.Verify(strings.Join(parts[0:2], "."), token.Signature, key); err != nil {
-		vErr.Inner = err
-		vErr.Errors |= ValidationErrorSignatureInvalid
+	if p.audiencePattern != "" {
+		matched := false
+		for _, aud := range audiences {
+			if matchAudiencePattern(p.audiencePattern, aud) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			vErr.Inner = fmt.Errorf("token has no audience matching %q", p.audiencePattern)
+			vErr.Errors |= ValidationErrorAudience
+		}
 	}
 
-	if vErr.valid() {
-		token.Valid = true
-		return token, nil
+	if p.issuer != "" {
+		iss, _ := claims["iss"].(string)
+		expected := p.issuer
+		if p.issuerNormalizer != nil {
+			iss, expected = p.issuerNormalizer(iss), p.issuerNormalizer(expected)
+		}
+		if iss == "" || !verifyIss(iss, expected, true) {
+			vErr.Inner = fmt.Errorf("token issuer is not %q", p.issuer)
+			vErr.Errors |= ValidationErrorIssuer
+		}
+	}
+
+	if p.nonce != "" && !claims.VerifyNonce(p.nonce, true) {
+		vErr.Inner = errors.New("token nonce does not match")
+		vErr.Errors |= ValidationErrorClaimsInvalid
+	}
+
+	if p.revocationChecker != nil {
+		if err := p.revocationChecker(claims); err != nil {
+			vErr.Inner = err
+			vErr.Errors |= ValidationErrorRevoked
+		}
+	}
+
+	if p.maxAge > 0 {
+		issued, ok := claims.numericDate("iat")
+		if !ok {
+			issued, ok = claims.numericDate("nbf")
+		}
+		if !ok {
+			vErr.Inner = errors.New("token has no iat or nbf claim to check its age")
+			vErr.Errors |= ValidationErrorIssuedAt
+		} else if now := TimeFunc().Unix(); issued < now-int64((p.maxAge+p.skew())/time.Second) {
+			// Compared in seconds, as for WithMaxExpiry: the age of an absurd
+			// iat would overflow a Duration
+			exp, hasExp := claims.numericDate("exp")
+			// Of an expired token, report the bound that was passed first
+			if vErr.Errors&ValidationErrorExpired == 0 || !hasExp || issued+int64(p.maxAge/time.Second) < exp {
+				vErr.Inner = &MaxAgeError{Age: durationUntil(now, issued), MaxAge: p.maxAge}
+			}
+			vErr.Errors |= ValidationErrorIssuedAt
+		}
+	}
+
+	if p.expirationRequired {
+		if _, ok := claims.numericDate("exp"); !ok {
+			// Missing, as opposed to expired, so other missing claims' bit
+			vErr.Inner = &ClaimError{"exp", ErrClaimMissing}
+			vErr.Errors |= ValidationErrorClaimsInvalid
+		}
+	}
+
+	if p.maxAuthAge > 0 && !claims.VerifyAuthTime(TimeFunc().Unix(), p.maxAuthAge+p.skew(), true) {
+		vErr.Inner = fmt.Errorf("token has no auth_time claim within %v", p.maxAuthAge)
+		vErr.Errors |= ValidationErrorClaimsInvalid
+	}
+
+	if p.maxExpiry > 0 {
+		if exp, ok := claims.numericDate("exp"); ok {
+			// Compare in seconds, an absurd exp would overflow a Duration
+			if exp > TimeFunc().Unix()+int64((p.maxExpiry+p.skew())/time.Second) {
+				vErr.Inner = &MaxExpiryError{ExpiresAt: time.Unix(exp, 0).UTC(), MaxExpiry: p.maxExpiry}
+				vErr.Errors |= ValidationErrorClaimsInvalid
+			}
+		}
+	}
+
+	if p.minLifetime > 0 || p.maxLifetime > 0 {
+		iat, hasIat := claims.numericDate("iat")
+		exp, hasExp := claims.numericDate("exp")
+		switch lifetime := exp - iat; {
+		case !hasIat || !hasExp:
+			vErr.Inner = errors.New("token lifetime is unknown without both iat and exp claims")
+			vErr.Errors |= ValidationErrorClaimsInvalid
+		case lifetime < int64(p.minLifetime/time.Second):
+			vErr.Inner = fmt.Errorf("token lifetime %vs is shorter than %v", lifetime, p.minLifetime)
+			vErr.Errors |= ValidationErrorClaimsInvalid
+		case p.maxLifetime > 0 && lifetime > int64(p.maxLifetime/time.Second):
+			vErr.Inner = fmt.Errorf("token lifetime %vs is longer than %v", lifetime, p.maxLifetime)
+			vErr.Errors |= ValidationErrorClaimsInvalid
+		}
+	}
+
+	if p.accessTokenProfile {
+		if bits, err := checkAccessTokenProfile(token.Header, claims); err != nil {
+			vErr.Inner = err
+			vErr.Errors |= bits
+		}
+	}
+
+	if p.validator != nil {
+		expNow, nbfNow := p.timeBounds()
+		if errs := p.validator.validate(claims, expNow, nbfNow); len(errs) > 0 {
+			vErr.Inner = errs
+			vErr.Errors |= errs.bits()
+		}
+	}
+
+	return vErr
+}
+
+// The times the time based claims are checked against with the parser's
+// skew: exp against expNow, and nbf and iat against nbfNow
+func (p *Parser) timeBounds() (expNow, nbfNow int64) {
+	now := TimeFunc().Unix()
+	skew := int64(p.skew() / time.Second)
+	expNow = now - skew
+	if p.inclusiveExpiry {
+		expNow--
+	}
+	return expNow, now + skew
+}
+
+// The clock skew tolerated, capped by the maximum allowed skew
+func (p *Parser) skew() time.Duration {
+	max := p.maxAllowedSkew
+	if max == 0 {
+		max = DefaultMaxAllowedSkew
+	}
+	if p.allowedSkew > max {
+		return max
+	}
+	return p.allowedSkew
+}
+
+// Reports whether any of the parser's own claim checks are configured
+func (p *Parser) inspectsClaims() bool {
+	return p.overridesTimeChecks() || p.maxAudiences > 0 || p.audience != "" || p.audiencePattern != "" || p.issuer != "" || p.nonce != "" || p.expirationRequired || p.maxAge > 0 || p.maxAuthAge > 0 || p.maxExpiry > 0 || p.minLifetime > 0 || p.maxLifetime > 0 || p.revocationChecker != nil || p.validator != nil || p.replayDetector != nil || p.accessTokenProfile
+}
+
+// Reports whether the parser is configured to check the time based claims
+// differently from the default Valid methods
+func (p *Parser) overridesTimeChecks() bool {
+	return p.allowedSkew != 0 || p.lenientNumericDates || p.inclusiveExpiry || p.forcedTimeValidation
+}
+
+// Returns the token claims as MapClaims, so the parser can inspect the
+// standard claims regardless of the Claims type the caller decoded into.
+func (p *Parser) mapClaims(token *Token, parts []string) (MapClaims, error) {
+	switch claims := token.Claims.(type) {
+	case MapClaims:
+		return claims, nil
+	case *dualClaims:
+		return claims.claims, nil
+	}
+	if parts[1] == "" {
+		// Detached payload, see ParseDetachedReader
+		return toMapClaims(token.Claims)
+	}
+
+	claimBytes, err := p.decodeSegment(parts[1])
+	if err != nil {
+		return nil, err
+	}
+	if p.lenientJSON {
+		claimBytes = trimJSON(claimBytes)
 	}
+	claims := MapClaims{}
+	dec := p.json().NewDecoder(bytes.NewBuffer(claimBytes))
+	dec.UseNumber()
+	if err := dec.Decode(&claims); err != nil {
+		return nil, err
+	}
+	if err := p.transformClaims(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// Applies the claim decryptors, then the claim transformers, to the members
+// of claims they are named for
+func (p *Parser) transformClaims(claims MapClaims) error {
+	for name, d := range p.claimDecryptors {
+		if v, ok := claims[name]; ok {
+			cleartext, err := d.decrypt(p.json(), name, v)
+			if err != nil {
+				return err
+			}
+			claims[name] = cleartext
+		}
+	}
+	for name, fn := range p.claimTransformers {
+		if v, ok := claims[name]; ok {
+			claims[name] = fn(v)
+		}
+	}
+	return nil
+}
 
-	return token, vErr
+// Applies the claim decryptors and transformers to an encoded claims
+// segment, so that any Claims type decodes the resulting values
+func (p *Parser) transformClaimBytes(claimBytes []byte) ([]byte, error) {
+	claims := MapClaims{}
+	dec := p.json().NewDecoder(bytes.NewBuffer(claimBytes))
+	dec.UseNumber()
+	if err := dec.Decode(&claims); err != nil {
+		return nil, err
+	}
+	if err := p.transformClaims(claims); err != nil {
+		return nil, err
+	}
+	return p.json().Marshal(claims)
 }
 
 // WARNING: Don't use this method unless you know what you're doing
@@ -94,33 +687,106 @@ func (p *Parser) ParseWithClaims(tokenString string, claims Claims, keyFunc Keyf
 // been checked previously in the stack) and you want to extract values from
 // it.
 func (p *Parser) ParseUnverified(tokenString string, claims Claims) (token *Token, parts []string, err error) {
-	parts = strings.Split(tokenString, ".")
-	if len(parts) != 3 {
-		return nil, parts, NewValidationError("token contains an invalid number of segments", ValidationErrorMalformed)
+	if err = p.checkTokenSize(len(tokenString)); err != nil {
+		return nil, nil, err
+	}
+	return p.parseUnverified(tokenString, nil, claims)
+}
+
+// Rejects a token of size bytes if it is longer than the parser allows,
+// before anything is split or decoded
+func (p *Parser) checkTokenSize(size int) error {
+	max := p.maxTokenSize
+	if max == 0 {
+		max = DefaultMaxTokenSize
 	}
+	if max > 0 && size > max {
+		return &ValidationError{Inner: &TokenSizeError{size, max}, Errors: ValidationErrorMalformed}
+	}
+	return nil
+}
 
+func (p *Parser) parseUnverified(tokenString string, raw []byte, claims Claims) (token *Token, parts []string, err error) {
+	// Splitting into the token's own array saves allocating parts
 	token = &Token{Raw: tokenString}
+	if !splitToken(tokenString, &token.segments) {
+		// Bounded, as a token of dots alone would otherwise split into as
+		// many segments
+		parts = strings.SplitN(tokenString, ".", 6)
+		if len(parts) == 5 && p.isJWEHeader(parts[0]) {
+			return nil, parts, &ValidationError{Inner: ErrTokenIsJWE, Errors: ValidationErrorMalformed}
+		}
+		return nil, parts, NewValidationError("token contains an invalid number of segments", ValidationErrorMalformed)
+	}
+	parts = token.segments[:]
+
+	// Bound the allocations of decoding before any segment is decoded
+	for i, max := range p.maxSegmentLength {
+		if max > 0 && len(parts[i]) > max {
+			return nil, parts, newSegmentError(Segment(i+1), &SegmentLengthError{len(parts[i]), max})
+		}
+	}
+
+	if p.strictDecoding {
+		for i, part := range parts {
+			if err = checkStrictSegment(part); err != nil {
+				return token, parts, newSegmentError(Segment(i+1), err)
+			}
+		}
+	}
+
+	sb, raw, scratch := p.segmentBuffers(tokenString, raw, parts)
+	defer putSegments(sb)
 
 	// parse Header
 	var headerBytes []byte
-	if headerBytes, err = DecodeSegment(parts[0]); err != nil {
+	if headerBytes, err = p.decodeRawSegment(raw, parts, 0, &scratch); err != nil {
 		if strings.HasPrefix(strings.ToLower(tokenString), "bearer ") {
 			return token, parts, NewValidationError("tokenstring should not contain 'bearer '", ValidationErrorMalformed)
 		}
-		return token, parts, &ValidationError{Inner: err, Errors: ValidationErrorMalformed}
+		return token, parts, newSegmentError(SegmentHeader, err)
+	}
+	if p.lenientJSON {
+		headerBytes = trimJSON(headerBytes)
+	}
+	if p.duplicateKeysRejected {
+		if err = checkDuplicateKeys(headerBytes); err != nil {
+			return token, parts, newSegmentError(SegmentHeader, err)
+		}
 	}
-	if err = json.Unmarshal(headerBytes, &token.Header); err != nil {
-		return token, parts, &ValidationError{Inner: err, Errors: ValidationErrorMalformed}
+	if err = p.json().Unmarshal(headerBytes, &token.Header); err != nil {
+		return token, parts, newSegmentError(SegmentHeader, err)
+	}
+	// Set before the claims are decoded, so that a token with a corrupt
+	// payload still reports the header and signing method
+	if method, ok := token.Header["alg"].(string); ok {
+		token.Method = GetSigningMethod(method)
 	}
 
 	// parse Claims
 	var claimBytes []byte
 	token.Claims = claims
 
-	if claimBytes, err = DecodeSegment(parts[1]); err != nil {
-		return token, parts, &ValidationError{Inner: err, Errors: ValidationErrorMalformed}
+	if claimBytes, err = p.decodeRawSegment(raw, parts, 1, &scratch); err != nil {
+		return token, parts, newSegmentError(SegmentClaims, err)
+	}
+	if p.lenientJSON {
+		claimBytes = trimJSON(claimBytes)
+	}
+	if !isJSONObject(claimBytes) {
+		return token, parts, newSegmentError(SegmentClaims, ErrClaimsNotObject)
+	}
+	if p.duplicateKeysRejected {
+		if err = checkDuplicateKeys(claimBytes); err != nil {
+			return token, parts, newSegmentError(SegmentClaims, err)
+		}
+	}
+	if len(p.claimTransformers) > 0 || len(p.claimDecryptors) > 0 {
+		if claimBytes, err = p.transformClaimBytes(claimBytes); err != nil {
+			return token, parts, newSegmentError(SegmentClaims, err)
+		}
 	}
-	dec := json.NewDecoder(bytes.NewBuffer(claimBytes))
+	dec := p.json().NewDecoder(bytes.NewBuffer(claimBytes))
 	if p.UseJSONNumber {
 		dec.UseNumber()
 	}
@@ -128,17 +794,24 @@ func (p *Parser) ParseUnverified(tokenString string, claims Claims) (token *Toke
 	if c, ok := token.Claims.(MapClaims); ok {
 		err = dec.Decode(&c)
 	} else {
+		if p.disallowUnknown {
+			dec.DisallowUnknownFields()
+		}
 		err = dec.Decode(&claims)
 	}
 	// Handle decode error
 	if err != nil {
-		return token, parts, &ValidationError{Inner: err, Errors: ValidationErrorMalformed}
+		return token, parts, newSegmentError(SegmentClaims, err)
+	}
+	// The decoder stops after the first value, unlike Unmarshal
+	if p.strictDecoding && !json.Valid(claimBytes) {
+		return token, parts, newSegmentError(SegmentClaims, ErrTrailingData)
 	}
 
 	// Lookup signature method
 	if method, ok := token.Header["alg"].(string); ok {
-		if token.Method = GetSigningMethod(method); token.Method == nil {
-			return token, parts, NewValidationError("signing method (alg) is unavailable.", ValidationErrorUnverifiable)
+		if token.Method == nil {
+			return token, parts, methodUnavailable(method)
 		}
 	} else {
 		return token, parts, NewValidationError("signing method (alg) is unspecified.", ValidationErrorUnverifiable)
@@ -146,3 +819,75 @@ func (p *Parser) ParseUnverified(tokenString string, claims Claims) (token *Toke
 
 	return token, parts, nil
 }
+
+// The error for a token whose alg names no registered signing method
+func methodUnavailable(alg string) *ValidationError {
+	msg := fmt.Sprintf("signing method (alg) %v is unavailable.", alg)
+	if strings.HasPrefix(alg, "HS") {
+		msg = fmt.Sprintf("signing method (alg) %v is unavailable, HMAC supports HS256, HS384 and HS512.", alg)
+	}
+	return NewValidationError(msg, ValidationErrorUnverifiable)
+}
+
+// Decodes a segment with DecodeSegment, first stripping any padding when
+// the parser allows it
+func (p *Parser) decodeSegment(seg string) ([]byte, error) {
+	if p.paddingAllowed {
+		seg = strings.TrimRight(seg, "=")
+	}
+	return DecodeSegment(seg)
+}
+
+// Returns the decimal form of a number decoded from a JSON header, as
+// float64 or json.Number
+func numericString(v interface{}) (string, bool) {
+	switch n := v.(type) {
+	case float64:
+		return strconv.FormatFloat(n, 'f', -1, 64), true
+	case json.Number:
+		return n.String(), true
+	}
+	return "", false
+}
+
+// Reports whether key is nil, or a typed nil such as a nil *rsa.PublicKey
+func isNilKey(key interface{}) bool {
+	if key == nil {
+		return true
+	}
+	switch v := reflect.ValueOf(key); v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface, reflect.Func, reflect.Chan:
+		return v.IsNil()
+	}
+	return false
+}
+
+// Strips a leading UTF-8 byte order mark and surrounding whitespace from a
+// decoded segment
+func trimJSON(data []byte) []byte {
+	data = bytes.TrimSpace(data)
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	return bytes.TrimSpace(data)
+}
+
+// Reports whether data starts like a JSON object, rather than an array, a
+// scalar or null, which no Claims type decodes meaningfully
+func isJSONObject(data []byte) bool {
+	data = bytes.TrimLeft(data, " \t\r\n")
+	return len(data) > 0 && data[0] == '{'
+}
+
+// Reports whether seg decodes to a JOSE header with an enc parameter, as used
+// by the five segment compact serialization of an encrypted JWE
+func (p *Parser) isJWEHeader(seg string) bool {
+	headerBytes, err := DecodeSegment(seg)
+	if err != nil {
+		return false
+	}
+	var header map[string]interface{}
+	if err := p.json().Unmarshal(headerBytes, &header); err != nil {
+		return false
+	}
+	_, ok := header["enc"]
+	return ok
+}
@@ -0,0 +1,702 @@
+package jwt
+
+import (
+	"crypto/x509"
+	"strings"
+	"time"
+)
+
+// ParserOption is used to implement functional-style options that modify the
+// behavior of the parser. To add new options, just create a function (ideally
+// beginning with With or Without) that returns an anonymous function that
+// takes a *Parser type as input and manipulates its configuration accordingly.
+type ParserOption func(*Parser)
+
+// NewParser creates a new Parser with the specified options
+func NewParser(options ...ParserOption) *Parser {
+	p := &Parser{}
+
+	for _, option := range options {
+		option(p)
+	}
+
+	return p
+}
+
+// WithJSONNumber decodes numbers in the claims as json.Number rather than
+// float64, as setting UseJSONNumber does.
+func WithJSONNumber() ParserOption {
+	return func(p *Parser) {
+		p.UseJSONNumber = true
+	}
+}
+
+// WithoutClaimsValidation skips the Valid method of the claims and the
+// parser's own claim checks, as setting SkipClaimsValidation does.  The
+// signature is still verified.
+func WithoutClaimsValidation() ParserOption {
+	return func(p *Parser) {
+		p.SkipClaimsValidation = true
+	}
+}
+
+// WithAllowedSkew tolerates a clock difference of up to d between the issuer
+// and this parser, in both directions: exp is accepted up to d after it has
+// passed, and nbf and iat are accepted up to d before they are reached.
+// In particular an iat exactly d seconds in the future is accepted, and one
+// d+1 seconds in the future is rejected as used before issued.
+//
+// The time based checks of the claims Valid method are replaced by the
+// parser's own checks when a skew is set, so the skew applies to MapClaims,
+// StandardClaims, RegisteredClaims and custom claims types alike, without
+// changing the claims.  It is the leeway of other JWT libraries.  Skew is
+// truncated to whole seconds, the resolution of the time based claims.
+//
+// A skew should only be a few minutes.  One above DefaultMaxAllowedSkew, or
+// the maximum set with WithMaxAllowedSkew, is clamped to that maximum, and
+// ErrAllowedSkewClamped is added to the Warnings of every token parsed.
+func WithAllowedSkew(d time.Duration) ParserOption {
+	return func(p *Parser) {
+		p.allowedSkew = d
+	}
+}
+
+// WithLeeway is WithAllowedSkew, under the name other JWT libraries use
+func WithLeeway(d time.Duration) ParserOption {
+	return WithAllowedSkew(d)
+}
+
+// The largest skew WithAllowedSkew tolerates unless WithMaxAllowedSkew is given
+const DefaultMaxAllowedSkew = 10 * time.Minute
+
+// WithMaxAllowedSkew replaces DefaultMaxAllowedSkew as the cap on the skew of
+// WithAllowedSkew, for deployments that truly need a larger skew.
+func WithMaxAllowedSkew(d time.Duration) ParserOption {
+	return func(p *Parser) {
+		p.maxAllowedSkew = d
+	}
+}
+
+// WithLenientNumericDates accepts exp, iat and nbf claims encoded as JSON
+// strings consisting solely of digits, optionally signed, such as
+// "1700000000".  By default such claims are not numeric dates and are
+// treated as absent.
+func WithLenientNumericDates() ParserOption {
+	return func(p *Parser) {
+		p.lenientNumericDates = true
+	}
+}
+
+// WithInclusiveExpiry accepts a token during the second its exp claim names.
+// RFC 7519 requires the current time to be before exp, so by default a token
+// is expired once now == exp.  Use this only to interoperate with legacy
+// issuers that expect the old inclusive behavior.
+func WithInclusiveExpiry() ParserOption {
+	return func(p *Parser) {
+		p.inclusiveExpiry = true
+	}
+}
+
+// WithLenientJSON tolerates a UTF-8 byte order mark and surrounding whitespace
+// in the decoded header and claims segments, which some producers emit.  The
+// signature is still verified over the segments exactly as transmitted.
+func WithLenientJSON() ParserOption {
+	return func(p *Parser) {
+		p.lenientJSON = true
+	}
+}
+
+// WithMaxAudiences rejects tokens whose aud claim holds more than n entries,
+// to bound the cost of audience matching for hostile tokens.  The default is
+// unlimited.
+func WithMaxAudiences(n int) ParserOption {
+	return func(p *Parser) {
+		p.maxAudiences = n
+	}
+}
+
+// WithAudienceExtractor reads the entries of the aud claim with fn, for
+// issuers that send aud in a non-standard shape such as {"primary": "svc"}.
+// fn receives the raw decoded claim, nil if absent, and its result is used by
+// the audience checks of the parser, WithMaxAudiences and WithAudiencePattern.
+// By default aud is read as a string or an array of strings.
+func WithAudienceExtractor(fn func(raw interface{}) []string) ParserOption {
+	return func(p *Parser) {
+		p.audienceExtractor = fn
+	}
+}
+
+// WithAudience requires the aud claim to be aud, or an array containing it,
+// and rejects tokens without that audience with ValidationErrorAudience.  The
+// comparison is exact; see WithAudiencePattern for wildcards.
+func WithAudience(aud string) ParserOption {
+	return func(p *Parser) {
+		p.audience = aud
+	}
+}
+
+// WithIssuer requires the iss claim to be iss, and rejects tokens with
+// another issuer or none with ValidationErrorIssuer.  The comparison is
+// exact unless an issuer normalization is set.
+func WithIssuer(iss string) ParserOption {
+	return func(p *Parser) {
+		p.issuer = iss
+	}
+}
+
+// WithIssuerNormalization applies fn to both the expected issuer of
+// WithIssuer and the iss claim before comparing them, for issuers whose
+// discovery document and tokens spell the issuer differently.  See
+// TrimTrailingSlash.
+func WithIssuerNormalization(fn func(iss string) string) ParserOption {
+	return func(p *Parser) {
+		p.issuerNormalizer = fn
+	}
+}
+
+// An issuer normalization for WithIssuerNormalization that makes
+// "https://issuer" and "https://issuer/" compare equal
+func TrimTrailingSlash(iss string) string {
+	return strings.TrimRight(iss, "/")
+}
+
+// WithNonce requires the nonce claim of an OpenID Connect ID token to equal
+// nonce, the value sent in the authentication request.  Tokens with another
+// nonce or none are rejected with ValidationErrorClaimsInvalid.
+func WithNonce(nonce string) ParserOption {
+	return func(p *Parser) {
+		p.nonce = nonce
+	}
+}
+
+// The key of WithPerIssuerMethods whose algs are accepted from issuers
+// without an entry of their own
+const DefaultIssuer = "*"
+
+// WithPerIssuerMethods accepts from each issuer only the algs listed for its
+// iss claim, for federations trusting issuers with different algorithms.
+// Tokens from issuers not in methods are rejected with ValidationErrorIssuer,
+// unless methods has a DefaultIssuer entry, and those with an alg not listed
+// with ValidationErrorSignatureInvalid, before the Keyfunc is called.  The
+// issuer normalization, if any, is applied to iss first.
+func WithPerIssuerMethods(methods map[string][]string) ParserOption {
+	return func(p *Parser) {
+		p.issuerMethods = methods
+	}
+}
+
+// WithAsymmetricKeyGuard refuses to verify an HMAC token with an asymmetric
+// key, or the PEM or DER encoding of one, returned by the Keyfunc.  This
+// stops the attack where a token is signed with HS256 and the issuer's public
+// key as the secret, should the Keyfunc hand out the public key whatever the
+// alg.  The parse fails with ValidationErrorUnverifiable and
+// ErrAsymmetricKeyForHMAC as the inner error.
+func WithAsymmetricKeyGuard() ParserOption {
+	return func(p *Parser) {
+		p.asymmetricKeyGuard = true
+	}
+}
+
+// WithOnKeyResolved calls fn with the key returned by the Keyfunc, before the
+// signature is verified, e.g. to record key usage metrics by kid without
+// wrapping every Keyfunc.  fn must not modify the key.  It is not called when
+// the Keyfunc fails or returns a nil key.
+func WithOnKeyResolved(fn func(token *Token, key interface{})) ParserOption {
+	return func(p *Parser) {
+		p.onKeyResolved = fn
+	}
+}
+
+// WithHooks makes the callbacks of hooks while parsing tokens with Parse,
+// ParseWithClaims, ParseBytes, ParseWithClaimsAsync and ParseNested, e.g. to
+// count failures, time the Keyfunc or log rejected tokens.  A nested token
+// counts as one parse.  hooks is copied.
+func WithHooks(hooks Hooks) ParserOption {
+	return func(p *Parser) {
+		p.hooks = &hooks
+	}
+}
+
+// WithPaddingAllowed accepts segments that end in '=' padding, which some
+// producers emit despite RFC 7515 requiring unpadded base64url.  Each segment
+// is handled on its own, so a token may mix padded and unpadded segments.
+// The padding is stripped before decoding; the signature is still verified
+// over the segments exactly as transmitted.
+func WithPaddingAllowed() ParserOption {
+	return func(p *Parser) {
+		p.paddingAllowed = true
+	}
+}
+
+// WithClaimsValidationOnInvalidSignature also validates the claims of a token
+// whose signature did not verify, so the returned ValidationError reports
+// every problem, for diagnostics.  By default the claims of such a token are
+// untrusted and not validated at all.  The token is never valid either way.
+func WithClaimsValidationOnInvalidSignature() ParserOption {
+	return func(p *Parser) {
+		p.claimsOnInvalidSignature = true
+	}
+}
+
+// WithLenientKid turns a numeric kid header parameter, as sent by some
+// non-compliant issuers, into its decimal string form before the Keyfunc is
+// called, so kid based key lookups such as KeyIDKeyfunc resolve it.  By
+// default a numeric kid is left as is and KeyIDKeyfunc rejects it.
+func WithLenientKid() ParserOption {
+	return func(p *Parser) {
+		p.lenientKid = true
+	}
+}
+
+// WithMaxAge rejects tokens issued more than d ago, regardless of their exp
+// claim, to limit how long a stolen long-lived token stays usable.  The age is
+// measured from the iat claim, or the nbf claim if there is no iat, and the
+// allowed skew is added to d.  A token with neither claim is rejected.
+//
+// The max age never extends the exp claim: a token is rejected once either
+// bound passes, so the earlier of the two is its effective expiry.  Exceeding
+// the max age is reported with ValidationErrorIssuedAt and an Inner
+// *MaxAgeError, an expired token with ValidationErrorExpired.  When both are
+// exceeded both bits are set, and Inner describes the bound passed first.
+func WithMaxAge(d time.Duration) ParserOption {
+	return func(p *Parser) {
+		p.maxAge = d
+	}
+}
+
+// WithMaxAuthAge rejects tokens whose auth_time claim is more than d ago, or
+// that have none, with ValidationErrorClaimsInvalid.  Use it to enforce the
+// OpenID Connect max_age parameter, or to require a recent login for
+// sensitive operations.  The allowed skew is added to d.
+func WithMaxAuthAge(d time.Duration) ParserOption {
+	return func(p *Parser) {
+		p.maxAuthAge = d
+	}
+}
+
+// WithIatExpWindow requires the lifetime of the token, from its iat to its
+// exp claim, to be between min and max, catching tokens whose lifetime is
+// implausibly short, likely an issuance bug, or long, likely overprivileged.
+// A max of 0 sets no upper bound.  Tokens without both claims are rejected,
+// and all failures are reported with ValidationErrorClaimsInvalid.
+func WithIatExpWindow(min, max time.Duration) ParserOption {
+	return func(p *Parser) {
+		p.minLifetime = min
+		p.maxLifetime = max
+	}
+}
+
+// WithMaxExpiry rejects tokens whose exp claim is more than d in the future,
+// such as an exp in the year 9999, with ValidationErrorClaimsInvalid and a
+// *MaxExpiryError as Inner error, rather than as expired.  That catches
+// misconfigured issuers that mint tokens which effectively never expire.
+// Unlike WithMaxAge it bounds the absolute exp, whatever the token's iat.
+// Tokens without an exp claim are not affected.  The allowed skew is added
+// to d.
+func WithMaxExpiry(d time.Duration) ParserOption {
+	return func(p *Parser) {
+		p.maxExpiry = d
+	}
+}
+
+// WithValidMethods sets ValidMethods: only tokens whose alg is one of methods
+// are accepted, and any other is rejected with ValidationErrorSignatureInvalid
+// before the Keyfunc is called, so a key can't be used with an algorithm of
+// another family, e.g. an RSA public key as an HS256 secret.  An empty list
+// rejects every token.
+func WithValidMethods(methods []string) ParserOption {
+	return func(p *Parser) {
+		p.ValidMethods = make([]string, len(methods))
+		copy(p.ValidMethods, methods)
+	}
+}
+
+// WithExpectedMethod only accepts tokens signed with method, compared by Alg,
+// and rejects any other before the Keyfunc is called.  This is the simplest
+// protection against algorithm confusion for single algorithm deployments.
+func WithExpectedMethod(method SigningMethod) ParserOption {
+	return func(p *Parser) {
+		p.expectedMethod = method
+	}
+}
+
+// WithForcedTimeValidation runs the standard exp, nbf and iat checks of
+// MapClaims on every token, whatever its Claims type, by inspecting the
+// decoded claims segment.  Use it when custom claims may have a Valid method
+// that does not check the time based claims, or none at all.
+func WithForcedTimeValidation() ParserOption {
+	return func(p *Parser) {
+		p.forcedTimeValidation = true
+	}
+}
+
+// WithRevocationChecker calls check with the claims of every token whose
+// signature verified, so a revocation store can be consulted by jti, sub or
+// any other claim.  A non-nil error rejects the token with
+// ValidationErrorRevoked and becomes its Inner error.  See RevocationList
+// for an in-memory deny-list.
+func WithRevocationChecker(check func(claims MapClaims) error) ParserOption {
+	return func(p *Parser) {
+		p.revocationChecker = check
+	}
+}
+
+// WithReplayDetector records the jti claim of every token with detector,
+// once its signature and all other claims checked out, or failed only
+// checks downgraded with WithWarnOnly, and rejects a token whose jti was
+// seen before with ValidationErrorId and ErrTokenReplayed as Inner error.
+// Tokens without a jti are rejected too, as are those the detector fails
+// on, with its error as Inner error.  The detector is given the exp claim
+// plus the allowed skew, until when the token would be accepted, or the
+// zero Time if the exp check is downgraded.  See NewMemoryReplayDetector.
+func WithReplayDetector(detector ReplayDetector) ParserOption {
+	return func(p *Parser) {
+		p.replayDetector = detector
+	}
+}
+
+// WithValidator runs the checks of v once the claims are validated, with
+// the parser's skew applied to the time based claims.  When any claim fails,
+// the Inner error of the ValidationError is the ClaimErrors listing all of
+// them, and the error bits of the registered claims that failed are set,
+// ValidationErrorClaimsInvalid for the others.
+func WithValidator(v *Validator) ParserOption {
+	return func(p *Parser) {
+		p.validator = v
+	}
+}
+
+// WithVerificationCache skips the key lookup and signature verification for
+// token strings whose signature verified within the TTL of cache, and before
+// their exp claim, for verifiers that see the same token many times.  The
+// signing method and header checks still run, and the claims are validated
+// on every parse, so a cached token is rejected once it expires.  A hit
+// skips the Keyfunc, the x5c chain of WithX5CVerification, the key checks
+// of WithAsymmetricKeyGuard and the key hooks, and leaves
+// Token.VerificationKey nil.  Only share cache between parsers that would
+// look up the same keys, or give each its namespace with
+// WithVerificationStore.
+func WithVerificationCache(cache *VerificationCache) ParserOption {
+	if cache == nil {
+		return WithVerificationStore(nil, "")
+	}
+	return WithVerificationStore(cache, "")
+}
+
+// WithVerificationStore is WithVerificationCache for any VerificationStore,
+// e.g. one shared by the replicas of a service.  Tokens are recorded under
+// namespace, so that parsers looking up keys differently, such as for
+// another audience or trust domain, don't accept each other's entries.  The
+// store is passed the context of ParseWithClaimsContext.
+func WithVerificationStore(store VerificationStore, namespace string) ParserOption {
+	return func(p *Parser) {
+		p.verificationStore = store
+		p.verificationSpace = namespace
+	}
+}
+
+// WithClaimTransformer replaces the value of the claim called name with the
+// result of fn, after the claims are decoded and before they are validated,
+// to normalize vendor specific encodings such as a comma separated aud.  fn
+// receives the value as decoded into a MapClaims with json.Number, and is
+// not called when the claim is absent.  Give the option once per claim.
+func WithClaimTransformer(name string, fn func(raw interface{}) interface{}) ParserOption {
+	return func(p *Parser) {
+		if p.claimTransformers == nil {
+			p.claimTransformers = make(map[string]func(interface{}) interface{})
+		}
+		p.claimTransformers[name] = fn
+	}
+}
+
+// WithCriticalHeader declares the header parameter called name as
+// understood, so tokens listing it in their crit header are accepted, and
+// checks its value with fn before the Keyfunc is called.  A non-nil error
+// rejects the token with ValidationErrorUnverifiable and becomes its Inner
+// error.  Tokens whose crit header lists parameters no handler was given for
+// are always rejected, RFC 7515 section 4.1.11.  The handler only vouches
+// for the parameter: the signature is still verified over the compact
+// serialization, so e.g. a b64 handler must reject false.  Give the option
+// once per parameter.
+func WithCriticalHeader(name string, fn func(value interface{}) error) ParserOption {
+	return func(p *Parser) {
+		if p.critHandlers == nil {
+			p.critHandlers = make(map[string]func(interface{}) error)
+		}
+		p.critHandlers[name] = fn
+	}
+}
+
+// WithExpectedType only accepts tokens whose typ header is one of types, e.g.
+// "JWT" or "at+jwt", and rejects any other before the Keyfunc is called, so
+// that a token issued for one purpose can't be used for another, RFC 8725
+// section 3.11.  Types compare case-insensitively and the "application/"
+// prefix is optional, RFC 7515 section 4.1.9.  A token without a typ header
+// is rejected too; give "" among types to accept one.
+func WithExpectedType(types ...string) ParserOption {
+	return func(p *Parser) {
+		p.expectedTypes = append([]string{}, types...)
+	}
+}
+
+// WithExpectedContentType only accepts tokens whose cty header is one of
+// types, compared as for WithExpectedType, e.g. "JWT" for nested tokens.
+func WithExpectedContentType(types ...string) ParserOption {
+	return func(p *Parser) {
+		p.expectedCty = append([]string{}, types...)
+	}
+}
+
+// WithJSONCodec decodes the header and claims with codec instead of
+// DefaultJSONCodec, e.g. a faster drop-in for encoding/json.
+func WithJSONCodec(codec JSONCodec) ParserOption {
+	return func(p *Parser) {
+		p.jsonCodec = codec
+	}
+}
+
+// WithDecryptionKey decrypts tokens in the five segment compact JWE form,
+// see EncryptToken, with key, an *rsa.PrivateKey or an *ecdsa.PrivateKey.  A
+// nested token, with the cty header "JWT", is then verified with the
+// Keyfunc as usual.  Encrypted claims that aren't a nested token carry no
+// signature and are rejected with ErrJWEUnsigned, unless
+// WithUnsignedEncryptedClaims is given too.  Without this option JWE tokens
+// are rejected with ErrTokenIsJWE.
+func WithDecryptionKey(key interface{}) ParserOption {
+	return func(p *Parser) {
+		p.decryptionKey = key
+	}
+}
+
+// WithUnsignedEncryptedClaims accepts JWE tokens whose plaintext is the
+// claims themselves, see EncryptClaims, rather than a signed nested token.
+// Such tokens carry no signature: the Keyfunc is not called, ValidMethods and
+// the other header checks don't apply, SignatureValid stays false, and as
+// RSA-OAEP and ECDH-ES encrypt to the public key, anyone holding it can
+// produce them.  Only use it when the public key is known to the issuer
+// alone.
+func WithUnsignedEncryptedClaims() ParserOption {
+	return func(p *Parser) {
+		p.unsignedJWEAllowed = true
+	}
+}
+
+// WithX5CVerification verifies the certificate chain in the x5c header of
+// every token against roots and verifies the signature with the public key
+// of its leaf, the first certificate, instead of calling the Keyfunc.  This
+// suits protocols that deliver the key in the token itself, such as App
+// Store server notifications.  The leaf must be valid for dnsName, unless
+// it is empty, and for one of keyUsages, any usage if none are given.
+// Certificates are checked at TimeFunc.  Tokens without an x5c header are
+// rejected with ErrX5CMissing, and those whose chain doesn't verify with
+// ValidationErrorUnverifiable and the x509 error as Inner error.  A nil
+// roots, which x509 takes for the system roots that let any certificate of
+// a public CA sign tokens, rejects every token with ErrX5CNoRoots.
+func WithX5CVerification(roots *x509.CertPool, dnsName string, keyUsages ...x509.ExtKeyUsage) ParserOption {
+	if len(keyUsages) == 0 {
+		keyUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageAny}
+	}
+	return func(p *Parser) {
+		p.x5cOptions = &x509.VerifyOptions{Roots: roots, DNSName: dnsName, KeyUsages: keyUsages}
+	}
+}
+
+// WithClaimDecryptor replaces the value of the claim called name, an
+// application encrypted value in base64, with its cleartext as a string.
+// fn receives the base64 decoded value and returns the cleartext.  This
+// happens after the claims are decoded and before they are validated or any
+// claim transformer runs.  A claim that is not a base64 string, or that fn
+// fails to decrypt, fails the parse with ValidationErrorMalformed.  Give the
+// option once per claim.
+func WithClaimDecryptor(name string, fn func(ciphertext []byte) ([]byte, error)) ParserOption {
+	return func(p *Parser) {
+		p.addClaimDecryptor(name, claimDecryptor{fn, false})
+	}
+}
+
+// WithJSONClaimDecryptor is WithClaimDecryptor for claims whose cleartext is
+// JSON, such as an object.  The cleartext replaces the claim as decoded JSON
+// rather than as a string.
+func WithJSONClaimDecryptor(name string, fn func(ciphertext []byte) ([]byte, error)) ParserOption {
+	return func(p *Parser) {
+		p.addClaimDecryptor(name, claimDecryptor{fn, true})
+	}
+}
+
+// WithAudiencePattern requires an entry of the aud claim to match pattern,
+// where each '*' stands for a single host label or path segment, e.g.
+// "https://*.api.example.com" for any tenant subdomain.  No other wildcards
+// or regular expressions are supported.
+//
+// A pattern accepts every audience it matches, including ones for services
+// that did not exist when it was written, so prefer exact audiences where
+// the set is known.
+func WithAudiencePattern(pattern string) ParserOption {
+	return func(p *Parser) {
+		p.audiencePattern = pattern
+	}
+}
+
+// WithDeprecatedMethods flags tokens signed with one of the algs, e.g. "RS256"
+// while migrating to "ES256", with ValidationErrorDeprecated.  Such tokens are
+// rejected unless the bit is downgraded with WithWarnOnly.  The check only
+// runs once the signature verified.
+func WithDeprecatedMethods(algs ...string) ParserOption {
+	return func(p *Parser) {
+		p.deprecatedMethods = append(p.deprecatedMethods, algs...)
+	}
+}
+
+// WithWarnOnly downgrades the validation error bits in bits, e.g.
+// ValidationErrorDeprecated, to warnings: they are recorded in Token.Warnings
+// and parsing succeeds if no other bit is set.  This allows rolling out a new
+// policy by logging violations before enforcing it.  Malformed, unverifiable
+// and invalid signature errors are never downgraded, nor are those of the
+// replay check, which runs once the rest are downgraded so that a token
+// accepted with warnings still uses up its jti.  See WithReplayDetector.
+func WithWarnOnly(bits uint32) ParserOption {
+	return func(p *Parser) {
+		p.warnOnly |= bits
+	}
+}
+
+// WithMaxSegmentLength rejects tokens whose segment seg, e.g. SegmentClaims,
+// is longer than n bytes as transmitted, before anything is decoded, so that
+// oversized tokens don't cause large allocations.  The ValidationErrorMalformed
+// returned wraps a *SegmentLengthError.  By default segments are only bounded
+// by the token size, see WithMaxTokenSize; a limit is best set from the
+// largest tokens the issuer is known to produce.
+func WithMaxSegmentLength(seg Segment, n int) ParserOption {
+	return func(p *Parser) {
+		if i := seg.Index(); i >= 0 && i < len(p.maxSegmentLength) {
+			p.maxSegmentLength[i] = n
+		}
+	}
+}
+
+// The longest token a parser accepts unless WithMaxTokenSize is given.  It is
+// far above any token in practice, so it only stops abusive input.
+const DefaultMaxTokenSize = 1 << 20
+
+// WithMaxTokenSize rejects tokens longer than n bytes as transmitted, in any
+// serialization, as malformed before they are split or decoded, replacing
+// DefaultMaxTokenSize.  The ValidationErrorMalformed returned wraps a
+// *TokenSizeError.  A negative n lifts the limit.  See WithMaxSegmentLength
+// to bound each segment on its own.
+func WithMaxTokenSize(n int) ParserOption {
+	return func(p *Parser) {
+		p.maxTokenSize = n
+	}
+}
+
+// WithCanonicalPayload verifies the signature over the canonical form of the
+// claims, with members sorted and no whitespace, instead of over the claims
+// segment as transmitted.  This is for issuers that sign the canonical claims
+// but transmit them in another encoding; sign such tokens with
+// WithCanonicalClaims.
+//
+// This diverges from compact JWS, RFC 7515, where the signature covers the
+// transmitted segments, so only enable it for issuers that need it.
+// Standard verifiers reject tokens whose transmitted claims aren't canonical.
+func WithCanonicalPayload() ParserOption {
+	return func(p *Parser) {
+		p.canonicalPayload = true
+	}
+}
+
+// WithDisallowUnknownClaims rejects tokens carrying claims that the struct
+// claims type being decoded into has no field for, as a malformed claims
+// segment, instead of silently dropping them.  This surfaces issuers sending
+// claims the application doesn't expect.  It has no effect on MapClaims.
+func WithDisallowUnknownClaims() ParserOption {
+	return func(p *Parser) {
+		p.disallowUnknown = true
+	}
+}
+
+// WithExpirationRequired rejects tokens without a numeric exp claim with
+// ValidationErrorClaimsInvalid and a *ClaimError for exp with
+// ErrClaimMissing as Inner error, so they aren't mistaken for expired
+// tokens.  RFC 7519 makes exp optional, which leaves tokens that are valid
+// forever.
+func WithExpirationRequired() ParserOption {
+	return func(p *Parser) {
+		p.expirationRequired = true
+	}
+}
+
+// WithNoneRejected rejects tokens using the none signing method even if the
+// Keyfunc returns UnsafeAllowNoneSignatureType, before the Keyfunc is called.
+func WithNoneRejected() ParserOption {
+	return func(p *Parser) {
+		p.noneRejected = true
+	}
+}
+
+// WithDuplicateKeysRejected rejects tokens whose header or claims repeat a
+// member name, at any depth, as malformed.  RFC 7519 requires rejecting them
+// or using the last value, and encoding/json silently uses the last one, so a
+// duplicate can make different parsers see different claims.
+func WithDuplicateKeysRejected() ParserOption {
+	return func(p *Parser) {
+		p.duplicateKeysRejected = true
+	}
+}
+
+// WithStrictDecoding rejects, as malformed, any token that other parsers
+// might decode differently: segments that are not canonical, unpadded
+// base64url, e.g. with padding, line breaks or set bits past the last byte,
+// a header or claims followed by anything but whitespace, and duplicate
+// member names, as WithDuplicateKeysRejected does.  Claims that are not a
+// JSON object are always rejected.  It takes precedence over
+// WithPaddingAllowed and WithLenientJSON.
+func WithStrictDecoding() ParserOption {
+	return func(p *Parser) {
+		p.strictDecoding = true
+		p.duplicateKeysRejected = true
+	}
+}
+
+// WithAccessTokenProfile only accepts OAuth 2.0 JWT access tokens as
+// profiled by RFC 9068, to be used by resource servers: the typ header must
+// be "at+jwt", the iss, exp, aud, sub, client_id, iat and jti claims must be
+// present, iss must equal issuer, WithIssuer, and aud must contain audience,
+// WithAudience.  Read the client and the granted scopes with
+// MapClaims.ClientID and MapClaims.Scopes.  See ValidateAccessToken to check
+// a token parsed otherwise.
+func WithAccessTokenProfile(issuer, audience string) ParserOption {
+	return func(p *Parser) {
+		WithIssuer(issuer)(p)
+		WithAudience(audience)(p)
+		p.accessTokenProfile = true
+	}
+}
+
+// WithStrictDefaults bundles the recommended strict checks, as a starting
+// point for new services:
+//
+//   - exp is required, WithExpirationRequired
+//   - exp, nbf and iat are checked with zero skew whatever the Claims type,
+//     WithForcedTimeValidation
+//   - none is rejected, WithNoneRejected
+//   - repeated member names are rejected, WithDuplicateKeysRejected
+//   - each segment is limited to 8 KiB, WithMaxSegmentLength
+//
+// Tokens must have three segments of unpadded base64url, as always.  Options
+// given after WithStrictDefaults can relax individual checks, e.g. a skew.
+func WithStrictDefaults() ParserOption {
+	return func(p *Parser) {
+		for _, option := range []ParserOption{
+			WithExpirationRequired(),
+			WithAllowedSkew(0),
+			WithForcedTimeValidation(),
+			WithNoneRejected(),
+			WithDuplicateKeysRejected(),
+			WithMaxSegmentLength(SegmentHeader, 8<<10),
+			WithMaxSegmentLength(SegmentClaims, 8<<10),
+			WithMaxSegmentLength(SegmentSignature, 8<<10),
+		} {
+			option(p)
+		}
+	}
+}
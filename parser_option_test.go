@@ -0,0 +1,1273 @@
+package jwt_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/form3tech-oss/jwt-go"
+	"github.com/form3tech-oss/jwt-go/test"
+)
+
+var allowedSkewTestData = []struct {
+	name   string
+	claims jwt.Claims
+	skew   time.Duration
+	valid  bool
+}{
+	{
+		"expired within skew",
+		jwt.MapClaims{"exp": float64(time.Now().Unix() - 20)},
+		30 * time.Second,
+		true,
+	},
+	{
+		"nbf within skew",
+		jwt.MapClaims{"nbf": float64(time.Now().Unix() + 20)},
+		30 * time.Second,
+		true,
+	},
+	{
+		"iat within skew",
+		jwt.MapClaims{"iat": float64(time.Now().Unix() + 20)},
+		30 * time.Second,
+		true,
+	},
+	{
+		"expired beyond skew",
+		jwt.MapClaims{"exp": float64(time.Now().Unix() - 40)},
+		30 * time.Second,
+		false,
+	},
+	{
+		"nbf beyond skew",
+		jwt.MapClaims{"nbf": float64(time.Now().Unix() + 40)},
+		30 * time.Second,
+		false,
+	},
+	{
+		"expired without skew",
+		jwt.MapClaims{"exp": float64(time.Now().Unix() - 20)},
+		0,
+		false,
+	},
+	{
+		"standard claims expired within skew",
+		&jwt.StandardClaims{ExpiresAt: time.Now().Unix() - 20},
+		30 * time.Second,
+		true,
+	},
+	{
+		"standard claims nbf within skew",
+		&jwt.StandardClaims{NotBefore: time.Now().Unix() + 20},
+		30 * time.Second,
+		true,
+	},
+	{
+		"registered claims expired within skew",
+		&jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(-20 * time.Second))},
+		30 * time.Second,
+		true,
+	},
+	{
+		"registered claims expired beyond skew",
+		&jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(-40 * time.Second))},
+		30 * time.Second,
+		false,
+	},
+}
+
+func TestParser_WithAllowedSkew(t *testing.T) {
+	privateKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+
+	for _, data := range allowedSkewTestData {
+		tokenString := test.MakeSampleToken(data.claims, privateKey)
+
+		var claims jwt.Claims = jwt.MapClaims{}
+		switch data.claims.(type) {
+		case *jwt.StandardClaims:
+			claims = &jwt.StandardClaims{}
+		case *jwt.RegisteredClaims:
+			claims = &jwt.RegisteredClaims{}
+		}
+
+		parser := jwt.NewParser(jwt.WithAllowedSkew(data.skew))
+		token, err := parser.ParseWithClaims(tokenString, claims, defaultKeyFunc)
+		if data.valid && err != nil {
+			t.Errorf("[%v] Error while verifying token: %v", data.name, err)
+		}
+		if !data.valid && err == nil {
+			t.Errorf("[%v] Invalid token passed validation", data.name)
+		}
+		if data.valid != token.Valid {
+			t.Errorf("[%v] Expecting token.Valid to be %v", data.name, data.valid)
+		}
+	}
+}
+
+var lenientNumericDatesTestData = []struct {
+	name    string
+	claims  jwt.MapClaims
+	lenient bool
+	valid   bool
+}{
+	{
+		"string exp expired, lenient",
+		jwt.MapClaims{"exp": fmt.Sprintf("%d", time.Now().Unix()-100)},
+		true,
+		false,
+	},
+	{
+		"string exp in future, lenient",
+		jwt.MapClaims{"exp": fmt.Sprintf("+%d", time.Now().Unix()+100)},
+		true,
+		true,
+	},
+	{
+		"string exp expired, strict",
+		jwt.MapClaims{"exp": fmt.Sprintf("%d", time.Now().Unix()-100)},
+		false,
+		true,
+	},
+	{
+		"non numeric string exp, lenient",
+		jwt.MapClaims{"exp": "tomorrow"},
+		true,
+		true,
+	},
+}
+
+func TestParser_WithMaxAllowedSkew(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	expiredHourAgo, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"exp": float64(time.Now().Unix() - 3600)}).SignedString(key)
+	expiredMinuteAgo, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"exp": float64(time.Now().Unix() - 60)}).SignedString(key)
+
+	// A 2 hour skew is clamped to DefaultMaxAllowedSkew
+	parser := jwt.NewParser(jwt.WithAllowedSkew(2 * time.Hour))
+	if _, err := parser.Parse(expiredHourAgo, keyFunc); err == nil {
+		t.Errorf("[clamped] Expecting a token expired an hour ago to be rejected")
+	}
+	token, err := parser.Parse(expiredMinuteAgo, keyFunc)
+	if err != nil {
+		t.Errorf("[clamped] Error while parsing token: %v", err)
+	}
+	if len(token.Warnings) != 1 || token.Warnings[0] != jwt.ErrAllowedSkewClamped {
+		t.Errorf("[clamped] Expecting ErrAllowedSkewClamped in the warnings, got %v", token.Warnings)
+	}
+
+	parser = jwt.NewParser(jwt.WithAllowedSkew(2*time.Hour), jwt.WithMaxAllowedSkew(3*time.Hour))
+	token, err = parser.Parse(expiredHourAgo, keyFunc)
+	if err != nil {
+		t.Errorf("[override] Error while parsing token: %v", err)
+	}
+	if token != nil && len(token.Warnings) != 0 {
+		t.Errorf("[override] Expecting no warnings, got %v", token.Warnings)
+	}
+
+	parser = jwt.NewParser(jwt.WithAllowedSkew(time.Minute))
+	if token, _ = parser.Parse(expiredMinuteAgo, keyFunc); token == nil || len(token.Warnings) != 0 {
+		t.Errorf("[within default] Expecting no warnings")
+	}
+}
+
+func TestParser_WithLenientNumericDates(t *testing.T) {
+	privateKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+
+	for _, data := range lenientNumericDatesTestData {
+		tokenString := test.MakeSampleToken(data.claims, privateKey)
+
+		var options []jwt.ParserOption
+		if data.lenient {
+			options = append(options, jwt.WithLenientNumericDates())
+		}
+		token, err := jwt.NewParser(options...).Parse(tokenString, defaultKeyFunc)
+		if data.valid && err != nil {
+			t.Errorf("[%v] Error while verifying token: %v", data.name, err)
+		}
+		if !data.valid {
+			if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorExpired {
+				t.Errorf("[%v] Expecting ValidationErrorExpired, got %v", data.name, err)
+			}
+		}
+		if exp := token.Claims.(jwt.MapClaims)["exp"]; exp != data.claims["exp"] {
+			t.Errorf("[%v] Claims should not be altered, got exp %v", data.name, exp)
+		}
+	}
+}
+
+func TestParser_WithInclusiveExpiry(t *testing.T) {
+	privateKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	now := time.Unix(1500000000, 0)
+	jwt.TimeFunc = func() time.Time { return now }
+	defer func() { jwt.TimeFunc = time.Now }()
+
+	tokenString := test.MakeSampleToken(jwt.MapClaims{"exp": float64(now.Unix())}, privateKey)
+
+	if _, err := jwt.NewParser().Parse(tokenString, defaultKeyFunc); err == nil {
+		t.Errorf("Token should be expired at now == exp by default")
+	}
+	if _, err := jwt.NewParser(jwt.WithInclusiveExpiry()).Parse(tokenString, defaultKeyFunc); err != nil {
+		t.Errorf("Token should be valid at now == exp with WithInclusiveExpiry: %v", err)
+	}
+}
+
+func TestParser_WithLenientJSON(t *testing.T) {
+	hmacKey := []byte("secret")
+	keyfunc := func(*jwt.Token) (interface{}, error) { return hmacKey, nil }
+
+	signingString := jwt.EncodeSegment([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." +
+		jwt.EncodeSegment([]byte("\xef\xbb\xbf \n{\"foo\":\"bar\"}\n"))
+	sig, _ := jwt.SigningMethodHS256.Sign(signingString, hmacKey)
+	tokenString := signingString + "." + sig
+
+	if _, err := jwt.NewParser().Parse(tokenString, keyfunc); err == nil {
+		t.Errorf("BOM prefixed payload should be rejected by default")
+	}
+
+	token, err := jwt.NewParser(jwt.WithLenientJSON()).Parse(tokenString, keyfunc)
+	if err != nil {
+		t.Fatalf("BOM prefixed payload should parse with WithLenientJSON: %v", err)
+	}
+	if foo := token.Claims.(jwt.MapClaims)["foo"]; foo != "bar" {
+		t.Errorf("Expecting foo to be bar, got %v", foo)
+	}
+}
+
+func TestParser_WithMaxAudiences(t *testing.T) {
+	privateKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	parser := jwt.NewParser(jwt.WithMaxAudiences(3))
+
+	for n := 2; n <= 4; n++ {
+		aud := make([]string, n)
+		for i := range aud {
+			aud[i] = fmt.Sprintf("aud-%d", i)
+		}
+		tokenString := test.MakeSampleToken(jwt.MapClaims{"aud": aud}, privateKey)
+
+		_, err := parser.Parse(tokenString, defaultKeyFunc)
+		if n <= 3 && err != nil {
+			t.Errorf("[%d audiences] Error while verifying token: %v", n, err)
+		}
+		if n > 3 {
+			if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorAudience {
+				t.Errorf("[%d audiences] Expecting ValidationErrorAudience, got %v", n, err)
+			}
+		}
+	}
+
+	// Entries that are not strings count too
+	tokenString := test.MakeSampleToken(jwt.MapClaims{"aud": []interface{}{"aud-0", 1, 2, 3}}, privateKey)
+	if _, err := parser.Parse(tokenString, defaultKeyFunc); err == nil || err.(*jwt.ValidationError).Errors != jwt.ValidationErrorAudience {
+		t.Errorf("[non-string audiences] Expecting ValidationErrorAudience, got %v", err)
+	}
+}
+
+func TestParser_WithAllowedSkewIssuedAt(t *testing.T) {
+	privateKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	now := time.Unix(1500000000, 0)
+	jwt.TimeFunc = func() time.Time { return now }
+	defer func() { jwt.TimeFunc = time.Now }()
+
+	const leeway = 30
+	parser := jwt.NewParser(jwt.WithAllowedSkew(leeway * time.Second))
+	for offset, valid := range map[int64]bool{0: true, leeway: true, leeway + 1: false} {
+		tokenString := test.MakeSampleToken(jwt.MapClaims{"iat": float64(now.Unix() + offset)}, privateKey)
+
+		_, err := parser.Parse(tokenString, defaultKeyFunc)
+		if valid && err != nil {
+			t.Errorf("[iat now+%d] Error while verifying token: %v", offset, err)
+		}
+		if !valid {
+			if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorIssuedAt {
+				t.Errorf("[iat now+%d] Expecting ValidationErrorIssuedAt, got %v", offset, err)
+			}
+		}
+	}
+}
+
+func TestParser_WithOnKeyResolved(t *testing.T) {
+	keys := map[string][]byte{"a": []byte("key-a"), "b": []byte("key-b")}
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		if key, ok := keys[kid]; ok {
+			return key, nil
+		}
+		return nil, fmt.Errorf("unknown kid %q", kid)
+	}
+
+	var resolvedKid string
+	var resolvedKey interface{}
+	parser := jwt.NewParser(jwt.WithOnKeyResolved(func(token *jwt.Token, key interface{}) {
+		resolvedKid, _ = token.Header["kid"].(string)
+		resolvedKey = key
+	}))
+
+	for _, kid := range []string{"a", "b"} {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"foo": "bar"})
+		token.Header["kid"] = kid
+		tokenString, _ := token.SignedString(keys[kid])
+
+		resolvedKid, resolvedKey = "", nil
+		if _, err := parser.Parse(tokenString, keyFunc); err != nil {
+			t.Errorf("[%v] Error while parsing token: %v", kid, err)
+		}
+		if resolvedKid != kid || string(resolvedKey.([]byte)) != string(keys[kid]) {
+			t.Errorf("[%v] Callback saw kid %q and key %v", kid, resolvedKid, resolvedKey)
+		}
+	}
+
+	// Not called when the Keyfunc fails
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"foo": "bar"})
+	token.Header["kid"] = "c"
+	tokenString, _ := token.SignedString([]byte("key-c"))
+	resolvedKey = nil
+	if _, err := parser.Parse(tokenString, keyFunc); err == nil {
+		t.Errorf("Expecting an error for an unknown kid")
+	}
+	if resolvedKey != nil {
+		t.Errorf("Callback should not fire when the Keyfunc fails")
+	}
+}
+
+func TestParser_WithPaddingAllowed(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+
+	// {"foo":"bar"} is 13 bytes, so its padded encoding ends in "=="
+	signingString := jwt.EncodeSegment([]byte(`{"alg":"HS256","typ":"JWT"}`)) + ".eyJmb28iOiJiYXIifQ=="
+	sig, err := jwt.SigningMethodHS256.Sign(signingString, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokenString := signingString + "." + sig
+
+	_, err = jwt.Parse(tokenString, keyFunc)
+	if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorMalformed || ve.Segment != jwt.SegmentClaims {
+		t.Errorf("Expecting the padded claims segment to be malformed, got %v", err)
+	}
+
+	token, err := jwt.NewParser(jwt.WithPaddingAllowed()).Parse(tokenString, keyFunc)
+	if err != nil || !token.Valid {
+		t.Errorf("Expecting a valid token with padding allowed, got %v", err)
+	}
+
+	// Padding on the signature is stripped too
+	if _, err := jwt.NewParser(jwt.WithPaddingAllowed()).Parse(tokenString+"==", keyFunc); err != nil {
+		t.Errorf("Expecting a padded signature to be accepted, got %v", err)
+	}
+}
+
+func TestParser_WithMaxAge(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	now := time.Now().Unix()
+	farFuture := float64(now + 24*3600)
+
+	var maxAgeTestData = []struct {
+		name   string
+		claims jwt.MapClaims
+		valid  bool
+	}{
+		{"issued 5 minutes ago", jwt.MapClaims{"iat": float64(now - 300), "exp": farFuture}, true},
+		{"issued 2 hours ago", jwt.MapClaims{"iat": float64(now - 7200), "exp": farFuture}, false},
+		{"nbf 2 hours ago", jwt.MapClaims{"nbf": float64(now - 7200), "exp": farFuture}, false},
+		{"nbf 5 minutes ago", jwt.MapClaims{"nbf": float64(now - 300)}, true},
+		{"no iat or nbf", jwt.MapClaims{"exp": farFuture}, false},
+	}
+
+	parser := jwt.NewParser(jwt.WithMaxAge(time.Hour))
+	for _, data := range maxAgeTestData {
+		tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, data.claims).SignedString(key)
+		_, err := parser.Parse(tokenString, keyFunc)
+		if data.valid && err != nil {
+			t.Errorf("[%v] Error while parsing token: %v", data.name, err)
+		}
+		if !data.valid {
+			if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorIssuedAt {
+				t.Errorf("[%v] Expecting ValidationErrorIssuedAt, got %v", data.name, err)
+			}
+		}
+	}
+}
+
+func TestParser_WithMaxAgeAndExpiry(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	now := time.Now().Unix()
+
+	var boundTestData = []struct {
+		name   string
+		claims jwt.MapClaims
+		errors uint32
+		maxAge bool
+	}{
+		{"within both", jwt.MapClaims{"iat": float64(now - 300), "exp": float64(now + 3600)}, 0, false},
+		{"exp valid, max age exceeded", jwt.MapClaims{"iat": float64(now - 7200), "exp": float64(now + 3600)}, jwt.ValidationErrorIssuedAt, true},
+		{"max age passed first", jwt.MapClaims{"iat": float64(now - 3*3600), "exp": float64(now - 1800)}, jwt.ValidationErrorIssuedAt | jwt.ValidationErrorExpired, true},
+		{"exp passed first", jwt.MapClaims{"iat": float64(now - 5400), "exp": float64(now - 3600)}, jwt.ValidationErrorIssuedAt | jwt.ValidationErrorExpired, false},
+		{"expired, max age valid", jwt.MapClaims{"iat": float64(now - 1200), "exp": float64(now - 600)}, jwt.ValidationErrorExpired, false},
+		// An age in nanoseconds beyond the range of a Duration must not wrap
+		{"iat centuries ago", jwt.MapClaims{"iat": float64(now - 9223372037), "exp": float64(now + 3600)}, jwt.ValidationErrorIssuedAt, true},
+	}
+
+	parser := jwt.NewParser(jwt.WithMaxAge(time.Hour))
+	for _, data := range boundTestData {
+		tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, data.claims).SignedString(key)
+		_, err := parser.Parse(tokenString, keyFunc)
+		if data.errors == 0 {
+			if err != nil {
+				t.Errorf("[%v] Error while parsing token: %v", data.name, err)
+			}
+			continue
+		}
+		ve, ok := err.(*jwt.ValidationError)
+		if !ok || ve.Errors != data.errors {
+			t.Errorf("[%v] Expecting error bits %v, got %v", data.name, data.errors, err)
+			continue
+		}
+		if maxAgeErr, ok := ve.Inner.(*jwt.MaxAgeError); ok != data.maxAge {
+			t.Errorf("[%v] Expecting max age reported to be %v, got %v", data.name, data.maxAge, ve.Inner)
+		} else if ok && maxAgeErr.MaxAge != time.Hour {
+			t.Errorf("[%v] Expecting max age of %v, got %v", data.name, time.Hour, maxAgeErr.MaxAge)
+		}
+	}
+}
+
+func TestParser_WithMaxExpiry(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	now := time.Now().Unix()
+	year9999 := float64(time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC).Unix())
+
+	var maxExpiryTestData = []struct {
+		name   string
+		claims jwt.MapClaims
+		valid  bool
+	}{
+		{"expires in 1 hour", jwt.MapClaims{"exp": float64(now + 3600)}, true},
+		{"expires in 2 days", jwt.MapClaims{"exp": float64(now + 2*24*3600)}, false},
+		{"expires in 9999", jwt.MapClaims{"iat": float64(now), "exp": year9999}, false},
+		{"no exp", jwt.MapClaims{"iat": float64(now)}, true},
+	}
+
+	parser := jwt.NewParser(jwt.WithMaxExpiry(24 * time.Hour))
+	for _, data := range maxExpiryTestData {
+		tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, data.claims).SignedString(key)
+		_, err := parser.Parse(tokenString, keyFunc)
+		if data.valid && err != nil {
+			t.Errorf("[%v] Error while parsing token: %v", data.name, err)
+		}
+		if !data.valid {
+			if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorClaimsInvalid {
+				t.Errorf("[%v] Expecting ValidationErrorClaimsInvalid, got %v", data.name, err)
+			} else if _, ok := ve.Inner.(*jwt.MaxExpiryError); !ok {
+				t.Errorf("[%v] Expecting a *MaxExpiryError, got %v", data.name, ve.Inner)
+			}
+		}
+	}
+}
+
+func TestParser_WithIatExpWindow(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	now := time.Now().Unix()
+
+	var iatExpWindowTestData = []struct {
+		name   string
+		claims jwt.MapClaims
+		max    time.Duration
+		valid  bool
+	}{
+		{"below min", jwt.MapClaims{"iat": float64(now), "exp": float64(now + 10)}, time.Hour, false},
+		{"at min", jwt.MapClaims{"iat": float64(now), "exp": float64(now + 60)}, time.Hour, true},
+		{"within range", jwt.MapClaims{"iat": float64(now), "exp": float64(now + 900)}, time.Hour, true},
+		{"above max", jwt.MapClaims{"iat": float64(now), "exp": float64(now + 2*3600)}, time.Hour, false},
+		{"no max", jwt.MapClaims{"iat": float64(now), "exp": float64(now + 2*3600)}, 0, true},
+		{"no iat", jwt.MapClaims{"exp": float64(now + 900)}, time.Hour, false},
+		{"no exp", jwt.MapClaims{"iat": float64(now)}, time.Hour, false},
+	}
+
+	for _, data := range iatExpWindowTestData {
+		tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, data.claims).SignedString(key)
+		_, err := jwt.NewParser(jwt.WithIatExpWindow(time.Minute, data.max)).Parse(tokenString, keyFunc)
+		if data.valid && err != nil {
+			t.Errorf("[%v] Error while parsing token: %v", data.name, err)
+		}
+		if !data.valid {
+			if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorClaimsInvalid {
+				t.Errorf("[%v] Expecting ValidationErrorClaimsInvalid, got %v", data.name, err)
+			}
+		}
+	}
+}
+
+func TestParser_WithIssuerNormalization(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+
+	var issuerTestData = []struct {
+		name    string
+		options []jwt.ParserOption
+		iss     interface{}
+		valid   bool
+	}{
+		{"exact match", []jwt.ParserOption{jwt.WithIssuer("https://issuer")}, "https://issuer", true},
+		{"trailing slash, exact", []jwt.ParserOption{jwt.WithIssuer("https://issuer")}, "https://issuer/", false},
+		{"trailing slash, normalized", []jwt.ParserOption{jwt.WithIssuer("https://issuer"), jwt.WithIssuerNormalization(jwt.TrimTrailingSlash)}, "https://issuer/", true},
+		{"expected trailing slash, normalized", []jwt.ParserOption{jwt.WithIssuer("https://issuer/"), jwt.WithIssuerNormalization(jwt.TrimTrailingSlash)}, "https://issuer", true},
+		{"other issuer, normalized", []jwt.ParserOption{jwt.WithIssuer("https://issuer"), jwt.WithIssuerNormalization(jwt.TrimTrailingSlash)}, "https://other/", false},
+		{"no iss", []jwt.ParserOption{jwt.WithIssuer("https://issuer")}, nil, false},
+	}
+
+	for _, data := range issuerTestData {
+		claims := jwt.MapClaims{}
+		if data.iss != nil {
+			claims["iss"] = data.iss
+		}
+		tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+		_, err := jwt.NewParser(data.options...).Parse(tokenString, keyFunc)
+		if data.valid && err != nil {
+			t.Errorf("[%v] Error while parsing token: %v", data.name, err)
+		}
+		if !data.valid {
+			if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorIssuer {
+				t.Errorf("[%v] Expecting ValidationErrorIssuer, got %v", data.name, err)
+			}
+		}
+	}
+}
+
+func TestParser_WithAudience(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	parser := jwt.NewParser(jwt.WithAudience("https://api"))
+
+	var audienceTestData = []struct {
+		name  string
+		aud   interface{}
+		valid bool
+	}{
+		{"single audience", "https://api", true},
+		{"one of several", []string{"https://other", "https://api"}, true},
+		{"other audience", "https://other", false},
+		{"prefix only", "https://api/v1", false},
+		{"no aud", nil, false},
+	}
+
+	for _, data := range audienceTestData {
+		claims := jwt.MapClaims{}
+		if data.aud != nil {
+			claims["aud"] = data.aud
+		}
+		tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+		_, err := parser.Parse(tokenString, keyFunc)
+		if data.valid && err != nil {
+			t.Errorf("[%v] Error while parsing token: %v", data.name, err)
+		}
+		if !data.valid {
+			if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorAudience {
+				t.Errorf("[%v] Expecting ValidationErrorAudience, got %v", data.name, err)
+			}
+		}
+	}
+}
+
+func TestParser_callSiteOptions(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"iss": "https://issuer",
+		"aud": "https://api",
+		"exp": time.Now().Add(-30 * time.Second).Unix(),
+		"n":   12345678901234567,
+	}).SignedString(key)
+
+	// Expired, unless within the leeway
+	if _, err := jwt.NewParser().Parse(tokenString, keyFunc); err == nil {
+		t.Errorf("Expecting the expired token to be rejected")
+	}
+	token, err := jwt.NewParser(
+		jwt.WithLeeway(time.Minute),
+		jwt.WithIssuer("https://issuer"),
+		jwt.WithAudience("https://api"),
+		jwt.WithJSONNumber(),
+	).Parse(tokenString, keyFunc)
+	if err != nil {
+		t.Fatalf("Error while parsing token: %v", err)
+	}
+	if n, ok := token.Claims.(jwt.MapClaims)["n"].(json.Number); !ok || n.String() != "12345678901234567" {
+		t.Errorf("Expecting n decoded as a json.Number, got %T", token.Claims.(jwt.MapClaims)["n"])
+	}
+
+	// Without claims validation only the signature counts
+	if _, err := jwt.NewParser(jwt.WithoutClaimsValidation(), jwt.WithIssuer("https://other")).Parse(tokenString, keyFunc); err != nil {
+		t.Errorf("[no claims validation] Error while parsing token: %v", err)
+	}
+	if _, err := jwt.NewParser(jwt.WithoutClaimsValidation()).Parse(tokenString, func(*jwt.Token) (interface{}, error) { return []byte("other"), nil }); err == nil {
+		t.Errorf("[no claims validation] Expecting a bad signature to be rejected")
+	}
+}
+
+func TestParser_WithNonce(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	parser := jwt.NewParser(jwt.WithNonce("n-0S6_WzA2Mj"))
+
+	var nonceTestData = []struct {
+		name   string
+		claims jwt.MapClaims
+		valid  bool
+	}{
+		{"matching", jwt.MapClaims{"nonce": "n-0S6_WzA2Mj"}, true},
+		{"mismatching", jwt.MapClaims{"nonce": "n-other"}, false},
+		{"missing", jwt.MapClaims{}, false},
+	}
+
+	for _, data := range nonceTestData {
+		tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, data.claims).SignedString(key)
+		_, err := parser.Parse(tokenString, keyFunc)
+		if data.valid && err != nil {
+			t.Errorf("[%v] Error while parsing token: %v", data.name, err)
+		}
+		if !data.valid {
+			if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorClaimsInvalid {
+				t.Errorf("[%v] Expecting ValidationErrorClaimsInvalid, got %v", data.name, err)
+			}
+		}
+	}
+}
+
+func TestParser_WithPerIssuerMethods(t *testing.T) {
+	rsaKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	ecKey := test.LoadECPrivateKeyFromDisk("test/ec256-private.pem")
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodECDSA); ok {
+			return &ecKey.PublicKey, nil
+		}
+		return &rsaKey.PublicKey, nil
+	}
+	methods := map[string][]string{
+		"https://idp-a": {"RS256"},
+		"https://idp-b": {"ES256"},
+	}
+
+	var perIssuerTestData = []struct {
+		name    string
+		methods map[string][]string
+		method  jwt.SigningMethod
+		key     interface{}
+		iss     string
+		errors  uint32
+	}{
+		{"issuer A, RS256", methods, jwt.SigningMethodRS256, rsaKey, "https://idp-a", 0},
+		{"issuer A, ES256", methods, jwt.SigningMethodES256, ecKey, "https://idp-a", jwt.ValidationErrorSignatureInvalid},
+		{"issuer B, ES256", methods, jwt.SigningMethodES256, ecKey, "https://idp-b", 0},
+		{"issuer B, RS256", methods, jwt.SigningMethodRS256, rsaKey, "https://idp-b", jwt.ValidationErrorSignatureInvalid},
+		{"unknown issuer", methods, jwt.SigningMethodRS256, rsaKey, "https://idp-c", jwt.ValidationErrorIssuer},
+		{"unknown issuer, default", map[string][]string{jwt.DefaultIssuer: {"RS256"}}, jwt.SigningMethodRS256, rsaKey, "https://idp-c", 0},
+		{"unknown issuer, default, ES256", map[string][]string{jwt.DefaultIssuer: {"RS256"}}, jwt.SigningMethodES256, ecKey, "https://idp-c", jwt.ValidationErrorSignatureInvalid},
+	}
+
+	for _, data := range perIssuerTestData {
+		tokenString, err := jwt.NewWithClaims(data.method, jwt.MapClaims{"iss": data.iss}).SignedString(data.key)
+		if err != nil {
+			t.Fatalf("[%v] Error while signing token: %v", data.name, err)
+		}
+		_, err = jwt.NewParser(jwt.WithPerIssuerMethods(data.methods)).Parse(tokenString, keyFunc)
+		if data.errors == 0 && err != nil {
+			t.Errorf("[%v] Error while parsing token: %v", data.name, err)
+		}
+		if data.errors != 0 {
+			if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != data.errors {
+				t.Errorf("[%v] Expecting error bits %v, got %v", data.name, data.errors, err)
+			}
+		}
+	}
+}
+
+func TestParser_WithAsymmetricKeyGuard(t *testing.T) {
+	publicKeyPEM, err := ioutil.ReadFile("test/sample_key.pub")
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+
+	// A Keyfunc that returns the public key whatever the alg
+	keyFunc := func(*jwt.Token) (interface{}, error) { return publicKeyPEM, nil }
+
+	// The attacker signs with HS256, using the public key as the secret
+	forged, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "admin"}).SignedString(publicKeyPEM)
+	if _, err := new(jwt.Parser).Parse(forged, keyFunc); err != nil {
+		t.Fatalf("[unguarded] Expecting the forged token to verify, got %v", err)
+	}
+
+	parser := jwt.NewParser(jwt.WithAsymmetricKeyGuard())
+	_, err = parser.Parse(forged, keyFunc)
+	if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorUnverifiable || ve.Inner != jwt.ErrAsymmetricKeyForHMAC {
+		t.Errorf("[forged] Expecting ErrAsymmetricKeyForHMAC, got %v", err)
+	}
+
+	rsaKeyFunc := func(*jwt.Token) (interface{}, error) { return &privateKey.PublicKey, nil }
+	_, err = parser.Parse(forged, rsaKeyFunc)
+	if ve, ok := err.(*jwt.ValidationError); !ok || ve.Inner != jwt.ErrAsymmetricKeyForHMAC {
+		t.Errorf("[parsed key] Expecting ErrAsymmetricKeyForHMAC, got %v", err)
+	}
+
+	tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"sub": "admin"}).SignedString(privateKey)
+	if _, err := parser.Parse(tokenString, rsaKeyFunc); err != nil {
+		t.Errorf("[RS256] Error while parsing token: %v", err)
+	}
+
+	secret := []byte("secret")
+	tokenString, _ = jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "admin"}).SignedString(secret)
+	if _, err := parser.Parse(tokenString, func(*jwt.Token) (interface{}, error) { return secret, nil }); err != nil {
+		t.Errorf("[HS256] Error while parsing token: %v", err)
+	}
+}
+
+func TestParser_WithValidMethods(t *testing.T) {
+	key := []byte("secret")
+	var called bool
+	keyFunc := func(*jwt.Token) (interface{}, error) {
+		called = true
+		return key, nil
+	}
+	hs256, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"foo": "bar"}).SignedString(key)
+	hs512, _ := jwt.NewWithClaims(jwt.SigningMethodHS512, jwt.MapClaims{"foo": "bar"}).SignedString(key)
+
+	var validMethodsTestData = []struct {
+		name        string
+		methods     []string
+		tokenString string
+		valid       bool
+	}{
+		{"listed", []string{"RS256", "HS256"}, hs256, true},
+		{"not listed", []string{"RS256", "HS256"}, hs512, false},
+		{"empty list", []string{}, hs256, false},
+	}
+
+	for _, data := range validMethodsTestData {
+		called = false
+		_, err := jwt.NewParser(jwt.WithValidMethods(data.methods)).Parse(data.tokenString, keyFunc)
+		if data.valid && err != nil {
+			t.Errorf("[%v] Error while parsing token: %v", data.name, err)
+		}
+		if !data.valid {
+			if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorSignatureInvalid {
+				t.Errorf("[%v] Expecting ValidationErrorSignatureInvalid, got %v", data.name, err)
+			}
+			if called {
+				t.Errorf("[%v] Keyfunc should not be called", data.name)
+			}
+		}
+	}
+
+	// The parser keeps its own copy of the list
+	methods := []string{"HS256"}
+	parser := jwt.NewParser(jwt.WithValidMethods(methods))
+	methods[0] = "HS512"
+	if _, err := parser.Parse(hs256, keyFunc); err != nil {
+		t.Errorf("[copied] Error while parsing token: %v", err)
+	}
+}
+
+func TestParser_WithExpectedMethod(t *testing.T) {
+	key := []byte("secret")
+	var called bool
+	keyFunc := func(*jwt.Token) (interface{}, error) {
+		called = true
+		return key, nil
+	}
+	parser := jwt.NewParser(jwt.WithExpectedMethod(jwt.SigningMethodHS256))
+
+	tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"foo": "bar"}).SignedString(key)
+	if _, err := parser.Parse(tokenString, keyFunc); err != nil {
+		t.Errorf("[matching] Error while parsing token: %v", err)
+	}
+
+	called = false
+	tokenString, _ = jwt.NewWithClaims(jwt.SigningMethodHS512, jwt.MapClaims{"foo": "bar"}).SignedString(key)
+	_, err := parser.Parse(tokenString, keyFunc)
+	if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorSignatureInvalid {
+		t.Errorf("[differing] Expecting ValidationErrorSignatureInvalid, got %v", err)
+	}
+	if called {
+		t.Errorf("[differing] Keyfunc should not be called")
+	}
+}
+
+// Claims whose Valid method checks nothing
+type noopClaims struct {
+	ExpiresAt int64  `json:"exp,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+}
+
+func (noopClaims) Valid() error { return nil }
+
+func TestParser_WithForcedTimeValidation(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	expired, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, noopClaims{ExpiresAt: time.Now().Unix() - 100}).SignedString(key)
+	current, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, noopClaims{ExpiresAt: time.Now().Unix() + 100}).SignedString(key)
+
+	if _, err := new(jwt.Parser).ParseWithClaims(expired, &noopClaims{}, keyFunc); err != nil {
+		t.Errorf("[default] Expecting the no-op Valid to accept the token, got %v", err)
+	}
+
+	parser := jwt.NewParser(jwt.WithForcedTimeValidation())
+	_, err := parser.ParseWithClaims(expired, &noopClaims{}, keyFunc)
+	if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorExpired {
+		t.Errorf("[forced] Expecting ValidationErrorExpired, got %v", err)
+	}
+	if _, err := parser.ParseWithClaims(current, &noopClaims{}, keyFunc); err != nil {
+		t.Errorf("[forced] Error while parsing a current token: %v", err)
+	}
+}
+
+func TestParser_WithRevocationChecker(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	errRevoked := fmt.Errorf("all tokens of this user were revoked")
+	parser := jwt.NewParser(jwt.WithRevocationChecker(func(claims jwt.MapClaims) error {
+		if claims["sub"] == "mallory" {
+			return errRevoked
+		}
+		return nil
+	}))
+
+	var revocationTestData = []struct {
+		name    string
+		claims  jwt.Claims
+		revoked bool
+	}{
+		{"revoked sub", jwt.MapClaims{"sub": "mallory"}, true},
+		{"revoked sub, struct claims", &jwt.StandardClaims{Subject: "mallory"}, true},
+		{"other sub", jwt.MapClaims{"sub": "alice"}, false},
+	}
+
+	for _, data := range revocationTestData {
+		tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, data.claims).SignedString(key)
+		_, err := parser.ParseWithClaims(tokenString, &jwt.StandardClaims{}, keyFunc)
+		if !data.revoked && err != nil {
+			t.Errorf("[%v] Error while parsing token: %v", data.name, err)
+		}
+		if data.revoked {
+			if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorRevoked || ve.Inner != errRevoked {
+				t.Errorf("[%v] Expecting ValidationErrorRevoked, got %v", data.name, err)
+			}
+		}
+	}
+}
+
+func TestParser_WithClaimTransformer(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	splitAudience := func(raw interface{}) interface{} {
+		if s, ok := raw.(string); ok {
+			return strings.Split(s, ",")
+		}
+		return raw
+	}
+	parser := jwt.NewParser(jwt.WithClaimTransformer("aud", splitAudience))
+	tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"aud": "billing,reports"}).SignedString(key)
+
+	token, err := parser.Parse(tokenString, keyFunc)
+	if err != nil {
+		t.Fatalf("[map] Error while parsing token: %v", err)
+	}
+	if aud, ok := token.Claims.(jwt.MapClaims).MatchedAudience("reports"); !ok || aud != "reports" {
+		t.Errorf("[map] Expecting the split aud to match, got %v", token.Claims.(jwt.MapClaims)["aud"])
+	}
+
+	claims := &jwt.StandardClaims{}
+	if _, err := parser.ParseWithClaims(tokenString, claims, keyFunc); err != nil {
+		t.Fatalf("[struct] Error while parsing token: %v", err)
+	}
+	if len(claims.Audience) != 2 || !claims.VerifyAudience("reports", true) {
+		t.Errorf("[struct] Expecting the split aud to match, got %v", claims.Audience)
+	}
+
+	// The transformed aud also bounds WithMaxAudiences
+	parser = jwt.NewParser(jwt.WithClaimTransformer("aud", splitAudience), jwt.WithMaxAudiences(1))
+	if _, err := parser.ParseWithClaims(tokenString, &jwt.StandardClaims{}, keyFunc); err == nil {
+		t.Errorf("[max audiences] Expecting two audiences to be rejected")
+	}
+}
+
+func TestParser_WithClaimDecryptor(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	xor := func(data []byte) ([]byte, error) {
+		out := make([]byte, len(data))
+		for i, b := range data {
+			out[i] = b ^ 0x5a
+		}
+		return out, nil
+	}
+	encrypt := func(cleartext string) string {
+		ciphertext, _ := xor([]byte(cleartext))
+		return base64.StdEncoding.EncodeToString(ciphertext)
+	}
+
+	tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub":     "1234567890",
+		"level":   3,
+		"ssn":     encrypt("078-05-1120"),
+		"profile": encrypt(`{"email":"jane@example.com","age":42}`),
+	}).SignedString(key)
+
+	parser := jwt.NewParser(jwt.WithClaimDecryptor("ssn", xor), jwt.WithJSONClaimDecryptor("profile", xor))
+	token, err := parser.Parse(tokenString, keyFunc)
+	if err != nil {
+		t.Fatalf("[map] Error while parsing token: %v", err)
+	}
+	claims := token.Claims.(jwt.MapClaims)
+	if claims["ssn"] != "078-05-1120" {
+		t.Errorf("[map] Expecting the decrypted ssn, got %v", claims["ssn"])
+	}
+	if email, _ := claims.GetPath("/profile/email"); email != "jane@example.com" {
+		t.Errorf("[map] Expecting the decrypted profile, got %v", claims["profile"])
+	}
+	if claims["sub"] != "1234567890" {
+		t.Errorf("[map] Expecting other claims untouched, got %v", claims["sub"])
+	}
+
+	var typed struct {
+		jwt.StandardClaims
+		SSN string `json:"ssn"`
+	}
+	if _, err := parser.ParseWithClaims(tokenString, &typed, keyFunc); err != nil {
+		t.Fatalf("[struct] Error while parsing token: %v", err)
+	}
+	if typed.SSN != "078-05-1120" {
+		t.Errorf("[struct] Expecting the decrypted ssn, got %v", typed.SSN)
+	}
+
+	failing := func([]byte) ([]byte, error) { return nil, errors.New("wrong key") }
+	_, err = jwt.NewParser(jwt.WithClaimDecryptor("ssn", failing)).Parse(tokenString, keyFunc)
+	if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorMalformed || ve.Segment != jwt.SegmentClaims {
+		t.Errorf("[failing] Expecting ValidationErrorMalformed in the claims, got %v", err)
+	}
+	_, err = jwt.NewParser(jwt.WithClaimDecryptor("level", xor)).Parse(tokenString, keyFunc)
+	if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorMalformed {
+		t.Errorf("[not a string] Expecting ValidationErrorMalformed, got %v", err)
+	}
+}
+
+func TestParser_WithAudiencePattern(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	parser := jwt.NewParser(jwt.WithAudiencePattern("https://*.api.example.com"))
+
+	var audiencePatternTestData = []struct {
+		aud   interface{}
+		valid bool
+	}{
+		{"https://tenant-123.api.example.com", true},
+		{[]string{"other", "https://tenant-9.api.example.com"}, true},
+		{"https://api.example.com", false},
+		{"https://a.b.api.example.com", false},
+		{"https://tenant.api.example.com.evil.com", false},
+		{"https://evil.com/.api.example.com", false},
+		{"https://tenant-123.api.example.org", false},
+		{nil, false},
+	}
+
+	for _, data := range audiencePatternTestData {
+		claims := jwt.MapClaims{}
+		if data.aud != nil {
+			claims["aud"] = data.aud
+		}
+		tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+		_, err := parser.Parse(tokenString, keyFunc)
+		if data.valid && err != nil {
+			t.Errorf("[%v] Error while parsing token: %v", data.aud, err)
+		}
+		if !data.valid {
+			if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorAudience {
+				t.Errorf("[%v] Expecting ValidationErrorAudience, got %v", data.aud, err)
+			}
+		}
+	}
+}
+
+func TestParser_WithPaddingAllowedMixedSegments(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+
+	// A 25 byte header, padded, followed by an unpadded 13 byte payload
+	header := base64.URLEncoding.EncodeToString([]byte(`{"alg":"HS256","kid":"a"}`))
+	if !strings.HasSuffix(header, "==") {
+		t.Fatalf("Expecting a padded header, got %v", header)
+	}
+	signingString := header + "." + jwt.EncodeSegment([]byte(`{"foo":"bar"}`))
+	sig, _ := jwt.SigningMethodHS256.Sign(signingString, key)
+	tokenString := signingString + "." + sig
+
+	if _, err := jwt.Parse(tokenString, keyFunc); err == nil {
+		t.Errorf("[default] Expecting the padded header to be rejected")
+	}
+
+	// The signature covers the padded header as transmitted, so stripping the
+	// padding from the signing input would break it
+	token, err := jwt.NewParser(jwt.WithPaddingAllowed()).Parse(tokenString, keyFunc)
+	if err != nil || !token.Valid {
+		t.Fatalf("[padding allowed] Error while parsing token: %v", err)
+	}
+	if token.Header["kid"] != "a" || token.Claims.(jwt.MapClaims)["foo"] != "bar" {
+		t.Errorf("[padding allowed] Unexpected header %v or claims %v", token.Header, token.Claims)
+	}
+}
+
+func TestParser_WithWarnOnly(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	current, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"foo": "bar"}).SignedString(key)
+	expired, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"exp": time.Now().Unix() - 100}).SignedString(key)
+
+	// Enforced, the deprecated method fails the parse
+	_, err := jwt.NewParser(jwt.WithDeprecatedMethods("HS256")).Parse(current, keyFunc)
+	if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorDeprecated {
+		t.Errorf("[enforced] Expecting ValidationErrorDeprecated, got %v", err)
+	}
+
+	parser := jwt.NewParser(jwt.WithDeprecatedMethods("HS256"), jwt.WithWarnOnly(jwt.ValidationErrorDeprecated))
+	token, err := parser.Parse(current, keyFunc)
+	if err != nil || !token.Valid {
+		t.Fatalf("[warn only] Error while parsing token: %v", err)
+	}
+	if len(token.Warnings) != 1 {
+		t.Fatalf("[warn only] Expecting one warning, got %v", token.Warnings)
+	}
+	if ve, ok := token.Warnings[0].(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorDeprecated {
+		t.Errorf("[warn only] Expecting a ValidationErrorDeprecated warning, got %v", token.Warnings[0])
+	}
+
+	// Bits that are not downgraded still fail, and keep the warning
+	token, err = parser.Parse(expired, keyFunc)
+	if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorExpired {
+		t.Errorf("[expired] Expecting ValidationErrorExpired, got %v", err)
+	}
+	if len(token.Warnings) != 1 {
+		t.Errorf("[expired] Expecting one warning, got %v", token.Warnings)
+	}
+
+	// Signature failures are never downgraded
+	_, err = jwt.NewParser(jwt.WithWarnOnly(jwt.ValidationErrorSignatureInvalid)).Parse(current, func(*jwt.Token) (interface{}, error) {
+		return []byte("other"), nil
+	})
+	if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorSignatureInvalid {
+		t.Errorf("[signature] Expecting ValidationErrorSignatureInvalid, got %v", err)
+	}
+}
+
+func TestParser_WithMaxSegmentLength(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	parser := jwt.NewParser(jwt.WithMaxSegmentLength(jwt.SegmentClaims, 64))
+
+	normal, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "alice"}).SignedString(key)
+	if _, err := parser.Parse(normal, keyFunc); err != nil {
+		t.Errorf("[normal] Error while parsing token: %v", err)
+	}
+
+	oversized, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": strings.Repeat("a", 100)}).SignedString(key)
+	_, err := parser.Parse(oversized, keyFunc)
+	ve, ok := err.(*jwt.ValidationError)
+	if !ok || ve.Errors != jwt.ValidationErrorMalformed || ve.Segment != jwt.SegmentClaims {
+		t.Fatalf("[oversized] Expecting a malformed claims segment, got %v", err)
+	}
+	cause, ok := ve.Inner.(interface{ Unwrap() error })
+	if !ok {
+		t.Fatalf("[oversized] Expecting a segment error, got %v", ve.Inner)
+	}
+	if le, ok := cause.Unwrap().(*jwt.SegmentLengthError); !ok || le.Max != 64 || le.Length <= 64 {
+		t.Errorf("[oversized] Expecting a SegmentLengthError, got %v", cause.Unwrap())
+	}
+
+	// Other segments are not limited
+	if _, err := jwt.NewParser(jwt.WithMaxSegmentLength(jwt.SegmentHeader, 64)).Parse(oversized, keyFunc); err != nil {
+		t.Errorf("[header limit] Error while parsing token: %v", err)
+	}
+}
+
+func TestParser_WithMaxTokenSize(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	normal, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "alice"}).SignedString(key)
+	oversized, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": strings.Repeat("a", jwt.DefaultMaxTokenSize)}).SignedString(key)
+
+	var maxTokenSizeTestData = []struct {
+		name        string
+		tokenString string
+		parser      *jwt.Parser
+		max         int
+	}{
+		{"normal", normal, new(jwt.Parser), 0},
+		{"default limit", oversized, new(jwt.Parser), jwt.DefaultMaxTokenSize},
+		{"lower limit", normal, jwt.NewParser(jwt.WithMaxTokenSize(64)), 64},
+		{"unlimited", oversized, jwt.NewParser(jwt.WithMaxTokenSize(-1)), 0},
+		{"dots", strings.Repeat(".", jwt.DefaultMaxTokenSize+1), new(jwt.Parser), jwt.DefaultMaxTokenSize},
+	}
+
+	for _, data := range maxTokenSizeTestData {
+		_, err := data.parser.Parse(data.tokenString, keyFunc)
+		if data.max == 0 {
+			if err != nil {
+				t.Errorf("[%v] Error while parsing token: %v", data.name, err)
+			}
+			continue
+		}
+		ve, ok := err.(*jwt.ValidationError)
+		if !ok || ve.Errors != jwt.ValidationErrorMalformed {
+			t.Errorf("[%v] Expecting ValidationErrorMalformed, got %v", data.name, err)
+			continue
+		}
+		if se, ok := ve.Inner.(*jwt.TokenSizeError); !ok || se.Max != data.max || se.Size != len(data.tokenString) {
+			t.Errorf("[%v] Expecting a TokenSizeError, got %v", data.name, ve.Inner)
+		}
+	}
+
+	if _, _, err := jwt.NewParser(jwt.WithMaxTokenSize(64)).ParseUnverified(normal, jwt.MapClaims{}); err == nil {
+		t.Errorf("[unverified] Expecting ParseUnverified to apply the limit")
+	}
+}
+
+func TestParser_WithDisallowUnknownClaims(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	parser := jwt.NewParser(jwt.WithDisallowUnknownClaims())
+
+	known, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "alice"}).SignedString(key)
+	unknown, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "alice", "role": "admin"}).SignedString(key)
+
+	if _, err := parser.ParseWithClaims(known, &jwt.StandardClaims{}, keyFunc); err != nil {
+		t.Errorf("[known] Error while parsing token: %v", err)
+	}
+	if _, err := new(jwt.Parser).ParseWithClaims(unknown, &jwt.StandardClaims{}, keyFunc); err != nil {
+		t.Errorf("[default] Error while parsing token: %v", err)
+	}
+	_, err := parser.ParseWithClaims(unknown, &jwt.StandardClaims{}, keyFunc)
+	if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorMalformed || ve.Segment != jwt.SegmentClaims {
+		t.Errorf("[unknown] Expecting a malformed claims segment, got %v", err)
+	}
+	if _, err := parser.Parse(unknown, keyFunc); err != nil {
+		t.Errorf("[map claims] Error while parsing token: %v", err)
+	}
+}
+
+func TestParser_WithAudienceExtractor(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	primaryAudience := func(raw interface{}) []string {
+		if obj, ok := raw.(map[string]interface{}); ok {
+			if aud, ok := obj["primary"].(string); ok {
+				return []string{aud}
+			}
+		}
+		return nil
+	}
+	tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"aud": map[string]interface{}{"primary": "svc"},
+	}).SignedString(key)
+
+	_, err := jwt.NewParser(jwt.WithAudiencePattern("svc")).Parse(tokenString, keyFunc)
+	if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorAudience {
+		t.Errorf("[default] Expecting ValidationErrorAudience, got %v", err)
+	}
+
+	parser := jwt.NewParser(jwt.WithAudiencePattern("svc"), jwt.WithAudienceExtractor(primaryAudience))
+	if _, err := parser.Parse(tokenString, keyFunc); err != nil {
+		t.Errorf("[extractor] Error while parsing token: %v", err)
+	}
+	other, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"aud": map[string]interface{}{"primary": "other"},
+	}).SignedString(key)
+	if _, err := parser.Parse(other, keyFunc); err == nil {
+		t.Errorf("[extractor] Expecting another primary audience to be rejected")
+	}
+}
+
+func TestParser_WithMaxAuthAge(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	now := time.Now().Unix()
+	parser := jwt.NewParser(jwt.WithMaxAuthAge(5 * time.Minute))
+
+	var maxAuthAgeTestData = []struct {
+		name   string
+		claims jwt.MapClaims
+		valid  bool
+	}{
+		{"authenticated a minute ago", jwt.MapClaims{"auth_time": now - 60}, true},
+		{"authenticated an hour ago", jwt.MapClaims{"auth_time": now - 3600}, false},
+		{"no auth_time", jwt.MapClaims{"sub": "alice"}, false},
+	}
+
+	for _, data := range maxAuthAgeTestData {
+		tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, data.claims).SignedString(key)
+		_, err := parser.Parse(tokenString, keyFunc)
+		if data.valid && err != nil {
+			t.Errorf("[%v] Error while parsing token: %v", data.name, err)
+		}
+		if !data.valid {
+			if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorClaimsInvalid {
+				t.Errorf("[%v] Expecting ValidationErrorClaimsInvalid, got %v", data.name, err)
+			}
+		}
+	}
+}
+
+func TestParser_WithStrictDefaults(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		if token.Method == jwt.SigningMethodNone {
+			return jwt.UnsafeAllowNoneSignatureType, nil
+		}
+		return key, nil
+	}
+	exp := float64(time.Now().Unix() + 3600)
+	sign := func(method jwt.SigningMethod, signKey interface{}, payload string) string {
+		signingString := jwt.EncodeSegment([]byte(`{"alg":"`+method.Alg()+`","typ":"JWT"}`)) + "." + jwt.EncodeSegment([]byte(payload))
+		sig, _ := method.Sign(signingString, signKey)
+		return signingString + "." + sig
+	}
+
+	var strictTestData = []struct {
+		name        string
+		tokenString string
+		errors      uint32
+	}{
+		{"compliant", sign(jwt.SigningMethodHS256, key, fmt.Sprintf(`{"sub":"alice","exp":%v}`, exp)), 0},
+		{"no exp", sign(jwt.SigningMethodHS256, key, `{"sub":"alice"}`), jwt.ValidationErrorClaimsInvalid},
+		{"duplicate claim", sign(jwt.SigningMethodHS256, key, fmt.Sprintf(`{"sub":"alice","exp":%v,"sub":"admin"}`, exp)), jwt.ValidationErrorMalformed},
+		{"duplicate nested claim", sign(jwt.SigningMethodHS256, key, fmt.Sprintf(`{"exp":%v,"cnf":[{"kid":"a","kid":"b"}]}`, exp)), jwt.ValidationErrorMalformed},
+		{"alg none", sign(jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, fmt.Sprintf(`{"sub":"alice","exp":%v}`, exp)), jwt.ValidationErrorSignatureInvalid},
+		{"oversized", sign(jwt.SigningMethodHS256, key, fmt.Sprintf(`{"pad":%q,"exp":%v}`, strings.Repeat("a", 10000), exp)), jwt.ValidationErrorMalformed},
+	}
+
+	strict := jwt.NewParser(jwt.WithStrictDefaults())
+	for _, data := range strictTestData {
+		// Everything passes the default parser
+		if _, err := new(jwt.Parser).Parse(data.tokenString, keyFunc); err != nil {
+			t.Errorf("[%v] Error while parsing token with default parser: %v", data.name, err)
+		}
+
+		_, err := strict.Parse(data.tokenString, keyFunc)
+		if data.errors == 0 {
+			if err != nil {
+				t.Errorf("[%v] Error while parsing token: %v", data.name, err)
+			}
+			continue
+		}
+		if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != data.errors {
+			t.Errorf("[%v] Expecting errors %v, got %v", data.name, data.errors, err)
+		}
+	}
+}
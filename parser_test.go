@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -95,7 +96,7 @@ var jwtTestData = []struct {
 		emptyKeyFunc,
 		jwt.MapClaims{"foo": "bar"},
 		false,
-		jwt.ValidationErrorSignatureInvalid,
+		jwt.ValidationErrorUnverifiable,
 		nil,
 	},
 	{
@@ -287,6 +288,33 @@ func TestParser_ParseUnverified(t *testing.T) {
 	}
 }
 
+func TestParseUnverified(t *testing.T) {
+	privateKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"iss": "https://tenant-a"})
+	token.Header["kid"] = "key-1"
+	tokenString, _ := token.SignedString(privateKey)
+
+	// The signature is not checked, so a tampered one decodes all the same
+	parts := strings.Split(tokenString, ".")
+	unverified, segments, err := jwt.ParseUnverified(parts[0]+"."+parts[1]+".AAAA", jwt.MapClaims{})
+	if err != nil {
+		t.Fatalf("Error while decoding token: %v", err)
+	}
+	if unverified.Valid || unverified.SignatureValid {
+		t.Errorf("Expecting an unverified token not to be valid")
+	}
+	if unverified.Header["kid"] != "key-1" || unverified.Claims.(jwt.MapClaims)["iss"] != "https://tenant-a" {
+		t.Errorf("Expecting the kid and iss of the token, got %v and %v", unverified.Header, unverified.Claims)
+	}
+	if len(segments) != 3 || segments[2] != "AAAA" {
+		t.Errorf("Expecting the segments of the token, got %v", segments)
+	}
+
+	if _, _, err := jwt.ParseUnverified("not a token", jwt.MapClaims{}); err == nil {
+		t.Errorf("Expecting an error for a malformed token")
+	}
+}
+
 // Helper method for benchmarking various methods
 func benchmarkSigning(b *testing.B, method jwt.SigningMethod, key interface{}) {
 	t := jwt.New(method)
@@ -299,3 +327,289 @@ func benchmarkSigning(b *testing.B, method jwt.SigningMethod, key interface{}) {
 	})
 
 }
+
+// Helper method for benchmarking the Verify method of various methods
+func benchmarkVerifying(b *testing.B, method jwt.SigningMethod, signKey, verifyKey interface{}) {
+	tokenString, err := jwt.New(method).SignedString(signKey)
+	if err != nil {
+		b.Fatal(err)
+	}
+	parts := strings.Split(tokenString, ".")
+	signingString := strings.Join(parts[0:2], ".")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := method.Verify(signingString, parts[2], verifyKey); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestParser_ParseMalformedSegment(t *testing.T) {
+	var malformedTestData = []struct {
+		name        string
+		tokenString string
+		segment     jwt.Segment
+	}{
+		{
+			"corrupt header base64",
+			"eyJ0eXAiOiJKV1QiLCJhbGciOiJSUzI1NiJ9!.eyJmb28iOiJiYXIifQ.sig",
+			jwt.SegmentHeader,
+		},
+		{
+			"corrupt header json",
+			jwt.EncodeSegment([]byte(`{"alg":`)) + ".eyJmb28iOiJiYXIifQ.sig",
+			jwt.SegmentHeader,
+		},
+		{
+			"corrupt payload base64",
+			"eyJ0eXAiOiJKV1QiLCJhbGciOiJSUzI1NiJ9.eyJmb28iOiJiYXIifQ!.sig",
+			jwt.SegmentClaims,
+		},
+		{
+			"corrupt payload json",
+			"eyJ0eXAiOiJKV1QiLCJhbGciOiJSUzI1NiJ9." + jwt.EncodeSegment([]byte(`{"foo":"bar"`)) + ".sig",
+			jwt.SegmentClaims,
+		},
+	}
+
+	for _, data := range malformedTestData {
+		_, err := new(jwt.Parser).Parse(data.tokenString, defaultKeyFunc)
+		ve, ok := err.(*jwt.ValidationError)
+		if !ok {
+			t.Errorf("[%v] Expecting *jwt.ValidationError, got %T", data.name, err)
+			continue
+		}
+		if ve.Errors&jwt.ValidationErrorMalformed == 0 {
+			t.Errorf("[%v] Expecting ValidationErrorMalformed, got %v", data.name, ve.Errors)
+		}
+		if ve.Segment != data.segment {
+			t.Errorf("[%v] Expecting segment %v, got %v", data.name, data.segment, ve.Segment)
+		}
+		if want := fmt.Sprintf("(index %d)", data.segment.Index()); !strings.Contains(err.Error(), want) {
+			t.Errorf("[%v] Expecting error message to contain %q, got %q", data.name, want, err.Error())
+		}
+	}
+}
+
+func TestParser_ParseCorruptPayloadKeepsHeader(t *testing.T) {
+	header := "eyJ0eXAiOiJKV1QiLCJhbGciOiJSUzI1NiJ9"
+	for _, payload := range []string{"eyJmb28iOiJiYXIifQ!", jwt.EncodeSegment([]byte(`{"foo":"bar"`))} {
+		for _, claims := range []jwt.Claims{jwt.MapClaims{}, &jwt.StandardClaims{}} {
+			token, err := new(jwt.Parser).ParseWithClaims(header+"."+payload+".sig", claims, defaultKeyFunc)
+			if ve, ok := err.(*jwt.ValidationError); !ok || ve.Segment != jwt.SegmentClaims {
+				t.Errorf("[%v, %T] Expecting a claims segment error, got %v", payload, claims, err)
+			}
+			if token == nil {
+				t.Errorf("[%v, %T] Expecting a token", payload, claims)
+				continue
+			}
+			if token.Header["alg"] != "RS256" || token.Header["typ"] != "JWT" {
+				t.Errorf("[%v, %T] Expecting the decoded header, got %v", payload, claims, token.Header)
+			}
+			if token.Method != jwt.SigningMethodRS256 {
+				t.Errorf("[%v, %T] Expecting RS256, got %v", payload, claims, token.Method)
+			}
+		}
+	}
+}
+
+func TestParser_ParseClaimsNotObject(t *testing.T) {
+	header := "eyJ0eXAiOiJKV1QiLCJhbGciOiJIUzI1NiJ9"
+	for _, payload := range []string{`[1,2,3]`, `"string"`, `42`, `null`, ` [{}]`} {
+		for _, claims := range []jwt.Claims{jwt.MapClaims{}, &jwt.StandardClaims{}} {
+			tokenString := header + "." + jwt.EncodeSegment([]byte(payload)) + ".sig"
+			_, err := new(jwt.Parser).ParseWithClaims(tokenString, claims, defaultKeyFunc)
+			ve, ok := err.(*jwt.ValidationError)
+			if !ok || ve.Errors != jwt.ValidationErrorMalformed || ve.Segment != jwt.SegmentClaims {
+				t.Errorf("[%v, %T] Expecting ValidationErrorMalformed in the claims, got %v", payload, claims, err)
+				continue
+			}
+			if !strings.Contains(err.Error(), jwt.ErrClaimsNotObject.Error()) {
+				t.Errorf("[%v, %T] Expecting %q, got %q", payload, claims, jwt.ErrClaimsNotObject, err)
+			}
+		}
+	}
+}
+
+func TestParser_ParseSignatureValidClaimsInvalid(t *testing.T) {
+	privateKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	tokenString := test.MakeSampleToken(jwt.MapClaims{"sub": "alice", "exp": float64(time.Now().Unix() - 100)}, privateKey)
+
+	token, err := new(jwt.Parser).Parse(tokenString, defaultKeyFunc)
+	if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorExpired {
+		t.Fatalf("Expecting ValidationErrorExpired, got %v", err)
+	}
+	if token.Valid {
+		t.Errorf("Expired token should not be valid")
+	}
+	if !token.SignatureValid {
+		t.Errorf("Expecting SignatureValid for an expired token with a good signature")
+	}
+	if sub := token.Claims.(jwt.MapClaims)["sub"]; sub != "alice" {
+		t.Errorf("Expecting sub to be readable from expired token, got %v", sub)
+	}
+
+	// A bad signature must never be reported as verified
+	parts := strings.Split(tokenString, ".")
+	token, _ = new(jwt.Parser).Parse(parts[0]+"."+parts[1]+".AAAA", defaultKeyFunc)
+	if token.SignatureValid {
+		t.Errorf("Expecting SignatureValid to be false for a bad signature")
+	}
+}
+
+func TestParser_ParseJWE(t *testing.T) {
+	header := jwt.EncodeSegment([]byte(`{"alg":"RSA-OAEP","enc":"A256GCM"}`))
+	tokenString := header + ".a2V5.aXY.Y2lwaGVydGV4dA.dGFn"
+
+	_, err := new(jwt.Parser).Parse(tokenString, defaultKeyFunc)
+	if ve, ok := err.(*jwt.ValidationError); !ok || ve.Inner != jwt.ErrTokenIsJWE {
+		t.Fatalf("Expecting ErrTokenIsJWE, got %v", err)
+	}
+
+	// Five segments without an enc header are just malformed
+	header = jwt.EncodeSegment([]byte(`{"alg":"RS256"}`))
+	_, err = new(jwt.Parser).Parse(header+".a.b.c.d", defaultKeyFunc)
+	if ve, ok := err.(*jwt.ValidationError); !ok || ve.Inner == jwt.ErrTokenIsJWE || ve.Errors != jwt.ValidationErrorMalformed {
+		t.Fatalf("Expecting a generic malformed error, got %v", err)
+	}
+}
+
+// Signing method that accepts any signature, to check the parser itself
+// rejects empty signatures
+type acceptAllSigningMethod struct{}
+
+func (m acceptAllSigningMethod) Alg() string { return "accept-all-test" }
+func (m acceptAllSigningMethod) Verify(signingString, signature string, key interface{}) error {
+	return nil
+}
+func (m acceptAllSigningMethod) Sign(signingString string, key interface{}) (string, error) {
+	return "", nil
+}
+
+func TestParser_ParseEmptySignature(t *testing.T) {
+	defer jwt.SnapshotSigningMethods()()
+	jwt.RegisterSigningMethod(acceptAllSigningMethod{}.Alg(), func() jwt.SigningMethod {
+		return acceptAllSigningMethod{}
+	})
+
+	for _, alg := range []string{"RS256", "HS256", acceptAllSigningMethod{}.Alg()} {
+		header := jwt.EncodeSegment([]byte(`{"alg":"` + alg + `","typ":"JWT"}`))
+		tokenString := header + ".eyJmb28iOiJiYXIifQ."
+
+		token, err := new(jwt.Parser).Parse(tokenString, defaultKeyFunc)
+		if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorSignatureInvalid {
+			t.Errorf("[%v] Expecting ValidationErrorSignatureInvalid, got %v", alg, err)
+		}
+		if token.Valid || token.SignatureValid {
+			t.Errorf("[%v] Token with empty signature should not be valid", alg)
+		}
+	}
+}
+
+// Shares one parser between goroutines.  Run with -race to detect shared state.
+func TestParser_ParseConcurrent(t *testing.T) {
+	privateKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	parser := jwt.NewParser(jwt.WithAllowedSkew(time.Minute))
+	parser.ValidMethods = []string{"RS256"}
+
+	const workers = 16
+	tokens := make([]string, workers)
+	for i := range tokens {
+		tokens[i] = test.MakeSampleToken(jwt.MapClaims{"worker": float64(i)}, privateKey)
+	}
+
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		go func(i int) {
+			for n := 0; n < 20; n++ {
+				token, err := parser.Parse(tokens[i], defaultKeyFunc)
+				if err != nil {
+					errs <- err
+					return
+				}
+				if worker := token.Claims.(jwt.MapClaims)["worker"]; worker != float64(i) {
+					errs <- fmt.Errorf("worker %v parsed claims of worker %v", i, worker)
+					return
+				}
+			}
+			errs <- nil
+		}(i)
+	}
+	for i := 0; i < workers; i++ {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestParser_ParseNilKey(t *testing.T) {
+	privateKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	tokenString := test.MakeSampleToken(jwt.MapClaims{"foo": "bar"}, privateKey)
+
+	var nilKeyTestData = []struct {
+		name    string
+		keyfunc jwt.Keyfunc
+	}{
+		{"nil interface", emptyKeyFunc},
+		{"typed nil pointer", func(*jwt.Token) (interface{}, error) { return (*rsa.PublicKey)(nil), nil }},
+		{"nil byte slice", func(*jwt.Token) (interface{}, error) { return []byte(nil), nil }},
+	}
+
+	for _, data := range nilKeyTestData {
+		_, err := new(jwt.Parser).Parse(tokenString, data.keyfunc)
+		ve, ok := err.(*jwt.ValidationError)
+		if !ok || ve.Inner != jwt.ErrNilKey || ve.Errors != jwt.ValidationErrorUnverifiable {
+			t.Errorf("[%v] Expecting ErrNilKey, got %v", data.name, err)
+		}
+	}
+}
+
+// Claims recording whether Valid was called
+type recordingClaims struct {
+	jwt.StandardClaims
+	validated *bool
+}
+
+func (c recordingClaims) Valid() error {
+	*c.validated = true
+	return c.StandardClaims.Valid()
+}
+
+func TestParser_ParseVerifiesSignatureFirst(t *testing.T) {
+	key := []byte("secret")
+	expired := jwt.StandardClaims{ExpiresAt: time.Now().Unix() - 100}
+	tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, expired).SignedString(key)
+	wrongKey := func(*jwt.Token) (interface{}, error) { return []byte("other"), nil }
+
+	var parserOrderTestData = []struct {
+		name      string
+		parser    *jwt.Parser
+		keyFunc   jwt.Keyfunc
+		validated bool
+		errors    uint32
+	}{
+		{"valid signature", new(jwt.Parser), func(*jwt.Token) (interface{}, error) { return key, nil }, true, jwt.ValidationErrorExpired},
+		{"invalid signature", new(jwt.Parser), wrongKey, false, jwt.ValidationErrorSignatureInvalid},
+		{"invalid signature, diagnostics", jwt.NewParser(jwt.WithClaimsValidationOnInvalidSignature()), wrongKey, true, jwt.ValidationErrorSignatureInvalid | jwt.ValidationErrorExpired},
+	}
+
+	for _, data := range parserOrderTestData {
+		var validated bool
+		_, err := data.parser.ParseWithClaims(tokenString, &recordingClaims{validated: &validated}, data.keyFunc)
+		if validated != data.validated {
+			t.Errorf("[%v] Expecting Valid called %v, got %v", data.name, data.validated, validated)
+		}
+		ve, ok := err.(*jwt.ValidationError)
+		if !ok || ve.Errors != data.errors {
+			t.Errorf("[%v] Expecting errors %v, got %v", data.name, data.errors, err)
+			continue
+		}
+		if data.errors&jwt.ValidationErrorSignatureInvalid != 0 && ve.Inner != jwt.ErrSignatureInvalid {
+			t.Errorf("[%v] Expecting the signature error as the cause, got %v", data.name, ve.Inner)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package jwt
+
+import (
+	"crypto"
+	"encoding/pem"
+)
+
+// Parses every PEM block of pemBytes, such as a bundle of concatenated
+// public keys during a key rotation, into an RSA, EC or Ed25519 public key.  A block
+// may hold a PKIX or PKCS1 public key or a certificate.  Fails if any block
+// is not one of those, or if there is no block at all.
+func ParsePublicKeysFromPEM(pemBytes []byte) ([]crypto.PublicKey, error) {
+	var keys []crypto.PublicKey
+	for {
+		var block *pem.Block
+		if block, pemBytes = pem.Decode(pemBytes); block == nil {
+			break
+		}
+		key, err := parsePublicKeyFromPEM(pem.EncodeToMemory(block))
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		return nil, ErrKeyMustBePEMEncoded
+	}
+	return keys, nil
+}
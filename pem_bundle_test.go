@@ -0,0 +1,53 @@
+package jwt_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"io/ioutil"
+	"testing"
+
+	"github.com/form3tech-oss/jwt-go"
+)
+
+// Reads the PEM files and concatenates them into one bundle
+func loadPEMBundle(t *testing.T, files ...string) []byte {
+	var bundle []byte
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bundle = append(bundle, data...)
+	}
+	return bundle
+}
+
+func TestParsePublicKeysFromPEM(t *testing.T) {
+	keys, err := jwt.ParsePublicKeysFromPEM(loadPEMBundle(t, "test/ec256-public.pem", "test/sample_key.pub"))
+	if err != nil {
+		t.Fatalf("Error parsing bundle: %v", err)
+	}
+	if len(keys) != 2 {
+		t.Fatalf("Expecting 2 keys, got %v", len(keys))
+	}
+	if _, ok := keys[0].(*ecdsa.PublicKey); !ok {
+		t.Errorf("Expecting the first key to be *ecdsa.PublicKey, got %T", keys[0])
+	}
+	if _, ok := keys[1].(*rsa.PublicKey); !ok {
+		t.Errorf("Expecting the second key to be *rsa.PublicKey, got %T", keys[1])
+	}
+
+	var invalidBundleTestData = []struct {
+		name   string
+		bundle []byte
+	}{
+		{"empty", nil},
+		{"not PEM", []byte("not a key")},
+		{"private key", loadPEMBundle(t, "test/sample_key.pub", "test/sample_key")},
+	}
+	for _, data := range invalidBundleTestData {
+		if _, err := jwt.ParsePublicKeysFromPEM(data.bundle); err == nil {
+			t.Errorf("[%v] Expecting an error", data.name)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package jwt
+
+import (
+	"fmt"
+	"time"
+)
+
+// Structured version of the registered claims of RFC 7519 section 4.1, like
+// StandardClaims, but with the time based claims as NumericDate, so they
+// read as time.Time whatever their JSON encoding.  Unset time claims are
+// nil.  Embed it in custom claims types.
+type RegisteredClaims struct {
+	Issuer    string       `json:"iss,omitempty"`
+	Subject   string       `json:"sub,omitempty"`
+	Audience  ClaimStrings `json:"aud,omitempty"`
+	ExpiresAt *NumericDate `json:"exp,omitempty"`
+	NotBefore *NumericDate `json:"nbf,omitempty"`
+	IssuedAt  *NumericDate `json:"iat,omitempty"`
+	ID        string       `json:"jti,omitempty"`
+}
+
+// Validates time based claims "exp, iat, nbf", as StandardClaims.Valid does.
+// There is no accounting for clock skew, and claims that are unset pass.
+// Without any of them TimeFunc is not called.
+func (c RegisteredClaims) Valid() error {
+	if c.ExpiresAt == nil && c.IssuedAt == nil && c.NotBefore == nil {
+		return nil
+	}
+
+	vErr := new(ValidationError)
+	now := TimeFunc()
+
+	if !c.VerifyExpiresAt(now, false) {
+		delta := now.Sub(c.ExpiresAt.Time).Truncate(time.Second)
+		vErr.Inner = fmt.Errorf("token is expired by %v", delta)
+		vErr.Errors |= ValidationErrorExpired
+	}
+
+	if !c.VerifyIssuedAt(now, false) {
+		vErr.Inner = fmt.Errorf("Token used before issued")
+		vErr.Errors |= ValidationErrorIssuedAt
+	}
+
+	if !c.VerifyNotBefore(now, false) {
+		vErr.Inner = fmt.Errorf("token is not valid yet")
+		vErr.Errors |= ValidationErrorNotValidYet
+	}
+
+	if vErr.valid() {
+		return nil
+	}
+
+	return vErr
+}
+
+// Compares the aud claim against cmp.
+// If required is false, this method will return true if the value matches or is unset
+func (c *RegisteredClaims) VerifyAudience(cmp string, req bool) bool {
+	return verifyAud(c.Audience, cmp, req)
+}
+
+// Compares the exp claim against cmp.  Fractional seconds are truncated.
+// If required is false, this method will return true if the value matches or is unset
+func (c *RegisteredClaims) VerifyExpiresAt(cmp time.Time, req bool) bool {
+	return verifyExp(c.ExpiresAt.unix(), cmp.Unix(), req)
+}
+
+// Compares the iat claim against cmp.
+// If required is false, this method will return true if the value matches or is unset
+func (c *RegisteredClaims) VerifyIssuedAt(cmp time.Time, req bool) bool {
+	return verifyIat(c.IssuedAt.unix(), cmp.Unix(), req)
+}
+
+// Compares the iss claim against cmp.
+// If required is false, this method will return true if the value matches or is unset
+func (c *RegisteredClaims) VerifyIssuer(cmp string, req bool) bool {
+	return verifyIss(c.Issuer, cmp, req)
+}
+
+// Compares the nbf claim against cmp.
+// If required is false, this method will return true if the value matches or is unset
+func (c *RegisteredClaims) VerifyNotBefore(cmp time.Time, req bool) bool {
+	return verifyNbf(c.NotBefore.unix(), cmp.Unix(), req)
+}
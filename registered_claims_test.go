@@ -0,0 +1,66 @@
+package jwt_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/form3tech-oss/jwt-go"
+)
+
+func TestRegisteredClaims(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	now := time.Now()
+
+	var registeredClaimsTestData = []struct {
+		name   string
+		claims jwt.MapClaims
+		errors uint32
+	}{
+		{"no time claims", jwt.MapClaims{"sub": "alice"}, 0},
+		{"valid", jwt.MapClaims{"iat": now.Unix(), "nbf": float64(now.Unix()), "exp": float64(now.Add(time.Hour).Unix()) + 0.5}, 0},
+		{"expired", jwt.MapClaims{"exp": now.Add(-time.Minute).Unix()}, jwt.ValidationErrorExpired},
+		{"not yet valid", jwt.MapClaims{"nbf": now.Add(time.Minute).Unix()}, jwt.ValidationErrorNotValidYet},
+		{"issued in the future", jwt.MapClaims{"iat": now.Add(time.Minute).Unix()}, jwt.ValidationErrorIssuedAt},
+	}
+
+	for _, data := range registeredClaimsTestData {
+		data.claims["iss"] = "https://issuer.example.com"
+		data.claims["aud"] = []string{"api"}
+		tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, data.claims).SignedString(key)
+
+		claims := &jwt.RegisteredClaims{}
+		_, err := jwt.ParseWithClaims(tokenString, claims, keyFunc)
+		if data.errors == 0 && err != nil {
+			t.Errorf("[%v] Error while parsing token: %v", data.name, err)
+		}
+		if data.errors != 0 {
+			if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != data.errors {
+				t.Errorf("[%v] Expecting error bits %v, got %v", data.name, data.errors, err)
+			}
+		}
+		if !claims.VerifyIssuer("https://issuer.example.com", true) || !claims.VerifyAudience("api", true) {
+			t.Errorf("[%v] Expecting iss and aud to decode, got %+v", data.name, claims)
+		}
+		if _, ok := data.claims["exp"]; !ok && claims.ExpiresAt != nil {
+			t.Errorf("[%v] Expecting an unset exp to be nil, got %v", data.name, claims.ExpiresAt)
+		}
+	}
+
+	// Time claims round trip as whole seconds
+	claims := jwt.RegisteredClaims{Subject: "alice", ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour))}
+	tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+	parsed := &jwt.RegisteredClaims{}
+	if _, err := jwt.ParseWithClaims(tokenString, parsed, keyFunc); err != nil {
+		t.Fatalf("Error while parsing token: %v", err)
+	}
+	if !parsed.ExpiresAt.Equal(claims.ExpiresAt.Time) || parsed.Subject != "alice" || parsed.IssuedAt != nil {
+		t.Errorf("Expecting the claims to round trip, got %+v", parsed)
+	}
+	if parsed.VerifyExpiresAt(now.Add(2*time.Hour), true) || !parsed.VerifyExpiresAt(now, true) {
+		t.Errorf("Expecting VerifyExpiresAt to compare against exp")
+	}
+	if !parsed.VerifyNotBefore(now, false) || parsed.VerifyNotBefore(now, true) {
+		t.Errorf("Expecting an unset nbf to pass only when not required")
+	}
+}
@@ -0,0 +1,89 @@
+package jwt
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Tracks the jti claims of one-time-use tokens, such as DPoP proofs or
+// password reset links, so a token can't be presented twice.  See
+// WithReplayDetector.
+type ReplayDetector interface {
+	// Seen records jti as used until exp, the zero Time for a token without
+	// an exp claim, and reports whether it was recorded before.  ctx is that
+	// of ParseWithClaimsContext, or context.Background().
+	Seen(ctx context.Context, jti string, exp time.Time) (bool, error)
+}
+
+// An in-memory ReplayDetector.  A jti is remembered until its exp, or for
+// the ttl given to NewMemoryReplayDetector if the token has no exp, and
+// forgotten entries are pruned as new ones are recorded.  Only use it with a
+// single process; replicas need a shared store.  A MemoryReplayDetector is
+// safe for concurrent use.
+type MemoryReplayDetector struct {
+	ttl time.Duration
+
+	mu        sync.Mutex
+	entries   map[string]time.Time // Expiry of each jti seen
+	nextPrune time.Time
+}
+
+// Creates a MemoryReplayDetector remembering a jti without exp for ttl
+func NewMemoryReplayDetector(ttl time.Duration) *MemoryReplayDetector {
+	return &MemoryReplayDetector{ttl: ttl, entries: make(map[string]time.Time)}
+}
+
+// Implements the Seen method from ReplayDetector
+func (d *MemoryReplayDetector) Seen(_ context.Context, jti string, exp time.Time) (bool, error) {
+	now := TimeFunc()
+	if exp.IsZero() {
+		exp = now.Add(d.ttl)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if now.After(d.nextPrune) {
+		for k, until := range d.entries {
+			if now.After(until) {
+				delete(d.entries, k)
+			}
+		}
+		d.nextPrune = now.Add(time.Minute)
+	}
+
+	if until, ok := d.entries[jti]; ok && !now.After(until) {
+		return true, nil
+	}
+	d.entries[jti] = exp
+	return false, nil
+}
+
+// Records the jti of token with the replay detector, once everything else
+// about the token checked out
+func (p *Parser) checkReplay(token *Token, parts []string) *ValidationError {
+	claims, err := p.mapClaims(token, parts)
+	if err != nil {
+		return &ValidationError{Inner: err, Errors: ValidationErrorMalformed}
+	}
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		return NewValidationError("token has no jti claim", ValidationErrorId)
+	}
+
+	// Accepted until exp plus the skew, so it must be remembered as long.
+	// With the exp check downgraded to a warning it is accepted for good,
+	// and is remembered as a token without exp is.
+	var exp time.Time
+	if date, ok := claims.numericDate("exp"); ok && p.warnOnly&ValidationErrorExpired == 0 {
+		exp = time.Unix(date, 0).Add(p.skew())
+	}
+	seen, err := p.replayDetector.Seen(p.context(), jti, exp)
+	if err != nil {
+		return &ValidationError{Inner: err, Errors: ValidationErrorId}
+	}
+	if seen {
+		return &ValidationError{Inner: ErrTokenReplayed, Errors: ValidationErrorId}
+	}
+	return nil
+}
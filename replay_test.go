@@ -0,0 +1,106 @@
+package jwt_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/form3tech-oss/jwt-go"
+)
+
+// Records the context and expiry it was called with
+type recordingReplayDetector struct {
+	ctx context.Context
+	exp time.Time
+	err error
+}
+
+func (d *recordingReplayDetector) Seen(ctx context.Context, jti string, exp time.Time) (bool, error) {
+	d.ctx, d.exp = ctx, exp
+	return false, d.err
+}
+
+func TestParser_WithReplayDetector(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	now := time.Now().Unix()
+	sign := func(claims jwt.MapClaims) string {
+		tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+		return tokenString
+	}
+	parser := jwt.NewParser(jwt.WithReplayDetector(jwt.NewMemoryReplayDetector(time.Hour)))
+	once := sign(jwt.MapClaims{"jti": "a", "exp": now + 100})
+	forged, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"jti": "e"}).SignedString([]byte("other"))
+
+	var replayTestData = []struct {
+		name        string
+		tokenString string
+		errors      uint32
+	}{
+		{"first use", once, 0},
+		{"replayed", once, jwt.ValidationErrorId},
+		{"other jti", sign(jwt.MapClaims{"jti": "b", "exp": now + 100}), 0},
+		{"no exp", sign(jwt.MapClaims{"jti": "c"}), 0},
+		{"no exp, replayed", sign(jwt.MapClaims{"jti": "c"}), jwt.ValidationErrorId},
+		{"no jti", sign(jwt.MapClaims{"exp": now + 100}), jwt.ValidationErrorId},
+		{"expired does not use up its jti", sign(jwt.MapClaims{"jti": "d", "exp": now - 100}), jwt.ValidationErrorExpired},
+		{"valid with that jti", sign(jwt.MapClaims{"jti": "d", "exp": now + 100}), 0},
+		{"forged does not use up its jti", forged, jwt.ValidationErrorSignatureInvalid},
+		{"genuine with that jti", sign(jwt.MapClaims{"jti": "e", "exp": now + 100}), 0},
+	}
+
+	for _, data := range replayTestData {
+		token, err := parser.Parse(data.tokenString, keyFunc)
+		if data.errors == 0 {
+			if err != nil || !token.Valid {
+				t.Errorf("[%v] Error while parsing token: %v", data.name, err)
+			}
+			continue
+		}
+		if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != data.errors {
+			t.Errorf("[%v] Expecting error bits %v, got %v", data.name, data.errors, err)
+		}
+	}
+
+	_, err := parser.Parse(once, keyFunc)
+	if ve, ok := err.(*jwt.ValidationError); !ok || ve.Inner != jwt.ErrTokenReplayed {
+		t.Errorf("Expecting ErrTokenReplayed, got %v", err)
+	}
+
+	// A token accepted with warnings uses up its jti too
+	parser = jwt.NewParser(jwt.WithReplayDetector(jwt.NewMemoryReplayDetector(time.Hour)), jwt.WithWarnOnly(jwt.ValidationErrorExpired))
+	expired := sign(jwt.MapClaims{"jti": "h", "exp": now - 100})
+	token, err := parser.Parse(expired, keyFunc)
+	if err != nil || !token.Valid || len(token.Warnings) != 1 {
+		t.Errorf("[warn only] Expecting the expired token to be accepted with a warning, got %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		_, err = parser.Parse(expired, keyFunc)
+		if ve, ok := err.(*jwt.ValidationError); !ok || ve.Inner != jwt.ErrTokenReplayed {
+			t.Errorf("[warn only, replayed] Expecting ErrTokenReplayed, got %v", err)
+		}
+	}
+
+	// The detector gets the context of the parse and exp plus the skew
+	type ctxKey struct{}
+	ctx := context.WithValue(context.Background(), ctxKey{}, "request")
+	detector := &recordingReplayDetector{}
+	parser = jwt.NewParser(jwt.WithReplayDetector(detector), jwt.WithAllowedSkew(time.Minute))
+	_, err = parser.ParseWithContext(ctx, sign(jwt.MapClaims{"jti": "f", "exp": now + 100}), func(context.Context, *jwt.Token) (interface{}, error) { return key, nil })
+	if err != nil {
+		t.Errorf("Error while parsing token: %v", err)
+	}
+	if detector.ctx != ctx || !detector.exp.Equal(time.Unix(now+160, 0)) {
+		t.Errorf("Expecting the parse context and exp plus skew, got %v and %v", detector.ctx, detector.exp)
+	}
+
+	detector.err = errors.New("store unavailable")
+	_, err = parser.Parse(sign(jwt.MapClaims{"jti": "g"}), keyFunc)
+	if ve, ok := err.(*jwt.ValidationError); !ok || ve.Inner != detector.err || ve.Errors != jwt.ValidationErrorId {
+		t.Errorf("Expecting the store error, got %v", err)
+	}
+	if detector.ctx != context.Background() {
+		t.Errorf("Expecting context.Background() for Parse")
+	}
+}
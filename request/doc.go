@@ -4,4 +4,7 @@
 // The main function is ParseFromRequest and it's WithClaims variant.
 // See examples for how to use the various Extractor implementations
 // or roll your own.
+//
+// Middleware wraps an http.Handler, only passing on requests with a valid
+// token, which handlers retrieve with jwt.TokenFromContext.
 package request
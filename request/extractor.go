@@ -50,6 +50,37 @@ func (e ArgumentExtractor) ExtractToken(req *http.Request) (string, error) {
 	return "", ErrNoTokenInRequest
 }
 
+// Extract token from the query parameters of the request URL only.  Unlike
+// ArgumentExtractor it never reads the request body.  Parameter names are
+// tried in order until there's a match.
+type QueryParameterExtractor []string
+
+func (e QueryParameterExtractor) ExtractToken(req *http.Request) (string, error) {
+	query := req.URL.Query()
+	// loop over parameter names and return the first one that contains data
+	for _, param := range e {
+		if tok := query.Get(param); tok != "" {
+			return tok, nil
+		}
+	}
+	return "", ErrNoTokenInRequest
+}
+
+// Extractor for finding a token in a cookie, as stored by browser-facing
+// services.  Looks at each specified cookie name in order until there's a
+// match.  A request without any of the cookies yields ErrNoTokenInRequest.
+type CookieExtractor []string
+
+func (e CookieExtractor) ExtractToken(req *http.Request) (string, error) {
+	// loop over cookie names and return the first one that contains data
+	for _, name := range e {
+		if cookie, err := req.Cookie(name); err == nil && cookie.Value != "" {
+			return cookie.Value, nil
+		}
+	}
+	return "", ErrNoTokenInRequest
+}
+
 // Tries Extractors in order until one returns a token string or an error occurs
 type MultiExtractor []Extractor
 
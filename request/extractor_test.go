@@ -34,6 +34,34 @@ var extractorTestData = []struct {
 		token:     extractorTestTokenA,
 		err:       nil,
 	},
+	{
+		name:      "query parameter",
+		extractor: QueryParameterExtractor{"access_token", "token"},
+		headers:   map[string]string{},
+		query:     url.Values{"token": {extractorTestTokenA}},
+		token:     extractorTestTokenA,
+		err:       nil,
+	},
+	{
+		name:      "query parameter miss",
+		extractor: QueryParameterExtractor{"token"},
+		headers:   map[string]string{"token": extractorTestTokenA},
+		query:     url.Values{"other": {extractorTestTokenB}},
+		token:     "",
+		err:       ErrNoTokenInRequest,
+	},
+	{
+		name: "cookie fallback",
+		extractor: MultiExtractor{
+			AuthorizationHeaderExtractor,
+			CookieExtractor{"session"},
+			QueryParameterExtractor{"token"},
+		},
+		headers: map[string]string{"Cookie": "session=" + extractorTestTokenA},
+		query:   url.Values{"token": {extractorTestTokenB}},
+		token:   extractorTestTokenA,
+		err:     nil,
+	},
 	{
 		name: "multiple extractors",
 		extractor: MultiExtractor{
@@ -53,6 +81,22 @@ var extractorTestData = []struct {
 		token:     "",
 		err:       ErrNoTokenInRequest,
 	},
+	{
+		name:      "simple cookie",
+		extractor: CookieExtractor{"session"},
+		headers:   map[string]string{"Cookie": "theme=dark; session=" + extractorTestTokenA},
+		query:     nil,
+		token:     extractorTestTokenA,
+		err:       nil,
+	},
+	{
+		name:      "cookie miss",
+		extractor: CookieExtractor{"session"},
+		headers:   map[string]string{"Cookie": "theme=dark"},
+		query:     nil,
+		token:     "",
+		err:       ErrNoTokenInRequest,
+	},
 	{
 		name:      "filter",
 		extractor: AuthorizationHeaderExtractor,
@@ -0,0 +1,119 @@
+package request
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/form3tech-oss/jwt-go"
+)
+
+// Writes the response to a request whose token is missing or invalid.  err
+// is ErrNoTokenInRequest, an error of the Extractor, or the error of the
+// parser.
+type ErrorHandler func(w http.ResponseWriter, req *http.Request, err error)
+
+// Returns an ErrorHandler responding with 401 Unauthorized and a bearer
+// token challenge, RFC 6750 section 3, in the WWW-Authenticate header.  A
+// request with a token that failed to verify also gets the invalid_token
+// error code, in the challenge and as a JSON body:
+//
+//	{"error": "invalid_token", "error_description": "the token is expired"}
+//
+// The description never includes the parser's error, which may reveal more
+// of the verification than clients should learn.
+func UnauthorizedHandler(realm string) ErrorHandler {
+	return func(w http.ResponseWriter, req *http.Request, err error) {
+		challenge := "Bearer"
+		if realm != "" {
+			challenge += fmt.Sprintf(" realm=%q", realm)
+		}
+		if err == ErrNoTokenInRequest {
+			w.Header().Set("WWW-Authenticate", challenge)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		description := "the token is invalid"
+		if ve, ok := err.(*jwt.ValidationError); ok && ve.Errors&jwt.ValidationErrorExpired != 0 {
+			description = "the token is expired"
+		}
+		if realm != "" {
+			challenge += ","
+		}
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`%v error="invalid_token", error_description=%q`, challenge, description))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnauthorized)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_token", "error_description": description})
+	}
+}
+
+type middleware struct {
+	extractor    Extractor
+	keyFunc      jwt.Keyfunc
+	parser       *jwt.Parser
+	newClaims    func() jwt.Claims
+	errorHandler ErrorHandler
+}
+
+type MiddlewareOption func(*middleware)
+
+// Extract the token with extractor, AuthorizationHeaderExtractor by default
+func WithExtractor(extractor Extractor) MiddlewareOption {
+	return func(m *middleware) {
+		m.extractor = extractor
+	}
+}
+
+// Decode the claims of every request into a value returned by newClaims,
+// rather than into MapClaims.  A new value is needed per request, as
+// requests are handled concurrently.
+func WithNewClaims(newClaims func() jwt.Claims) MiddlewareOption {
+	return func(m *middleware) {
+		m.newClaims = newClaims
+	}
+}
+
+// Parse using a custom parser, e.g. one requiring an issuer and audience
+func WithMiddlewareParser(parser *jwt.Parser) MiddlewareOption {
+	return func(m *middleware) {
+		m.parser = parser
+	}
+}
+
+// Respond to requests without a valid token with handler, rather than with
+// UnauthorizedHandler("")
+func WithErrorHandler(handler ErrorHandler) MiddlewareOption {
+	return func(m *middleware) {
+		m.errorHandler = handler
+	}
+}
+
+// Returns a middleware that extracts and verifies the token of every request
+// with keyFunc, as ParseFromRequest does.  Requests with a valid token are
+// passed to the next handler, with the token in their context; retrieve it
+// with jwt.TokenFromContext.  Other requests are answered by the error
+// handler and go no further.
+func Middleware(keyFunc jwt.Keyfunc, options ...MiddlewareOption) func(http.Handler) http.Handler {
+	m := &middleware{
+		extractor:    AuthorizationHeaderExtractor,
+		keyFunc:      keyFunc,
+		parser:       &jwt.Parser{},
+		newClaims:    func() jwt.Claims { return jwt.MapClaims{} },
+		errorHandler: UnauthorizedHandler(""),
+	}
+	for _, option := range options {
+		option(m)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			token, err := ParseFromRequest(req, m.extractor, m.keyFunc, WithClaims(m.newClaims()), WithParser(m.parser))
+			if err != nil {
+				m.errorHandler(w, req, err)
+				return
+			}
+			next.ServeHTTP(w, req.WithContext(jwt.WithTokenContext(req.Context(), token)))
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package request
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/form3tech-oss/jwt-go"
+	"github.com/form3tech-oss/jwt-go/test"
+)
+
+func TestMiddleware(t *testing.T) {
+	privateKey := test.LoadRSAPrivateKeyFromDisk("../test/sample_key")
+	publicKey := test.LoadRSAPublicKeyFromDisk("../test/sample_key.pub")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return publicKey, nil }
+
+	handler := Middleware(keyFunc, WithErrorHandler(UnauthorizedHandler("api")))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := jwt.TokenFromContext(r.Context())
+		if !ok {
+			t.Errorf("Expecting the token in the request context")
+			return
+		}
+		w.Write([]byte(token.Claims.(jwt.MapClaims)["sub"].(string)))
+	}))
+
+	var middlewareTestData = []struct {
+		name      string
+		header    string
+		status    int
+		challenge string
+		body      string
+	}{
+		{"valid", "Bearer " + test.MakeSampleToken(jwt.MapClaims{"sub": "alice"}, privateKey), http.StatusOK, "", "alice"},
+		{"no token", "", http.StatusUnauthorized, `Bearer realm="api"`, ""},
+		{"expired", "Bearer " + test.MakeSampleToken(jwt.MapClaims{"exp": time.Now().Unix() - 100}, privateKey), http.StatusUnauthorized, `Bearer realm="api", error="invalid_token", error_description="the token is expired"`, "the token is expired"},
+		{"malformed", "Bearer abc", http.StatusUnauthorized, `Bearer realm="api", error="invalid_token", error_description="the token is invalid"`, "the token is invalid"},
+	}
+
+	for _, data := range middlewareTestData {
+		r := makeExampleRequest("GET", "/", map[string]string{"Authorization": data.header}, nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != data.status {
+			t.Errorf("[%v] Expecting status %v, got %v", data.name, data.status, w.Code)
+		}
+		if challenge := w.Header().Get("WWW-Authenticate"); challenge != data.challenge {
+			t.Errorf("[%v] Expecting challenge %q, got %q", data.name, data.challenge, challenge)
+		}
+		if data.status == http.StatusOK {
+			if w.Body.String() != data.body {
+				t.Errorf("[%v] Expecting body %q, got %q", data.name, data.body, w.Body.String())
+			}
+			continue
+		}
+		var body map[string]string
+		json.Unmarshal(w.Body.Bytes(), &body)
+		if body["error_description"] != data.body {
+			t.Errorf("[%v] Expecting error description %q, got %q", data.name, data.body, body["error_description"])
+		}
+	}
+}
+
+func TestMiddleware_options(t *testing.T) {
+	privateKey := test.LoadRSAPrivateKeyFromDisk("../test/sample_key")
+	publicKey := test.LoadRSAPublicKeyFromDisk("../test/sample_key.pub")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return publicKey, nil }
+
+	var handled error
+	handler := Middleware(keyFunc,
+		WithExtractor(CookieExtractor{"session"}),
+		WithNewClaims(func() jwt.Claims { return &jwt.StandardClaims{} }),
+		WithMiddlewareParser(jwt.NewParser(jwt.WithIssuer("https://issuer"))),
+		WithErrorHandler(func(w http.ResponseWriter, r *http.Request, err error) {
+			handled = err
+			w.WriteHeader(http.StatusForbidden)
+		}),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, _ := jwt.TokenFromContext(r.Context())
+		w.Write([]byte(token.Claims.(*jwt.StandardClaims).Subject))
+	}))
+
+	r := makeExampleRequest("GET", "/", map[string]string{"Cookie": "session=" + test.MakeSampleToken(jwt.MapClaims{"iss": "https://issuer", "sub": "alice"}, privateKey)}, nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || w.Body.String() != "alice" {
+		t.Errorf("[cookie] Expecting alice, got %v %q", w.Code, w.Body.String())
+	}
+
+	r = makeExampleRequest("GET", "/", map[string]string{"Cookie": "session=" + test.MakeSampleToken(jwt.MapClaims{"iss": "https://other"}, privateKey)}, nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if ve, ok := handled.(*jwt.ValidationError); w.Code != http.StatusForbidden || !ok || ve.Errors != jwt.ValidationErrorIssuer {
+		t.Errorf("[other issuer] Expecting the error handler to get ValidationErrorIssuer, got %v %v", w.Code, handled)
+	}
+}
@@ -51,6 +51,14 @@ var requestTestData = []struct {
 		url.Values{"token": {"%v"}},
 		true,
 	},
+	{
+		"cookie token",
+		jwt.MapClaims{"foo": "bar"},
+		CookieExtractor{"session"},
+		map[string]string{"Cookie": "session=%v"},
+		url.Values{},
+		true,
+	},
 }
 
 func TestParseRequest(t *testing.T) {
@@ -101,3 +109,15 @@ func TestParseRequest(t *testing.T) {
 		}
 	}
 }
+
+func TestParseRequest_missingCookie(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/", nil)
+	r.AddCookie(&http.Cookie{Name: "theme", Value: "dark"})
+	keyfunc := func(*jwt.Token) (interface{}, error) {
+		return nil, fmt.Errorf("keyfunc must not be called")
+	}
+
+	if _, err := ParseFromRequest(r, CookieExtractor{"session"}, keyfunc); err != ErrNoTokenInRequest {
+		t.Errorf("Expecting ErrNoTokenInRequest, got %v", err)
+	}
+}
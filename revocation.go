@@ -0,0 +1,92 @@
+package jwt
+
+import (
+	"sync"
+	"time"
+)
+
+// Reports whether a token was revoked, given its claims once the signature
+// verified.  Use one with WithRevocationChecker(checker.Check).  A non-nil
+// error rejects the token with ValidationErrorRevoked.
+type RevocationChecker interface {
+	Check(claims MapClaims) error
+}
+
+// An in-memory RevocationChecker deny-listing tokens by jti, e.g. a single
+// compromised token, or by sub, e.g. to log a user out of every session.
+// Entries are kept until the time given when revoking, which should be the
+// latest exp of the tokens affected, and pruned after.  A RevocationList is
+// safe for concurrent use.
+type RevocationList struct {
+	mu        sync.Mutex
+	jtis      map[string]time.Time // Revoked jti claims, until when
+	subjects  map[string]revokedSubject
+	nextPrune time.Time
+}
+
+type revokedSubject struct {
+	at    time.Time // Tokens issued at or before this time are revoked
+	until time.Time
+}
+
+// Creates an empty RevocationList
+func NewRevocationList() *RevocationList {
+	return &RevocationList{jtis: make(map[string]time.Time), subjects: make(map[string]revokedSubject)}
+}
+
+// Revokes the token with the jti claim jti until the time until
+func (l *RevocationList) RevokeJTI(jti string, until time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.jtis[jti] = until
+}
+
+// Revokes the tokens with the sub claim sub issued so far, until the time
+// until.  Tokens issued later, e.g. after the user logged in again, are not
+// affected; tokens of sub without an iat claim are revoked.
+func (l *RevocationList) RevokeSubject(sub string, until time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.subjects[sub] = revokedSubject{TimeFunc(), until}
+}
+
+// Implements the Check method from RevocationChecker, returning
+// ErrTokenRevoked for a revoked token
+func (l *RevocationList) Check(claims MapClaims) error {
+	now := TimeFunc()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.prune(now)
+
+	if jti, ok := claims["jti"].(string); ok {
+		if until, ok := l.jtis[jti]; ok && !now.After(until) {
+			return ErrTokenRevoked
+		}
+	}
+	if sub, ok := claims["sub"].(string); ok {
+		if revoked, ok := l.subjects[sub]; ok && !now.After(revoked.until) {
+			if iat, ok := claims.numericDate("iat"); !ok || iat <= revoked.at.Unix() {
+				return ErrTokenRevoked
+			}
+		}
+	}
+	return nil
+}
+
+// Drops the entries past their time, at most once a minute
+func (l *RevocationList) prune(now time.Time) {
+	if !now.After(l.nextPrune) {
+		return
+	}
+	for jti, until := range l.jtis {
+		if now.After(until) {
+			delete(l.jtis, jti)
+		}
+	}
+	for sub, revoked := range l.subjects {
+		if now.After(revoked.until) {
+			delete(l.subjects, sub)
+		}
+	}
+	l.nextPrune = now.Add(time.Minute)
+}
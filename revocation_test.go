@@ -0,0 +1,60 @@
+package jwt_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/form3tech-oss/jwt-go"
+)
+
+func TestRevocationList(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	now := time.Unix(1600000000, 0)
+	jwt.TimeFunc = func() time.Time { return now }
+	defer func() { jwt.TimeFunc = time.Now }()
+
+	list := jwt.NewRevocationList()
+	parser := jwt.NewParser(jwt.WithRevocationChecker(list.Check))
+	list.RevokeJTI("stolen", now.Add(time.Hour))
+	list.RevokeSubject("mallory", now.Add(time.Hour))
+	revokedAt := now.Unix()
+	now = now.Add(time.Minute)
+
+	var revocationListTestData = []struct {
+		name    string
+		claims  jwt.MapClaims
+		revoked bool
+	}{
+		{"revoked jti", jwt.MapClaims{"jti": "stolen", "sub": "alice"}, true},
+		{"other jti", jwt.MapClaims{"jti": "fresh", "sub": "alice"}, false},
+		{"revoked sub", jwt.MapClaims{"sub": "mallory", "iat": revokedAt - 60}, true},
+		{"revoked sub, issued at revocation", jwt.MapClaims{"sub": "mallory", "iat": revokedAt}, true},
+		{"revoked sub, no iat", jwt.MapClaims{"sub": "mallory"}, true},
+		{"revoked sub, issued later", jwt.MapClaims{"sub": "mallory", "iat": revokedAt + 1}, false},
+	}
+
+	for _, data := range revocationListTestData {
+		tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, data.claims).SignedString(key)
+		_, err := parser.Parse(tokenString, keyFunc)
+		if !data.revoked && err != nil {
+			t.Errorf("[%v] Error while parsing token: %v", data.name, err)
+		}
+		if data.revoked {
+			if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorRevoked || ve.Inner != jwt.ErrTokenRevoked {
+				t.Errorf("[%v] Expecting ValidationErrorRevoked, got %v", data.name, err)
+			}
+		}
+	}
+
+	// Entries are dropped once past their time
+	now = now.Add(2 * time.Hour)
+	if err := list.Check(jwt.MapClaims{"jti": "stolen"}); err != nil {
+		t.Errorf("Expecting the jti to be forgotten, got %v", err)
+	}
+	if err := list.Check(jwt.MapClaims{"sub": "mallory"}); err != nil {
+		t.Errorf("Expecting the sub to be forgotten, got %v", err)
+	}
+
+	var _ jwt.RevocationChecker = list
+}
@@ -4,6 +4,8 @@ import (
 	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
+	"hash"
+	"io"
 )
 
 // Implements the RSA family of signing methods signing methods
@@ -47,41 +49,60 @@ func (m *SigningMethodRSA) Alg() string {
 // Implements the Verify method from SigningMethod
 // For this signing method, must be an *rsa.PublicKey structure.
 func (m *SigningMethodRSA) Verify(signingString, signature string, key interface{}) error {
-	var err error
-
 	// Decode the signature
-	var sig []byte
-	if sig, err = DecodeSegment(signature); err != nil {
+	sb, sig, err := decodeSignature(signature)
+	defer putSignature(sb)
+	if err != nil {
 		return err
 	}
 
-	var rsaKey *rsa.PublicKey
-	var ok bool
+	hasher, check, err := m.verifier(key)
+	if err != nil {
+		return err
+	}
+	io.WriteString(hasher, signingString)
+	return check(sig)
+}
 
-	if rsaKey, ok = key.(*rsa.PublicKey); !ok {
-		return ErrInvalidKeyType
+// Returns the hash the signing input is written to, and a function verifying
+// the decoded signature against its sum.  Verify is hot in gateways, so the
+// hasher comes from a pool and is returned to it by the function.
+func (m *SigningMethodRSA) verifier(key interface{}) (hash.Hash, func(sig []byte) error, error) {
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, nil, ErrInvalidKeyType
 	}
 
 	// Create hasher
 	if !m.Hash.Available() {
-		return ErrHashUnavailable
+		return nil, nil, ErrHashUnavailable
 	}
-	hasher := m.Hash.New()
-	hasher.Write([]byte(signingString))
+	hasher := getHash(m.Hash)
 
-	// Verify the signature
-	return rsa.VerifyPKCS1v15(rsaKey, m.Hash, hasher.Sum(nil), sig)
+	return hasher, func(sig []byte) error {
+		defer putHash(m.Hash, hasher)
+		hasher.sum = hasher.Sum(hasher.sum[:0])
+		return rsa.VerifyPKCS1v15(rsaKey, m.Hash, hasher.sum, sig)
+	}, nil
 }
 
 // Implements the Sign method from SigningMethod
-// For this signing method, must be an *rsa.PrivateKey structure.
+// For this signing method, must be an *rsa.PrivateKey structure, or any
+// crypto.Signer with an *rsa.PublicKey, such as an HSM or KMS backed key.
 func (m *SigningMethodRSA) Sign(signingString string, key interface{}) (string, error) {
-	var rsaKey *rsa.PrivateKey
-	var ok bool
+	var signer crypto.Signer
 
 	// Validate type of key
-	if rsaKey, ok = key.(*rsa.PrivateKey); !ok {
-		return "", ErrInvalidKey
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		signer = k
+	case crypto.Signer:
+		if _, ok := k.Public().(*rsa.PublicKey); !ok {
+			return "", ErrInvalidKeyType
+		}
+		signer = k
+	default:
+		return "", ErrInvalidKeyType
 	}
 
 	// Create the hasher
@@ -92,8 +113,9 @@ func (m *SigningMethodRSA) Sign(signingString string, key interface{}) (string,
 	hasher := m.Hash.New()
 	hasher.Write([]byte(signingString))
 
-	// Sign the string and return the encoded bytes
-	if sigBytes, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, m.Hash, hasher.Sum(nil)); err == nil {
+	// Sign the string and return the encoded bytes.  Passing the hash as the
+	// signer options selects PKCS #1 v1.5.
+	if sigBytes, err := signer.Sign(rand.Reader, hasher.Sum(nil), m.Hash); err == nil {
 		return EncodeSegment(sigBytes), nil
 	} else {
 		return "", err
@@ -6,6 +6,8 @@ import (
 	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
+	"hash"
+	"io"
 )
 
 // Implements the RSAPSS family of signing methods signing methods
@@ -79,48 +81,81 @@ func init() {
 	})
 }
 
+// Returns a copy of m signing with a salt of signSaltLength bytes and only
+// accepting signatures with a salt of verifySaltLength bytes.  Either may be
+// rsa.PSSSaltLengthEqualsHash, the length RFC 7518 requires, or
+// rsa.PSSSaltLengthAuto.  The copy isn't registered, so parsed tokens keep
+// using m unless it is registered for the alg.
+func (m *SigningMethodRSAPSS) WithSaltLength(signSaltLength, verifySaltLength int) *SigningMethodRSAPSS {
+	return &SigningMethodRSAPSS{
+		SigningMethodRSA: m.SigningMethodRSA,
+		Options:          &rsa.PSSOptions{SaltLength: signSaltLength},
+		VerifyOptions:    &rsa.PSSOptions{SaltLength: verifySaltLength},
+	}
+}
+
 // Implements the Verify method from SigningMethod
 // For this verify method, key must be an rsa.PublicKey struct
 func (m *SigningMethodRSAPSS) Verify(signingString, signature string, key interface{}) error {
-	var err error
-
 	// Decode the signature
-	var sig []byte
-	if sig, err = DecodeSegment(signature); err != nil {
+	sb, sig, err := decodeSignature(signature)
+	defer putSignature(sb)
+	if err != nil {
+		return err
+	}
+
+	hasher, check, err := m.verifier(key)
+	if err != nil {
 		return err
 	}
+	io.WriteString(hasher, signingString)
+	return check(sig)
+}
 
+// Returns the hash the signing input is written to, and a function verifying
+// the decoded signature against its sum.  As for SigningMethodRSA, the hasher
+// comes from a pool and is returned to it by the function.
+func (m *SigningMethodRSAPSS) verifier(key interface{}) (hash.Hash, func(sig []byte) error, error) {
 	var rsaKey *rsa.PublicKey
 	switch k := key.(type) {
 	case *rsa.PublicKey:
 		rsaKey = k
 	default:
-		return ErrInvalidKey
+		return nil, nil, ErrInvalidKeyType
 	}
 
 	// Create hasher
 	if !m.Hash.Available() {
-		return ErrHashUnavailable
+		return nil, nil, ErrHashUnavailable
 	}
-	hasher := m.Hash.New()
-	hasher.Write([]byte(signingString))
+	hasher := getHash(m.Hash)
 
 	opts := m.Options
 	if m.VerifyOptions != nil {
 		opts = m.VerifyOptions
 	}
 
-	return rsa.VerifyPSS(rsaKey, m.Hash, hasher.Sum(nil), sig, opts)
+	return hasher, func(sig []byte) error {
+		defer putHash(m.Hash, hasher)
+		hasher.sum = hasher.Sum(hasher.sum[:0])
+		return rsa.VerifyPSS(rsaKey, m.Hash, hasher.sum, sig, opts)
+	}, nil
 }
 
 // Implements the Sign method from SigningMethod
-// For this signing method, key must be an rsa.PrivateKey struct
+// For this signing method, key must be an rsa.PrivateKey struct, or any
+// crypto.Signer with an *rsa.PublicKey, such as an HSM or KMS backed key.
 func (m *SigningMethodRSAPSS) Sign(signingString string, key interface{}) (string, error) {
-	var rsaKey *rsa.PrivateKey
+	var signer crypto.Signer
 
 	switch k := key.(type) {
 	case *rsa.PrivateKey:
-		rsaKey = k
+		signer = k
+	case crypto.Signer:
+		if _, ok := k.Public().(*rsa.PublicKey); !ok {
+			return "", ErrInvalidKeyType
+		}
+		signer = k
 	default:
 		return "", ErrInvalidKeyType
 	}
@@ -133,8 +168,14 @@ func (m *SigningMethodRSAPSS) Sign(signingString string, key interface{}) (strin
 	hasher := m.Hash.New()
 	hasher.Write([]byte(signingString))
 
-	// Sign the string and return the encoded bytes
-	if sigBytes, err := rsa.SignPSS(rand.Reader, rsaKey, m.Hash, hasher.Sum(nil), m.Options); err == nil {
+	// Sign the string and return the encoded bytes.  Passing PSSOptions as
+	// the signer options selects RSASSA-PSS.  Without Options the salt is as
+	// long as possible, as rsa.SignPSS does for nil options.
+	opts := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto, Hash: m.Hash}
+	if m.Options != nil {
+		opts.SaltLength = m.Options.SaltLength
+	}
+	if sigBytes, err := signer.Sign(rand.Reader, hasher.Sum(nil), opts); err == nil {
 		return EncodeSegment(sigBytes), nil
 	} else {
 		return "", err
@@ -3,6 +3,7 @@
 package jwt_test
 
 import (
+	"crypto"
 	"crypto/rsa"
 	"io/ioutil"
 	"strings"
@@ -130,6 +131,54 @@ func TestRSAPSSSaltLengthCompatibility(t *testing.T) {
 	}
 }
 
+func TestRSAPSSWithSaltLength(t *testing.T) {
+	strict := jwt.SigningMethodPS256.WithSaltLength(rsa.PSSSaltLengthEqualsHash, rsa.PSSSaltLengthEqualsHash)
+	salt20 := jwt.SigningMethodPS256.WithSaltLength(20, 20)
+	auto := jwt.SigningMethodPS256.WithSaltLength(rsa.PSSSaltLengthAuto, rsa.PSSSaltLengthAuto)
+
+	if strict.Alg() != "PS256" {
+		t.Errorf("Expecting the copy to keep the alg, got %v", strict.Alg())
+	}
+	if !verify(strict, makeToken(jwt.SigningMethodPS256)) {
+		t.Error("Strict salt length should accept tokens signed by SigningMethodPS256")
+	}
+	if verify(strict, makeToken(auto)) {
+		t.Error("Strict salt length should not accept an auto salt length")
+	}
+	if !verify(salt20, makeToken(salt20)) || verify(salt20, makeToken(strict)) {
+		t.Error("A 20 byte salt length should accept only 20 byte salts")
+	}
+	if !verify(auto, makeToken(salt20)) {
+		t.Error("Auto salt length should accept any salt length")
+	}
+	if jwt.SigningMethodPS256.VerifyOptions.SaltLength != rsa.PSSSaltLengthAuto {
+		t.Error("WithSaltLength should not modify SigningMethodPS256")
+	}
+}
+
+// A method built without Options signs with the longest salt, as
+// rsa.SignPSS does for nil options
+func TestRSAPSSNilOptions(t *testing.T) {
+	method := &jwt.SigningMethodRSAPSS{SigningMethodRSA: &jwt.SigningMethodRSA{Name: "PS256", Hash: crypto.SHA256}}
+	tokenString := makeToken(method)
+	if !verify(method, tokenString) || !verify(jwt.SigningMethodPS256, tokenString) {
+		t.Error("Expecting a token signed without Options to verify")
+	}
+	strict := jwt.SigningMethodPS256.WithSaltLength(rsa.PSSSaltLengthEqualsHash, rsa.PSSSaltLengthEqualsHash)
+	if verify(strict, tokenString) {
+		t.Error("Expecting the salt not to be the length of the hash")
+	}
+}
+
+func BenchmarkPS256Signing(b *testing.B) {
+	benchmarkSigning(b, jwt.SigningMethodPS256, test.LoadRSAPrivateKeyFromDisk("test/sample_key"))
+}
+
+func BenchmarkPS256Verifying(b *testing.B) {
+	key := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	benchmarkVerifying(b, jwt.SigningMethodPS256, key, &key.PublicKey)
+}
+
 func makeToken(method jwt.SigningMethod) string {
 	token := jwt.NewWithClaims(method, jwt.StandardClaims{
 		Issuer:   "example",
@@ -1,10 +1,16 @@
 package jwt_test
 
 import (
-	"github.com/form3tech-oss/jwt-go"
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
 	"io/ioutil"
 	"strings"
 	"testing"
+
+	"github.com/form3tech-oss/jwt-go"
+	"github.com/form3tech-oss/jwt-go/test"
 )
 
 var rsaTestData = []struct {
@@ -154,6 +160,59 @@ func TestRSAKeyParsing(t *testing.T) {
 
 }
 
+func TestRSAKeyParsingEncodings(t *testing.T) {
+	key := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	pkcs8, _ := x509.MarshalPKCS8PrivateKey(key)
+	pkix, _ := x509.MarshalPKIXPublicKey(&key.PublicKey)
+
+	privateKeys := map[string][]byte{
+		"PKCS1": pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}),
+		"PKCS8": pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}),
+	}
+	publicKeys := map[string][]byte{
+		"PKIX":  pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pkix}),
+		"PKCS1": pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&key.PublicKey)}),
+	}
+
+	testData := rsaTestData[0]
+	parts := strings.Split(testData.tokenString, ".")
+	for privateName, privatePEM := range privateKeys {
+		privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(privatePEM)
+		if err != nil {
+			t.Errorf("[%v] Failed to parse private key: %v", privateName, err)
+			continue
+		}
+		sig, err := jwt.SigningMethodRS256.Sign(strings.Join(parts[0:2], "."), privateKey)
+		if err != nil || sig != parts[2] {
+			t.Errorf("[%v] Incorrect signature: %v", privateName, err)
+		}
+		for publicName, publicPEM := range publicKeys {
+			publicKey, err := jwt.ParseRSAPublicKeyFromPEM(publicPEM)
+			if err != nil {
+				t.Errorf("[%v] Failed to parse public key: %v", publicName, err)
+				continue
+			}
+			if err := jwt.SigningMethodRS256.Verify(strings.Join(parts[0:2], "."), sig, publicKey); err != nil {
+				t.Errorf("[%v/%v] Error while verifying key: %v", privateName, publicName, err)
+			}
+		}
+	}
+}
+
+func TestRSAMismatchedKeyType(t *testing.T) {
+	ecdsaKey := test.LoadECPrivateKeyFromDisk("test/ec256-private.pem")
+	parts := strings.Split(rsaTestData[0].tokenString, ".")
+
+	for _, method := range []jwt.SigningMethod{jwt.SigningMethodRS256, jwt.SigningMethodPS256} {
+		if _, err := method.Sign(strings.Join(parts[0:2], "."), ecdsaKey); err != jwt.ErrInvalidKeyType {
+			t.Errorf("[%v] Expecting ErrInvalidKeyType when signing with an EC key, got %v", method.Alg(), err)
+		}
+		if err := method.Verify(strings.Join(parts[0:2], "."), parts[2], &ecdsaKey.PublicKey); err != jwt.ErrInvalidKeyType {
+			t.Errorf("[%v] Expecting ErrInvalidKeyType when verifying with an EC key, got %v", method.Alg(), err)
+		}
+	}
+}
+
 func BenchmarkRS256Signing(b *testing.B) {
 	key, _ := ioutil.ReadFile("test/sample_key")
 	parsedKey, err := jwt.ParseRSAPrivateKeyFromPEM(key)
@@ -183,3 +242,49 @@ func BenchmarkRS512Signing(b *testing.B) {
 
 	benchmarkSigning(b, jwt.SigningMethodRS512, parsedKey)
 }
+
+func BenchmarkRS256Verifying(b *testing.B) {
+	key := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	benchmarkVerifying(b, jwt.SigningMethodRS256, key, &key.PublicKey)
+}
+
+func BenchmarkRS384Verifying(b *testing.B) {
+	key := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	benchmarkVerifying(b, jwt.SigningMethodRS384, key, &key.PublicKey)
+}
+
+func BenchmarkRS512Verifying(b *testing.B) {
+	key := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	benchmarkVerifying(b, jwt.SigningMethodRS512, key, &key.PublicKey)
+}
+
+// The pooled hasher must keep Verify below the allocations of hashing the
+// signing input afresh for every call
+func TestRSAVerifyAllocs(t *testing.T) {
+	key := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	for _, method := range []jwt.SigningMethod{jwt.SigningMethodRS256, jwt.SigningMethodPS256} {
+		tokenString, _ := jwt.New(method).SignedString(key)
+		parts := strings.Split(tokenString, ".")
+		signingString := strings.Join(parts[0:2], ".")
+		sig, _ := jwt.DecodeSegment(parts[2])
+
+		allocs := testing.AllocsPerRun(100, func() {
+			if err := method.Verify(signingString, parts[2], &key.PublicKey); err != nil {
+				t.Fatal(err)
+			}
+		})
+		unpooled := testing.AllocsPerRun(100, func() {
+			jwt.DecodeSegment(parts[2])
+			hasher := crypto.SHA256.New()
+			hasher.Write([]byte(signingString))
+			if method == jwt.SigningMethodRS256 {
+				rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, hasher.Sum(nil), sig)
+			} else {
+				rsa.VerifyPSS(&key.PublicKey, crypto.SHA256, hasher.Sum(nil), sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto})
+			}
+		})
+		if allocs >= unpooled {
+			t.Errorf("[%v] Expecting fewer than %v allocations per Verify, got %v", method.Alg(), unpooled, allocs)
+		}
+	}
+}
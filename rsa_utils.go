@@ -71,7 +71,7 @@ func ParseRSAPrivateKeyFromPEMWithPassword(key []byte, password string) (*rsa.Pr
 	return pkey, nil
 }
 
-// Parse PEM encoded PKCS1 or PKCS8 public key
+// Parse PEM encoded PKIX or PKCS1 public key, or the public key of a certificate
 func ParseRSAPublicKeyFromPEM(key []byte) (*rsa.PublicKey, error) {
 	var err error
 
@@ -86,6 +86,8 @@ func ParseRSAPublicKeyFromPEM(key []byte) (*rsa.PublicKey, error) {
 	if parsedKey, err = x509.ParsePKIXPublicKey(block.Bytes); err != nil {
 		if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
 			parsedKey = cert.PublicKey
+		} else if pkcs1Key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+			parsedKey = pkcs1Key
 		} else {
 			return nil, err
 		}
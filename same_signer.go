@@ -0,0 +1,63 @@
+package jwt
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrTokenNotParsed = errors.New("token has no signing method, header or raw form to compare")
+
+// Reports whether a and b, both parsed tokens, appear to have been signed by
+// the same key, without needing the key: they must use the same alg and name
+// the same key with their kid header.  A token without a kid yields
+// ErrKidMissing, and one whose kid is not a string ErrKidInvalid.
+//
+// When both tokens have the same signing input and a deterministic signing
+// method, HMAC or RSA PKCS #1 v1.5, their signatures are compared as well,
+// since the same key would have produced the same signature.
+//
+// This is a heuristic, not cryptographic proof: a kid is chosen by the
+// issuer, and over different payloads it is all that is compared.  Verify
+// both tokens with the key before relying on their provenance.
+func SameSigner(a, b *Token) (bool, error) {
+	if a == nil || b == nil || a.Method == nil || b.Method == nil || a.Header == nil || b.Header == nil {
+		return false, ErrTokenNotParsed
+	}
+
+	rawA, okA := a.Header["kid"]
+	rawB, okB := b.Header["kid"]
+	if !okA || !okB {
+		return false, ErrKidMissing
+	}
+	kidA, okA := rawA.(string)
+	kidB, okB := rawB.(string)
+	if !okA || !okB {
+		return false, ErrKidInvalid
+	}
+	if a.Method.Alg() != b.Method.Alg() || kidA != kidB {
+		return false, nil
+	}
+
+	if isDeterministicMethod(a.Method) && a.Raw != "" && signingInput(a.Raw) == signingInput(b.Raw) {
+		return a.Signature == b.Signature, nil
+	}
+	return true, nil
+}
+
+// Reports whether signing the same input with the same key always gives the
+// same signature
+func isDeterministicMethod(method SigningMethod) bool {
+	switch method.(type) {
+	case *SigningMethodHMAC, *SigningMethodRSA:
+		return true
+	}
+	return false
+}
+
+// Returns the header and claims segments of a compact token
+func signingInput(raw string) string {
+	if i := strings.LastIndex(raw, "."); i >= 0 {
+		return raw[:i]
+	}
+	return raw
+}
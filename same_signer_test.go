@@ -0,0 +1,56 @@
+package jwt_test
+
+import (
+	"testing"
+
+	"github.com/form3tech-oss/jwt-go"
+)
+
+func TestSameSigner(t *testing.T) {
+	keys := map[string]interface{}{"a": []byte("key a"), "b": []byte("key b")}
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return keys[kid], nil
+	}
+	makeToken := func(kid string, key []byte, claims jwt.MapClaims) *jwt.Token {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		if kid != "" {
+			token.Header["kid"] = kid
+		}
+		tokenString, _ := token.SignedString(key)
+		// Forged tokens fail verification, but are still returned parsed
+		parsed, _ := jwt.Parse(tokenString, keyFunc)
+		return parsed
+	}
+
+	alice := makeToken("a", []byte("key a"), jwt.MapClaims{"sub": "alice"})
+	bob := makeToken("a", []byte("key a"), jwt.MapClaims{"sub": "bob"})
+	other := makeToken("b", []byte("key b"), jwt.MapClaims{"sub": "alice"})
+	// Same kid and payload, but signed with another key
+	forged := makeToken("a", []byte("key b"), jwt.MapClaims{"sub": "alice"})
+	noKid := makeToken("", []byte("key a"), jwt.MapClaims{"sub": "alice"})
+	// A kid that is an array can't be compared with ==
+	arrayKid := &jwt.Token{Method: jwt.SigningMethodHS256, Header: map[string]interface{}{"kid": []interface{}{"a"}}}
+
+	var sameSignerTestData = []struct {
+		name string
+		a, b *jwt.Token
+		same bool
+		err  error
+	}{
+		{"same kid", alice, bob, true, nil},
+		{"same kid and payload", alice, makeToken("a", []byte("key a"), jwt.MapClaims{"sub": "alice"}), true, nil},
+		{"different kid", alice, other, false, nil},
+		{"same kid, different signature", alice, forged, false, nil},
+		{"no kid", alice, noKid, false, jwt.ErrKidMissing},
+		{"array kid", arrayKid, arrayKid, false, jwt.ErrKidInvalid},
+		{"not parsed", alice, &jwt.Token{}, false, jwt.ErrTokenNotParsed},
+	}
+
+	for _, data := range sameSignerTestData {
+		same, err := jwt.SameSigner(data.a, data.b)
+		if same != data.same || err != data.err {
+			t.Errorf("[%v] Expecting %v, %v, got %v, %v", data.name, data.same, data.err, same, err)
+		}
+	}
+}
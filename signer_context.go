@@ -0,0 +1,43 @@
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"io"
+)
+
+// A crypto.Signer that accepts a context, e.g. a client of a remote KMS, so
+// that signing can be cancelled or bounded by a deadline.  See
+// Token.SignedStringContext.
+type ContextSigner interface {
+	crypto.Signer
+	SignContext(ctx context.Context, rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error)
+}
+
+// Like SignedString, but signs with signer, passing ctx on if signer is a
+// ContextSigner.  Other signers are used as by SignedString, once ctx was
+// checked not to be done already.  The signing method must accept a
+// crypto.Signer as key, as the RSA, RSA-PSS, ECDSA and EdDSA methods do.
+func (t *Token) SignedStringContext(ctx context.Context, signer crypto.Signer) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if cs, ok := signer.(ContextSigner); ok {
+		return t.SignedString(&boundSigner{ctx, cs})
+	}
+	return t.SignedString(signer)
+}
+
+// Binds a ContextSigner to a context, to pass it through SigningMethod.Sign
+type boundSigner struct {
+	ctx    context.Context
+	signer ContextSigner
+}
+
+func (s *boundSigner) Public() crypto.PublicKey {
+	return s.signer.Public()
+}
+
+func (s *boundSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.signer.SignContext(s.ctx, rand, digest, opts)
+}
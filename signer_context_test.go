@@ -0,0 +1,62 @@
+package jwt_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/form3tech-oss/jwt-go"
+	"github.com/form3tech-oss/jwt-go/test"
+)
+
+// A ContextSigner standing in for a remote KMS that takes delay to respond
+type slowSigner struct {
+	*rsa.PrivateKey
+	delay time.Duration
+}
+
+func (s *slowSigner) SignContext(ctx context.Context, rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	select {
+	case <-time.After(s.delay):
+		return s.PrivateKey.Sign(rand, digest, opts)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func TestToken_SignedStringContext(t *testing.T) {
+	key := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return &key.PublicKey, nil }
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"foo": "bar"})
+
+	tokenString, err := token.SignedStringContext(context.Background(), &slowSigner{key, time.Millisecond})
+	if err != nil {
+		t.Fatalf("[context signer] Error signing token: %v", err)
+	}
+	if _, err := jwt.Parse(tokenString, keyFunc); err != nil {
+		t.Errorf("[context signer] Error while parsing token: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := token.SignedStringContext(ctx, &slowSigner{key, time.Minute}); err != context.DeadlineExceeded {
+		t.Errorf("[deadline] Expecting context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("[deadline] Expecting the slow signer to be aborted, took %v", elapsed)
+	}
+
+	// Signers without context support sign as usual, unless ctx is done
+	if _, err := token.SignedStringContext(context.Background(), key); err != nil {
+		t.Errorf("[plain signer] Error signing token: %v", err)
+	}
+	cancelled, cancelNow := context.WithCancel(context.Background())
+	cancelNow()
+	if _, err := token.SignedStringContext(cancelled, key); err != context.Canceled {
+		t.Errorf("[plain signer, cancelled] Expecting context.Canceled, got %v", err)
+	}
+}
@@ -1,6 +1,10 @@
 package jwt
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
 	"sync"
 )
 
@@ -33,3 +37,96 @@ func GetSigningMethod(alg string) (method SigningMethod) {
 	}
 	return
 }
+
+// Captures the registered signing methods and returns a function restoring
+// them, undoing any RegisterSigningMethod call made in between.  Meant for
+// tests registering temporary methods:
+//
+//	defer jwt.SnapshotSigningMethods()()
+func SnapshotSigningMethods() func() {
+	signingMethodLock.RLock()
+	snapshot := make(map[string]func() SigningMethod, len(signingMethods))
+	for alg, f := range signingMethods {
+		snapshot[alg] = f
+	}
+	signingMethodLock.RUnlock()
+
+	return func() {
+		signingMethodLock.Lock()
+		defer signingMethodLock.Unlock()
+
+		signingMethods = snapshot
+	}
+}
+
+// Returns the alg values that can be verified with key, based on its type and,
+// for EC keys, its curve.  Feed the result to Parser.ValidMethods to tie the
+// accepted algorithms to the key material, so a token can never select an
+// algorithm from another family.  Returns nil for unsupported key types.
+func AllowedMethodsForKey(key interface{}) []string {
+	switch k := key.(type) {
+	case *rsa.PublicKey, *rsa.PrivateKey:
+		return []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}
+	case *ecdsa.PublicKey:
+		return ecdsaMethodsForCurve(k.Curve)
+	case *ecdsa.PrivateKey:
+		return ecdsaMethodsForCurve(k.Curve)
+	case []byte, string:
+		return []string{"HS256", "HS384", "HS512"}
+	}
+	if isEd25519Key(key) {
+		return []string{"EdDSA"}
+	}
+	return nil
+}
+
+// The algs of curve: ES256, ES384 and ES512 for the NIST curves, and ES256K
+// for the curve given to RegisterES256K
+func ecdsaMethodsForCurve(curve elliptic.Curve) []string {
+	switch curve {
+	case elliptic.P256():
+		return []string{"ES256"}
+	case elliptic.P384():
+		return []string{"ES384"}
+	case elliptic.P521():
+		return []string{"ES512"}
+	}
+	if m, ok := GetSigningMethod("ES256K").(*SigningMethodES256K); ok && m.Curve != nil && curve == m.Curve {
+		return []string{"ES256K"}
+	}
+	return nil
+}
+
+// Returns the signing method to use with signer, based on its public key: RS256
+// for RSA keys, ES256, ES384 or ES512 for EC keys depending on the curve, or
+// ES256K for the curve given to RegisterES256K, and EdDSA for Ed25519 keys.  Useful with HSM or KMS backed signers whose key
+// type is not known up front.
+func SigningMethodFromSigner(signer crypto.Signer) (SigningMethod, error) {
+	switch k := signer.Public().(type) {
+	case *rsa.PublicKey:
+		return SigningMethodRS256, nil
+	case *ecdsa.PublicKey:
+		if methods := ecdsaMethodsForCurve(k.Curve); len(methods) > 0 {
+			return GetSigningMethod(methods[0]), nil
+		}
+	}
+	if isEd25519Key(signer.Public()) {
+		return ed25519Method(), nil
+	}
+	return nil, ErrInvalidKeyType
+}
+
+// Verifies signature, as raw bytes rather than base64url, over an arbitrary
+// signing string with method and key.  Useful when the signing input and the
+// signature are stored apart from the compact serialization.
+func VerifySignature(signingString string, signature []byte, method SigningMethod, key interface{}) error {
+	if sv, ok := method.(streamVerifier); ok {
+		hasher, check, err := sv.verifier(key)
+		if err != nil {
+			return err
+		}
+		hasher.Write([]byte(signingString))
+		return check(signature)
+	}
+	return method.Verify(signingString, EncodeSegment(signature), key)
+}
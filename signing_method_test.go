@@ -0,0 +1,173 @@
+package jwt_test
+
+import (
+	"crypto"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/form3tech-oss/jwt-go"
+	"github.com/form3tech-oss/jwt-go/test"
+)
+
+func TestSnapshotSigningMethods(t *testing.T) {
+	restore := jwt.SnapshotSigningMethods()
+	jwt.RegisterSigningMethod(acceptAllSigningMethod{}.Alg(), func() jwt.SigningMethod {
+		return acceptAllSigningMethod{}
+	})
+	replaced := &jwt.SigningMethodHMAC{Name: "HS256", Hash: crypto.SHA512}
+	jwt.RegisterSigningMethod("HS256", func() jwt.SigningMethod {
+		return replaced
+	})
+
+	tokenString, err := jwt.New(acceptAllSigningMethod{}).SignedString(nil)
+	if err != nil {
+		t.Fatalf("Error signing token: %v", err)
+	}
+	if _, err := jwt.Parse(tokenString+"c2ln", func(*jwt.Token) (interface{}, error) { return "key", nil }); err != nil {
+		t.Errorf("[registered] Error while parsing token: %v", err)
+	}
+	if jwt.GetSigningMethod("HS256") != replaced {
+		t.Errorf("[registered] Expecting HS256 to be replaced")
+	}
+
+	restore()
+	if method := jwt.GetSigningMethod(acceptAllSigningMethod{}.Alg()); method != nil {
+		t.Errorf("[restored] Expecting %v to be unregistered, got %v", acceptAllSigningMethod{}.Alg(), method)
+	}
+	if jwt.GetSigningMethod("HS256") != jwt.SigningMethodHS256 {
+		t.Errorf("[restored] Expecting the original HS256")
+	}
+}
+
+func TestAllowedMethodsForKey(t *testing.T) {
+	var allowedMethodsTestData = []struct {
+		name    string
+		key     interface{}
+		methods []string
+	}{
+		{"RSA public", test.LoadRSAPublicKeyFromDisk("test/sample_key.pub"), []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}},
+		{"RSA private", test.LoadRSAPrivateKeyFromDisk("test/sample_key"), []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512"}},
+		{"EC P-256", test.LoadECPublicKeyFromDisk("test/ec256-public.pem"), []string{"ES256"}},
+		{"EC P-384", test.LoadECPublicKeyFromDisk("test/ec384-public.pem"), []string{"ES384"}},
+		{"EC P-521", test.LoadECPrivateKeyFromDisk("test/ec512-private.pem"), []string{"ES512"}},
+		{"HMAC", []byte("secret"), []string{"HS256", "HS384", "HS512"}},
+		{"HMAC string", "secret", []string{"HS256", "HS384", "HS512"}},
+		{"unsupported", 42, nil},
+	}
+
+	for _, data := range allowedMethodsTestData {
+		if got := jwt.AllowedMethodsForKey(data.key); !reflect.DeepEqual(data.methods, got) {
+			t.Errorf("[%v] Expecting %v, got %v", data.name, data.methods, got)
+		}
+	}
+}
+
+func TestAllowedMethodsForKey_parser(t *testing.T) {
+	ecKey := test.LoadECPrivateKeyFromDisk("test/ec256-private.pem")
+	tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{}).SignedString(ecKey)
+
+	// An RSA only allow-list rejects the EC token before the keyfunc is consulted
+	parser := &jwt.Parser{ValidMethods: jwt.AllowedMethodsForKey(jwtTestDefaultKey)}
+	if _, err := parser.Parse(tokenString, defaultKeyFunc); err == nil {
+		t.Errorf("Token signed with another key family passed validation")
+	}
+}
+
+// A crypto.Signer that hides the concrete private key, like an HSM would
+type opaqueSigner struct {
+	signer crypto.Signer
+}
+
+func (s opaqueSigner) Public() crypto.PublicKey {
+	return s.signer.Public()
+}
+
+func (s opaqueSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return s.signer.Sign(rand, digest, opts)
+}
+
+func TestSigningMethodFromSigner(t *testing.T) {
+	rsaKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	ecKey := test.LoadECPrivateKeyFromDisk("test/ec512-private.pem")
+
+	var signerTestData = []struct {
+		name      string
+		signer    crypto.Signer
+		publicKey interface{}
+		alg       string
+	}{
+		{"RSA", opaqueSigner{rsaKey}, &rsaKey.PublicKey, "RS256"},
+		{"ECDSA", opaqueSigner{ecKey}, &ecKey.PublicKey, "ES512"},
+	}
+
+	for _, data := range signerTestData {
+		method, err := jwt.SigningMethodFromSigner(data.signer)
+		if err != nil {
+			t.Errorf("[%v] Error selecting signing method: %v", data.name, err)
+			continue
+		}
+		if method.Alg() != data.alg {
+			t.Errorf("[%v] Expecting %v, got %v", data.name, data.alg, method.Alg())
+		}
+
+		tokenString, err := jwt.NewWithClaims(method, jwt.MapClaims{"foo": "bar"}).SignedString(data.signer)
+		if err != nil {
+			t.Errorf("[%v] Error signing token: %v", data.name, err)
+			continue
+		}
+		token, err := jwt.Parse(tokenString, func(*jwt.Token) (interface{}, error) { return data.publicKey, nil })
+		if err != nil || !token.Valid {
+			t.Errorf("[%v] Error while verifying token: %v", data.name, err)
+		}
+	}
+
+	// The PSS variant works with signers too
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodPS256, jwt.MapClaims{}).SignedString(opaqueSigner{rsaKey})
+	if err != nil {
+		t.Fatalf("[PS256] Error signing token: %v", err)
+	}
+	if _, err := jwt.Parse(tokenString, defaultKeyFunc); err != nil {
+		t.Errorf("[PS256] Error while verifying token: %v", err)
+	}
+
+	// A signer of the wrong family is rejected
+	if _, err := jwt.SigningMethodES256.Sign("payload", opaqueSigner{rsaKey}); err != jwt.ErrInvalidKeyType {
+		t.Errorf("Expecting ErrInvalidKeyType, got %v", err)
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	hmacKey, _ := ioutil.ReadFile("test/hmacTestKey")
+	rsaKey := test.LoadRSAPublicKeyFromDisk("test/sample_key.pub")
+
+	var verifySignatureTestData = []struct {
+		name        string
+		tokenString string
+		method      jwt.SigningMethod
+		key         interface{}
+		valid       bool
+	}{
+		{"HS256", hmacTestData[0].tokenString, jwt.SigningMethodHS256, hmacKey, true},
+		{"HS256 invalid", hmacTestData[3].tokenString, jwt.SigningMethodHS256, hmacKey, false},
+		{"RS256", rsaTestData[0].tokenString, jwt.SigningMethodRS256, rsaKey, true},
+		{"RS256 wrong key type", rsaTestData[0].tokenString, jwt.SigningMethodRS256, hmacKey, false},
+	}
+
+	for _, data := range verifySignatureTestData {
+		parts := strings.Split(data.tokenString, ".")
+		sig, err := jwt.DecodeSegment(parts[2])
+		if err != nil {
+			t.Fatalf("[%v] Error decoding signature: %v", data.name, err)
+		}
+		err = jwt.VerifySignature(strings.Join(parts[0:2], "."), sig, data.method, data.key)
+		if data.valid && err != nil {
+			t.Errorf("[%v] Error while verifying signature: %v", data.name, err)
+		}
+		if !data.valid && err == nil {
+			t.Errorf("[%v] Invalid signature passed validation", data.name)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package jwt
+
+import (
+	"encoding/base64"
+)
+
+// Checks that seg is canonical, unpadded base64url, RFC 7515 section 2: only
+// characters of the URL-safe alphabet, and no set bits after the last byte
+// it encodes.  Decoding alone accepts line breaks anywhere and ignores the
+// unused bits, so several strings decode to the same bytes.
+func checkStrictSegment(seg string) error {
+	for i := 0; i < len(seg); i++ {
+		if base64URLValue(seg[i]) < 0 {
+			return base64.CorruptInputError(i)
+		}
+	}
+	if len(seg) == 0 {
+		return nil
+	}
+
+	last := base64URLValue(seg[len(seg)-1])
+	switch len(seg) % 4 {
+	case 1:
+		return base64.CorruptInputError(len(seg) - 1)
+	case 2:
+		if last&0x0f != 0 {
+			return base64.CorruptInputError(len(seg) - 1)
+		}
+	case 3:
+		if last&0x03 != 0 {
+			return base64.CorruptInputError(len(seg) - 1)
+		}
+	}
+	return nil
+}
+
+// The value of c in the base64url alphabet, or -1 if it is not in it
+func base64URLValue(c byte) int {
+	switch {
+	case 'A' <= c && c <= 'Z':
+		return int(c - 'A')
+	case 'a' <= c && c <= 'z':
+		return int(c-'a') + 26
+	case '0' <= c && c <= '9':
+		return int(c-'0') + 52
+	case c == '-':
+		return 62
+	case c == '_':
+		return 63
+	}
+	return -1
+}
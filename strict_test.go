@@ -0,0 +1,70 @@
+package jwt_test
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/form3tech-oss/jwt-go"
+)
+
+func TestParser_WithStrictDecoding(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	header := jwt.EncodeSegment([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	sign := func(header, claims string) string {
+		sig, _ := jwt.SigningMethodHS256.Sign(header+"."+claims, key)
+		return header + "." + claims + "." + sig
+	}
+	// Sets an unused bit of the last character, which decoding ignores
+	nonCanonical := func(seg string) string {
+		const alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_"
+		return seg[:len(seg)-1] + string(alphabet[strings.IndexByte(alphabet, seg[len(seg)-1])+1])
+	}
+	// 11 bytes, so the last character has two unused bits
+	claims := jwt.EncodeSegment([]byte(`{"sub":"1"}`))
+	valid := sign(header, claims)
+
+	var strictTestData = []struct {
+		name        string
+		tokenString string
+		segment     jwt.Segment
+	}{
+		{"valid", valid, jwt.SegmentNone},
+		{"padded claims", sign(header, claims+"="), jwt.SegmentClaims},
+		{"padded signature", valid + "=", jwt.SegmentSignature},
+		{"line break", sign(header, claims[:4]+"\n"+claims[4:]), jwt.SegmentClaims},
+		{"standard alphabet", sign(header, base64.RawStdEncoding.EncodeToString([]byte(`{"sub":"??>"}`))), jwt.SegmentClaims},
+		{"non-canonical claims", sign(header, nonCanonical(claims)), jwt.SegmentClaims},
+		{"non-canonical signature", nonCanonical(valid), jwt.SegmentSignature},
+		{"trailing data", sign(header, jwt.EncodeSegment([]byte(`{"sub":"1"}{"sub":"2"}`))), jwt.SegmentClaims},
+		{"duplicate claim", sign(header, jwt.EncodeSegment([]byte(`{"sub":"1","sub":"2"}`))), jwt.SegmentClaims},
+		{"duplicate header", sign(jwt.EncodeSegment([]byte(`{"alg":"none","alg":"HS256"}`)), claims), jwt.SegmentHeader},
+		{"not an object", sign(header, jwt.EncodeSegment([]byte(`"sub"`))), jwt.SegmentClaims},
+	}
+
+	parser := jwt.NewParser(jwt.WithStrictDecoding(), jwt.WithPaddingAllowed(), jwt.WithLenientJSON())
+	for _, data := range strictTestData {
+		_, err := parser.Parse(data.tokenString, keyFunc)
+		if data.segment == jwt.SegmentNone {
+			if err != nil {
+				t.Errorf("[%v] Expecting no error, got %v", data.name, err)
+			}
+			continue
+		}
+		ve, ok := err.(*jwt.ValidationError)
+		if !ok || ve.Errors&jwt.ValidationErrorMalformed == 0 || ve.Segment != data.segment {
+			t.Errorf("[%v] Expecting a malformed %v segment, got %v", data.name, data.segment, err)
+		}
+		if _, err := parser.ParseBytes([]byte(data.tokenString), keyFunc); err == nil {
+			t.Errorf("[%v] Expecting ParseBytes to fail as well", data.name)
+		}
+	}
+
+	// Without it, the lenient decodings are accepted
+	for _, tokenString := range []string{strictTestData[3].tokenString, strictTestData[5].tokenString, strictTestData[7].tokenString} {
+		if _, err := jwt.Parse(tokenString, keyFunc); err != nil {
+			t.Errorf("Expecting %q to parse without WithStrictDecoding, got %v", tokenString, err)
+		}
+	}
+}
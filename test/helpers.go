@@ -1,6 +1,7 @@
 package test
 
 import (
+	"crypto/ecdsa"
 	"crypto/rsa"
 	"github.com/form3tech-oss/jwt-go"
 	"io/ioutil"
@@ -30,6 +31,30 @@ func LoadRSAPublicKeyFromDisk(location string) *rsa.PublicKey {
 	return key
 }
 
+func LoadECPrivateKeyFromDisk(location string) *ecdsa.PrivateKey {
+	keyData, e := ioutil.ReadFile(location)
+	if e != nil {
+		panic(e.Error())
+	}
+	key, e := jwt.ParseECPrivateKeyFromPEM(keyData)
+	if e != nil {
+		panic(e.Error())
+	}
+	return key
+}
+
+func LoadECPublicKeyFromDisk(location string) *ecdsa.PublicKey {
+	keyData, e := ioutil.ReadFile(location)
+	if e != nil {
+		panic(e.Error())
+	}
+	key, e := jwt.ParseECPublicKeyFromPEM(keyData)
+	if e != nil {
+		panic(e.Error())
+	}
+	return key
+}
+
 func MakeSampleToken(c jwt.Claims, key interface{}) string {
 	token := jwt.NewWithClaims(jwt.SigningMethodRS256, c)
 	s, e := token.SignedString(key)
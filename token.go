@@ -1,15 +1,19 @@
 package jwt
 
 import (
+	"crypto"
+	"crypto/sha256"
 	"encoding/base64"
-	"encoding/json"
+	"reflect"
 	"strings"
 	"time"
 )
 
 // TimeFunc provides the current time when parsing token to validate "exp" claim (expiration time).
 // You can override it to use another time value.  This is useful for testing or if your
-// server uses a different time zone than your tokens.
+// server clock is known to be off.  Time based claims are seconds since the Unix
+// epoch, in UTC, and are only ever compared with the Unix time of TimeFunc, so
+// the location of the time it returns makes no difference.
 var TimeFunc = time.Now
 
 // Parse methods use this callback function to supply
@@ -27,15 +31,49 @@ type Token struct {
 	Claims    Claims                 // The second segment of the token
 	Signature string                 // The third segment of the token.  Populated when you Parse a token
 	Valid     bool                   // Is the token valid?  Populated when you Parse/Verify a token
+
+	// Did the signature verify?  Populated when you Parse a token.  When set, a
+	// ValidationError returned alongside the token is purely claim based
+	// (expired, not valid yet, ...) and the fully decoded Claims can be read,
+	// e.g. for audit logging.  They still must not be trusted for authorization.
+	SignatureValid bool
+
+	// The key the signature verified with, as returned by the Keyfunc or, for
+	// a VerificationKeySet, the key of the set that verified it.  Populated
+	// when you Parse a token, unless a VerificationStore skipped the check.
+	VerificationKey interface{}
+
+	canonicalClaims bool      // Encode the claims canonically when signing. See WithCanonicalClaims
+	segments        [3]string // The segments of Raw. See Segments
+
+	// Validation errors downgraded by WithWarnOnly.  Populated when you Parse a
+	// token; they don't affect Valid or the error returned by Parse.
+	Warnings []error
+}
+
+// TokenOption configures a Token created by New or NewWithClaims
+type TokenOption func(*Token)
+
+// WithType sets the typ header of the token to typ instead of "JWT", e.g. to
+// "at+jwt" for OAuth 2.0 access tokens (RFC 9068).  An empty typ removes the
+// header.
+func WithType(typ string) TokenOption {
+	return func(t *Token) {
+		if typ == "" {
+			delete(t.Header, "typ")
+			return
+		}
+		t.Header["typ"] = typ
+	}
 }
 
 // Create a new Token.  Takes a signing method
-func New(method SigningMethod) *Token {
-	return NewWithClaims(method, MapClaims{})
+func New(method SigningMethod, opts ...TokenOption) *Token {
+	return NewWithClaims(method, MapClaims{}, opts...)
 }
 
-func NewWithClaims(method SigningMethod, claims Claims) *Token {
-	return &Token{
+func NewWithClaims(method SigningMethod, claims Claims, opts ...TokenOption) *Token {
+	t := &Token{
 		Header: map[string]interface{}{
 			"typ": "JWT",
 			"alg": method.Alg(),
@@ -43,6 +81,18 @@ func NewWithClaims(method SigningMethod, claims Claims) *Token {
 		Claims: claims,
 		Method: method,
 	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Returns the three segments of a parsed compact token, base64url encoded
+// exactly as they appear in Raw, e.g. to forward or audit the token.  Joined
+// with dots they equal Raw.  Tokens that weren't parsed from the compact
+// serialization return empty strings.
+func (t *Token) Segments() (header, payload, signature string) {
+	return t.segments[0], t.segments[1], t.segments[2]
 }
 
 // Get the complete, signed token
@@ -58,6 +108,140 @@ func (t *Token) SignedString(key interface{}) (string, error) {
 	return strings.Join([]string{sstr, sig}, "."), nil
 }
 
+// RefreshOption configures Refresh
+type RefreshOption func(*refreshOptions)
+
+type refreshOptions struct {
+	kid   string // Replaces the kid header, if set
+	newID bool   // Give the refreshed token a jti of its own
+}
+
+// WithRefreshKeyID sets the kid header of the refreshed token to kid, naming
+// the key it is signed with
+func WithRefreshKeyID(kid string) RefreshOption {
+	return func(o *refreshOptions) {
+		o.kid = kid
+	}
+}
+
+// WithRefreshNewID gives the refreshed token a random jti claim, instead of
+// none, e.g. for a ReplayDetector
+func WithRefreshNewID() RefreshOption {
+	return func(o *refreshOptions) {
+		o.newID = true
+	}
+}
+
+// Re-sign the claims and header of token with key, as a new token issued now
+// and expiring after newExpiry.  token must be Valid, as returned by Parse,
+// or ErrTokenNotValid is returned: only a token whose signature and claims
+// were checked is worth re-issuing.  The iat and exp claims are replaced,
+// the jti claim is removed since it identifies the original token, see
+// WithRefreshNewID, and every other claim is kept.  The headers identifying
+// the key, kid, jku, jwk, x5u, x5c, x5t and x5t#S256, are kept if key is the
+// key token verified with, and removed otherwise, see WithRefreshKeyID.
+// token itself is not modified.  Struct claims are re-encoded as MapClaims.
+func Refresh(token *Token, newExpiry time.Duration, key interface{}, opts ...RefreshOption) (string, error) {
+	if !token.Valid {
+		return "", ErrTokenNotValid
+	}
+	var o refreshOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	original, err := toMapClaims(token.Claims)
+	if err != nil {
+		return "", err
+	}
+
+	claims := make(MapClaims, len(original))
+	for k, v := range original {
+		claims[k] = v
+	}
+	delete(claims, "jti")
+	if o.newID {
+		if err := claims.SetRandomJTI(); err != nil {
+			return "", err
+		}
+	}
+	claims.SetIssuedNow()
+	claims.SetExpiry(newExpiry)
+
+	refreshed := NewWithClaims(token.Method, claims)
+	// The key headers describe the key token was verified with
+	copyHeader(refreshed.Header, token.Header, sameKey(token.VerificationKey, key))
+	if o.kid != "" {
+		refreshed.Header["kid"] = o.kid
+	}
+	return refreshed.SignedString(key)
+}
+
+// The header parameters of RFC 7515 identifying the key a token is signed
+// with, which are stale once it is signed with another key
+var keyHeaders = []string{"kid", "jku", "jwk", "x5u", "x5c", "x5t", "x5t#S256"}
+
+// Copies the parameters of header src to dst, except alg, which dst already
+// names, and the keyHeaders unless keepKey is set
+func copyHeader(dst, src map[string]interface{}, keepKey bool) {
+	for k, v := range src {
+		if k != "alg" {
+			dst[k] = v
+		}
+	}
+	if !keepKey {
+		for _, k := range keyHeaders {
+			delete(dst, k)
+		}
+	}
+}
+
+// Reports whether the verification key verification and the signing key key
+// are the same key, or halves of the same key pair
+func sameKey(verification, key interface{}) bool {
+	if verification == nil {
+		return false
+	}
+	if signer, ok := key.(crypto.Signer); ok {
+		key = signer.Public()
+	}
+	if signer, ok := verification.(crypto.Signer); ok {
+		verification = signer.Public()
+	}
+	return reflect.DeepEqual(verification, key)
+}
+
+// Re-signs each of tokens with newMethod and newKey, e.g. to move still valid
+// tokens to a new key during rotation.  Each token is first parsed and
+// validated with oldKeyfunc, by a parser created from opts with NewParser
+// and WithJSONNumber, so that numbers are carried over exactly; pass e.g.
+// WithValidMethods or WithMaxTokenSize to constrain the old tokens.  Its
+// claims are kept as they are, and its header too, except that alg names
+// newMethod and the headers identifying the old key, kid, jku, jwk, x5u,
+// x5c, x5t and x5t#S256, are removed.  Set a new kid by re-signing with a
+// Token if it is needed.
+//
+// The results are in the order of tokens: a token that doesn't parse or
+// validate, or fails to sign, has an empty string and its error, the others
+// the re-signed token and a nil error.
+func ReSignBatch(tokens []string, oldKeyfunc Keyfunc, newMethod SigningMethod, newKey interface{}, opts ...ParserOption) ([]string, []error) {
+	parser := NewParser(append([]ParserOption{WithJSONNumber()}, opts...)...)
+	signed := make([]string, len(tokens))
+	errs := make([]error, len(tokens))
+	for i, tokenString := range tokens {
+		token, err := parser.Parse(tokenString, oldKeyfunc)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+
+		resigned := NewWithClaims(newMethod, token.Claims)
+		copyHeader(resigned.Header, token.Header, false)
+		signed[i], errs[i] = resigned.SignedString(newKey)
+	}
+	return signed, errs
+}
+
 // Generate the signing string.  This is the
 // most expensive part of the whole deal.  Unless you
 // need this for something special, just go straight for
@@ -68,13 +252,18 @@ func (t *Token) SigningString() (string, error) {
 	for i, _ := range parts {
 		var jsonValue []byte
 		if i == 0 {
-			if jsonValue, err = json.Marshal(t.Header); err != nil {
+			if jsonValue, err = DefaultJSONCodec.Marshal(t.Header); err != nil {
 				return "", err
 			}
 		} else {
-			if jsonValue, err = json.Marshal(t.Claims); err != nil {
+			if jsonValue, err = DefaultJSONCodec.Marshal(t.Claims); err != nil {
 				return "", err
 			}
+			if t.canonicalClaims {
+				if jsonValue, err = canonicalJSON(jsonValue); err != nil {
+					return "", err
+				}
+			}
 		}
 
 		parts[i] = EncodeSegment(jsonValue)
@@ -93,16 +282,32 @@ func ParseWithClaims(tokenString string, claims Claims, keyFunc Keyfunc) (*Token
 	return new(Parser).ParseWithClaims(tokenString, claims, keyFunc)
 }
 
+// WARNING: The returned token is NOT verified and must not be trusted
+//
+// Decodes the header and claims of tokenString into a token, along with its
+// segments, without verifying the signature or validating the claims.  Use it
+// only to pick how to verify the token, e.g. reading iss or kid to select the
+// key set of a tenant, then verify it with Parse.  See Parser.ParseUnverified.
+func ParseUnverified(tokenString string, claims Claims) (*Token, []string, error) {
+	return new(Parser).ParseUnverified(tokenString, claims)
+}
+
+// Returns a short, stable identifier of tokenString, the first 16 bytes of
+// its SHA-256 hash base64url encoded, for log correlation and cache keys
+// without exposing the token itself
+func Fingerprint(tokenString string) string {
+	sum := sha256.Sum256([]byte(tokenString))
+	return EncodeSegment(sum[:16])
+}
+
 // Encode JWT specific base64url encoding with padding stripped
 func EncodeSegment(seg []byte) string {
 	return strings.TrimRight(base64.URLEncoding.EncodeToString(seg), "=")
 }
 
-// Decode JWT specific base64url encoding with padding stripped
+// Decode JWT specific base64url encoding with padding stripped.  RFC 7515
+// requires segments to be unpadded base64url, so '=' padding and the '+' and
+// '/' characters of standard base64 are rejected.  See WithPaddingAllowed.
 func DecodeSegment(seg string) ([]byte, error) {
-	if l := len(seg) % 4; l > 0 {
-		seg += strings.Repeat("=", 4-l)
-	}
-
-	return base64.URLEncoding.DecodeString(seg)
+	return base64.RawURLEncoding.DecodeString(seg)
 }
@@ -0,0 +1,149 @@
+package jwt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Reports whether t and other have the same signing method, header and
+// claims.  Numbers are compared by value, so a claim decoded as float64 equals
+// the same claim held as an int or json.Number.  Intended for tests.
+func (t *Token) Equal(other *Token) bool {
+	return t.Diff(other) == ""
+}
+
+// Returns a human readable description of the differences between the signing
+// method, header and claims of t and other, one per line, or the empty string
+// when they are equal.  Numbers are compared by value, as in Equal.
+func (t *Token) Diff(other *Token) string {
+	if t == nil || other == nil {
+		if t == other {
+			return ""
+		}
+		return fmt.Sprintf("token: %v != %v\n", t, other)
+	}
+
+	var diff strings.Builder
+	if a, b := methodAlg(t.Method), methodAlg(other.Method); a != b {
+		fmt.Fprintf(&diff, "method: %q != %q\n", a, b)
+	}
+	diffValues(&diff, "header", t.Header, other.Header)
+	diffValues(&diff, "claims", t.Claims, other.Claims)
+	return diff.String()
+}
+
+// Signs claims with method and signKey, then parses and verifies the result
+// with verifyKey, so a mismatched sign and verify key setup fails in one call.
+// Returns an error if verification fails or the verified token does not hold
+// the signed header and claims.  The returned token has MapClaims.  Intended
+// for tests.
+func SignAndVerify(method SigningMethod, signKey, verifyKey interface{}, claims Claims) (*Token, error) {
+	signed := NewWithClaims(method, claims)
+	tokenString, err := signed.SignedString(signKey)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := &Parser{ValidMethods: []string{method.Alg()}}
+	token, err := parser.Parse(tokenString, func(*Token) (interface{}, error) {
+		return verifyKey, nil
+	})
+	if err != nil {
+		return token, err
+	}
+	if diff := signed.Diff(token); diff != "" {
+		return token, fmt.Errorf("verified token differs from the signed token:\n%v", diff)
+	}
+	return token, nil
+}
+
+func methodAlg(m SigningMethod) string {
+	if m == nil {
+		return ""
+	}
+	return m.Alg()
+}
+
+// Writes the differences between the JSON objects a and b, by top level member
+func diffValues(diff *strings.Builder, name string, a, b interface{}) {
+	na, errA := normalizeJSON(a)
+	nb, errB := normalizeJSON(b)
+	if errA != nil || errB != nil {
+		fmt.Fprintf(diff, "%v: cannot compare: %v %v\n", name, errA, errB)
+		return
+	}
+
+	ma, okA := na.(map[string]interface{})
+	mb, okB := nb.(map[string]interface{})
+	if !okA || !okB {
+		if !reflect.DeepEqual(na, nb) {
+			fmt.Fprintf(diff, "%v: %v != %v\n", name, na, nb)
+		}
+		return
+	}
+
+	keys := make(map[string]bool)
+	for k := range ma {
+		keys[k] = true
+	}
+	for k := range mb {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	for _, k := range sorted {
+		va, inA := ma[k]
+		vb, inB := mb[k]
+		switch {
+		case !inA:
+			fmt.Fprintf(diff, "%v[%q]: missing != %v\n", name, k, vb)
+		case !inB:
+			fmt.Fprintf(diff, "%v[%q]: %v != missing\n", name, k, va)
+		case !reflect.DeepEqual(va, vb):
+			fmt.Fprintf(diff, "%v[%q]: %v != %v\n", name, k, va, vb)
+		}
+	}
+}
+
+// Round trips v through JSON, converting all numbers to float64 so they
+// compare by value regardless of the Go type they were held in
+func normalizeJSON(v interface{}) (interface{}, error) {
+	data, err := DefaultJSONCodec.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var decoded interface{}
+	dec := DefaultJSONCodec.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&decoded); err != nil {
+		return nil, err
+	}
+	return normalizeNumbers(decoded), nil
+}
+
+func normalizeNumbers(v interface{}) interface{} {
+	switch vv := v.(type) {
+	case json.Number:
+		if f, err := strconv.ParseFloat(string(vv), 64); err == nil {
+			return f
+		}
+	case map[string]interface{}:
+		for k, e := range vv {
+			vv[k] = normalizeNumbers(e)
+		}
+	case []interface{}:
+		for i, e := range vv {
+			vv[i] = normalizeNumbers(e)
+		}
+	}
+	return v
+}
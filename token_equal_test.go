@@ -0,0 +1,77 @@
+package jwt_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/form3tech-oss/jwt-go"
+	"github.com/form3tech-oss/jwt-go/test"
+)
+
+func TestToken_Equal(t *testing.T) {
+	a := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "alice", "exp": float64(1500000000)})
+	b := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "alice", "exp": 1500000000})
+	if !a.Equal(b) {
+		t.Errorf("Tokens differing only in numeric type should be equal, diff:\n%v", a.Diff(b))
+	}
+	if diff := a.Diff(b); diff != "" {
+		t.Errorf("Expecting empty diff, got:\n%v", diff)
+	}
+
+	// Struct claims compare against the equivalent map
+	c := jwt.NewWithClaims(jwt.SigningMethodHS256, &jwt.StandardClaims{Subject: "alice", ExpiresAt: 1500000000})
+	if !a.Equal(c) {
+		t.Errorf("Struct and map claims with the same values should be equal, diff:\n%v", a.Diff(c))
+	}
+}
+
+func TestToken_Diff(t *testing.T) {
+	a := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "alice", "exp": float64(1500000000)})
+	b := jwt.NewWithClaims(jwt.SigningMethodHS384, jwt.MapClaims{"sub": "bob", "exp": 1500000000})
+
+	if a.Equal(b) {
+		t.Fatalf("Tokens with different values should not be equal")
+	}
+	diff := a.Diff(b)
+	for _, want := range []string{`method: "HS256" != "HS384"`, `header["alg"]: HS256 != HS384`, `claims["sub"]: alice != bob`} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("Expecting diff to contain %q, got:\n%v", want, diff)
+		}
+	}
+	if strings.Contains(diff, "exp") {
+		t.Errorf("Expecting exp to compare equal, got:\n%v", diff)
+	}
+}
+
+func TestSignAndVerify(t *testing.T) {
+	hmacKey := []byte("secret")
+	rsaPrivate := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	rsaPublic := test.LoadRSAPublicKeyFromDisk("test/sample_key.pub")
+	claims := jwt.StandardClaims{Subject: "user", ExpiresAt: 4102444800}
+
+	var signAndVerifyTestData = []struct {
+		name      string
+		method    jwt.SigningMethod
+		signKey   interface{}
+		verifyKey interface{}
+		valid     bool
+	}{
+		{"HS256", jwt.SigningMethodHS256, hmacKey, hmacKey, true},
+		{"HS256 wrong key", jwt.SigningMethodHS256, hmacKey, []byte("other"), false},
+		{"RS256", jwt.SigningMethodRS256, rsaPrivate, rsaPublic, true},
+		{"RS256 private key to verify", jwt.SigningMethodRS256, rsaPrivate, rsaPrivate, false},
+	}
+
+	for _, data := range signAndVerifyTestData {
+		token, err := jwt.SignAndVerify(data.method, data.signKey, data.verifyKey, claims)
+		if data.valid && err != nil {
+			t.Errorf("[%v] Error while round tripping: %v", data.name, err)
+		}
+		if !data.valid && err == nil {
+			t.Errorf("[%v] Expecting an error", data.name)
+		}
+		if data.valid && token.Claims.(jwt.MapClaims)["sub"] != "user" {
+			t.Errorf("[%v] Unexpected claims: %v", data.name, token.Claims)
+		}
+	}
+}
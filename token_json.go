@@ -0,0 +1,84 @@
+package jwt
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// The stored form of a Token, see Token.MarshalJSON
+type tokenJSON struct {
+	Raw            string                 `json:"raw,omitempty"`
+	Alg            string                 `json:"alg,omitempty"`
+	Header         map[string]interface{} `json:"header,omitempty"`
+	Claims         json.RawMessage        `json:"claims,omitempty"`
+	Signature      string                 `json:"signature,omitempty"`
+	Valid          bool                   `json:"valid"`
+	SignatureValid bool                   `json:"signatureValid"`
+}
+
+// Encodes the token, including its Raw form and signature, so that it can be
+// stored and restored with UnmarshalJSON.  The claims are encoded with
+// DefaultJSONCodec.  The validity flags are stored as
+// they were when the token was parsed; re-parse Raw to verify it again.
+func (t Token) MarshalJSON() ([]byte, error) {
+	stored := tokenJSON{
+		Raw:            t.Raw,
+		Alg:            methodAlg(t.Method),
+		Header:         t.Header,
+		Signature:      t.Signature,
+		Valid:          t.Valid,
+		SignatureValid: t.SignatureValid,
+	}
+	if t.Claims != nil {
+		claims, err := DefaultJSONCodec.Marshal(t.Claims)
+		if err != nil {
+			return nil, err
+		}
+		stored.Claims = claims
+	}
+	return json.Marshal(stored)
+}
+
+// Restores a token encoded by MarshalJSON.  The claims are decoded into
+// t.Claims if it is set, and into MapClaims with json.Number values
+// otherwise.  The signing method is looked up by its alg.
+func (t *Token) UnmarshalJSON(data []byte) error {
+	var stored tokenJSON
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return err
+	}
+
+	if t.Claims == nil {
+		t.Claims = MapClaims{}
+	}
+	if len(stored.Claims) > 0 {
+		dec := DefaultJSONCodec.NewDecoder(bytes.NewReader(stored.Claims))
+		dec.UseNumber()
+		var err error
+		// Special case for map type to avoid weird pointer behavior
+		if c, ok := t.Claims.(MapClaims); ok {
+			err = dec.Decode(&c)
+		} else {
+			err = dec.Decode(t.Claims)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	t.Raw = stored.Raw
+	t.segments = [3]string{}
+	if parts := strings.Split(t.Raw, "."); len(parts) == 3 {
+		copy(t.segments[:], parts)
+	}
+	t.Method = nil
+	if stored.Alg != "" {
+		t.Method = GetSigningMethod(stored.Alg)
+	}
+	t.Header = stored.Header
+	t.Signature = stored.Signature
+	t.Valid = stored.Valid
+	t.SignatureValid = stored.SignatureValid
+	return nil
+}
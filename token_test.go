@@ -0,0 +1,357 @@
+package jwt_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/form3tech-oss/jwt-go"
+	"github.com/form3tech-oss/jwt-go/test"
+)
+
+func TestRefresh(t *testing.T) {
+	key := []byte("secret")
+	keyfunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	issued := time.Now().Add(-time.Hour).Unix()
+
+	original := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": "alice",
+		"jti": "original",
+		"iat": float64(issued),
+		"exp": float64(time.Now().Unix() + 60),
+	})
+	original.Header["kid"] = "key-1"
+	original.Header["x5t"] = "thumbprint-1"
+	original.Header["cty"] = "example"
+	tokenString, _ := original.SignedString(key)
+	token, _ := jwt.Parse(tokenString, keyfunc)
+
+	refreshedString, err := jwt.Refresh(token, time.Hour, key)
+	if err != nil {
+		t.Fatalf("Error refreshing token: %v", err)
+	}
+	refreshed, err := jwt.Parse(refreshedString, keyfunc)
+	if err != nil || !refreshed.Valid {
+		t.Fatalf("Refreshed token should be valid: %v", err)
+	}
+
+	claims := refreshed.Claims.(jwt.MapClaims)
+	if claims["sub"] != "alice" {
+		t.Errorf("Expecting sub to be kept, got %v", claims["sub"])
+	}
+	if _, ok := claims["jti"]; ok {
+		t.Errorf("Expecting jti to be removed, got %v", claims["jti"])
+	}
+	if iat := claims["iat"].(float64); int64(iat) <= issued {
+		t.Errorf("Expecting a new iat, got %v", iat)
+	}
+	if exp := claims["exp"].(float64); int64(exp) < time.Now().Add(59*time.Minute).Unix() {
+		t.Errorf("Expecting exp an hour from now, got %v", exp)
+	}
+	if refreshed.Header["kid"] != "key-1" || refreshed.Header["x5t"] != "thumbprint-1" || refreshed.Header["cty"] != "example" {
+		t.Errorf("Expecting header to be kept, got %v", refreshed.Header)
+	}
+
+	// The input token is left untouched
+	if claims := token.Claims.(jwt.MapClaims); claims["jti"] != "original" || claims["iat"] != float64(issued) {
+		t.Errorf("Refresh modified the input token: %v", claims)
+	}
+
+	// Only valid tokens are refreshed
+	expiredString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"exp": float64(issued + 60)}).SignedString(key)
+	expired, _ := jwt.Parse(expiredString, keyfunc)
+	if _, err := jwt.Refresh(expired, time.Hour, key); err != jwt.ErrTokenNotValid {
+		t.Errorf("[invalid] Expecting ErrTokenNotValid, got %v", err)
+	}
+	if _, err := jwt.Refresh(original, time.Hour, key); err != jwt.ErrTokenNotValid {
+		t.Errorf("[not parsed] Expecting ErrTokenNotValid, got %v", err)
+	}
+
+	// The kid goes with the key, and a new jti can be issued
+	newKey := []byte("new secret")
+	var refreshTestData = []struct {
+		name  string
+		key   []byte
+		opts  []jwt.RefreshOption
+		kid   interface{}
+		newID bool
+	}{
+		{"other key", newKey, nil, nil, false},
+		{"other key and kid", newKey, []jwt.RefreshOption{jwt.WithRefreshKeyID("key-2")}, "key-2", false},
+		{"new jti", key, []jwt.RefreshOption{jwt.WithRefreshNewID()}, "key-1", true},
+	}
+	for _, data := range refreshTestData {
+		refreshedString, err := jwt.Refresh(token, time.Hour, data.key, data.opts...)
+		if err != nil {
+			t.Fatalf("[%v] Error refreshing token: %v", data.name, err)
+		}
+		refreshed, err := jwt.Parse(refreshedString, func(*jwt.Token) (interface{}, error) { return data.key, nil })
+		if err != nil {
+			t.Fatalf("[%v] Refreshed token should be valid: %v", data.name, err)
+		}
+		if refreshed.Header["kid"] != data.kid {
+			t.Errorf("[%v] Expecting kid %v, got %v", data.name, data.kid, refreshed.Header["kid"])
+		}
+		// The other key headers go with the kid of the old key
+		if x5t, sameKey := refreshed.Header["x5t"], data.kid == "key-1"; (x5t != nil) != sameKey || refreshed.Header["cty"] != "example" {
+			t.Errorf("[%v] Expecting x5t only with the same key and cty kept, got %v", data.name, refreshed.Header)
+		}
+		jti, _ := refreshed.Claims.(jwt.MapClaims)["jti"].(string)
+		if (jti != "" && jti != "original") != data.newID {
+			t.Errorf("[%v] Expecting a new jti: %v, got %q", data.name, data.newID, jti)
+		}
+	}
+
+	// The private key of the verification key is the same key
+	privateKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	rsaToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"sub": "alice"})
+	rsaToken.Header["kid"] = "rsa-1"
+	rsaString, _ := rsaToken.SignedString(privateKey)
+	parsed, _ := jwt.Parse(rsaString, defaultKeyFunc)
+	if refreshedString, err := jwt.Refresh(parsed, time.Hour, privateKey); err != nil {
+		t.Errorf("[rsa] Error refreshing token: %v", err)
+	} else if refreshed, _ := jwt.Parse(refreshedString, defaultKeyFunc); refreshed.Header["kid"] != "rsa-1" {
+		t.Errorf("[rsa] Expecting the kid to be kept, got %v", refreshed.Header)
+	}
+}
+
+func TestReSignBatch(t *testing.T) {
+	oldKey := []byte("secret")
+	oldKeyfunc := func(*jwt.Token) (interface{}, error) { return oldKey, nil }
+	newKey := test.LoadRSAPrivateKeyFromDisk("test/sample_key")
+	newKeyfunc := func(*jwt.Token) (interface{}, error) {
+		return test.LoadRSAPublicKeyFromDisk("test/sample_key.pub"), nil
+	}
+
+	sign := func(claims jwt.MapClaims, key interface{}) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		token.Header["kid"] = "old-key"
+		token.Header["x5c"] = []string{"old-certificate"}
+		token.Header["cty"] = "example"
+		tokenString, _ := token.SignedString(key)
+		return tokenString
+	}
+	tokens := []string{
+		sign(jwt.MapClaims{"sub": "alice", "exp": float64(time.Now().Add(time.Hour).Unix()), "big": 9007199254740993}, oldKey),
+		sign(jwt.MapClaims{"sub": "bob"}, []byte("other")),
+		sign(jwt.MapClaims{"sub": "carol", "exp": float64(time.Now().Add(-time.Hour).Unix())}, oldKey),
+		"not a token",
+		sign(jwt.MapClaims{"sub": "dave"}, oldKey),
+	}
+
+	signed, errs := jwt.ReSignBatch(tokens, oldKeyfunc, jwt.SigningMethodRS256, newKey)
+	if len(signed) != len(tokens) || len(errs) != len(tokens) {
+		t.Fatalf("Expecting a result per token, got %v and %v", len(signed), len(errs))
+	}
+
+	for i, sub := range []string{"alice", "", "", "", "dave"} {
+		if sub == "" {
+			if errs[i] == nil || signed[i] != "" {
+				t.Errorf("[%v] Expecting an error and no token, got %v and %q", i, errs[i], signed[i])
+			}
+			continue
+		}
+		if errs[i] != nil {
+			t.Errorf("[%v] Expecting no error, got %v", i, errs[i])
+			continue
+		}
+
+		token, err := new(jwt.Parser).Parse(signed[i], newKeyfunc)
+		if err != nil || !token.Valid {
+			t.Errorf("[%v] Expecting re-signed token to be valid under the new key: %v", i, err)
+			continue
+		}
+		if token.Method != jwt.SigningMethodRS256 || token.Header["cty"] != "example" {
+			t.Errorf("[%v] Expecting alg RS256 and the header kept, got %v", i, token.Header)
+		}
+		for _, name := range []string{"kid", "x5c"} {
+			if _, ok := token.Header[name]; ok {
+				t.Errorf("[%v] Expecting %v to be removed, got %v", i, name, token.Header[name])
+			}
+		}
+		if claims := token.Claims.(jwt.MapClaims); claims["sub"] != sub {
+			t.Errorf("[%v] Expecting sub %v, got %v", i, sub, claims["sub"])
+		}
+	}
+
+	// Numbers are carried over exactly
+	payload := strings.Split(signed[0], ".")[1]
+	decoded, _ := jwt.DecodeSegment(payload)
+	if !strings.Contains(string(decoded), `"big":9007199254740993`) {
+		t.Errorf("Expecting claims to be kept verbatim, got %s", decoded)
+	}
+
+	// The parser options apply to the old tokens
+	_, errs = jwt.ReSignBatch(tokens[:1], oldKeyfunc, jwt.SigningMethodRS256, newKey, jwt.WithValidMethods([]string{"RS256"}))
+	if ve, ok := errs[0].(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorSignatureInvalid {
+		t.Errorf("[options] Expecting ValidationErrorSignatureInvalid for an HS256 token, got %v", errs[0])
+	}
+}
+
+func TestTimeFuncLocation(t *testing.T) {
+	defer func(f func() time.Time) { jwt.TimeFunc = f }(jwt.TimeFunc)
+	now := time.Now()
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	parser := jwt.NewParser(jwt.WithMaxAge(time.Hour), jwt.WithMaxExpiry(time.Hour))
+
+	var timeFuncTestData = []struct {
+		name       string
+		claims     jwt.MapClaims
+		valid      bool
+		parserOnly bool // Only fails the checks of the parser options
+	}{
+		{"valid", jwt.MapClaims{"iat": float64(now.Unix() - 60), "nbf": float64(now.Unix() - 60), "exp": float64(now.Unix() + 60)}, true, false},
+		{"expired", jwt.MapClaims{"exp": float64(now.Unix() - 1)}, false, false},
+		{"not valid yet", jwt.MapClaims{"nbf": float64(now.Unix() + 60)}, false, false},
+		{"too old", jwt.MapClaims{"iat": float64(now.Unix() - 2*3600)}, false, true},
+		{"expires too late", jwt.MapClaims{"exp": float64(now.Unix() + 2*3600)}, false, true},
+	}
+
+	for _, loc := range []*time.Location{time.UTC, time.FixedZone("UTC+14", 14*3600), time.FixedZone("UTC-12", -12*3600)} {
+		jwt.TimeFunc = func() time.Time { return now.In(loc) }
+		for _, data := range timeFuncTestData {
+			tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, data.claims).SignedString(key)
+			_, err := parser.Parse(tokenString, keyFunc)
+			if data.valid != (err == nil) {
+				t.Errorf("[%v, %v] Expecting valid %v, got %v", loc, data.name, data.valid, err)
+			}
+			if valid := data.claims.ValidAt(now.In(loc)) == nil; !data.parserOnly && valid != data.valid {
+				t.Errorf("[%v, %v] Expecting ValidAt %v", loc, data.name, data.valid)
+			}
+		}
+	}
+}
+
+func TestDecodeSegment(t *testing.T) {
+	var decodeSegmentTestData = []struct {
+		name  string
+		seg   string
+		valid bool
+	}{
+		{"unpadded", "eyJmb28iOiJiYXIifQ", true},
+		{"url alphabet", "-_8", true},
+		{"padded", "eyJmb28iOiJiYXIifQ==", false},
+		{"standard alphabet", "+/8", false},
+	}
+
+	for _, data := range decodeSegmentTestData {
+		_, err := jwt.DecodeSegment(data.seg)
+		if data.valid && err != nil {
+			t.Errorf("[%v] Error while decoding segment: %v", data.name, err)
+		}
+		if !data.valid && err == nil {
+			t.Errorf("[%v] Expecting an error", data.name)
+		}
+	}
+}
+
+func TestToken_JSON(t *testing.T) {
+	key := []byte("secret")
+	keyfunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "user", "n": 1.5}).SignedString(key)
+
+	parsed, err := jwt.Parse(tokenString, keyfunc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(parsed)
+	if err != nil {
+		t.Fatalf("Error marshalling token: %v", err)
+	}
+	var restored jwt.Token
+	if err := json.Unmarshal(data, &restored); err != nil {
+		t.Fatalf("Error unmarshalling token: %v", err)
+	}
+
+	if restored.Raw != parsed.Raw || restored.Signature != parsed.Signature || restored.Valid != parsed.Valid {
+		t.Errorf("Restored token differs: %+v", restored)
+	}
+	if diff := parsed.Diff(&restored); diff != "" {
+		t.Errorf("Restored token differs:\n%v", diff)
+	}
+
+	if h, p, s := restored.Segments(); h+"."+p+"."+s != parsed.Raw {
+		t.Errorf("Restored segments differ from Raw")
+	}
+
+	reparsed, err := jwt.Parse(restored.Raw, keyfunc)
+	if err != nil || !reparsed.Valid {
+		t.Fatalf("Restored Raw does not verify: %v", err)
+	}
+	if reparsed.Signature != parsed.Signature || !reparsed.Equal(parsed) {
+		t.Errorf("Re-parsed token differs from the original")
+	}
+}
+
+func TestToken_Segments(t *testing.T) {
+	key := []byte("secret")
+	keyfunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "user"}).SignedString(key)
+
+	for _, parser := range []*jwt.Parser{new(jwt.Parser), jwt.NewParser(jwt.WithPaddingAllowed())} {
+		token, err := parser.Parse(tokenString, keyfunc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		header, payload, signature := token.Segments()
+		if header+"."+payload+"."+signature != token.Raw {
+			t.Errorf("Segments %v, %v, %v don't rejoin to %v", header, payload, signature, token.Raw)
+		}
+		if signature != token.Signature {
+			t.Errorf("Expecting signature segment %v, got %v", token.Signature, signature)
+		}
+	}
+
+	// Unparsed tokens have no segments
+	if header, payload, signature := jwt.New(jwt.SigningMethodHS256).Segments(); header != "" || payload != "" || signature != "" {
+		t.Errorf("Expecting no segments for a token that wasn't parsed")
+	}
+}
+
+func TestWithType(t *testing.T) {
+	key := []byte("secret")
+	keyfunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+
+	var typeTestData = []struct {
+		name string
+		opts []jwt.TokenOption
+		typ  interface{}
+	}{
+		{"default", nil, "JWT"},
+		{"access token", []jwt.TokenOption{jwt.WithType("at+jwt")}, "at+jwt"},
+		{"empty", []jwt.TokenOption{jwt.WithType("")}, nil},
+	}
+
+	for _, data := range typeTestData {
+		tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"foo": "bar"}, data.opts...).SignedString(key)
+		if err != nil {
+			t.Fatalf("[%v] Error signing token: %v", data.name, err)
+		}
+		token, err := jwt.Parse(tokenString, keyfunc)
+		if err != nil {
+			t.Fatalf("[%v] Error while parsing token: %v", data.name, err)
+		}
+		if typ := token.Header["typ"]; typ != data.typ {
+			t.Errorf("[%v] Expecting typ %v, got %v", data.name, data.typ, typ)
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	key := []byte("secret")
+	a, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "a"}).SignedString(key)
+	b, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "b"}).SignedString(key)
+
+	if jwt.Fingerprint(a) != jwt.Fingerprint(a) {
+		t.Errorf("Expecting identical tokens to have identical fingerprints")
+	}
+	if jwt.Fingerprint(a) == jwt.Fingerprint(b) {
+		t.Errorf("Expecting different tokens to have different fingerprints")
+	}
+	if fp := jwt.Fingerprint(a); len(fp) != 22 || strings.Contains(a, fp) {
+		t.Errorf("Unexpected fingerprint %q", fp)
+	}
+}
@@ -0,0 +1,111 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"time"
+)
+
+// MarshalSingleStringAsArray controls how a ClaimStrings holding exactly one
+// value is serialized.  By default it is encoded as a bare JSON string, which
+// is what most consumers expect.  Set it to true for strict consumers that
+// require the aud claim to always be an array.
+//
+// This is a package wide setting, read when tokens are signed.  To apply it to
+// MapClaims, store the audience as a ClaimStrings value.
+var MarshalSingleStringAsArray = false
+
+// ClaimStrings is used for claims that can be either a single string or an
+// array of strings, such as aud.  It accepts both forms when decoded.
+type ClaimStrings []string
+
+func (s *ClaimStrings) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	var aud []string
+	switch v := value.(type) {
+	case nil:
+	case string:
+		aud = append(aud, v)
+	case []interface{}:
+		for _, vv := range v {
+			vs, ok := vv.(string)
+			if !ok {
+				return errors.New("claim array contains a non-string value")
+			}
+			aud = append(aud, vs)
+		}
+	default:
+		return errors.New("claim is neither a string nor an array of strings")
+	}
+
+	*s = aud
+	return nil
+}
+
+func (s ClaimStrings) MarshalJSON() ([]byte, error) {
+	if len(s) == 1 && !MarshalSingleStringAsArray {
+		return json.Marshal(s[0])
+	}
+
+	return json.Marshal([]string(s))
+}
+
+// NumericDate is a time based claim such as exp, a JSON number of seconds
+// since the Unix epoch, RFC 7519 section 2.  It decodes integers, numbers
+// with a fractional part and numbers in exponent notation alike, in UTC, and
+// encodes as an integer number of seconds.
+type NumericDate struct {
+	time.Time
+}
+
+// Creates a NumericDate of t, truncated to a whole second as it is encoded
+func NewNumericDate(t time.Time) *NumericDate {
+	return &NumericDate{t.Truncate(time.Second)}
+}
+
+func (date *NumericDate) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		return errors.New("numeric date is a string, not a number")
+	}
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return err
+	}
+
+	if seconds, err := number.Int64(); err == nil {
+		date.Time = time.Unix(seconds, 0).UTC()
+		return nil
+	}
+	f, err := number.Float64()
+	if err != nil {
+		return errors.New("numeric date is not a number of seconds")
+	}
+	// NaN fails both comparisons
+	if !(f >= math.MinInt64 && f < math.MaxInt64) {
+		return errors.New("numeric date is out of range")
+	}
+	seconds, fraction := math.Modf(f)
+	date.Time = time.Unix(int64(seconds), int64(fraction*1e9)).UTC()
+	return nil
+}
+
+func (date NumericDate) MarshalJSON() ([]byte, error) {
+	return strconv.AppendInt(nil, date.Unix(), 10), nil
+}
+
+// Seconds since the Unix epoch, 0 for a nil date as for an unset claim
+func (date *NumericDate) unix() int64 {
+	if date == nil {
+		return 0
+	}
+	return date.Unix()
+}
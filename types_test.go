@@ -0,0 +1,118 @@
+package jwt_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/form3tech-oss/jwt-go"
+)
+
+var claimStringsMarshalTestData = []struct {
+	name    string
+	value   jwt.ClaimStrings
+	asArray bool
+	json    string
+}{
+	{"single as string", jwt.ClaimStrings{"a"}, false, `"a"`},
+	{"single as array", jwt.ClaimStrings{"a"}, true, `["a"]`},
+	{"multiple", jwt.ClaimStrings{"a", "b"}, false, `["a","b"]`},
+	{"multiple as array", jwt.ClaimStrings{"a", "b"}, true, `["a","b"]`},
+}
+
+func TestClaimStrings_MarshalJSON(t *testing.T) {
+	defer func(asArray bool) { jwt.MarshalSingleStringAsArray = asArray }(jwt.MarshalSingleStringAsArray)
+
+	for _, data := range claimStringsMarshalTestData {
+		jwt.MarshalSingleStringAsArray = data.asArray
+
+		got, err := json.Marshal(data.value)
+		if err != nil {
+			t.Errorf("[%v] Error marshalling: %v", data.name, err)
+		}
+		if string(got) != data.json {
+			t.Errorf("[%v] Expecting %v, got %v", data.name, data.json, string(got))
+		}
+
+		// The setting applies to the aud claim of StandardClaims and MapClaims alike
+		want := `{"aud":` + data.json + `}`
+		got, _ = json.Marshal(jwt.StandardClaims{Audience: data.value})
+		if string(got) != want {
+			t.Errorf("[%v] Expecting StandardClaims %v, got %v", data.name, want, string(got))
+		}
+		got, _ = json.Marshal(jwt.MapClaims{"aud": data.value})
+		if string(got) != want {
+			t.Errorf("[%v] Expecting MapClaims %v, got %v", data.name, want, string(got))
+		}
+	}
+}
+
+func TestClaimStrings_UnmarshalJSON(t *testing.T) {
+	for input, want := range map[string]jwt.ClaimStrings{
+		`"a"`:       {"a"},
+		`["a","b"]`: {"a", "b"},
+		`null`:      nil,
+	} {
+		var got jwt.ClaimStrings
+		if err := json.Unmarshal([]byte(input), &got); err != nil {
+			t.Errorf("[%v] Error unmarshalling: %v", input, err)
+		}
+		if !reflect.DeepEqual(want, got) {
+			t.Errorf("[%v] Expecting %v, got %v", input, want, got)
+		}
+	}
+
+	var got jwt.ClaimStrings
+	if err := json.Unmarshal([]byte(`[1]`), &got); err == nil {
+		t.Errorf("Expecting error unmarshalling a non-string array")
+	}
+}
+
+func TestNumericDate_UnmarshalJSON(t *testing.T) {
+	var numericDateTestData = []struct {
+		input string
+		want  time.Time
+		valid bool
+	}{
+		{`1700000000`, time.Unix(1700000000, 0), true},
+		{`1700000000.5`, time.Unix(1700000000, 5e8), true},
+		{`1.7e9`, time.Unix(1700000000, 0), true},
+		{`0`, time.Unix(0, 0), true},
+		{`"1700000000"`, time.Time{}, false},
+		{`true`, time.Time{}, false},
+		{`1e300`, time.Time{}, false},
+		{`-1e300`, time.Time{}, false},
+	}
+
+	for _, data := range numericDateTestData {
+		var got jwt.NumericDate
+		err := json.Unmarshal([]byte(data.input), &got)
+		if data.valid && (err != nil || !got.Equal(data.want) || got.Location() != time.UTC) {
+			t.Errorf("[%v] Expecting %v in UTC, got %v (%v)", data.input, data.want, got, err)
+		}
+		if !data.valid && err == nil {
+			t.Errorf("[%v] Expecting an error, got %v", data.input, got)
+		}
+	}
+
+	// A json.Number, as decoded with UseJSONNumber, is re-encoded as a number
+	var claims struct {
+		Exp *jwt.NumericDate `json:"exp"`
+		Nbf *jwt.NumericDate `json:"nbf"`
+	}
+	data, _ := json.Marshal(map[string]interface{}{"exp": json.Number("1700000000"), "nbf": nil})
+	if err := json.Unmarshal(data, &claims); err != nil || claims.Exp.Unix() != 1700000000 || claims.Nbf != nil {
+		t.Errorf("Expecting exp to decode and nbf to be nil, got %v and %v (%v)", claims.Exp, claims.Nbf, err)
+	}
+}
+
+func TestNumericDate_MarshalJSON(t *testing.T) {
+	date := jwt.NewNumericDate(time.Unix(1700000000, 999999999))
+	if got, err := json.Marshal(date); err != nil || string(got) != `1700000000` {
+		t.Errorf("Expecting whole seconds, got %s (%v)", got, err)
+	}
+	if got, _ := json.Marshal(jwt.NumericDate{Time: time.Unix(1700000000, 5e8)}); string(got) != `1700000000` {
+		t.Errorf("Expecting fractional seconds to be truncated, got %s", got)
+	}
+}
@@ -0,0 +1,157 @@
+package jwt
+
+import (
+	"time"
+)
+
+// Checks a set of claims one by one, reporting every claim that fails rather
+// than only the first, as ClaimErrors.  Build one with NewValidator and use
+// it through WithValidator, or on its own with Validate.
+//
+// The time based claims exp, nbf and iat are always checked when present, as
+// MapClaims.Valid does.  A Validator is safe for concurrent use: it is not
+// changed once built, and never changes the claims it checks.
+type Validator struct {
+	required []string
+	checks   []claimCheck
+	leeway   time.Duration
+}
+
+type claimCheck struct {
+	claim string
+	fn    func(value interface{}) error
+}
+
+// Configures a Validator.  See NewValidator.
+type ValidatorOption func(*Validator)
+
+// Creates a Validator checking the claims configured by options
+func NewValidator(options ...ValidatorOption) *Validator {
+	v := &Validator{}
+	for _, option := range options {
+		option(v)
+	}
+	return v
+}
+
+// RequireClaims fails the claims missing any of names with ErrClaimMissing
+func RequireClaims(names ...string) ValidatorOption {
+	return func(v *Validator) {
+		v.required = append(v.required, names...)
+	}
+}
+
+// RequireExpiration fails claims without an exp claim
+func RequireExpiration() ValidatorOption {
+	return RequireClaims("exp")
+}
+
+// RequireIssuedAt fails claims without an iat claim
+func RequireIssuedAt() ValidatorOption {
+	return RequireClaims("iat")
+}
+
+// RequireJTI fails claims without a jti claim
+func RequireJTI() ValidatorOption {
+	return RequireClaims("jti")
+}
+
+// WithValidatorLeeway tolerates a clock skew of d in the exp, nbf and iat
+// checks of Validate, truncated to whole seconds, as WithLeeway does for a
+// parser.  As there, a leeway above DefaultMaxAllowedSkew is clamped to it.
+// When the Validator is used through WithValidator, the parser's skew
+// applies instead.
+func WithValidatorLeeway(d time.Duration) ValidatorOption {
+	return func(v *Validator) {
+		if d > DefaultMaxAllowedSkew {
+			d = DefaultMaxAllowedSkew
+		}
+		v.leeway = d
+	}
+}
+
+// WithClaimValidator checks the claim named claim with fn, which receives its
+// decoded value and returns why it is invalid, if it is.  fn is only called
+// when the claim is present; combine it with RequireClaims for a required
+// claim.  Several functions may check the same claim, in the order given.
+func WithClaimValidator(claim string, fn func(value interface{}) error) ValidatorOption {
+	return func(v *Validator) {
+		v.checks = append(v.checks, claimCheck{claim, fn})
+	}
+}
+
+// Runs every check of the Validator against claims at the time now, within
+// its leeway, and returns the failures as ClaimErrors, or nil if there are
+// none
+func (v *Validator) Validate(claims MapClaims) error {
+	now := TimeFunc().Unix()
+	leeway := int64(v.leeway / time.Second)
+	if errs := v.validate(claims, now-leeway, now+leeway); len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// The failed claims, checking exp against expNow and nbf and iat against
+// nbfNow, so the parser can apply its skew
+func (v *Validator) validate(claims MapClaims, expNow, nbfNow int64) ClaimErrors {
+	var errs ClaimErrors
+	for _, name := range v.required {
+		if _, ok := claims[name]; !ok {
+			errs = append(errs, &ClaimError{name, ErrClaimMissing})
+		}
+	}
+
+	for _, name := range []string{"exp", "nbf", "iat"} {
+		if _, ok := claims[name]; !ok {
+			continue
+		}
+		date, ok := claims.numericDate(name)
+		switch {
+		case !ok:
+			errs = append(errs, &ClaimError{name, ErrClaimNotNumericDate})
+		case name == "exp" && !verifyExp(date, expNow, false):
+			errs = append(errs, &ClaimError{name, ErrTokenExpired})
+		case name == "nbf" && !verifyNbf(date, nbfNow, false):
+			errs = append(errs, &ClaimError{name, ErrTokenNotValidYet})
+		case name == "iat" && !verifyIat(date, nbfNow, false):
+			errs = append(errs, &ClaimError{name, ErrTokenUsedBeforeIssued})
+		}
+	}
+
+	for _, check := range v.checks {
+		value, ok := claims[check.claim]
+		if !ok {
+			continue
+		}
+		if err := check.fn(value); err != nil {
+			errs = append(errs, &ClaimError{check.claim, err})
+		}
+	}
+	return errs
+}
+
+// The ValidationError bits of the failed claims: the bit of each registered
+// claim, or ValidationErrorClaimsInvalid for other claims
+func (errs ClaimErrors) bits() uint32 {
+	var bits uint32
+	for _, err := range errs {
+		switch err.Claim {
+		case "aud":
+			bits |= ValidationErrorAudience
+		case "exp":
+			bits |= ValidationErrorExpired
+		case "iat":
+			bits |= ValidationErrorIssuedAt
+		case "iss":
+			bits |= ValidationErrorIssuer
+		case "nbf":
+			bits |= ValidationErrorNotValidYet
+		case "jti":
+			bits |= ValidationErrorId
+		default:
+			bits |= ValidationErrorClaimsInvalid
+		}
+	}
+	return bits
+}
@@ -0,0 +1,128 @@
+package jwt_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/form3tech-oss/jwt-go"
+)
+
+func scopeValidator(value interface{}) error {
+	scope, ok := value.(string)
+	if !ok || !strings.Contains(" "+scope+" ", " read ") {
+		return errors.New("scope does not grant read")
+	}
+	return nil
+}
+
+func TestValidator(t *testing.T) {
+	now := time.Now().Unix()
+	validator := jwt.NewValidator(
+		jwt.RequireExpiration(),
+		jwt.RequireIssuedAt(),
+		jwt.RequireJTI(),
+		jwt.WithClaimValidator("scope", scopeValidator),
+	)
+
+	var validatorTestData = []struct {
+		name   string
+		claims jwt.MapClaims
+		failed []string
+	}{
+		{"valid", jwt.MapClaims{"exp": float64(now + 100), "iat": float64(now), "jti": "1", "scope": "read write"}, nil},
+		{"no scope", jwt.MapClaims{"exp": float64(now + 100), "iat": float64(now), "jti": "1"}, nil},
+		{"all missing", jwt.MapClaims{}, []string{"exp", "iat", "jti"}},
+		{"expired, bad scope", jwt.MapClaims{"exp": float64(now - 100), "iat": float64(now - 200), "jti": "1", "scope": "write"}, []string{"exp", "scope"}},
+		{"future iat and nbf", jwt.MapClaims{"exp": float64(now + 100), "iat": float64(now + 50), "nbf": float64(now + 50), "jti": "1"}, []string{"nbf", "iat"}},
+		{"string exp", jwt.MapClaims{"exp": "tomorrow", "iat": float64(now), "jti": "1"}, []string{"exp"}},
+	}
+
+	for _, data := range validatorTestData {
+		err := validator.Validate(data.claims)
+		if data.failed == nil {
+			if err != nil {
+				t.Errorf("[%v] Error while validating claims: %v", data.name, err)
+			}
+			continue
+		}
+		errs, ok := err.(jwt.ClaimErrors)
+		if !ok {
+			t.Errorf("[%v] Expecting ClaimErrors, got %v", data.name, err)
+			continue
+		}
+		var failed []string
+		for _, e := range errs {
+			failed = append(failed, e.Claim)
+		}
+		if !reflect.DeepEqual(failed, data.failed) {
+			t.Errorf("[%v] Expecting failed claims %v, got %v", data.name, data.failed, failed)
+		}
+	}
+
+	err := validator.Validate(jwt.MapClaims{"exp": float64(now - 100), "iat": float64(now - 200)})
+	if err == nil || err.Error() != "jti: required claim is missing; exp: token is expired" {
+		t.Errorf("Expecting every failure in the message, got %v", err)
+	}
+	if errs := err.(jwt.ClaimErrors); errs[1].Err != jwt.ErrTokenExpired {
+		t.Errorf("Expecting ErrTokenExpired, got %v", errs[1].Err)
+	}
+}
+
+func TestValidator_WithValidatorLeeway(t *testing.T) {
+	now := time.Now().Unix()
+	validator := jwt.NewValidator(jwt.WithValidatorLeeway(time.Minute))
+	claims := jwt.MapClaims{"exp": float64(now - 30), "iat": float64(now + 30), "nbf": float64(now + 30)}
+
+	if err := validator.Validate(claims); err != nil {
+		t.Errorf("Expecting the claims to be valid within the leeway, got %v", err)
+	}
+	if err := jwt.NewValidator().Validate(claims); err == nil {
+		t.Errorf("Expecting the claims to be invalid without a leeway")
+	}
+	if err := validator.Validate(jwt.MapClaims{"exp": float64(now - 90)}); err == nil {
+		t.Errorf("Expecting a token expired beyond the leeway to be invalid")
+	}
+
+	// Clamped to DefaultMaxAllowedSkew, as for a parser
+	lenient := jwt.NewValidator(jwt.WithValidatorLeeway(24 * time.Hour))
+	if err := lenient.Validate(jwt.MapClaims{"exp": float64(now - 1200)}); err == nil {
+		t.Errorf("Expecting a token expired beyond DefaultMaxAllowedSkew to be invalid")
+	}
+	if err := lenient.Validate(jwt.MapClaims{"exp": float64(now - 300)}); err != nil {
+		t.Errorf("Expecting the claims to be valid within DefaultMaxAllowedSkew, got %v", err)
+	}
+	if !reflect.DeepEqual(claims, jwt.MapClaims{"exp": float64(now - 30), "iat": float64(now + 30), "nbf": float64(now + 30)}) {
+		t.Errorf("Expecting the claims to be unchanged, got %v", claims)
+	}
+}
+
+func TestParser_WithValidator(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	now := time.Now().Unix()
+	validator := jwt.NewValidator(jwt.RequireJTI(), jwt.WithClaimValidator("scope", scopeValidator))
+
+	tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"exp": now - 30, "scope": "write"}).SignedString(key)
+	_, err := jwt.NewParser(jwt.WithValidator(validator)).Parse(tokenString, keyFunc)
+	ve, ok := err.(*jwt.ValidationError)
+	if !ok || ve.Errors != jwt.ValidationErrorExpired|jwt.ValidationErrorId|jwt.ValidationErrorClaimsInvalid {
+		t.Fatalf("Expecting the bits of exp, jti and scope, got %v", err)
+	}
+	if errs, ok := ve.Inner.(jwt.ClaimErrors); !ok || len(errs) != 3 {
+		t.Errorf("Expecting the three failed claims as ClaimErrors, got %v", ve.Inner)
+	}
+
+	// The parser's skew applies to the time based claims
+	_, err = jwt.NewParser(jwt.WithValidator(validator), jwt.WithLeeway(time.Minute)).Parse(tokenString, keyFunc)
+	if errs, ok := err.(*jwt.ValidationError).Inner.(jwt.ClaimErrors); !ok || len(errs) != 2 || errs[0].Claim != "jti" || errs[1].Claim != "scope" {
+		t.Errorf("Expecting only jti and scope to fail within the leeway, got %v", err)
+	}
+
+	tokenString, _ = jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"jti": "1", "scope": "read"}).SignedString(key)
+	if _, err := jwt.NewParser(jwt.WithValidator(validator)).Parse(tokenString, keyFunc); err != nil {
+		t.Errorf("Error while parsing token: %v", err)
+	}
+}
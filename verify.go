@@ -0,0 +1,86 @@
+package jwt
+
+import (
+	"math"
+	"time"
+)
+
+// The outcome of Parser.Verify, for logging and metrics as well as the
+// authorization decision
+type VerificationResult struct {
+	Token           *Token        // The parsed token
+	Valid           bool          // Whether the signature verified and every check passed
+	SignatureValid  bool          // Whether the signature verified
+	Alg             string        // The alg of the token's signing method
+	Errors          uint32        // The ValidationError... bits of the failed checks, 0 if all passed
+	Failed          []string      // The stable codes of the failed checks, see ValidationError.Codes
+	ClaimsError     error         // Why the claims are not valid, nil if they are
+	MatchedAudience string        // The entry of the aud claim matching WithAudience or WithAudiencePattern, if set
+	Remaining       time.Duration // Time until exp, negative once it passed, 0 without an exp claim; clamped to the range of a Duration
+}
+
+// Parses and verifies tokenString as Parse does, but describes the outcome in
+// a VerificationResult.  err is non-nil only for fatal failures, a malformed
+// or unverifiable token or an invalid signature; a token whose signature
+// verified but whose claims did not pass is returned with a nil err, its
+// failed checks listed in the result.  The result is nil only if the token
+// could not be decoded; otherwise it is filled in as far as the token got.
+func (p *Parser) Verify(tokenString string, keyFunc Keyfunc) (*VerificationResult, error) {
+	token, err := p.Parse(tokenString, keyFunc)
+	if token == nil {
+		return nil, err
+	}
+
+	result := &VerificationResult{Token: token, Valid: token.Valid, SignatureValid: token.SignatureValid}
+	if token.Method != nil {
+		result.Alg = token.Method.Alg()
+	}
+	if err != nil {
+		ve, ok := err.(*ValidationError)
+		if ok {
+			result.Errors = ve.Errors
+			result.Failed = ve.Codes()
+		}
+		if !ok || ve.Errors&validationErrorFatal != 0 || !token.SignatureValid {
+			return result, err
+		}
+		result.ClaimsError = err
+	}
+
+	claims, _ := token.Claims.(MapClaims)
+	result.MatchedAudience = p.matchedAudience(claims)
+	if exp, ok := claims.numericDate("exp"); ok {
+		result.Remaining = durationUntil(exp, TimeFunc().Unix())
+	}
+	return result, nil
+}
+
+// The entry of the aud claim of claims, as read by WithAudienceExtractor if
+// set, that matches WithAudiencePattern or WithAudience
+func (p *Parser) matchedAudience(claims MapClaims) string {
+	audiences := claims.audiences()
+	if p.audienceExtractor != nil {
+		audiences = p.audienceExtractor(claims["aud"])
+	}
+	for _, aud := range audiences {
+		if p.audiencePattern != "" && matchAudiencePattern(p.audiencePattern, aud) {
+			return aud
+		}
+		if p.audiencePattern == "" && p.audience != "" && aud == p.audience {
+			return aud
+		}
+	}
+	return ""
+}
+
+// The time from now until exp, both in seconds since the epoch, clamped to
+// the range of a Duration so that an absurd exp doesn't overflow
+func durationUntil(exp, now int64) time.Duration {
+	const maxSeconds = math.MaxInt64 / float64(time.Second)
+	if seconds := float64(exp) - float64(now); seconds >= maxSeconds {
+		return math.MaxInt64
+	} else if seconds <= -maxSeconds {
+		return math.MinInt64
+	}
+	return time.Duration(exp-now) * time.Second
+}
@@ -0,0 +1,78 @@
+package jwt_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/form3tech-oss/jwt-go"
+)
+
+func TestParser_Verify(t *testing.T) {
+	key := []byte("secret")
+	keyFunc := func(*jwt.Token) (interface{}, error) { return key, nil }
+	now := time.Now().Unix()
+	parser := jwt.NewParser(jwt.WithAudiencePattern("https://*.example.com"))
+
+	sign := func(claims jwt.MapClaims) string {
+		tokenString, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+		return tokenString
+	}
+
+	// A valid token
+	result, err := parser.Verify(sign(jwt.MapClaims{"aud": []string{"other", "https://api.example.com"}, "exp": float64(now + 3600)}), keyFunc)
+	if err != nil {
+		t.Fatalf("Expecting no error, got %v", err)
+	}
+	if !result.Valid || !result.SignatureValid || result.Alg != "HS256" || result.Errors != 0 || result.Failed != nil || result.ClaimsError != nil {
+		t.Errorf("Expecting a valid HS256 token, got %+v", result)
+	}
+	if result.MatchedAudience != "https://api.example.com" {
+		t.Errorf("Expecting the matched audience, got %q", result.MatchedAudience)
+	}
+	if result.Remaining <= 59*time.Minute || result.Remaining > time.Hour {
+		t.Errorf("Expecting about an hour remaining, got %v", result.Remaining)
+	}
+
+	// An expired, but correctly signed token
+	result, err = parser.Verify(sign(jwt.MapClaims{"aud": "https://api.example.com", "exp": float64(now - 60)}), keyFunc)
+	if err != nil {
+		t.Fatalf("Expecting no error for a claims failure, got %v", err)
+	}
+	if result.Valid || !result.SignatureValid || result.Errors != jwt.ValidationErrorExpired || len(result.Failed) != 1 || result.Failed[0] != "token_expired" {
+		t.Errorf("Expecting a signed, expired token, got %+v", result)
+	}
+	if ve, ok := result.ClaimsError.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorExpired {
+		t.Errorf("Expecting the claims error, got %v", result.ClaimsError)
+	}
+	if result.Remaining > -time.Minute+time.Second || result.Remaining < -time.Minute-time.Second {
+		t.Errorf("Expecting a minute past expiry, got %v", result.Remaining)
+	}
+
+	// A bad signature is fatal
+	result, err = parser.Verify(sign(jwt.MapClaims{"exp": float64(now + 3600)}), func(*jwt.Token) (interface{}, error) { return []byte("other"), nil })
+	if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != jwt.ValidationErrorSignatureInvalid {
+		t.Errorf("Expecting ValidationErrorSignatureInvalid, got %v", err)
+	}
+	if result == nil || result.SignatureValid || result.Valid || result.Alg != "HS256" {
+		t.Errorf("Expecting an unverified HS256 token, got %+v", result)
+	}
+
+	// An exp that doesn't fit a Duration
+	result, _ = parser.Verify(sign(jwt.MapClaims{"aud": "https://api.example.com", "exp": float64(1 << 62)}), keyFunc)
+	if result == nil || result.Remaining != time.Duration(1<<63-1) {
+		t.Errorf("Expecting the remaining time clamped to the maximum Duration, got %+v", result)
+	}
+
+	// The audience matched by WithAudience, as read by WithAudienceExtractor
+	split := func(aud interface{}) []string { s, _ := aud.(string); return strings.Split(s, ",") }
+	result, _ = jwt.NewParser(jwt.WithAudience("reports"), jwt.WithAudienceExtractor(split)).Verify(sign(jwt.MapClaims{"aud": "billing,reports"}), keyFunc)
+	if result == nil || !result.Valid || result.MatchedAudience != "reports" {
+		t.Errorf("Expecting the audience matched by WithAudience, got %+v", result)
+	}
+
+	// As is a malformed token
+	if result, err = parser.Verify("not a token", keyFunc); result != nil || err == nil {
+		t.Errorf("Expecting no result and an error, got %+v and %v", result, err)
+	}
+}
@@ -0,0 +1,47 @@
+package jwt
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+)
+
+// The public key of the leaf certificate of the x5c header of token, once
+// the chain verified against the options of WithX5CVerification
+func (p *Parser) x5cKey(token *Token) (interface{}, error) {
+	if p.x5cOptions.Roots == nil {
+		// x509 would fall back to the system roots, which any public CA chains to
+		return nil, &ValidationError{Inner: ErrX5CNoRoots, Errors: ValidationErrorUnverifiable}
+	}
+	chain, ok := token.Header["x5c"].([]interface{})
+	if !ok || len(chain) == 0 {
+		return nil, &ValidationError{Inner: ErrX5CMissing, Errors: ValidationErrorUnverifiable}
+	}
+
+	// Unlike the segments, x5c entries are standard, padded base64 of DER
+	certs := make([]*x509.Certificate, len(chain))
+	for i, entry := range chain {
+		s, ok := entry.(string)
+		if !ok {
+			return nil, NewValidationError(fmt.Sprintf("x5c entry %d is not a string", i), ValidationErrorMalformed)
+		}
+		der, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return nil, &ValidationError{Inner: err, Errors: ValidationErrorMalformed}
+		}
+		if certs[i], err = x509.ParseCertificate(der); err != nil {
+			return nil, &ValidationError{Inner: err, Errors: ValidationErrorMalformed}
+		}
+	}
+
+	opts := *p.x5cOptions
+	opts.Intermediates = x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+	opts.CurrentTime = TimeFunc()
+	if _, err := certs[0].Verify(opts); err != nil {
+		return nil, &ValidationError{Inner: err, Errors: ValidationErrorUnverifiable}
+	}
+	return certs[0].PublicKey, nil
+}
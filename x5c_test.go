@@ -0,0 +1,125 @@
+package jwt_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/form3tech-oss/jwt-go"
+)
+
+// Issues a certificate for key, signed by parent and parentKey, or self-signed
+// if parent is nil
+func makeCertificate(t *testing.T, template *x509.Certificate, key *ecdsa.PrivateKey, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) *x509.Certificate {
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestParser_WithX5CVerification(t *testing.T) {
+	newKey := func() *ecdsa.PrivateKey {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+	ca := func(name string) *x509.Certificate {
+		return &x509.Certificate{
+			Subject:               pkix.Name{CommonName: name},
+			IsCA:                  true,
+			BasicConstraintsValid: true,
+			KeyUsage:              x509.KeyUsageCertSign,
+		}
+	}
+
+	rootKey, intermediateKey, leafKey, otherKey := newKey(), newKey(), newKey(), newKey()
+	root := makeCertificate(t, ca("root"), rootKey, nil, nil)
+	otherRoot := makeCertificate(t, ca("other root"), otherKey, nil, nil)
+	intermediate := makeCertificate(t, ca("intermediate"), intermediateKey, root, rootKey)
+	leaf := makeCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "notifications"},
+		DNSNames:    []string{"notifications.example.com"},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}, leafKey, intermediate, intermediateKey)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+	otherRoots := x509.NewCertPool()
+	otherRoots.AddCert(otherRoot)
+
+	sign := func(key *ecdsa.PrivateKey, certs ...*x509.Certificate) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{"notificationType": "REFUND"})
+		if certs != nil {
+			var chain []string
+			for _, cert := range certs {
+				chain = append(chain, base64.StdEncoding.EncodeToString(cert.Raw))
+			}
+			token.Header["x5c"] = chain
+		}
+		tokenString, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tokenString
+	}
+
+	var x5cTestData = []struct {
+		name        string
+		tokenString string
+		option      jwt.ParserOption
+		errors      uint32
+	}{
+		{"valid chain", sign(leafKey, leaf, intermediate), jwt.WithX5CVerification(roots, ""), 0},
+		{"hostname and usage", sign(leafKey, leaf, intermediate), jwt.WithX5CVerification(roots, "notifications.example.com", x509.ExtKeyUsageCodeSigning), 0},
+		{"untrusted root", sign(leafKey, leaf, intermediate), jwt.WithX5CVerification(otherRoots, ""), jwt.ValidationErrorUnverifiable},
+		{"missing intermediate", sign(leafKey, leaf), jwt.WithX5CVerification(roots, ""), jwt.ValidationErrorUnverifiable},
+		{"wrong hostname", sign(leafKey, leaf, intermediate), jwt.WithX5CVerification(roots, "other.example.com"), jwt.ValidationErrorUnverifiable},
+		{"wrong usage", sign(leafKey, leaf, intermediate), jwt.WithX5CVerification(roots, "", x509.ExtKeyUsageServerAuth), jwt.ValidationErrorUnverifiable},
+		{"signed by another key", sign(otherKey, leaf, intermediate), jwt.WithX5CVerification(roots, ""), jwt.ValidationErrorSignatureInvalid},
+		{"no x5c", sign(leafKey), jwt.WithX5CVerification(roots, ""), jwt.ValidationErrorUnverifiable},
+		{"no roots", sign(leafKey, leaf, intermediate), jwt.WithX5CVerification(nil, ""), jwt.ValidationErrorUnverifiable},
+	}
+
+	for _, data := range x5cTestData {
+		// The key comes from the chain, so no Keyfunc is given
+		token, err := jwt.NewParser(data.option).Parse(data.tokenString, nil)
+		if data.errors == 0 {
+			if err != nil || !token.Valid {
+				t.Errorf("[%v] Error while verifying token: %v", data.name, err)
+			}
+			continue
+		}
+		if ve, ok := err.(*jwt.ValidationError); !ok || ve.Errors != data.errors {
+			t.Errorf("[%v] Expecting error bits %v, got %v", data.name, data.errors, err)
+		}
+	}
+
+	_, err := jwt.NewParser(jwt.WithX5CVerification(roots, "")).Parse(sign(leafKey), nil)
+	if ve, ok := err.(*jwt.ValidationError); !ok || ve.Inner != jwt.ErrX5CMissing {
+		t.Errorf("Expecting ErrX5CMissing, got %v", err)
+	}
+	_, err = jwt.NewParser(jwt.WithX5CVerification(nil, "")).Parse(sign(leafKey, leaf, intermediate), nil)
+	if ve, ok := err.(*jwt.ValidationError); !ok || ve.Inner != jwt.ErrX5CNoRoots {
+		t.Errorf("Expecting ErrX5CNoRoots, got %v", err)
+	}
+}